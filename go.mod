@@ -4,9 +4,12 @@ go 1.15
 
 require (
 	bou.ke/monkey v1.0.2
+	github.com/mattn/go-runewidth v0.0.13
+	github.com/rivo/uniseg v0.2.0
 	github.com/sirupsen/logrus v1.7.0
 	github.com/snugfox/ansi-escapes v0.2.0
 	github.com/spf13/cobra v1.1.1
+	github.com/spf13/pflag v1.0.5
 	github.com/t-tomalak/logrus-easy-formatter v0.0.0-20190827215021-c074f06c5816
 	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c // indirect
 )
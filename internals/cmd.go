@@ -1,15 +1,14 @@
 package internals
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"os/exec"
-	"regexp"
-	"runtime"
 
 	"github.com/spf13/cobra"
 
 	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -27,43 +26,67 @@ This method will directly run the root command - from where flow of control is b
 to various methods/functions depending on user input
 */
 func Execute() {
-	// Fetch current location for `ffmpeg` and `ffprobe` executables - used by default
-	// unless custom path is supplied by the user, or the executables can't be found
-	ffmpegPath, ffprobePath := findBinaries()
+	// Resolve the default values for the flags - values passed through flags always
+	// take precedence over these defaults. Sources are in the order of precedence,
+	// values from a profile (if any) are applied once the flags are parsed. Executables
+	// are not looked up here, keeping `--help` (and the like) free of any lookups - see
+	// `locateBinaries`
+	loadConfigFile()
+	defaultSources = []config.Source{
+		{Name: sourceEnv, Defaults: config.FromEnv()},
+		{Name: sourceFile, Defaults: config.FromFile(configFile)},
+		{Name: sourceLocale, Defaults: config.FromLocale()},
+		{Name: sourceBuiltin, Defaults: config.Builtin("", "")},
+	}
 
-	// Override the output for `--version` flag - default output is (relatively) ugly
-	cmd.SetVersionTemplate(
-		fmt.Sprintf(
-			`
-%s v%s
- - os/arch: %s/%s
- - go version: %s
+	defaults := config.Resolve(config.Values(defaultSources)...)
+
+	// Override the output for `--version` flag - default output is (relatively) ugly.
+	// The template function ensures versions for FFmpeg/FFprobe are fetched only when
+	// the flag is used
+	cobra.AddTemplateFunc("buildInfo", func() string {
+		return fetchBuildInfo().String()
+	})
+
+	cmd.SetVersionTemplate("{{buildInfo}}")
+
+	// Logs are written to the log file once the flags are parsed, the path to the log
+	// file can be passed through a flag
+	cobra.OnInitialize(openLogFile)
+	defer closeLogFile()
+
+	// Attach subcommands to the root command
+	versionFlags(versionCmd)
+	inspectFlags(inspectCmd, &userInput, &defaults)
+	historyFlags(historyCmd)
+	undoFlags(undoCmd)
+	cmd.AddCommand(versionCmd, inspectCmd, historyCmd, resumeCmd, syncCmd, undoCmd)
+
+	// Flags shared by every command
+	cmd.PersistentFlags().StringVar(
+		&userInput.LogFile,
+		"log-file",
+		"",
+		"Path to the log file, defaults to a file in the user state directory",
+	)
 
-Licensed under MIT
-`,
-			title,
-			version,
-			runtime.GOOS,
-			runtime.GOARCH,
-			runtime.Version(),
-		),
+	cmd.PersistentFlags().BoolVar(
+		&userInput.LogStderr,
+		"log-stderr",
+		false,
+		"Mirror the logs to stderr, along with the log file",
 	)
 
 	// Add flags to root command
 	boolFlags(cmd, &userInput)
-	stringFlags(
-		cmd,
-		&userInput,
-		&ffmpegPath,
-		&ffprobePath,
-	)
+	traversalFlags(cmd, &userInput)
+	numericFlags(cmd, &userInput)
+	stringFlags(cmd, &userInput, &defaults)
 
 	if rootErr := cmd.Execute(); rootErr != nil {
 		// Force-quit in case an error is encountered.
 		log.Errorf("(cmd/Execute) encountered an error: \n%v", rootErr)
-		commons.Printf(
-			"\nEncountered an unexpected error! Check logs for details\n",
-		)
+		commons.Error("\nEncountered an unexpected error! Check logs for details\n")
 
 		// Non-zero exit code
 		os.Exit(commons.UnexpectedError)
@@ -89,10 +112,194 @@ func boolFlags(command *cobra.Command, input *commons.UserInput) {
 	)
 
 	command.Flags().BoolVar(
-		&input.IsDirect,
-		"direct",
+		&input.StrictSync,
+		"strict-sync",
 		false,
-		"Use root directory as source directory",
+		"Skip media files with subtitles out of sync",
+	)
+
+	command.Flags().BoolVar(
+		&input.AllMedia,
+		"all-media",
+		false,
+		"Process each media file in a source directory with multiple media files",
+	)
+
+	command.Flags().BoolVarP(
+		&input.AssumeYes,
+		"yes",
+		"y",
+		false,
+		"Accept all prompts without asking",
+	)
+
+	command.Flags().BoolVar(
+		&input.AssumeNo,
+		"no",
+		false,
+		"Decline all prompts without asking",
+	)
+
+	command.Flags().BoolVar(
+		&input.KillStalled,
+		"kill-stalled",
+		false,
+		"Kill encodes that stop making progress",
+	)
+
+	command.Flags().BoolVar(
+		&input.SkipExisting,
+		"skip-existing",
+		false,
+		"Skip media files with an up-to-date output",
+	)
+
+	command.Flags().BoolVar(
+		&input.Diagnostics,
+		"diagnostics",
+		false,
+		"Save a diagnostic bundle for each failed FFmpeg command",
+	)
+
+	command.Flags().BoolVar(
+		&input.Safe,
+		"safe",
+		false,
+		"Never overwrite or delete existing files, nor write to source directories",
+	)
+
+	command.Flags().BoolVar(
+		&input.KeepAllSubs,
+		"keep-all-subs",
+		false,
+		"Attach every subtitle file, instead of the best match for the media file",
+	)
+
+	command.Flags().BoolVar(
+		&input.NoProbeCache,
+		"no-probe-cache",
+		false,
+		"Probe every file again, bypassing the probe cache",
+	)
+
+	command.Flags().BoolVar(
+		&input.Porcelain,
+		"porcelain",
+		false,
+		"Write results to stdout as JSON lines, and progress to stderr without colors",
+	)
+
+	command.Flags().BoolVar(
+		&input.CleanStale,
+		"clean-stale",
+		false,
+		"Remove partial outputs left behind by earlier runs, without asking",
+	)
+
+	command.Flags().BoolVar(
+		&input.TouchFailed,
+		"touch-failed",
+		false,
+		"Drop a marker in source directories that fail, for --retry-failed",
+	)
+
+	command.Flags().BoolVar(
+		&input.RetryFailed,
+		"retry-failed",
+		false,
+		"Process only the source directories marked by --touch-failed",
+	)
+
+	command.Flags().BoolVar(
+		&input.SplitByLang,
+		"split-by-lang",
+		false,
+		"Create one output for each subtitle language, instead of a single output",
+	)
+
+	command.Flags().BoolVarP(
+		&input.Quiet,
+		"quiet",
+		"q",
+		false,
+		"Print only warnings and errors, hiding the progress",
+	)
+
+	command.Flags().BoolVar(
+		&input.NoColor,
+		"no-color",
+		false,
+		"Print messages without colors",
+	)
+
+	command.Flags().BoolVar(
+		&input.UseTrash,
+		"use-trash",
+		false,
+		"Move files to the trash instead of deleting them permanently",
+	)
+
+	command.Flags().BoolVar(
+		&input.ExportMetadata,
+		"export-metadata",
+		false,
+		"Save the streams and format of each output as JSON, next to the output",
+	)
+
+	command.Flags().BoolVar(
+		&input.StatsTags,
+		"stats-tags",
+		false,
+		"Write track statistics tags to the outputs, requires mkvpropedit",
+	)
+
+	command.Flags().BoolVar(
+		&input.KeepTemp,
+		"keep-temp",
+		false,
+		"Keep the temporary files of the run, for debugging",
+	)
+
+	command.Flags().BoolVar(
+		&input.PrintConfig,
+		"print-config",
+		false,
+		"Print the resolved configuration as JSON, without running",
+	)
+
+	command.Flags().BoolVar(
+		&input.ValidateConfig,
+		"validate-config",
+		false,
+		"Validate the configuration file, without running",
+	)
+
+	command.Flags().BoolVar(
+		&input.Plan,
+		"plan",
+		false,
+		"Scan the root directory and print the plan, asking before merging",
+	)
+
+	command.Flags().BoolVar(
+		&input.Verbose,
+		"verbose",
+		false,
+		"Print the streams in each output before merging it",
+	)
+
+	command.Flags().BoolVar(
+		&input.NoFastPath,
+		"no-fast-path",
+		false,
+		"Always remux with FFmpeg, even if attachments alone are being added",
+	)
+
+	command.Flags().BoolVar(
+		&input.AlsoMP4,
+		"also-mp4",
+		false,
+		"Write an MP4 copy of each output as well, converting text subtitles",
 	)
 
 	// Override `help` and `version` flags - for a better output
@@ -112,13 +319,211 @@ func boolFlags(command *cobra.Command, input *commons.UserInput) {
 }
 
 /*
-StringFlags is a simple helper function to add all string flags to the command.
+TraversalFlags is a simple helper function to attach flags dictating the way the root
+directory is traversed - these flags are shared by all commands that walk the root
+directory.
 */
-func stringFlags(command *cobra.Command, input *commons.UserInput, ffmpegPath,
-	ffprobePath *string) {
-	// Message to log if a flag can't be marked as required
-	failMsg := "(cmd/stringFlags) failed to mark `%s` flag as required\nerror; %v"
+func traversalFlags(command *cobra.Command, input *commons.UserInput) {
+	command.Flags().BoolVar(
+		&input.IsDirect,
+		"direct",
+		false,
+		"Use root directory as source directory",
+	)
+
+	command.Flags().BoolVar(
+		&input.CaseSensitive,
+		"case-sensitive",
+		false,
+		"Match names case sensitively in every exclusion and inclusion rule",
+	)
 
+	command.Flags().BoolVar(
+		&input.ExtractArchives,
+		"extract-archives",
+		false,
+		"Extract zip archives in source directories, grouping the files inside",
+	)
+
+	command.Flags().BoolVar(
+		&input.NoSampleFilter,
+		"no-sample-filter",
+		false,
+		"Treat samples and trailers as media files, instead of leaving them out",
+	)
+
+	command.Flags().StringSliceVarP(
+		&input.Exclusions,
+		"exclude",
+		"E",
+		[]string{},
+		"List of files to be ignored",
+	)
+
+	// Using a string array - patterns are not split on commas, these are common
+	// in regex patterns (i.e. `\d{1,3}`)
+	command.Flags().StringArrayVar(
+		&input.RegexExclusions,
+		"rexclude",
+		[]string{},
+		"Regex pattern to dictate files to be ignored, can be repeated",
+	)
+
+	command.Flags().BoolVar(
+		&input.MatchPaths,
+		"match-paths",
+		false,
+		"Match exclusions against relative paths and directory names",
+	)
+
+	command.Flags().StringSliceVar(
+		&input.Inclusions,
+		"only",
+		[]string{},
+		"Glob pattern(s) to dictate directories/files to be processed",
+	)
+
+	command.Flags().StringVar(
+		&input.RegexInclude,
+		"rinclude",
+		"",
+		"Regex pattern to dictate directories/files to be processed",
+	)
+
+	command.Flags().StringSliceVar(
+		&input.MediaExt,
+		"media-ext",
+		[]string{},
+		"Additional extension(s) for files to be treated as media files",
+	)
+
+	command.Flags().StringVar(
+		&input.Classifier,
+		"classifier",
+		"",
+		"Executable deciding the category of files, see the docs for the protocol",
+	)
+
+	command.Flags().StringVar(
+		&input.Profile,
+		"profile",
+		"",
+		"Named profile from the configuration file to be used",
+	)
+
+	command.Flags().StringVar(
+		&input.DonorRegex,
+		"donor-regex",
+		"",
+		"Regex pattern for the media file to extract subtitles/fonts/chapters from",
+	)
+
+	command.Flags().StringVar(
+		&input.RawRegex,
+		"raw-regex",
+		"",
+		"Regex pattern for the media file to merge the extracted streams into",
+	)
+}
+
+/*
+NumericFlags is a simple helper function to attach integer and float flags to the
+command
+*/
+func numericFlags(command *cobra.Command, input *commons.UserInput) {
+	command.Flags().IntVar(
+		&input.Sample,
+		"sample",
+		0,
+		"Merge only the first N seconds of each media file",
+	)
+
+	command.Flags().IntVar(
+		&input.SyncThreshold,
+		"sync-threshold",
+		300, // five minutes - leaves room for credits, previews, etc.
+		"Max difference (in seconds) between subtitle and media duration",
+	)
+
+	command.Flags().IntVar(
+		&input.StallTimeout,
+		"stall-timeout",
+		120,
+		"Seconds without progress before an encode is considered stalled",
+	)
+
+	command.Flags().IntVar(
+		&input.StallRetries,
+		"stall-retries",
+		0,
+		"Number of times a killed encode is to be retried",
+	)
+
+	command.Flags().IntVar(
+		&input.TimeoutBase,
+		"timeout-base",
+		0, // no timeout
+		"Seconds allowed for each source directory, on top of --timeout-factor",
+	)
+
+	command.Flags().Float64Var(
+		&input.TimeoutFactor,
+		"timeout-factor",
+		0,
+		"Time allowed for each source directory, per second of media duration",
+	)
+
+	command.Flags().IntVar(
+		&input.Threads,
+		"threads",
+		0, // let FFmpeg decide
+		"Number of threads used by FFmpeg, zero lets FFmpeg decide",
+	)
+
+	command.Flags().IntVar(
+		&input.MuxingQueueSize,
+		"max-muxing-queue-size",
+		1024, // fixes "Too many packets buffered" for most media files
+		"Max packets buffered by FFmpeg while waiting for all streams",
+	)
+
+	command.Flags().IntVar(
+		&input.MaxSubs,
+		"max-subs",
+		0, // no limit
+		"Max subtitle files merged into a media file, zero for no limit",
+	)
+
+	command.Flags().IntVar(
+		&input.MaxAttachments,
+		"max-attachments",
+		0, // no limit
+		"Max attachments merged into a media file, zero for no limit",
+	)
+
+	command.Flags().Float64Var(
+		&input.MaxLoad,
+		"max-load",
+		0,
+		"System load above which source directories are not started",
+	)
+
+	command.Flags().Float64Var(
+		&input.StatsInterval,
+		"stats-interval",
+		0, // picked for the output in use
+		"Seconds between two progress updates, i.e. 0.5",
+	)
+}
+
+/*
+StringFlags is a simple helper function to add all string flags to the command.
+*/
+func stringFlags(
+	command *cobra.Command,
+	input *commons.UserInput,
+	defaults *config.Defaults,
+) {
 	// Do not mark the root flag as required - it can be passed in as an argument too!
 	rootFlag := "root" // easy access/modification
 	command.Flags().StringVar(
@@ -137,57 +542,58 @@ func stringFlags(command *cobra.Command, input *commons.UserInput, ffmpegPath,
 		)
 	}
 
+	outputFlag := "output" // easy access/modification
+	command.Flags().StringVarP(
+		&input.OutputPath,
+		outputFlag,
+		"o",
+		defaults.OutputPath,
+		"Directory to store the results in",
+	)
+
+	if err := command.MarkFlagDirname(outputFlag); err != nil {
+		log.Debugf(
+			"(cmd/stringFlags) failed to restrict `%s` flag!\nerror; %v",
+			outputFlag,
+			err,
+		)
+	}
+
 	ffmpegFlag := "ffmpeg" // easy modification
 	command.Flags().StringVar(
 		&input.FFmpegPath,
 		ffmpegFlag,
-		*ffmpegPath, // empty string if not found
+		defaults.FFmpegPath, // located through the `PATH` if blank
 		"Path to ffmpeg executable",
 	)
 
-	// Mark ffmpeg flag as required if the executable could not be located implicitly
-	if *ffmpegPath == "" {
-		if err := command.MarkFlagRequired(ffmpegFlag); err != nil {
-			log.Debugf(
-				failMsg,
-				ffmpegFlag,
-				err,
-			)
-		}
-	}
-
 	ffprobeFlag := "ffprobe" // easy modification
 	command.Flags().StringVar(
 		&input.FFprobePath,
 		ffprobeFlag,
-		*ffprobePath, // empty string if not found
+		defaults.FFprobePath, // located through the `PATH` if blank
 		"Path to ffprobe executable",
 	)
 
-	// Mark ffprobe flag as required if the executable could not be located
-	if *ffprobePath == "" {
-		if err := command.MarkFlagRequired(ffprobeFlag); err != nil {
-			log.Debugf(
-				failMsg,
-				ffprobeFlag,
-				err,
-			)
-		}
-	}
+	command.Flags().StringVar(
+		&input.TitleMap,
+		"title-map",
+		"",
+		"CSV file mapping subtitle files to track titles (and languages)",
+	)
 
-	command.Flags().StringSliceVarP(
-		&input.Exclusions,
-		"exclude",
-		"E",
-		[]string{},
-		"List of files to be ignored",
+	command.Flags().StringVar(
+		&input.ChaptersFile,
+		"chapters-file",
+		"",
+		"Chapters file for every source directory, {dir} is replaced by its name",
 	)
 
 	command.Flags().StringVar(
-		&input.RegexExclude,
-		"rexclude",
+		&input.MkvpropeditPath,
+		"mkvpropedit",
 		"",
-		"Regex pattern to dictate files to be ignored",
+		"Path to mkvpropedit executable, used for statistics tags and the fast path",
 	)
 
 	command.Flags().StringVar(
@@ -197,13 +603,156 @@ func stringFlags(command *cobra.Command, input *commons.UserInput, ffmpegPath,
 		"Custom title for subtitles files",
 	)
 
+	command.Flags().StringVar(
+		&input.ScheduleWindow,
+		"schedule",
+		"",
+		"Wall-clock window (HH:MM-HH:MM) in which media files are processed",
+	)
+
+	command.Flags().StringVar(
+		&input.IOLimit,
+		"io-limit",
+		"",
+		"Read throughput shared by the FFmpeg processes, i.e. 50M (per second)",
+	)
+
 	command.Flags().StringVarP(
 		&input.SubLang,
 		"language",
 		"l",
-		"eng", // set default subtitle language to english
+		defaults.Language, // defaults to english, unless resolved otherwise
 		"Subtitle language",
 	)
+
+	command.Flags().StringSliceVar(
+		&input.SubOrder,
+		"sub-order",
+		[]string{},
+		"Languages (lang1,lang2,...) in the order subtitles are to be attached",
+	)
+
+	command.Flags().StringArrayVar(
+		&input.AttachmentDesc,
+		"attachment-desc",
+		[]string{},
+		"Description for attached fonts or chapters (font=<desc>, chapter=<desc>)",
+	)
+
+	command.Flags().StringVar(
+		&input.OnCollision,
+		"on-collision",
+		commons.CollisionError,
+		"Policy for output names colliding across source directories "+
+			"(error, suffix or mirror)",
+	)
+
+	command.Flags().StringVar(
+		&input.CollisionSuffix,
+		"collision-suffix",
+		commons.SuffixCounter,
+		"Suffix for colliding output names with the suffix policy "+
+			"(counter, dir or hash)",
+	)
+
+	command.Flags().StringVar(
+		&input.OutputLayout,
+		"output-layout",
+		commons.LayoutCentral,
+		"Where outputs are stored (central, sibling or subfolder)",
+	)
+
+	command.Flags().StringVar(
+		&input.SDHRegex,
+		"sdh-regex",
+		"",
+		"Regex pattern for subtitle files meant for the hearing impaired (SDH)",
+	)
+
+	command.Flags().StringVar(
+		&input.FFmpegLogLevel,
+		"ffmpeg-loglevel",
+		commons.DefaultLogLevel,
+		"Log level for FFmpeg (quiet, error, warning, info, verbose, debug...)",
+	)
+
+	command.Flags().StringVar(
+		&input.MetricsAddr,
+		"metrics-addr",
+		"",
+		"Address (host:port) to serve Prometheus metrics for the run on",
+	)
+
+	command.Flags().StringVar(
+		&input.PlanFile,
+		"plan-file",
+		"",
+		"Save the plan for the run to a file (implies --plan), without merging",
+	)
+
+	command.Flags().StringVar(
+		&input.ApplyPlan,
+		"apply",
+		"",
+		"Process only the source directories in a plan saved through --plan-file",
+	)
+
+	command.Flags().StringVar(
+		&input.Order,
+		"order",
+		commons.OrderName,
+		"Order source directories are processed in "+
+			"(name, size-asc, size-desc or mtime)",
+	)
+
+	command.Flags().StringArrayVar(
+		&input.Env,
+		"env",
+		[]string{},
+		"Environment variable (KEY=VALUE) for FFmpeg processes, can be repeated",
+	)
+
+	command.Flags().StringSliceVar(
+		&input.CopyExtras,
+		"copy-extras",
+		[]string{},
+		"Glob pattern(s) for files to be copied as is into the output directory",
+	)
+
+	command.Flags().StringVar(
+		&input.UILanguage,
+		"lang-ui",
+		"",
+		"Language for messages shown, picked from LANG if not specified",
+	)
+
+	command.Flags().StringVar(
+		&input.SubPosition,
+		"sub-position",
+		commons.SubPositionBack,
+		"Place attached subtitles before (front) or after (back) existing subtitles",
+	)
+
+	command.Flags().StringVar(
+		&input.SubEncoding,
+		"sub-encoding",
+		commons.EncodingAuto,
+		"Encoding of text subtitles (auto, keep, or an encoding such as CP1251)",
+	)
+
+	command.Flags().StringVar(
+		&input.SubtitleCodec,
+		"subtitle-codec",
+		commons.SubCodecAuto,
+		"Codec for the subtitles attached (auto, copy, srt or ass)",
+	)
+
+	command.Flags().StringVar(
+		&input.ProbeCache,
+		"probe-cache",
+		config.ProbeCachePath(title), // empty string if cache directory is unknown
+		"File in which FFprobe results are cached across runs",
+	)
 }
 
 /*
@@ -235,6 +784,77 @@ func findBinaries() (ffmpegPath, ffprobePath string) {
 	return ffmpegPath, ffprobePath
 }
 
+/*
+LocateBinaries fills in the paths to the ffmpeg and ffprobe executables not supplied by
+the user (or through the defaults), by looking them up in the `PATH` - paths that can't
+be located are left blank. The path to mkvpropedit is looked up the same way, only if
+it is of use to the run.
+
+Called only by the commands that run the executables, or report the paths - commands
+such as `--help` never look up the executables.
+*/
+func locateBinaries(input *commons.UserInput) {
+	// Track statistics tags are written by mkvpropedit, the fast path is taken only
+	// if mkvpropedit is present - outputs are remuxed otherwise
+	if input.MkvpropeditPath == "" && (input.StatsTags || !input.NoFastPath) {
+		if path, err := exec.LookPath("mkvpropedit"); err != nil {
+			log.Debugf("(cmd/locateBinaries) unable to locate mkvpropedit \n`%v`", err)
+		} else {
+			input.MkvpropeditPath = path
+		}
+	}
+
+	if input.FFmpegPath != "" && input.FFprobePath != "" {
+		return
+	}
+
+	ffmpegPath, ffprobePath := findBinaries()
+	if input.FFmpegPath == "" {
+		input.FFmpegPath = ffmpegPath
+	}
+
+	if input.FFprobePath == "" {
+		input.FFprobePath = ffprobePath
+	}
+}
+
+/*
+RequireBinaries force-stops the application if the path to the ffmpeg or ffprobe
+executable is missing (or mkvpropedit, with track statistics tags), should be called
+once the executables are located - only runs that merge files require the executables.
+*/
+func requireBinaries(input *commons.UserInput) {
+	for _, exe := range []struct{ flag, path string }{
+		{"ffmpeg", input.FFmpegPath},
+		{"ffprobe", input.FFprobePath},
+	} {
+		if exe.path != "" {
+			continue
+		}
+
+		log.Warnf("(cmd/requireBinaries) unable to locate %s", exe.flag)
+		commons.Error(
+			"Unable to locate %s, use the `--%s` flag to set the path to the "+
+				"executable\n\n",
+			exe.flag,
+			exe.flag,
+		)
+
+		os.Exit(commons.ExecNotFound)
+	}
+
+	// FFmpeg can't write track statistics tags, these require mkvpropedit
+	if input.StatsTags && input.MkvpropeditPath == "" {
+		log.Warnf("(cmd/requireBinaries) unable to locate mkvpropedit")
+		commons.Error(
+			"`--stats-tags` requires mkvpropedit (MKVToolNix), use the " +
+				"`--mkvpropedit` flag to set the path to the executable\n\n",
+		)
+
+		os.Exit(commons.ExecNotFound)
+	}
+}
+
 /*
 HandlerTest is a function designed to consume `test` flag. This function will attempt to
 test the entire setup - to be used by users after to check if dependencies are present
@@ -247,38 +867,33 @@ Return value of empty string(s) signifies an error occurred while attempting to
 the executable(s) - in case of an error, the traceback will be logged implicitly
 */
 func handlerTest() (ffmpegVersion, ffprobeVersion string) {
-	// Regex pattern to fetch the next word after the word `version` to fetch the
-	// version tag from the output of the command. Might need to change it if the
-	// output of ffmpeg is modified.
-	regex := regexp.MustCompile(`version (\S*)`)
+	// Running ffmpeg executable with a `-version` flag - the version is extracted
+	// from the banner, builds with an unrecognized banner report the first line.
+	output, err := commons.ToolCommand(
+		context.Background(),
+		userInput.FFmpegPath,
+		"-version",
+	).Output()
 
-	// Running ffmpeg executable with a `-version` flag.
-	output, err := exec.Command(userInput.FFmpegPath, "-version").Output()
 	if err != nil {
 		// If error occurs, log and proceed normally - `ffmpegVersion` will remain blank
 		log.Warnf("(rootCmd/handlerTest) failed to fetch ffmpeg version: \n%v", err)
 	} else {
-		// Extracting version from the output of the command.
-		//
-		// Note: The first index in the result will be the entire string that matches
-		// the regex pattern, following this, (index 1 and on) will be contents from the
-		// capture group(s) sequentially.
-		//
-		// Extracting info from the first capture group (at index 1) directly. If the
-		// output of `ffmpeg -version` command changes in the future, this may need
-		// to be modified.
-		ffmpegVersion = string(regex.FindSubmatch(output)[1])
+		ffmpegVersion = commons.ParseVersion(string(output))
 	}
 
 	// Running the same command for ffprobe
-	output, err = exec.Command(userInput.FFprobePath, "-version").Output()
+	output, err = commons.ToolCommand(
+		context.Background(),
+		userInput.FFprobePath,
+		"-version",
+	).Output()
+
 	if err != nil {
 		// If error occurs, log and proceed - `ffprobeVersion` will be a blank string.
 		log.Warnf("(rootCmd/handlerTest) failed to fetch ffprobe version: \n%v", err)
 	} else {
-		// Note: Using `regex.FindSubmatch` - same as above. Might need to modify this
-		// if the output of version command changes.
-		ffprobeVersion = string(regex.FindSubmatch(output)[1])
+		ffprobeVersion = commons.ParseVersion(string(output))
 	}
 
 	// If `err` was not null in any scenario, the string will be empty.
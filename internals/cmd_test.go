@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
 
 	"github.com/spf13/cobra"
 
@@ -82,6 +83,81 @@ func TestFetchLocation(t *testing.T) {
 	}
 }
 
+func TestLocateBinaries(t *testing.T) {
+	defer monkey.Unpatch(exec.LookPath)
+
+	// Executables are never looked up if both paths are present
+	monkey.Patch(exec.LookPath, func(string) (string, error) {
+		t.Errorf("(cmd/locateBinaries) executable looked up unexpectedly")
+		return "", errors.New("")
+	})
+
+	input := commons.UserInput{
+		FFmpegPath:      "ffmpeg path",
+		FFprobePath:     "probe path",
+		MkvpropeditPath: "mkvpropedit path",
+	}
+
+	locateBinaries(&input)
+
+	// mkvpropedit is never looked up if it is of no use
+	input = commons.UserInput{
+		FFmpegPath:  "ffmpeg path",
+		FFprobePath: "probe path",
+		NoFastPath:  true,
+	}
+
+	locateBinaries(&input)
+
+	// Blank paths are filled in, paths supplied by the user are kept as is
+	monkey.Patch(exec.LookPath, func(file string) (string, error) {
+		return "/bin/" + file, nil
+	})
+
+	input = commons.UserInput{FFmpegPath: "ffmpeg path"}
+	locateBinaries(&input)
+	if input.FFmpegPath != "ffmpeg path" || input.FFprobePath != "/bin/ffprobe" ||
+		input.MkvpropeditPath != "/bin/mkvpropedit" {
+		t.Errorf(
+			"(cmd/locateBinaries) unexpected paths \nffmpeg: %q \nffprobe: %q"+
+				"\nmkvpropedit: %q",
+			input.FFmpegPath,
+			input.FFprobePath,
+			input.MkvpropeditPath,
+		)
+	}
+}
+
+func TestRequireBinaries(t *testing.T) {
+	exitCode := commons.StatusOK
+	defer monkey.Unpatch(os.Exit)
+	monkey.Patch(os.Exit, func(code int) {
+		exitCode = code
+	})
+
+	requireBinaries(&commons.UserInput{FFmpegPath: "ffmpeg", FFprobePath: "ffprobe"})
+	if exitCode != commons.StatusOK {
+		t.Errorf("(cmd/requireBinaries) unexpected exit code: %d", exitCode)
+	}
+
+	requireBinaries(&commons.UserInput{FFmpegPath: "ffmpeg"})
+	if exitCode != commons.ExecNotFound {
+		t.Errorf("(cmd/requireBinaries) missing executable not reported")
+	}
+
+	// Track statistics tags require mkvpropedit
+	exitCode = commons.StatusOK
+	requireBinaries(&commons.UserInput{
+		FFmpegPath:  "ffmpeg",
+		FFprobePath: "ffprobe",
+		StatsTags:   true,
+	})
+
+	if exitCode != commons.ExecNotFound {
+		t.Errorf("(cmd/requireBinaries) missing mkvpropedit not reported")
+	}
+}
+
 /*
 TestExecute runs tests on the Execute method.
 
@@ -130,9 +206,8 @@ func TestExecute(t *testing.T) {
 }
 
 func TestStringFlags(t *testing.T) {
-	// The functioning of `stringFlags()` involves adding flags and marking them as
-	// required if needed; the former doesn't need to be tested (no chance of failure)
-	// and the latter can't be tested (API limitations)
+	// The functioning of `stringFlags()` involves adding flags and restricting their
+	// auto-completion; the former doesn't need to be tested (no chance of failure)
 	//
 	// This test function will simply use patches to imitate failure where needed to
 	// improve coverage score - failure can't be tested either since failure handling
@@ -159,8 +234,7 @@ func TestStringFlags(t *testing.T) {
 		stringFlags(
 			rootCmd,
 			&input,
-			&in.ffmpegPath,
-			&in.ffprobePath,
+			&config.Defaults{FFmpegPath: in.ffmpegPath, FFprobePath: in.ffprobePath},
 		)
 	}
 
@@ -175,21 +249,8 @@ func TestStringFlags(t *testing.T) {
 		func(*cobra.Command, string) error { return errors.New("test error") },
 	)
 
-	defer monkey.UnpatchInstanceMethod(
-		reflect.TypeOf(rootCmd),
-		"MarkFlagRequired",
-	)
-
-	monkey.PatchInstanceMethod(
-		reflect.TypeOf(rootCmd),
-		"MarkFlagRequired",
-		func(*cobra.Command, string) error { return errors.New("testo error") },
-	)
-
-	blank := ""
-
 	rootCmd.ResetFlags()
-	stringFlags(rootCmd, &input, &blank, &blank)
+	stringFlags(rootCmd, &input, &config.Defaults{})
 }
 
 /*
@@ -0,0 +1,58 @@
+package commons
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/*
+NameCase decides how names are compared by the rules matching files and directories -
+exclusions, inclusions, regex rules, the ignore files and the patterns for extra files.
+Names are compared irrespective of case unless the comparison is case sensitive; the
+same rules then behave the same on case-insensitive file systems (Windows, macOS) and
+the rest.
+*/
+type NameCase struct {
+	Sensitive bool
+}
+
+// Fold returns the form of a name (or pattern) used while comparing names
+func (casing NameCase) Fold(name string) string {
+	if casing.Sensitive {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+// Equal checks if two names are the same
+func (casing NameCase) Equal(a, b string) bool {
+	if casing.Sensitive {
+		return a == b
+	}
+
+	return strings.EqualFold(a, b)
+}
+
+/*
+Glob checks if a name matches a glob pattern, malformed patterns never match - patterns
+should be validated through `filepath.Match()` beforehand.
+*/
+func (casing NameCase) Glob(pattern, name string) bool {
+	match, _ := filepath.Match(casing.Fold(pattern), casing.Fold(name))
+	return match
+}
+
+/*
+Compile compiles a regex pattern, the pattern matches irrespective of case unless the
+comparison is case sensitive. Flags set in the pattern itself take precedence, i.e.
+`(?-i)` keeps a pattern case sensitive regardless.
+*/
+func (casing NameCase) Compile(pattern string) (*regexp.Regexp, error) {
+	if casing.Sensitive {
+		return regexp.Compile(pattern)
+	}
+
+	return regexp.Compile("(?i)" + pattern)
+}
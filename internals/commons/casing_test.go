@@ -0,0 +1,48 @@
+package commons
+
+import "testing"
+
+func TestNameCase(t *testing.T) {
+	insensitive, sensitive := NameCase{}, NameCase{Sensitive: true}
+
+	if !insensitive.Equal("Movie.NFO", "movie.nfo") || sensitive.Equal(
+		"Movie.NFO",
+		"movie.nfo",
+	) || !sensitive.Equal("movie.nfo", "movie.nfo") {
+		t.Errorf("(casing/Equal) unexpected result while comparing names")
+	}
+
+	if !insensitive.Glob("*S02E*", "show s02e01") || sensitive.Glob(
+		"*S02E*",
+		"show s02e01",
+	) || insensitive.Glob("[invalid", "[invalid") {
+		t.Errorf("(casing/Glob) unexpected result while matching glob patterns")
+	}
+
+	for _, in := range []struct {
+		casing  NameCase
+		pattern string
+		name    string
+		result  bool
+	}{
+		{insensitive, `^sample`, "Sample.mkv", true},
+		{sensitive, `^sample`, "Sample.mkv", false},
+		{sensitive, `(?i)^sample`, "Sample.mkv", true},
+		{insensitive, `(?-i)^sample`, "Sample.mkv", false},
+	} {
+		rule, err := in.casing.Compile(in.pattern)
+		if err != nil || rule.MatchString(in.name) != in.result {
+			t.Errorf(
+				"(casing/Compile) unexpected result \npattern: `%s` \nsensitive: %v"+
+					"\nerror: %v",
+				in.pattern,
+				in.casing.Sensitive,
+				err,
+			)
+		}
+	}
+
+	if _, err := insensitive.Compile("(]"); err == nil {
+		t.Errorf("(casing/Compile) malformed pattern compiled")
+	}
+}
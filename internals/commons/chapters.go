@@ -0,0 +1,27 @@
+package commons
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Placeholder in the path to the chapters file, replaced with the name of each source
+// directory
+const ChaptersDirPlaceholder = "{dir}"
+
+/*
+ChaptersPath returns the path to the chapters file supplied by the user for a source
+directory, with the placeholder replaced by the name of the source directory - the path
+is blank if chapters are to be detected from the source directory.
+*/
+func (userInput *UserInput) ChaptersPath(sourceDir string) string {
+	if userInput.ChaptersFile == "" {
+		return ""
+	}
+
+	return strings.ReplaceAll(
+		userInput.ChaptersFile,
+		ChaptersDirPlaceholder,
+		filepath.Base(sourceDir),
+	)
+}
@@ -0,0 +1,55 @@
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChaptersFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(chapters/ChaptersPath) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Chapters are detected from the source directories by default
+	input := UserInput{IsTest: true}
+	code, _ := input.Initialize()
+	if code != StatusOK || input.ChaptersPath(dir) != "" {
+		t.Errorf("(chapters/ChaptersPath) unexpected path without a chapters file")
+	}
+
+	// The same file is used for every source directory
+	path := filepath.Join(dir, "chapters.xml")
+	_ = ioutil.WriteFile(path, []byte("<Chapters/>"), 0644)
+
+	input = UserInput{ChaptersFile: path, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK || err != nil ||
+		input.ChaptersPath("/media/Show S01") != path {
+		t.Errorf("(chapters/ChaptersPath) unexpected path \nerror: %v", err)
+	}
+
+	// The placeholder is replaced by the name of the source directory, the file is
+	// checked for each source directory instead
+	template := filepath.Join(dir, "{dir}.xml")
+	input = UserInput{ChaptersFile: template, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK || err != nil {
+		t.Errorf("(chapters/Initialize) template rejected \nerror: %v", err)
+	}
+
+	expected := filepath.Join(dir, "Show S01.xml")
+	if res := input.ChaptersPath("/media/Show S01"); res != expected {
+		t.Errorf("(chapters/ChaptersPath) expected %q, got %q", expected, res)
+	}
+
+	// Missing files and directories can't be used
+	for _, invalid := range []string{filepath.Join(dir, "missing.xml"), dir} {
+		input = UserInput{ChaptersFile: invalid, IsTest: true}
+		if code, _ := input.Initialize(); code != ChaptersError {
+			t.Errorf("(chapters/Initialize) invalid chapters file used: %q", invalid)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package commons
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -29,6 +31,93 @@ const (
 	// no subtitle/attachment/chapter file to attach, etc.
 	SourceDirectoryError = 15
 
+	// Exit code used when the root directory is read-only, and the results can't be
+	// stored inside it (i.e. a custom output directory is required)
+	ReadOnlyRoot = 16
+
+	// Ran into an error while attempting to parse a glob pattern
+	GlobError = 17
+
+	// Exit code used when the run is cancelled midway - for example, by an interrupt
+	Interrupted = 18
+
+	// Exit code used when flags that can't be used together are combined
+	FlagConflict = 19
+
+	// Ran into an error while attempting to parse the schedule window
+	ScheduleError = 20
+
+	// Language supplied for the subtitles is not a valid language code
+	LanguageError = 21
+
+	// Ran into an error while transferring files from (or to) a remote root directory
+	RemoteError = 22
+
+	// Exit code used when the run is stopped as it would modify existing files while
+	// running in safe mode
+	SafetyViolation = 23
+
+	// Output names collide across source directories, or the policy to handle the
+	// collisions is invalid
+	OutputCollision = 24
+
+	// Description supplied for an attachment type is invalid
+	AttachmentError = 25
+
+	// Character encoding supplied for subtitle files is invalid
+	EncodingError = 26
+
+	// Configuration file is invalid, or the profile selected is not present in it
+	ConfigError = 27
+
+	// Position supplied for the subtitle streams attached is invalid
+	SubPositionError = 28
+
+	// Not enough free space for the outputs, and the user chose not to continue
+	InsufficientSpace = 29
+
+	// Environment variable supplied for the FFmpeg processes is invalid
+	EnvError = 30
+
+	// Title map supplied for the subtitle files can't be read, or is malformed
+	TitleMapError = 31
+
+	// Codec supplied for the subtitle streams is unknown, or can't be used with the
+	// output container
+	SubCodecError = 32
+
+	// Chapters file supplied for the source directories can't be read
+	ChaptersError = 33
+
+	// Output for a media file would overwrite one of the files it is created from
+	SameFileError = 34
+
+	// Order supplied for the source directories is unknown
+	OrderError = 35
+
+	// Log level supplied for the FFmpeg processes is unknown
+	LogLevelError = 36
+
+	// Address supplied for the metrics endpoint is malformed, or can't be listened on
+	MetricsError = 37
+
+	// Plan applied to the run can't be read, doesn't match the root directory, or the
+	// plan for the run can't be saved
+	PlanError = 38
+
+	// Source directory ran past the time allowed for it, scaled by the duration of
+	// its media files
+	TimedOut = 39
+
+	// Layout supplied for the outputs is unknown
+	LayoutError = 40
+
+	// Limit supplied for the read throughput of the FFmpeg processes is malformed
+	IOLimitError = 41
+
+	// Duration supplied for sample mode is negative
+	SampleError = 42
+
 	// Exit code for a successful termination.
 	StatusOK = 0
 
@@ -43,6 +132,10 @@ var (
 	// The main output stream - can be set only once during the lifetime of the
 	// application, any output to be sent to the user will be written to this stream.
 	outStream io.Writer = nil
+
+	// Serializes writes to the console - messages are printed by the main thread and
+	// the goroutines tracking the encodes alike, each write is kept whole
+	writeMu sync.Mutex
 )
 
 /*
@@ -63,8 +156,8 @@ func SetOutput(stream io.Writer) {
 				"when it has a value already",
 		)
 
-		Printf(
-			"Error: This error is should not occur. \n\nIf you're seeing this " +
+		Error(
+			"This error is should not occur. \n\nIf you're seeing this " +
 				"message, someone isn't doing their job properly\n\n\t\t(0_0/)\n\n",
 		)
 
@@ -83,18 +176,26 @@ func GetOutput() io.Writer {
 Printf is a simple method that acts as a bridge between the application and the user
 
 It is designed to print messages to the console, and provides the same interface as
-`fmt.Printf` - providing a layer of abstraction along ease of modification.
+`fmt.Printf` - providing a layer of abstraction along ease of modification. The format
+string is translated to the language selected for messages (if a translation exists).
 */
 func Printf(format string, printable ...interface{}) {
 	if outStream == nil {
 		return
 	}
 
-	_, _ = fmt.Fprintf(
-		outStream,
-		format,
-		printable...,
-	)
+	write(outStream, fmt.Sprintf(Translate(format), printable...))
+}
+
+/*
+Write writes the text to the stream in a single write, while holding the lock for the
+console - text written by other goroutines never ends up in the middle of it.
+*/
+func write(stream io.Writer, text string) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	_, _ = io.WriteString(stream, text)
 }
 
 /*
@@ -116,3 +217,36 @@ func Stringify(files *[]os.FileInfo) string {
 
 	return res
 }
+
+/*
+IsWritable is a simple helper function to check if new items can be created inside a
+directory - useful to detect directories present on a read-only mount.
+
+The check is performed by creating (and immediately removing) a temporary file inside
+the directory. A response of false indicates that the directory can't be written to.
+*/
+func IsWritable(dir string) bool {
+	file, err := ioutil.TempFile(dir, ".auto-sub-*")
+	if err != nil {
+		log.Debugf(
+			"(commons/IsWritable) unable to write to directory: \"%s\" \nerror: %v",
+			dir,
+			err,
+		)
+
+		return false
+	}
+
+	// Close the connection to the file, and remove it - failure to remove the file
+	// does not matter, the directory is writable regardless
+	_ = file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		log.Debugf(
+			"(commons/IsWritable) failed to remove temporary file: \"%s\" \nerror: %v",
+			file.Name(),
+			err,
+		)
+	}
+
+	return true
+}
@@ -76,8 +76,8 @@ func TestPrintf(t *testing.T) {
 
 	// Test to ensure a call to `Printf` is ignored in case out-stream is null
 	outStream = nil
-	defer monkey.Unpatch(fmt.Fprintf)
-	monkey.Patch(fmt.Fprintf, func(io.Writer, string, ...interface{}) (int, error) {
+	defer monkey.Unpatch(io.WriteString)
+	monkey.Patch(io.WriteString, func(io.Writer, string) (int, error) {
 		t.Errorf(
 			"(commons/Printf) running `Printf()` when `outStream` is null!",
 		)
@@ -145,3 +145,40 @@ func TestStringify(t *testing.T) {
 		)
 	}
 }
+
+func TestIsWritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf(
+			"(commons/IsWritable) failed to create temp directory \nerror: %v",
+			err,
+		)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if !IsWritable(dir) {
+		t.Errorf(
+			"(commons/IsWritable) temporary directory reported as read-only"+
+				"\npath: \"%s\"",
+			dir,
+		)
+	}
+
+	// The check should not leave anything behind in the directory
+	if items, _ := ioutil.ReadDir(dir); len(items) != 0 {
+		t.Errorf(
+			"(commons/IsWritable) check left behind %d item(s) in the directory",
+			len(items),
+		)
+	}
+
+	// Non-existent directories can't be written to
+	if invalid := filepath.Join(dir, "invalid dir"); IsWritable(invalid) {
+		t.Errorf(
+			"(commons/IsWritable) non-existent directory reported as writable"+
+				"\npath: \"%s\"",
+			invalid,
+		)
+	}
+}
@@ -0,0 +1,180 @@
+package commons
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+/*
+Level indicates the severity of a message printed to the console
+*/
+type Level int
+
+/*
+Levels for the messages printed to the console, in the increasing order of severity
+*/
+const (
+	// Progress and other informational messages, hidden in quiet mode
+	LevelInfo Level = iota
+
+	// Messages confirming that something completed, hidden in quiet mode
+	LevelSuccess
+
+	// Problems that do not stop the run
+	LevelWarn
+
+	// Problems that stop the run (or a part of it), written to the error stream
+	LevelError
+)
+
+// Prefixes added to the messages by level, translated along with the messages
+var levelPrefixes = map[Level]string{
+	LevelWarn:  "Warning: ",
+	LevelError: "Error: ",
+}
+
+// ANSI escapes used to color the messages by level - the prefix is colored if the
+// level has one, the message itself otherwise
+var levelColors = map[Level]string{
+	LevelSuccess: "\x1b[32m",
+	LevelWarn:    "\x1b[33m",
+	LevelError:   "\x1b[31m",
+}
+
+// ANSI escape resetting the color
+const colorReset = "\x1b[0m"
+
+var (
+	consoleMu sync.RWMutex
+
+	// Booleans indicating if informational messages are to be hidden, and if messages
+	// can be colored (only ever done if the stream is a terminal)
+	quietConsole = false
+	colorConsole = true
+
+	// Stream to which errors are written, errors go to the output stream if not set
+	errStream io.Writer = nil
+)
+
+/*
+SetConsole sets the modes for the console - in quiet mode, only warnings and errors are
+printed. Colors are used only if enabled, the stream is a terminal and the `NO_COLOR`
+environment variable is not set.
+*/
+func SetConsole(quiet, color bool) {
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+
+	quietConsole = quiet
+	colorConsole = color
+}
+
+/*
+Quiet returns a boolean indicating if the console is in quiet mode
+*/
+func Quiet() bool {
+	consoleMu.RLock()
+	defer consoleMu.RUnlock()
+
+	return quietConsole
+}
+
+/*
+SetErrorOutput sets the stream to which errors are written, typically the standard
+error - setting a nil stream writes errors to the output stream instead.
+*/
+func SetErrorOutput(stream io.Writer) {
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+
+	errStream = stream
+}
+
+/*
+Info prints an informational message, takes the same arguments as `Printf`
+*/
+func Info(format string, printable ...interface{}) {
+	printLevel(LevelInfo, format, printable...)
+}
+
+/*
+Success prints a message confirming that something completed, takes the same arguments
+as `Printf`
+*/
+func Success(format string, printable ...interface{}) {
+	printLevel(LevelSuccess, format, printable...)
+}
+
+/*
+Warn prints a warning, prefixed with `Warning:` - takes the same arguments as `Printf`
+*/
+func Warn(format string, printable ...interface{}) {
+	printLevel(LevelWarn, format, printable...)
+}
+
+/*
+Error prints an error to the error stream, prefixed with `Error:` - takes the same
+arguments as `Printf`
+*/
+func Error(format string, printable ...interface{}) {
+	printLevel(LevelError, format, printable...)
+}
+
+/*
+PrintLevel formats the message and writes it to the stream for the level, along with
+the prefix and the color for the level. Newlines leading the message are kept ahead
+of the prefix.
+*/
+func printLevel(level Level, format string, printable ...interface{}) {
+	consoleMu.RLock()
+	quiet, color, stream := quietConsole, colorConsole, outStream
+	if level == LevelError && errStream != nil {
+		stream = errStream
+	}
+	consoleMu.RUnlock()
+
+	if stream == nil || (quiet && level < LevelWarn) {
+		return
+	}
+
+	msg := Sprintf(format, printable...)
+	body := strings.TrimLeft(msg, "\n")
+	leading := msg[:len(msg)-len(body)]
+
+	prefix := ""
+	if levelPrefixes[level] != "" {
+		prefix = Translate(levelPrefixes[level])
+	}
+
+	if escape, ok := levelColors[level]; ok && color && colorTerminal(stream) {
+		if prefix != "" {
+			prefix = escape + prefix + colorReset
+		} else {
+			// Trailing whitespace is left out of the colored part
+			text := strings.TrimRight(body, " \n")
+			body = escape + text + colorReset + body[len(text):]
+		}
+	}
+
+	write(stream, leading+prefix+body)
+}
+
+/*
+ColorTerminal returns a boolean indicating if colors can be written to the stream, i.e.
+the stream is a terminal and colors are not disabled through `NO_COLOR`
+*/
+func colorTerminal(stream io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	file, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+
+	_, ok = terminalWidth(file.Fd())
+	return ok
+}
@@ -0,0 +1,55 @@
+package commons
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestConsole(t *testing.T) {
+	defer func(stream io.Writer) { outStream = stream }(outStream)
+	defer SetErrorOutput(nil)
+	defer SetConsole(false, true)
+
+	out, errs := &bytes.Buffer{}, &bytes.Buffer{}
+	outStream = out
+	SetConsole(false, true)
+
+	// Errors go to the output stream unless an error stream is set
+	Info("info %d\n", 1)
+	Success("success\n")
+	Warn("warn\n")
+	Error("\nerror: %v\n", "test")
+
+	// Colors are never written to streams that aren't terminals
+	expected := "info 1\nsuccess\nWarning: warn\n\nError: error: test\n"
+	if out.String() != expected {
+		t.Errorf(
+			"(console/printLevel) unexpected output \nexpected: %q \nretrieved: %q",
+			expected,
+			out.String(),
+		)
+	}
+
+	out.Reset()
+	SetErrorOutput(errs)
+	SetConsole(true, true)
+	if !Quiet() {
+		t.Errorf("(console/Quiet) quiet mode not set")
+	}
+
+	// Only warnings and errors are printed in quiet mode
+	Info("info\n")
+	Success("success\n")
+	Warn("warn\n")
+	Error("error\n")
+
+	if out.String() != "Warning: warn\n" || errs.String() != "Error: error\n" {
+		t.Errorf(
+			"(console/printLevel) unexpected output in quiet mode \noutput: %q"+
+				"\nerrors: %q",
+			out.String(),
+			errs.String(),
+		)
+	}
+}
@@ -0,0 +1,105 @@
+package commons
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+/*
+Locale forced on FFmpeg/FFprobe, distro-patched builds translating their output follow
+the locale - the C locale keeps the output in English, with a period as the decimal
+separator.
+*/
+const toolLocale = "C"
+
+/*
+Pattern to extract the version from the banner printed by `-version`, distro builds
+differ in case (`FFmpeg version`) and in the format of the version, i.e. `n4.3.1` or
+`4.2.4-1ubuntu0.1`
+*/
+var versionPattern = regexp.MustCompile(`(?i)\bversion:?\s+(\S+)`)
+
+var (
+	toolEnvMu sync.RWMutex
+
+	// Variables (in the form `KEY=VALUE`) added to the environment of FFmpeg/FFprobe,
+	// overriding the variables inherited
+	toolVariables []string
+)
+
+/*
+SetToolEnv sets the variables added to the environment of every FFmpeg/FFprobe process,
+each in the form `KEY=VALUE`. The locale can't be overridden.
+*/
+func SetToolEnv(variables []string) {
+	toolEnvMu.Lock()
+	defer toolEnvMu.Unlock()
+
+	toolVariables = append([]string{}, variables...)
+}
+
+/*
+ToolCommand returns the command to run an FFmpeg executable (FFmpeg or FFprobe) with
+the arguments supplied, forcing the C locale - ensures the output of the command can be
+parsed irrespective of the locale, or the build in use.
+*/
+func ToolCommand(ctx context.Context, executable string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Env = toolEnv(os.Environ())
+
+	return cmd
+}
+
+/*
+ToolEnv returns the environment with the variables set by the user and the locale
+forced, variables overriding the locale are replaced - `LANGUAGE` takes precedence over
+`LC_ALL` for translations.
+*/
+func toolEnv(environ []string) []string {
+	toolEnvMu.RLock()
+	defer toolEnvMu.RUnlock()
+
+	replaced := map[string]bool{"LC_ALL": true, "LANGUAGE": true}
+	for _, variable := range toolVariables {
+		replaced[envKey(variable)] = true
+	}
+
+	env := make([]string, 0, len(environ)+len(toolVariables)+1)
+	for _, variable := range environ {
+		if !replaced[envKey(variable)] {
+			env = append(env, variable)
+		}
+	}
+
+	env = append(env, toolVariables...)
+	return append(env, "LC_ALL="+toolLocale)
+}
+
+/*
+EnvKey returns the name of an environment variable in the form `KEY=VALUE`
+*/
+func envKey(variable string) string {
+	if i := strings.Index(variable, "="); i >= 0 {
+		return variable[:i]
+	}
+
+	return variable
+}
+
+/*
+ParseVersion extracts the version from the output of `<executable> -version`, the first
+line is returned as is if the banner is not recognized. Returns a blank string for a
+blank output.
+*/
+func ParseVersion(output string) string {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(output), "\n", 2)[0])
+	if match := versionPattern.FindStringSubmatch(line); match != nil {
+		return match[1]
+	}
+
+	return line
+}
@@ -0,0 +1,74 @@
+package commons
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, in := range []struct {
+		output   string
+		expected string
+	}{
+		{"ffmpeg version 4.3.1 Copyright (c) 2000-2020", "4.3.1"},
+		{"FFmpeg Version n4.3.1 Copyright (c) 2000-2020", "n4.3.1"},
+		{"ffprobe version 4.2.4-1ubuntu0.1 Copyright", "4.2.4-1ubuntu0.1"},
+		{"\nffmpeg version: N-100401-g1b3d4e\nbuilt with gcc", "N-100401-g1b3d4e"},
+		{"custom build 2020\nconfiguration:", "custom build 2020"},
+		{"", ""},
+	} {
+		if res := ParseVersion(in.output); res != in.expected {
+			t.Errorf(
+				"(exec/ParseVersion) unexpected result for %q"+
+					"\nexpected: %s \nfound: %s",
+				in.output,
+				in.expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestToolEnv(t *testing.T) {
+	env := toolEnv([]string{
+		"PATH=/usr/bin",
+		"LC_ALL=de_DE.UTF-8",
+		"LANGUAGE=de",
+		"LANG=de_DE.UTF-8",
+	})
+
+	expected := []string{"PATH=/usr/bin", "LANG=de_DE.UTF-8", "LC_ALL=C"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf(
+			"(exec/toolEnv) unexpected environment \nexpected: %v \nfound: %v",
+			expected,
+			env,
+		)
+	}
+
+	// Variables set by the user replace the ones inherited, the locale is still forced
+	defer SetToolEnv(nil)
+	SetToolEnv([]string{"TMPDIR=/scratch", "CUDA_VISIBLE_DEVICES=1"})
+
+	env = toolEnv([]string{"PATH=/usr/bin", "TMPDIR=/tmp"})
+	expected = []string{
+		"PATH=/usr/bin",
+		"TMPDIR=/scratch",
+		"CUDA_VISIBLE_DEVICES=1",
+		"LC_ALL=C",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf(
+			"(exec/toolEnv) unexpected environment \nexpected: %v \nfound: %v",
+			expected,
+			env,
+		)
+	}
+
+	cmd := ToolCommand(context.Background(), "ffmpeg", "-version")
+	if len(cmd.Env) == 0 || cmd.Env[len(cmd.Env)-1] != "LC_ALL=C" {
+		t.Errorf("(exec/ToolCommand) locale not forced \nenvironment: %v", cmd.Env)
+	}
+}
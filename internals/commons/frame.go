@@ -0,0 +1,45 @@
+package commons
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Frame composes output that is to reach the console as a whole, i.e. a progress dialog
+along with the escapes moving the cursor to the start of the previous one. Nothing is
+written until the frame is flushed - the frame is then written at once, messages
+printed by other goroutines are never mixed into it, and the terminal never shows a
+half-drawn dialog.
+*/
+type Frame struct {
+	buf strings.Builder
+}
+
+/*
+Printf appends to the frame, takes the same arguments as `Printf` - the format string is
+translated as well
+*/
+func (frame *Frame) Printf(format string, printable ...interface{}) {
+	_, _ = fmt.Fprintf(&frame.buf, Translate(format), printable...)
+}
+
+// Len returns the number of bytes in the frame, yet to be written
+func (frame *Frame) Len() int {
+	return frame.buf.Len()
+}
+
+/*
+Flush writes the frame to the output stream in a single write, and empties the frame
+for reuse. Empty frames are not written.
+*/
+func (frame *Frame) Flush() {
+	text := frame.buf.String()
+	frame.buf.Reset()
+
+	if outStream == nil || text == "" {
+		return
+	}
+
+	write(outStream, text)
+}
@@ -0,0 +1,79 @@
+package commons
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	defer func(stream io.Writer) { outStream = stream }(outStream)
+
+	out := &bytes.Buffer{}
+	outStream = out
+
+	// Nothing is written until the frame is flushed
+	var frame Frame
+	frame.Printf("frame: %d", 1)
+	frame.Printf(", done %d%%", 50)
+	if out.Len() != 0 || frame.Len() == 0 {
+		t.Errorf("(frame/Printf) frame written before flush: %q", out.String())
+	}
+
+	frame.Flush()
+	if out.String() != "frame: 1, done 50%" || frame.Len() != 0 {
+		t.Errorf("(frame/Flush) unexpected output: %q", out.String())
+	}
+
+	// Empty frames are not written, neither are frames without an output stream
+	frame.Flush()
+	outStream = nil
+	frame.Printf("lost")
+	frame.Flush()
+	if out.String() != "frame: 1, done 50%" {
+		t.Errorf("(frame/Flush) unexpected output: %q", out.String())
+	}
+}
+
+func TestFrameConcurrent(t *testing.T) {
+	defer func(stream io.Writer) { outStream = stream }(outStream)
+
+	out := &bytes.Buffer{}
+	outStream = out
+
+	// Frames and messages written from several goroutines are never interleaved
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				var frame Frame
+				for k := 0; k < 10; k++ {
+					frame.Printf("f")
+				}
+
+				frame.Printf("\n")
+				frame.Flush()
+				Printf("%s\n", strings.Repeat("p", 10))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 800 {
+		t.Errorf("(frame/Flush) expected 800 lines, got %d", len(lines))
+	}
+
+	for _, line := range lines {
+		if line != strings.Repeat("f", 10) && line != strings.Repeat("p", 10) {
+			t.Errorf("(frame/Flush) torn output: %q", line)
+			break
+		}
+	}
+}
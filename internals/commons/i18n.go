@@ -0,0 +1,141 @@
+package commons
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+/*
+Catalog maps messages shown to the user to their translation in a language. Messages
+are identified by the English format string itself, i.e. the one passed to `Printf`
+or `Sprintf` - a message missing from a catalog is shown in English.
+
+Translations must contain the same formatting verbs, in the same order, as the
+English message.
+*/
+type Catalog map[string]string
+
+// Language used for messages when the selected language has no catalog
+const DefaultUILanguage = "en"
+
+var (
+	catalogMu sync.RWMutex
+
+	// Catalogs for each language (by their two-letter code). The English messages are
+	// present in the code, making the English catalog an empty one.
+	catalogs = map[string]Catalog{
+		DefaultUILanguage: {},
+	}
+
+	// Language currently used for messages
+	uiLanguage = DefaultUILanguage
+)
+
+/*
+RegisterCatalog registers the catalog for a language, replacing the existing catalog
+for the language (if any). The language is expected to be a two-letter code, values
+like `de_DE.UTF-8` are reduced to the language code.
+*/
+func RegisterCatalog(lang string, catalog Catalog) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	catalogs[normalizeLanguage(lang)] = catalog
+}
+
+/*
+HasCatalog returns a boolean indicating if a catalog is registered for the language
+*/
+func HasCatalog(lang string) bool {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	_, ok := catalogs[normalizeLanguage(lang)]
+	return ok
+}
+
+/*
+SetUILanguage selects the language used for messages shown to the user. Falls back to
+English if no catalog is registered for the language; the language selected is
+returned.
+*/
+func SetUILanguage(lang string) string {
+	lang = normalizeLanguage(lang)
+	if !HasCatalog(lang) {
+		lang = DefaultUILanguage
+	}
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	uiLanguage = lang
+	return uiLanguage
+}
+
+/*
+UILanguage returns the language currently used for messages shown to the user
+*/
+func UILanguage() string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	return uiLanguage
+}
+
+/*
+DetectUILanguage returns the language set through the environment, following the
+usual order of precedence - `LC_ALL`, `LC_MESSAGES` and then `LANG`. Returns an empty
+string if none of these are set.
+*/
+func DetectUILanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := normalizeLanguage(os.Getenv(env)); lang != "" {
+			return lang
+		}
+	}
+
+	return ""
+}
+
+/*
+NormalizeLanguage reduces a locale (like `pt_BR.UTF-8` or `de-DE`) to the lowercase
+language code. The `C` and `POSIX` locales are treated as English.
+*/
+func normalizeLanguage(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	lang = strings.ToLower(lang)
+	if lang == "c" || lang == "posix" {
+		return DefaultUILanguage
+	}
+
+	return lang
+}
+
+/*
+Translate returns the translation of a message in the language currently selected,
+the message is returned as-is if it is missing from the catalog.
+*/
+func Translate(msg string) string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	if translated, ok := catalogs[uiLanguage][msg]; ok {
+		return translated
+	}
+
+	return msg
+}
+
+/*
+Sprintf provides the same interface as `fmt.Sprintf`, translating the format string
+before formatting it - to be used for messages shown to the user.
+*/
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(Translate(format), a...)
+}
@@ -0,0 +1,92 @@
+package commons
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":            "",
+		"en":          "en",
+		"de_DE.UTF-8": "de",
+		"pt-BR":       "pt",
+		"FR":          "fr",
+		"C":           DefaultUILanguage,
+		"POSIX":       DefaultUILanguage,
+		"sr@latin":    "sr",
+	} {
+		if res := normalizeLanguage(input); res != expected {
+			t.Errorf(
+				"(i18n/normalizeLanguage) unexpected result for %q \nexpected: %q "+
+					"\nfound: %q",
+				input,
+				expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer func() {
+		SetUILanguage(DefaultUILanguage)
+		delete(catalogs, "xx")
+	}()
+
+	RegisterCatalog("xx_XX.UTF-8", Catalog{
+		"Processed: %d": "Verarbeitet: %d",
+	})
+
+	if lang := SetUILanguage("xx"); lang != "xx" {
+		t.Errorf("(i18n/SetUILanguage) expected `xx` to be selected, found %q", lang)
+	}
+
+	if res := Sprintf("Processed: %d", 4); res != "Verarbeitet: 4" {
+		t.Errorf("(i18n/Sprintf) message not translated, found %q", res)
+	}
+
+	// Messages missing from the catalog are shown in English
+	if res := Sprintf("Skipped: %d", 2); res != "Skipped: 2" {
+		t.Errorf("(i18n/Sprintf) unexpected message for a missing translation: %q", res)
+	}
+
+	// Languages without a catalog fall back to English
+	if lang := SetUILanguage("yy"); lang != DefaultUILanguage || UILanguage() != lang {
+		t.Errorf("(i18n/SetUILanguage) expected fallback to English, found %q", lang)
+	}
+
+	if res := Sprintf("Processed: %d", 4); res != "Processed: 4" {
+		t.Errorf("(i18n/Sprintf) expected English message, found %q", res)
+	}
+}
+
+func TestDetectUILanguage(t *testing.T) {
+	envs := []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+	original := map[string]string{}
+	for _, env := range envs {
+		original[env] = os.Getenv(env)
+		_ = os.Unsetenv(env)
+	}
+
+	defer func() {
+		for env, value := range original {
+			_ = os.Setenv(env, value)
+		}
+	}()
+
+	if lang := DetectUILanguage(); lang != "" {
+		t.Errorf("(i18n/DetectUILanguage) expected blank language, found %q", lang)
+	}
+
+	_ = os.Setenv("LANG", "de_DE.UTF-8")
+	if lang := DetectUILanguage(); lang != "de" {
+		t.Errorf("(i18n/DetectUILanguage) expected `de` from LANG, found %q", lang)
+	}
+
+	// `LC_ALL` overrides `LANG`
+	_ = os.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if lang := DetectUILanguage(); lang != "fr" {
+		t.Errorf("(i18n/DetectUILanguage) expected `fr` from LC_ALL, found %q", lang)
+	}
+}
@@ -0,0 +1,75 @@
+package commons
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern for subtitle language codes, i.e. two-letter (ISO 639-1) or three-letter
+// (ISO 639-2) codes
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}$`)
+
+/*
+Two-letter language codes (ISO 639-1) mapped to the three-letter codes (ISO 639-2/B)
+used by FFmpeg for the language metadata - only the common languages are present.
+*/
+var languageCodes = map[string]string{
+	"ar": "ara",
+	"cs": "cze",
+	"da": "dan",
+	"de": "ger",
+	"el": "gre",
+	"en": "eng",
+	"es": "spa",
+	"fi": "fin",
+	"fr": "fre",
+	"he": "heb",
+	"hi": "hin",
+	"hu": "hun",
+	"id": "ind",
+	"it": "ita",
+	"ja": "jpn",
+	"ko": "kor",
+	"nl": "dut",
+	"no": "nor",
+	"pl": "pol",
+	"pt": "por",
+	"ro": "rum",
+	"ru": "rus",
+	"sv": "swe",
+	"th": "tha",
+	"tr": "tur",
+	"uk": "ukr",
+	"vi": "vie",
+	"zh": "chi",
+}
+
+/*
+LanguageCode validates a subtitle language code, returning the (lowercase) three-letter
+code used by FFmpeg for it - two-letter (ISO 639-1) codes are mapped to the three-letter
+(ISO 639-2) codes, three-letter codes are returned as is.
+*/
+func LanguageCode(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !languagePattern.MatchString(code) {
+		return "", fmt.Errorf(
+			"`%s` is not a two or three-letter (ISO 639) language code",
+			code,
+		)
+	}
+
+	if len(code) == 3 {
+		return code, nil
+	}
+
+	if mapped, ok := languageCodes[code]; ok {
+		return mapped, nil
+	}
+
+	return "", fmt.Errorf(
+		"`%s` is not a known two-letter language code, use the three-letter "+
+			"(ISO 639-2) code instead",
+		code,
+	)
+}
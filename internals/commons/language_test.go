@@ -0,0 +1,31 @@
+package commons
+
+import "testing"
+
+func TestLanguageCode(t *testing.T) {
+	for in, expected := range map[string]string{
+		"eng":  "eng",
+		"JPN":  "jpn",
+		"en":   "eng",
+		" De ": "ger",
+		"zh":   "chi",
+	} {
+		if res, err := LanguageCode(in); err != nil || res != expected {
+			t.Errorf(
+				"(language/LanguageCode) unexpected code for %q \nexpected: %s "+
+					"\nresult: %s \nerror: %v",
+				in,
+				expected,
+				res,
+				err,
+			)
+		}
+	}
+
+	// Unknown two-letter codes can't be mapped, malformed codes are rejected
+	for _, in := range []string{"jp", "e", "english", "en-US", "e1"} {
+		if res, err := LanguageCode(in); err == nil {
+			t.Errorf("(language/LanguageCode) %q accepted as %q", in, res)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package commons
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+SystemLoad returns the load average of the system over the last minute - on platforms
+without a load average (i.e. Windows), the CPU usage is scaled to the same range, i.e.
+a value equal to the number of CPUs indicates that every CPU is busy.
+*/
+func SystemLoad() (float64, error) {
+	return systemLoad()
+}
+
+/*
+ParseLoadAvg reads the load average over the last minute from the output of
+`/proc/loadavg` (`0.52 0.58 0.59 1/467 2051`) or `sysctl vm.loadavg`
+(`{ 0.52 0.58 0.59 }`), the first value is the one for the last minute.
+*/
+func parseLoadAvg(text string) (float64, error) {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(text), "{}"))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected load average: %q", text)
+	}
+
+	return strconv.ParseFloat(fields[0], 64)
+}
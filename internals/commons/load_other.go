@@ -0,0 +1,38 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package commons
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+/*
+SystemLoad has no load average to read on this platform, the average CPU usage (in
+percent) is read through PowerShell instead - scaled by the number of CPUs to match the
+range of a load average.
+*/
+func systemLoad() (float64, error) {
+	output, err := exec.Command(
+		"powershell",
+		"-NoProfile",
+		"-NonInteractive",
+		"-Command",
+		"(Get-CimInstance Win32_Processor | "+
+			"Measure-Object -Property LoadPercentage -Average).Average",
+	).Output()
+
+	if err != nil {
+		return 0, err
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return percent / 100 * float64(runtime.NumCPU()), nil
+}
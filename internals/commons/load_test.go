@@ -0,0 +1,29 @@
+package commons
+
+import "testing"
+
+func TestParseLoadAvg(t *testing.T) {
+	tests := map[string]float64{
+		"0.52 0.58 0.59 1/467 2051\n": 0.52,
+		"{ 1.75 1.61 1.72 }\n":        1.75,
+		"12.00":                       12,
+	}
+
+	for text, expected := range tests {
+		if load, err := parseLoadAvg(text); err != nil || load != expected {
+			t.Errorf(
+				"(load/parseLoadAvg) expected %v for %q, got %v \nerror: %v",
+				expected,
+				text,
+				load,
+				err,
+			)
+		}
+	}
+
+	for _, text := range []string{"", "{ }", "busy"} {
+		if _, err := parseLoadAvg(text); err == nil {
+			t.Errorf("(load/parseLoadAvg) no error for %q", text)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package commons
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+)
+
+/*
+SystemLoad reads the load average from `/proc/loadavg` on Linux, and through `sysctl`
+on macOS and FreeBSD.
+*/
+func systemLoad() (float64, error) {
+	if runtime.GOOS == "linux" {
+		contents, err := ioutil.ReadFile("/proc/loadavg")
+		if err != nil {
+			return 0, err
+		}
+
+		return parseLoadAvg(string(contents))
+	}
+
+	output, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	return parseLoadAvg(string(output))
+}
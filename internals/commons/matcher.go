@@ -0,0 +1,148 @@
+package commons
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Name of the file containing the patterns for items to be ignored in a directory
+const IgnoreFileName = ".autosubignore"
+
+/*
+IgnorePattern is a single (parsed) line from an ignore file
+*/
+type ignorePattern struct {
+	glob     string // glob pattern, folded as per the case of names
+	negate   bool   // pattern starts with `!`, i.e. re-includes matching items
+	dirOnly  bool   // pattern ends with `/`, i.e. matches directories only
+	fullPath bool   // pattern contains `/`, i.e. matches the relative path
+}
+
+/*
+Matcher matches items against the patterns present in an ignore file, using a subset of
+the `.gitignore` syntax;
+
+	# comments, and blank lines are skipped
+	*.nfo        glob pattern, matched against the name of the item
+	extras/      trailing slash, matches directories only
+	/Season 1/*  patterns containing a slash are matched against the path relative
+	             to the directory containing the ignore file
+	!keep.ass    leading exclamation mark, re-includes items ignored by a previous
+	             pattern
+
+The last pattern matching an item decides the result. Patterns are matched irrespective
+of case unless names are case sensitive, same as exclusions.
+*/
+type Matcher struct {
+	patterns []ignorePattern
+	casing   NameCase
+}
+
+/*
+NewMatcher parses the lines from an ignore file into a matcher, invalid patterns are
+skipped.
+*/
+func NewMatcher(lines []string, casing NameCase) *Matcher {
+	matcher := &Matcher{casing: casing}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		pattern.fullPath = strings.Contains(line, "/")
+		pattern.glob = casing.Fold(strings.TrimPrefix(line, "/"))
+
+		// Validate the glob pattern, the error is returned only for malformed patterns
+		if _, err := path.Match(pattern.glob, ""); err != nil || pattern.glob == "" {
+			log.Warnf("(matcher/NewMatcher) skip invalid pattern: `%s`", line)
+			continue
+		}
+
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+
+	return matcher
+}
+
+/*
+LoadMatcher reads the ignore file present in a directory, the matcher returned is empty
+if the directory does not contain an ignore file.
+*/
+func LoadMatcher(dir string, casing NameCase) (*Matcher, error) {
+	file, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return &Matcher{casing: casing}, nil
+	} else if err != nil {
+		return &Matcher{casing: casing}, err
+	}
+
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &Matcher{casing: casing}, err
+	}
+
+	log.Debugf(
+		"(matcher/LoadMatcher) loaded ignore file from: \"%s\" \npatterns: %d",
+		dir,
+		len(lines),
+	)
+
+	return NewMatcher(lines, casing), nil
+}
+
+/*
+Match decides if an item is to be ignored, the path should be relative to the directory
+containing the ignore file (using forward slashes). A response of true indicates the
+item is to be ignored.
+*/
+func (matcher *Matcher) Match(relPath string, isDir bool) bool {
+	if matcher == nil {
+		return false
+	}
+
+	relPath = matcher.casing.Fold(relPath)
+	name := path.Base(relPath)
+
+	ignored := false
+	for _, pattern := range matcher.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+
+		target := name
+		if pattern.fullPath {
+			target = relPath
+		}
+
+		// Patterns have been validated already, error can be ignored
+		if match, _ := path.Match(pattern.glob, target); match {
+			ignored = !pattern.negate
+		}
+	}
+
+	return ignored
+}
@@ -0,0 +1,161 @@
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	matcher := NewMatcher([]string{
+		"# comment, followed by a blank line",
+		"",
+		"*.nfo",
+		"  Sample.mkv  ",
+		"extras/",
+		"/Season 1/*.ass",
+		"*.ttf",
+		"!Keep.ttf",
+		"[invalid",
+	}, NameCase{})
+
+	for _, in := range []struct {
+		path   string
+		isDir  bool
+		result bool
+	}{
+		{"movie.nfo", false, true},
+		{"show/episode.NFO", false, true},
+		{"sample.mkv", false, true},
+		{"movie.mkv", false, false},
+		{"Extras", true, true},
+		{"extras", false, false}, // directories only
+		{"Season 1/subs.ass", false, true},
+		{"Season 2/subs.ass", false, false},
+		{"subs.ass", false, false},
+		{"font.ttf", false, true},
+		{"keep.ttf", false, false}, // re-included
+		{"[invalid", false, false},
+	} {
+		if res := matcher.Match(in.path, in.isDir); res != in.result {
+			t.Errorf(
+				"(matcher/Match) unexpected result \npath: `%s` \ndirectory: %v"+
+					"\nexpected: %v \nresult: %v",
+				in.path,
+				in.isDir,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Patterns match the exact case if names are case sensitive
+	sensitive := NewMatcher(
+		[]string{"*.nfo", "Sample.mkv"},
+		NameCase{Sensitive: true},
+	)
+	if !sensitive.Match("Sample.mkv", false) || sensitive.Match("sample.mkv", false) ||
+		sensitive.Match("movie.NFO", false) {
+		t.Errorf("(matcher/Match) case sensitive patterns matched irrespective of case")
+	}
+
+	// Nil matchers ignore nothing
+	var empty *Matcher
+	if empty.Match("movie.nfo", false) {
+		t.Errorf("(matcher/Match) nil matcher ignored an item")
+	}
+}
+
+func TestLoadMatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(matcher/LoadMatcher) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Missing ignore files should result in an empty matcher
+	matcher, err := LoadMatcher(dir, NameCase{})
+	if err != nil || matcher.Match("a.nfo", false) {
+		t.Errorf("(matcher/LoadMatcher) unexpected result without ignore file")
+	}
+
+	contents := strings.Join([]string{"*.nfo", "extras/"}, "\n")
+	if err = ioutil.WriteFile(
+		filepath.Join(dir, IgnoreFileName),
+		[]byte(contents),
+		0644,
+	); err != nil {
+		t.Fatalf("(matcher/LoadMatcher) failed to create ignore file: %v", err)
+	}
+
+	matcher, err = LoadMatcher(dir, NameCase{})
+	if err != nil || !matcher.Match("a.nfo", false) || !matcher.Match("extras", true) {
+		t.Errorf("(matcher/LoadMatcher) patterns not loaded \nerror: %v", err)
+	}
+}
+
+func TestIgnoreFileWithIgnoreFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(userInput/IgnoreFile) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	source := filepath.Join(root, "Show S01E01")
+	if err := os.Mkdir(source, 0755); err != nil {
+		t.Fatalf("(userInput/IgnoreFile) failed to create directory: %v", err)
+	}
+
+	for dir, contents := range map[string]string{
+		root:   "*.nfo\nExtras/\nShow S01E01/signs.ass",
+		source: "*.txt",
+	} {
+		if err := ioutil.WriteFile(
+			filepath.Join(dir, IgnoreFileName),
+			[]byte(contents),
+			0644,
+		); err != nil {
+			t.Fatalf("(userInput/IgnoreFile) failed to create ignore file: %v", err)
+		}
+	}
+
+	input := UserInput{RootPath: root, Exclusions: []string{"tags.xml"}}
+	if code, err := input.Initialize(); code != StatusOK {
+		t.Fatalf("(userInput/IgnoreFile) failed to initialize \nerror: %v", err)
+	}
+
+	for name, result := range map[string]bool{
+		"movie.nfo":    true,  // root ignore file
+		"signs.ass":    true,  // root ignore file, relative path
+		"notes.txt":    true,  // source ignore file
+		"tags.xml":     true,  // exclusions, merged with ignore files
+		IgnoreFileName: true,  // the ignore file itself
+		"dialogue.ass": false, // no match
+	} {
+		name := name // pin
+		if res := input.IgnoreFile(&source, &name); res != result {
+			t.Errorf(
+				"(userInput/IgnoreFile) unexpected result \nfile: `%s`"+
+					"\nexpected: %v \nresult: %v",
+				name,
+				result,
+				res,
+			)
+		}
+	}
+
+	// Patterns from the source directory apply to that directory only
+	other, name := filepath.Join(root, "Show S01E02"), "notes.txt"
+	if input.IgnoreFile(&other, &name) {
+		t.Errorf("(userInput/IgnoreFile) source ignore file applied to other directory")
+	}
+
+	if !input.IgnoreDir(filepath.Join(root, "Extras")) ||
+		input.IgnoreDir(filepath.Join(root, "Show S01E02")) {
+		t.Errorf("(userInput/IgnoreDir) unexpected result with root ignore file")
+	}
+}
@@ -0,0 +1,58 @@
+package commons
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Maximum length of a path on Windows, unless the path uses the long-path prefix
+	maxPathWindows = 260
+
+	// Prefix used on Windows to lift the limit on the length of a path
+	longPathPrefix = `\\?\`
+)
+
+// Operating system the application is running on - a variable to allow tests to
+// imitate other platforms
+var goos = runtime.GOOS
+
+/*
+NormalizePath converts a path into the form used throughout the application; the path
+is cleaned and converted to an absolute path. On Windows, long paths are prefixed with
+`\\?\` - lifts the limit on the length of the path.
+
+The path is otherwise left as is, i.e. unicode characters and leading/trailing spaces
+(valid in file names) are retained. Normalizing a path more than once is safe.
+*/
+func NormalizePath(path string) string {
+	if path == "" || strings.HasPrefix(path, longPathPrefix) {
+		return path
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	} else {
+		log.Debugf(
+			"(commons/NormalizePath) failed to fetch absolute path for %q \nerror: %v",
+			path,
+			err,
+		)
+
+		path = filepath.Clean(path)
+	}
+
+	if goos == "windows" && len(path) >= maxPathWindows {
+		if strings.HasPrefix(path, `\\`) {
+			// UNC paths, i.e. `\\server\share` use a different prefix
+			return longPathPrefix + `UNC\` + strings.TrimPrefix(path, `\\`)
+		}
+
+		return longPathPrefix + path
+	}
+
+	return path
+}
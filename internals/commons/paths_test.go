@@ -0,0 +1,48 @@
+package commons
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	for _, in := range []struct {
+		path, result string
+	}{
+		{"", ""},
+		{"relative/path", filepath.Join(cwd, "relative", "path")},
+		{"/root//dir/../file.mkv", filepath.Clean("/root/file.mkv")},
+		{"/root/ünïcödé 🎬/file .mkv ", "/root/ünïcödé 🎬/file .mkv "},
+		{`\\?\C:\already\prefixed`, `\\?\C:\already\prefixed`},
+	} {
+		if res := NormalizePath(in.path); res != in.result {
+			t.Errorf(
+				"(commons/NormalizePath) unexpected result \ninput: %q"+
+					"\nexpected: %q \nresult: %q",
+				in.path,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Imitate windows - long paths should be prefixed, normalizing twice is safe
+	defer func(original string) { goos = original }(goos)
+	goos = "windows"
+
+	long := "/" + strings.Repeat("a", maxPathWindows)
+	if res := NormalizePath(NormalizePath(long)); res != longPathPrefix+long {
+		t.Errorf(
+			"(commons/NormalizePath) long path not prefixed \nresult: %q",
+			res,
+		)
+	}
+
+	if res := NormalizePath("/short"); res != "/short" {
+		t.Errorf("(commons/NormalizePath) short path modified \nresult: %q", res)
+	}
+}
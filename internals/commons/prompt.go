@@ -0,0 +1,112 @@
+package commons
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Answers assumed for the prompts, decided by the `--yes` and `--no` flags
+*/
+const (
+	// Ask the user, the prompt is declined if the user can't be asked
+	AssumeNothing = iota
+
+	// Accept every prompt without asking
+	AssumeYes
+
+	// Decline every prompt without asking
+	AssumeNo
+)
+
+/*
+PromptPolicy is the single point through which every prompt presented to the user is
+routed - ensures the answers assumed through flags are respected consistently.
+
+Prompts are declined if the user can't be asked, i.e. when the input is not a terminal
+(for example, in scripts or scheduled jobs) and no answer is assumed. A nil policy
+declines every prompt as well.
+*/
+type PromptPolicy struct {
+	// Answer to be assumed for the prompts
+	assume int
+
+	// Stream from which the answers are read, and a flag indicating if the stream is
+	// interactive, i.e. a terminal
+	input       *bufio.Reader
+	interactive bool
+}
+
+/*
+NewPromptPolicy creates a policy reading answers from the standard input, the answers
+assumed are decided by the flags.
+*/
+func NewPromptPolicy(assumeYes, assumeNo bool) *PromptPolicy {
+	assume := AssumeNothing
+	switch {
+	case assumeYes:
+		assume = AssumeYes
+	case assumeNo:
+		assume = AssumeNo
+	}
+
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+
+	return newPolicy(assume, os.Stdin, interactive)
+}
+
+// NewPolicy creates a policy reading answers from the stream supplied
+func newPolicy(assume int, input io.Reader, interactive bool) *PromptPolicy {
+	return &PromptPolicy{
+		assume:      assume,
+		input:       bufio.NewReader(input),
+		interactive: interactive,
+	}
+}
+
+/*
+Confirm presents a yes/no question to the user, returning true if the user accepts -
+or if the answer is assumed to be yes. The question is printed regardless, ensuring the
+decision is visible in the output.
+*/
+func (policy *PromptPolicy) Confirm(question string) bool {
+	switch {
+	case policy == nil:
+		return false
+
+	case policy.assume == AssumeYes:
+		Printf("%s [y/N]: y (assumed)\n", question)
+		return true
+
+	case policy.assume == AssumeNo:
+		Printf("%s [y/N]: n (assumed)\n", question)
+		return false
+
+	case !policy.interactive:
+		Printf("%s [y/N]: n (non-interactive)\n", question)
+		log.Debugf("(prompt/Confirm) input not interactive, declining: %s", question)
+		return false
+	}
+
+	Printf("%s [y/N]: ", question)
+
+	answer, err := policy.input.ReadString('\n')
+	if err != nil && answer == "" {
+		log.Debugf("(prompt/Confirm) failed to read answer \nerror: %v", err)
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package commons
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	for _, in := range []struct {
+		assume      int
+		answer      string
+		interactive bool
+		result      bool
+	}{
+		{AssumeYes, "no\n", true, true}, // assumed answers take precedence
+		{AssumeNo, "yes\n", true, false},
+		{AssumeNothing, "yes\n", false, false}, // can't ask, decline
+		{AssumeNothing, "y\n", true, true},
+		{AssumeNothing, " YES \n", true, true},
+		{AssumeNothing, "yes", true, true}, // no trailing newline
+		{AssumeNothing, "\n", true, false},
+		{AssumeNothing, "nope\n", true, false},
+		{AssumeNothing, "", true, false},
+	} {
+		policy := newPolicy(in.assume, strings.NewReader(in.answer), in.interactive)
+		if res := policy.Confirm("Overwrite?"); res != in.result {
+			t.Errorf(
+				"(prompt/Confirm) unexpected result \nassume: %d \nanswer: %q"+
+					"\ninteractive: %v \nexpected: %v \nresult: %v",
+				in.assume,
+				in.answer,
+				in.interactive,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Nil policy should decline every prompt
+	var policy *PromptPolicy
+	if policy.Confirm("Overwrite?") {
+		t.Errorf("(prompt/Confirm) prompt accepted by a nil policy")
+	}
+
+	if res := NewPromptPolicy(true, false); res.assume != AssumeYes {
+		t.Errorf("(prompt/NewPromptPolicy) answer not assumed \nresult: %+v", res)
+	}
+
+	// Answers can't be assumed to be both, yes and no
+	input := UserInput{AssumeYes: true, AssumeNo: true, IsTest: true}
+	if code, err := input.Initialize(); code != FlagConflict || err == nil {
+		t.Errorf(
+			"(userInput/Initialize) conflicting answers not reported \ncode: %d",
+			code,
+		)
+	}
+}
@@ -0,0 +1,42 @@
+package commons
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Multipliers for the units accepted in a rate, in binary multiples
+var rateUnits = map[string]float64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+}
+
+/*
+ParseRate parses a throughput in bytes per second, such as `50M` or `1.5G` - the unit
+is optional and in binary multiples (`K`, `M` or `G`), a trailing `B` (or `/s`) is
+ignored. The rate should be positive.
+*/
+func ParseRate(value string) (int64, error) {
+	rate := strings.ToUpper(strings.TrimSpace(value))
+	rate = strings.TrimSuffix(strings.TrimSuffix(rate, "/S"), "B")
+
+	unit := ""
+	if n := len(rate); n > 0 && strings.ContainsAny(rate[n-1:], "KMG") {
+		rate, unit = rate[:n-1], rate[n-1:]
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid rate `%s`, expected a rate such as `50M`", value)
+	}
+
+	bytes := int64(amount * rateUnits[unit])
+	if bytes < 1 {
+		return 0, fmt.Errorf("rate `%s` is below a byte per second", value)
+	}
+
+	return bytes, nil
+}
@@ -0,0 +1,31 @@
+package commons
+
+import "testing"
+
+func TestParseRate(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"1048576": 1 << 20,
+		"50M":     50 << 20,
+		"50mb":    50 << 20,
+		"512K/s":  512 << 10,
+		"1.5G":    3 << 29,
+		" 2 MB ":  2 << 20,
+	} {
+		if rate, err := ParseRate(value); err != nil || rate != expected {
+			t.Errorf(
+				"(rate/ParseRate) unexpected rate for `%s` \nexpected: %d"+
+					"\nfound: %d \nerror: %v",
+				value,
+				expected,
+				rate,
+				err,
+			)
+		}
+	}
+
+	for _, value := range []string{"", "M", "fast", "-5M", "0", "0.1", "5T"} {
+		if _, err := ParseRate(value); err == nil {
+			t.Errorf("(rate/ParseRate) invalid rate accepted: `%s`", value)
+		}
+	}
+}
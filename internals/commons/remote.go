@@ -0,0 +1,201 @@
+package commons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+/*
+Remote is implemented by each kind of remote root directory supported - allows the
+root directory to be staged to (and results uploaded from) the local disk, FFmpeg is
+always run on local files.
+
+Names used are relative to the root directory, i.e. names of items directly inside
+the remote root directory.
+*/
+type Remote interface {
+	// List returns the names of the items present in the remote root directory
+	List(ctx context.Context) ([]string, error)
+
+	// Download copies an item (recursively) from the remote root directory into a
+	// local directory
+	Download(ctx context.Context, name, localDir string) error
+
+	// Upload copies a local item (recursively) into the remote root directory
+	Upload(ctx context.Context, localPath string) error
+
+	// String returns the remote root directory in the form supplied by the user
+	String() string
+}
+
+// Used to run the commands transferring files, variable allows patching for tests
+var remoteCommand = exec.CommandContext
+
+/*
+IsRemotePath returns a boolean indicating if the path points to a remote location, i.e.
+is a URL like `sftp://user@host/path`
+*/
+func IsRemotePath(path string) bool {
+	return strings.Contains(path, "://")
+}
+
+/*
+ParseRemote parses the URL to a remote root directory, returning an error if the URL is
+malformed or uses a scheme that isn't supported.
+*/
+func ParseRemote(rawURL string) (Remote, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("malformed remote path \"%s\": %v", rawURL, err)
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "sftp":
+		if parsed.Hostname() == "" {
+			return nil, fmt.Errorf("host missing from remote path \"%s\"", rawURL)
+		}
+
+		if _, ok := parsed.User.Password(); ok {
+			return nil, errors.New(
+				"passwords aren't accepted in remote paths, use SSH keys instead",
+			)
+		}
+
+		remote := &sftpRemote{
+			raw:  rawURL,
+			host: parsed.Hostname(),
+			port: parsed.Port(),
+			path: parsed.Path,
+		}
+
+		if parsed.User != nil {
+			remote.user = parsed.User.Username()
+		}
+
+		if remote.path == "" {
+			remote.path = "/"
+		}
+
+		return remote, nil
+
+	default:
+		return nil, fmt.Errorf(
+			"unsupported scheme `%s` in remote path \"%s\"",
+			parsed.Scheme,
+			rawURL,
+		)
+	}
+}
+
+/*
+SftpRemote is a remote root directory accessed over SFTP - uses the `sftp` client
+from OpenSSH in batch mode. Authentication is left to the client, i.e. SSH keys (or
+an agent) should allow logging in without a prompt.
+*/
+type sftpRemote struct {
+	raw  string // URL as supplied by the user
+	user string
+	host string
+	port string
+	path string // path to the root directory on the remote machine
+}
+
+func (remote *sftpRemote) String() string {
+	return remote.raw
+}
+
+func (remote *sftpRemote) List(ctx context.Context) ([]string, error) {
+	output, err := remote.batch(ctx, "ls -1a "+sftpQuote(remote.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// Batch mode echoes each command, prefixed with the prompt
+		if line == "" || strings.HasPrefix(line, "sftp>") {
+			continue
+		}
+
+		// Items may be listed with the full path, depending on the server
+		name := path.Base(line)
+		if name == "." || name == ".." || name == "/" {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (remote *sftpRemote) Download(ctx context.Context, name, localDir string) error {
+	_, err := remote.batch(
+		ctx,
+		"get -pr "+sftpQuote(path.Join(remote.path, name))+" "+sftpQuote(localDir),
+	)
+
+	return err
+}
+
+func (remote *sftpRemote) Upload(ctx context.Context, localPath string) error {
+	_, err := remote.batch(
+		ctx,
+		"put -pr "+sftpQuote(localPath)+" "+sftpQuote(remote.path),
+	)
+
+	return err
+}
+
+/*
+Batch runs the commands through the `sftp` client in batch mode, returning the output
+of the client. The client stops at the first command that fails.
+*/
+func (remote *sftpRemote) batch(
+	ctx context.Context,
+	commands ...string,
+) (string, error) {
+	target := remote.host
+	if remote.user != "" {
+		target = remote.user + "@" + target
+	}
+
+	args := []string{"-b", "-"}
+	if remote.port != "" {
+		args = append(args, "-P", remote.port)
+	}
+
+	cmd := remoteCommand(ctx, "sftp", append(args, target)...)
+	cmd.Stdin = strings.NewReader(strings.Join(commands, "\n") + "\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf(
+			"sftp command failed for \"%s\": %v \n%s",
+			remote.raw,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return stdout.String(), nil
+}
+
+/*
+SftpQuote wraps a path in double quotes for use in an SFTP batch command, escaping
+quotes and backslashes present in the path
+*/
+func sftpQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
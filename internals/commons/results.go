@@ -0,0 +1,51 @@
+package commons
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	resultMu sync.Mutex
+
+	// Stream to which machine-readable results are written, results are discarded
+	// unless a stream is set (i.e. in porcelain mode)
+	resultStream io.Writer = nil
+)
+
+/*
+SetResultOutput sets the stream to which machine-readable results are written - each
+result is written as a single line of JSON. Setting a nil stream discards the results.
+*/
+func SetResultOutput(stream io.Writer) {
+	resultMu.Lock()
+	defer resultMu.Unlock()
+
+	resultStream = stream
+}
+
+/*
+EmitResult writes a machine-readable result to the result stream (if set), as a single
+line of JSON. Failures are logged, and do not affect the run.
+*/
+func EmitResult(result interface{}) {
+	resultMu.Lock()
+	defer resultMu.Unlock()
+
+	if resultStream == nil {
+		return
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		log.Warnf("(commons/EmitResult) failed to marshal result \nerror: %v", err)
+		return
+	}
+
+	if _, err = resultStream.Write(append(line, '\n')); err != nil {
+		log.Warnf("(commons/EmitResult) failed to write result \nerror: %v", err)
+	}
+}
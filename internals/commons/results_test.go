@@ -0,0 +1,40 @@
+package commons
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEmitResult(t *testing.T) {
+	defer SetResultOutput(nil)
+
+	// Results are discarded unless a stream is set
+	EmitResult(map[string]int{"discarded": 1})
+
+	var buffer bytes.Buffer
+	SetResultOutput(&buffer)
+
+	EmitResult(map[string]string{"event": "file"})
+	EmitResult(map[string]int{"count": 2})
+
+	expected := `{"event":"file"}` + "\n" + `{"count":2}` + "\n"
+	if res := buffer.String(); res != expected {
+		t.Errorf(
+			"(results/EmitResult) unexpected results written \nexpected: %q "+
+				"\nfound: %q",
+			expected,
+			res,
+		)
+	}
+
+	// Values that can't be marshalled are skipped, without affecting the stream
+	buffer.Reset()
+	EmitResult(make(chan int))
+
+	if buffer.Len() != 0 {
+		t.Errorf(
+			"(results/EmitResult) invalid result written to the stream \nfound: %q",
+			buffer.String(),
+		)
+	}
+}
@@ -0,0 +1,143 @@
+package commons
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// Kinds of files a metadata rule can be limited to, rules apply to both if blank
+const (
+	RuleSubtitle   = "subtitle"
+	RuleAttachment = "attachment"
+)
+
+// Pattern for dispositions set through the rules, i.e. `+forced` or `default-forced`
+var dispositionPattern = regexp.MustCompile(`^[+-]?[a-z_]+([+-][a-z_]+)*$`)
+
+/*
+MetadataRule is a rule from the configuration file, matching subtitle files (and
+attachments) by their name to set the metadata for their streams - or to leave them
+out of the output altogether;
+
+	{"match": "(?i)signs", "kind": "subtitle", "title": "Signs & Songs"}
+	{"match": "\\.sdh\\.", "disposition": "+hearing_impaired", "language": "eng"}
+	{"match": "(?i)^comic", "kind": "attachment", "exclude": true}
+
+The pattern is matched against the name of the file, files in subtitle folders are
+named by their path relative to the source directory (with forward slashes). Blank
+values leave the stream as is; the title is used as the description for attachments,
+while the language and the disposition apply to subtitles alone.
+*/
+type MetadataRule struct {
+	Match       string `json:"match"`
+	Kind        string `json:"kind,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+	Exclude     bool   `json:"exclude,omitempty"`
+
+	// Compiled pattern, set while validating the user input
+	pattern *regexp.Regexp
+}
+
+/*
+RuleAction is the result of the rules matching a file, every rule matching the file is
+applied in order - values from later rules replace the ones set earlier.
+*/
+type RuleAction struct {
+	Language    string
+	Title       string
+	Disposition string
+	Exclude     bool
+}
+
+/*
+Validate checks the values in the rule, the pattern is compiled as is - the case of the
+names is decided by the flags, once the rule is used for a run.
+*/
+func (rule *MetadataRule) Validate() error {
+	if rule.Match == "" {
+		return errors.New("blank pattern, `match` is required")
+	}
+
+	if _, err := regexp.Compile(rule.Match); err != nil {
+		return fmt.Errorf("failed to compile `%s`: %v", rule.Match, err)
+	}
+
+	if rule.Kind != "" && rule.Kind != RuleSubtitle && rule.Kind != RuleAttachment {
+		return fmt.Errorf(
+			"unknown kind `%s`, expected `%s` or `%s`",
+			rule.Kind,
+			RuleSubtitle,
+			RuleAttachment,
+		)
+	}
+
+	if rule.Language != "" {
+		code, err := LanguageCode(rule.Language)
+		if err != nil {
+			return err
+		}
+
+		rule.Language = code
+	}
+
+	if rule.Disposition != "" && !dispositionPattern.MatchString(rule.Disposition) {
+		return fmt.Errorf("malformed disposition `%s`", rule.Disposition)
+	}
+
+	return nil
+}
+
+/*
+CompileRules validates the metadata rules and compiles their patterns as per the case
+of the names, invalid rules are reported.
+*/
+func (userInput *UserInput) compileRules(report *ValidationReport) {
+	for i := range userInput.Rules {
+		rule := &userInput.Rules[i]
+		if err := rule.Validate(); err != nil {
+			report.add(fmt.Sprintf("rules[%d]", i), ConfigError, err)
+			continue
+		}
+
+		rule.pattern, _ = userInput.Casing().Compile(rule.Match)
+	}
+}
+
+/*
+ApplyRules returns the result of the metadata rules matching a file of the kind
+supplied, the boolean returned is false if no rule matches the file.
+*/
+func (userInput *UserInput) ApplyRules(kind, name string) (RuleAction, bool) {
+	var action RuleAction
+	matched := false
+
+	name = filepath.ToSlash(name)
+	for i := range userInput.Rules {
+		rule := &userInput.Rules[i]
+		if rule.pattern == nil || rule.Kind != "" && rule.Kind != kind ||
+			!rule.pattern.MatchString(name) {
+			continue
+		}
+
+		matched = true
+		if rule.Language != "" {
+			action.Language = rule.Language
+		}
+
+		if rule.Title != "" {
+			action.Title = rule.Title
+		}
+
+		if rule.Disposition != "" {
+			action.Disposition = rule.Disposition
+		}
+
+		action.Exclude = action.Exclude || rule.Exclude
+	}
+
+	return action, matched
+}
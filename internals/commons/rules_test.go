@@ -0,0 +1,74 @@
+package commons
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyRules(t *testing.T) {
+	input := UserInput{
+		Rules: []MetadataRule{
+			{Match: `\bsdh\b`, Disposition: "+hearing_impaired", Language: "eng"},
+			{Match: "signs", Kind: RuleSubtitle, Title: "Signs & Songs"},
+			{Match: "^Subs/", Kind: RuleSubtitle, Language: "jpn"},
+			{Match: `\.otf$`, Kind: RuleAttachment, Exclude: true},
+		},
+		IsTest: true,
+	}
+
+	if code, err := input.Initialize(); code != StatusOK {
+		t.Fatalf("(userInput/Initialize) failed to initialize \nerror: %v", err)
+	}
+
+	for _, in := range []struct {
+		kind    string
+		name    string
+		matched bool
+		action  RuleAction
+	}{
+		{RuleSubtitle, "Episode.SDH.srt", true, RuleAction{
+			Language:    "eng",
+			Disposition: "+hearing_impaired",
+		}},
+		{RuleSubtitle, "Subs/Signs.sdh.ass", true, RuleAction{
+			Language:    "jpn",
+			Title:       "Signs & Songs",
+			Disposition: "+hearing_impaired",
+		}},
+		{RuleAttachment, "Signs.otf", true, RuleAction{Exclude: true}},
+		{RuleSubtitle, "English.otf", false, RuleAction{}},
+		{RuleAttachment, "Arial.ttf", false, RuleAction{}},
+	} {
+		action, matched := input.ApplyRules(in.kind, in.name)
+		if matched != in.matched || action != in.action {
+			t.Errorf(
+				"(rules/ApplyRules) unexpected result for `%s` \nexpected: %+v"+
+					"\nresult: %+v",
+				in.name,
+				in.action,
+				action,
+			)
+		}
+	}
+
+	// Invalid rules are reported along with their position
+	input = UserInput{
+		Rules: []MetadataRule{
+			{Match: "signs", Title: "Signs"},
+			{Match: "signs", Language: "english"},
+			{Match: "signs", Disposition: "hearing impaired"},
+		},
+		IsTest: true,
+	}
+
+	code, err := input.Initialize()
+	if code != ConfigError || err == nil ||
+		!strings.Contains(err.Error(), "rules[1]") ||
+		!strings.Contains(err.Error(), "rules[2]") {
+		t.Errorf(
+			"(rules/compileRules) invalid rules not reported \ncode: %d \nerror: %v",
+			code,
+			err,
+		)
+	}
+}
@@ -0,0 +1,124 @@
+package commons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Maximum length (in bytes) of a file name on most file systems
+	maxNameLength = 255
+
+	// Room left in a name for the suffixes added to it later, i.e. the extension for
+	// partial outputs and snapshots, or the language code for split outputs
+	nameSuffixRoom = 32
+
+	// Number of hex digits from the hash of the name added to a truncated name
+	nameHashLength = 8
+)
+
+// Names reserved by Windows for devices - reserved irrespective of the extension
+var reservedNames = regexp.MustCompile(
+	`(?i)^(con|prn|aux|nul|com[0-9¹²³]|lpt[0-9¹²³])$`,
+)
+
+// Characters that can't be used in file names on Windows
+const invalidNameChars = `<>:"/\|?*`
+
+/*
+SafeName forms a file name from the stem and the extension (i.e. `.mkv`) that can be
+created on the platform - on Windows, names reserved for devices (`CON.mkv`) are
+suffixed with an underscore, and invalid characters are replaced with underscores.
+Names too long for the file system are truncated on every platform, with a hash of the
+stem appended to keep truncated names distinct - the same stem always results in the
+same name. The extension is never modified.
+*/
+func SafeName(stem, ext string) string {
+	safe := stem
+	if goos == "windows" {
+		safe = windowsStem(safe)
+	}
+
+	limit := maxNameLength - nameSuffixRoom - len(ext)
+	if len(safe) > limit {
+		sum := sha256.Sum256([]byte(stem))
+		hash := "~" + hex.EncodeToString(sum[:])[:nameHashLength]
+
+		// Truncated at a character boundary, never in the middle of a character
+		cut := limit - len(hash)
+		for cut > 0 && !utf8.RuneStart(safe[cut]) {
+			cut--
+		}
+
+		safe = safe[:cut] + hash
+	}
+
+	if safe != stem {
+		log.Debugf(
+			"(safename/SafeName) name %q changed to %q",
+			stem+ext,
+			safe+ext,
+		)
+	}
+
+	return safe + ext
+}
+
+/*
+WindowsStem replaces the characters Windows does not allow in a file name, and
+suffixes stems reserved for devices with an underscore - Windows ignores everything
+after the first dot (and trailing spaces) while checking for reserved names.
+*/
+func windowsStem(stem string) string {
+	stem = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(invalidNameChars, r) {
+			return '_'
+		}
+
+		return r
+	}, stem)
+
+	base := stem
+	if i := strings.Index(base, "."); i >= 0 {
+		base = base[:i]
+	}
+
+	if reservedNames.MatchString(strings.TrimRight(base, " ")) {
+		stem = base + "_" + stem[len(base):]
+	}
+
+	return stem
+}
+
+/*
+SanitizeText returns text that can be used in metadata (i.e. the title of a track) and
+printed as is - names on Linux are arbitrary bytes, names created on older systems are
+rarely valid UTF-8. Bytes that aren't valid UTF-8 are read as Latin-1 (the encoding
+these names mostly are in), while control characters are escaped as `\xNN`. Valid
+text is returned unchanged.
+*/
+func SanitizeText(text string) string {
+	var res strings.Builder
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == utf8.RuneError && size == 1 && text[i] >= 0xA0:
+			// Latin-1 maps each byte to the code point with the same value
+			res.WriteRune(rune(text[i]))
+		case r == utf8.RuneError && size == 1, r < 0x20, r == 0x7F:
+			res.WriteString(fmt.Sprintf("\\x%02X", text[i]))
+		default:
+			res.WriteString(text[i : i+size])
+		}
+
+		i += size
+	}
+
+	return res.String()
+}
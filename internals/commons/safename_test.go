@@ -0,0 +1,75 @@
+package commons
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSafeName(t *testing.T) {
+	defer func(original string) { goos = original }(goos)
+
+	// Names are left as is on other platforms, unless too long
+	goos = "linux"
+	if name := SafeName(`CON: "Part" 1?`, ".mkv"); name != `CON: "Part" 1?.mkv` {
+		t.Errorf("(safename/SafeName) name modified on linux: %q", name)
+	}
+
+	goos = "windows"
+	for stem, expected := range map[string]string{
+		"Movie (2020)":  "Movie (2020).mkv",
+		"CON":           "CON_.mkv",
+		"nul.sample":    "nul_.sample.mkv",
+		"Com1":          "Com1_.mkv",
+		"CONSOLE":       "CONSOLE.mkv",
+		`What? "Why"`:   "What_ _Why_.mkv",
+		"a/b\\c:d|e*f":  "a_b_c_d_e_f.mkv",
+		"Tab\tin name":  "Tab_in name.mkv",
+		"Contents.Lpt9": "Contents.Lpt9.mkv",
+	} {
+		if name := SafeName(stem, ".mkv"); name != expected {
+			t.Errorf(
+				"(safename/SafeName) expected %q for %q, got %q",
+				expected,
+				stem,
+				name,
+			)
+		}
+	}
+
+	// Long names are truncated at a character boundary, keeping the extension
+	long := strings.Repeat("é", 200)
+	name := SafeName(long, ".sample.mkv")
+	if len(name) > maxNameLength-nameSuffixRoom || !utf8.ValidString(name) ||
+		!strings.HasSuffix(name, ".sample.mkv") {
+		t.Errorf("(safename/SafeName) unexpected name for long stem: %q", name)
+	}
+
+	// The same stem always results in the same name, distinct stems never collide
+	if SafeName(long, ".mkv") != SafeName(long, ".mkv") ||
+		SafeName(long+"a", ".mkv") == SafeName(long+"b", ".mkv") {
+		t.Errorf("(safename/SafeName) truncated names are not deterministic")
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	for text, expected := range map[string]string{
+		"Episode 01":            "Episode 01",
+		"Café – 日本語":            "Café – 日本語",
+		"Caf\xe9 cr\xe8me":      "Café crème",
+		"Broken \x81\x9f bytes": `Broken \x81\x9F bytes`,
+		"Tab\tand\nnewline\x7f": `Tab\x09and\x0Anewline\x7F`,
+		"Cut \xe6\x97":          "Cut æ\\x97",
+	} {
+		res := SanitizeText(text)
+		if res != expected || !utf8.ValidString(res) {
+			t.Errorf(
+				"(safename/SanitizeText) unexpected result for %q \nexpected: %q"+
+					"\nfound: %q",
+				text,
+				expected,
+				res,
+			)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package commons
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layout used to parse the start/end of a schedule window
+const scheduleLayout = "15:04"
+
+/*
+Schedule is a simple structure representing a daily wall-clock window, for example,
+`01:00-07:00`. The window can wrap around midnight, i.e. `22:00-06:00` is valid.
+
+A window with the same start and end covers the entire day.
+*/
+type Schedule struct {
+	// Start and end of the window, as offsets from midnight
+	start, end time.Duration
+
+	// Window as supplied by the user, used in output messages
+	raw string
+}
+
+/*
+ParseSchedule parses a window of the form `HH:MM-HH:MM` (using the 24-hour clock).
+*/
+func ParseSchedule(window string) (*Schedule, error) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf(
+			"invalid schedule window %q, expected HH:MM-HH:MM",
+			window,
+		)
+	}
+
+	var offsets [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse(scheduleLayout, strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q in schedule window", part)
+		}
+
+		offsets[i] = time.Duration(t.Hour())*time.Hour +
+			time.Duration(t.Minute())*time.Minute
+	}
+
+	return &Schedule{
+		start: offsets[0],
+		end:   offsets[1],
+		raw:   strings.TrimSpace(window),
+	}, nil
+}
+
+/*
+Contains checks if a point of time lies inside the window.
+*/
+func (schedule *Schedule) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+
+	switch {
+	case schedule.start == schedule.end:
+		// Window covers the entire day
+		return true
+
+	case schedule.start < schedule.end:
+		return offset >= schedule.start && offset < schedule.end
+
+	default:
+		// Window wraps around midnight
+		return offset >= schedule.start || offset < schedule.end
+	}
+}
+
+/*
+Until returns the duration from a point of time to the next start of the window - zero
+if the point of time lies inside the window.
+*/
+func (schedule *Schedule) Until(t time.Time) time.Duration {
+	if schedule.Contains(t) {
+		return 0
+	}
+
+	wait := schedule.start - sinceMidnight(t)
+	if wait < 0 {
+		// Window starts tomorrow
+		wait += 24 * time.Hour
+	}
+
+	return wait
+}
+
+// String returns the window as supplied by the user
+func (schedule *Schedule) String() string {
+	return schedule.raw
+}
+
+// SinceMidnight returns the wall-clock time as an offset from midnight
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
@@ -0,0 +1,65 @@
+package commons
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for window, valid := range map[string]bool{
+		"01:00-07:00":   true,
+		" 22:30 - 6:15": true,
+		"00:00-00:00":   true,
+		"01:00":         false,
+		"01:00-07:00-1": false,
+		"25:00-07:00":   false,
+		"1am-7am":       false,
+		"":              false,
+	} {
+		if _, err := ParseSchedule(window); (err == nil) != valid {
+			t.Errorf(
+				"(schedule/ParseSchedule) unexpected result \nwindow: %q"+
+					"\nexpected valid: %v \nerror: %v",
+				window,
+				valid,
+				err,
+			)
+		}
+	}
+}
+
+func TestScheduleUntil(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	for _, in := range []struct {
+		window string
+		now    time.Time
+		wait   time.Duration
+	}{
+		{"01:00-07:00", at(3, 0), 0},
+		{"01:00-07:00", at(7, 0), 18 * time.Hour},
+		{"01:00-07:00", at(0, 30), 30 * time.Minute},
+		{"22:00-06:00", at(23, 0), 0}, // wraps around midnight
+		{"22:00-06:00", at(5, 59), 0},
+		{"22:00-06:00", at(12, 0), 10 * time.Hour},
+		{"00:00-00:00", at(12, 0), 0}, // entire day
+	} {
+		schedule, err := ParseSchedule(in.window)
+		if err != nil {
+			t.Fatalf("(schedule/ParseSchedule) failed to parse %q", in.window)
+		}
+
+		if res := schedule.Until(in.now); res != in.wait {
+			t.Errorf(
+				"(schedule/Until) unexpected wait \nwindow: %s \nnow: %s"+
+					"\nexpected: %v \nresult: %v",
+				in.window,
+				in.now.Format(scheduleLayout),
+				in.wait,
+				res,
+			)
+		}
+	}
+}
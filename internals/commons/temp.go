@@ -0,0 +1,161 @@
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Prefix for the temporary workspaces created for each run, used to recognize the
+// workspaces left behind by runs that did not complete
+const TempPrefix = "auto-sub-run-"
+
+// Name of the file holding the process ID of the run owning a workspace
+const TempOwnerFile = "owner"
+
+/*
+TempManager manages the temporary workspace of a run - a single directory in the
+temporary directory of the system, created when first required, holding one directory
+for each purpose (i.e. converted subtitles). The workspace is removed once the run
+completes or is cancelled, unless it is to be kept for debugging.
+*/
+type TempManager struct {
+	mu sync.Mutex
+
+	// Path to the workspace, empty until the workspace is created
+	root string
+
+	// Boolean indicating if the workspace is to be kept on cleanup
+	keep bool
+}
+
+var (
+	tempMu sync.RWMutex
+
+	// Temporary workspace of the ongoing run
+	runTemp = &TempManager{}
+)
+
+// NewTempManager returns a manager for a new workspace, created when first required
+func NewTempManager(keep bool) *TempManager {
+	return &TempManager{keep: keep}
+}
+
+// SetTemp sets the manager for the temporary workspace of the ongoing run
+func SetTemp(manager *TempManager) {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+
+	if manager == nil {
+		manager = &TempManager{}
+	}
+
+	runTemp = manager
+}
+
+// Temp returns the manager for the temporary workspace of the ongoing run
+func Temp() *TempManager {
+	tempMu.RLock()
+	defer tempMu.RUnlock()
+
+	return runTemp
+}
+
+// Root returns the path to the workspace, empty if the workspace is yet to be created
+func (manager *TempManager) Root() string {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return manager.root
+}
+
+/*
+Dir creates a new directory in the workspace, with the name starting with the prefix
+supplied - the workspace is created first, if required. Each call returns a new
+directory, the directory can be removed through `Release` once no longer required.
+*/
+func (manager *TempManager) Dir(prefix string) (string, error) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.root == "" {
+		root, err := ioutil.TempDir("", TempPrefix)
+		if err != nil {
+			return "", err
+		}
+
+		// Workspaces of runs still running are never treated as leftovers
+		owner := filepath.Join(root, TempOwnerFile)
+		err = ioutil.WriteFile(owner, []byte(strconv.Itoa(os.Getpid())), 0644)
+		if err != nil {
+			log.Debugf(
+				"(temp/Dir) failed to record the owner of the workspace \nerror: %v",
+				err,
+			)
+		}
+
+		log.Debugf("(temp/Dir) created temporary workspace: \"%s\"", root)
+		manager.root = root
+	}
+
+	return ioutil.TempDir(manager.root, prefix)
+}
+
+/*
+Release removes a directory (or file) created in the workspace once no longer required,
+the path is left as is if the workspace is to be kept. Paths outside the workspace are
+never removed.
+*/
+func (manager *TempManager) Release(path string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.keep || manager.root == "" {
+		return
+	}
+
+	rel, err := filepath.Rel(manager.root, path)
+	if err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		log.Debugf("(temp/Release) path outside the workspace: \"%s\"", path)
+		return
+	}
+
+	if err = os.RemoveAll(path); err != nil {
+		log.Debugf("(temp/Release) failed to remove: \"%s\" \nerror: %v", path, err)
+	}
+}
+
+/*
+Cleanup removes the workspace, unless it is to be kept - returns the path to the
+workspace if it is kept, empty otherwise. The manager can be reused after a cleanup,
+a new workspace is created when required.
+*/
+func (manager *TempManager) Cleanup() (kept string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.root == "" {
+		return ""
+	}
+
+	if manager.keep {
+		return manager.root
+	}
+
+	if err := os.RemoveAll(manager.root); err != nil {
+		log.Warnf(
+			"(temp/Cleanup) failed to remove workspace: \"%s\" \nerror: %v",
+			manager.root,
+			err,
+		)
+	}
+
+	manager.root = ""
+	return ""
+}
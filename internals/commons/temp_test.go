@@ -0,0 +1,96 @@
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTempManager(t *testing.T) {
+	manager := NewTempManager(false)
+	if manager.Root() != "" || manager.Cleanup() != "" {
+		t.Errorf("(temp/Dir) workspace created before it is required")
+	}
+
+	first, err := manager.Dir("subs")
+	if err != nil {
+		t.Fatalf("(temp/Dir) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(manager.Root())
+
+	second, err := manager.Dir("subs")
+	if err != nil {
+		t.Fatalf("(temp/Dir) failed to create directory: %v", err)
+	}
+
+	// Directories are created in a single workspace, recognizable by the prefix
+	root := manager.Root()
+	if !strings.HasPrefix(filepath.Base(root), TempPrefix) {
+		t.Errorf("(temp/Dir) unexpected workspace: %q", root)
+	}
+
+	// Workspace records the process owning it
+	owner, _ := ioutil.ReadFile(filepath.Join(root, TempOwnerFile))
+	if string(owner) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("(temp/Dir) unexpected owner: %q", owner)
+	}
+
+	if first == second || filepath.Dir(first) != root || filepath.Dir(second) != root {
+		t.Errorf("(temp/Dir) unexpected directories: %q, %q", first, second)
+	}
+
+	// Released directories are removed, paths outside the workspace are left as is
+	manager.Release(first)
+	manager.Release(root)
+	manager.Release(filepath.Dir(root))
+	if _, err = os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("(temp/Release) directory not removed: %q", first)
+	}
+
+	if _, err = os.Stat(second); err != nil {
+		t.Errorf("(temp/Release) directory removed unexpectedly: %v", err)
+	}
+
+	if kept := manager.Cleanup(); kept != "" {
+		t.Errorf("(temp/Cleanup) workspace kept unexpectedly: %q", kept)
+	}
+
+	if _, err = os.Stat(root); !os.IsNotExist(err) || manager.Root() != "" {
+		t.Errorf("(temp/Cleanup) workspace not removed: %q", root)
+	}
+}
+
+func TestTempManagerKeep(t *testing.T) {
+	manager := NewTempManager(true)
+	dir, err := manager.Dir("subs")
+	if err != nil {
+		t.Fatalf("(temp/Dir) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(manager.Root())
+
+	// Nothing is removed if the workspace is to be kept
+	manager.Release(dir)
+	if kept := manager.Cleanup(); kept != filepath.Dir(dir) {
+		t.Errorf("(temp/Cleanup) unexpected path for kept workspace: %q", kept)
+	}
+
+	if _, err = os.Stat(dir); err != nil {
+		t.Errorf("(temp/Cleanup) kept directory removed: %v", err)
+	}
+
+	// The manager of the ongoing run is reset to the default
+	SetTemp(manager)
+	if Temp() != manager {
+		t.Errorf("(temp/SetTemp) manager not set")
+	}
+
+	SetTemp(nil)
+	if Temp() == manager || Temp() == nil {
+		t.Errorf("(temp/SetTemp) manager not reset")
+	}
+}
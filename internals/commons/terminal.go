@@ -0,0 +1,28 @@
+package commons
+
+import (
+	"os"
+	"strconv"
+)
+
+// Width assumed when the width of the terminal can't be determined
+const defaultTerminalWidth = 80
+
+/*
+TerminalWidth returns the number of columns in the terminal to which the output is
+written. Uses the `COLUMNS` environment variable if the terminal can't be queried,
+defaulting to 80 columns if even that fails.
+*/
+func TerminalWidth() int {
+	if file, ok := GetOutput().(*os.File); ok {
+		if width, ok := terminalWidth(file.Fd()); ok && width > 0 {
+			return width
+		}
+	}
+
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+
+	return defaultTerminalWidth
+}
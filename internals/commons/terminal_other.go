@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package commons
+
+/*
+TerminalWidth can't query the terminal on this platform, the width from the environment
+(or the default width) is used instead.
+*/
+func terminalWidth(uintptr) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,35 @@
+package commons
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestTerminalWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+
+	// Output stream is not a terminal, the width from the environment should be used
+	defer func(stream io.Writer) { outStream = stream }(outStream)
+	outStream = &bytes.Buffer{}
+
+	for columns, width := range map[string]int{
+		"132":     132,
+		"":        defaultTerminalWidth,
+		"invalid": defaultTerminalWidth,
+		"-10":     defaultTerminalWidth,
+	} {
+		os.Setenv("COLUMNS", columns)
+
+		if res := TerminalWidth(); res != width {
+			t.Errorf(
+				"(terminal/TerminalWidth) unexpected width \nCOLUMNS: `%s`"+
+					"\nexpected: %d \nresult: %d",
+				columns,
+				width,
+				res,
+			)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package commons
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+/*
+TerminalWidth queries the width of the terminal through the file descriptor, the
+boolean is false if the file descriptor does not point to a terminal.
+*/
+func terminalWidth(fd uintptr) (int, bool) {
+	// Structure filled in by the `TIOCGWINSZ` ioctl
+	var size struct {
+		rows, cols, xPixels, yPixels uint16
+	}
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&size)), //nolint:gosec // required by the ioctl
+	)
+
+	if errno != 0 {
+		return 0, false
+	}
+
+	return int(size.cols), true
+}
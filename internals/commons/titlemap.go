@@ -0,0 +1,104 @@
+package commons
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+TrackTitle is the title (and language) to be used for a subtitle file, as per the title
+map - empty values leave the defaults in place.
+*/
+type TrackTitle struct {
+	Title    string
+	Language string
+}
+
+/*
+LoadTitleMap reads the title map from a CSV file, each row mapping a subtitle file to
+the title of its track and an optional language;
+
+	# comments, and blank lines are skipped
+	English.ass,Full Subtitles
+	Show A/Signs.ass,Signs & Songs,eng
+
+Files are named by their name, or by their path relative to the root directory (with
+forward slashes) to set titles for a single source directory. The map is keyed as per
+the case of names supplied.
+*/
+func LoadTitleMap(path string, casing NameCase) (map[string]TrackTitle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	titles := make(map[string]TrackTitle)
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf(
+				"row %d: expected `file,title[,language]`, found %d field(s)",
+				row,
+				len(record),
+			)
+		}
+
+		name := strings.Trim(filepath.ToSlash(strings.TrimSpace(record[0])), "/")
+		if name == "" {
+			return nil, fmt.Errorf("row %d: file name missing", row)
+		}
+
+		title := TrackTitle{Title: strings.TrimSpace(record[1])}
+		if len(record) == 3 {
+			if language := strings.TrimSpace(record[2]); language != "" {
+				code, err := LanguageCode(language)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %v", row, err)
+				}
+
+				title.Language = code
+			}
+		}
+
+		titles[casing.Fold(name)] = title
+	}
+
+	return titles, nil
+}
+
+/*
+TrackTitle returns the title (and language) from the title map for a subtitle file, the
+path relative to the root directory takes precedence over the name of the file. The
+boolean returned indicates if the file is present in the title map.
+*/
+func (userInput *UserInput) TrackTitle(sourceDir, name string) (TrackTitle, bool) {
+	if len(userInput.TitleMaps) == 0 {
+		return TrackTitle{}, false
+	}
+
+	casing := userInput.Casing()
+	rel := userInput.relativePath(filepath.Join(sourceDir, name))
+	if title, ok := userInput.TitleMaps[casing.Fold(rel)]; ok {
+		return title, true
+	}
+
+	title, ok := userInput.TitleMaps[casing.Fold(filepath.Base(name))]
+	return title, ok
+}
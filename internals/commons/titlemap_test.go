@@ -0,0 +1,75 @@
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTitleMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(titlemap/LoadTitleMap) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "titles.csv")
+	contents := "# file,title,language\n" +
+		"English.ass,Full Subtitles\n\n" +
+		"\"Show A/Signs.ass\", \"Signs, Songs\", ENG\n" +
+		"Signs.ass,Signs\n"
+
+	if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("(titlemap/LoadTitleMap) failed to create file: %v", err)
+	}
+
+	input := UserInput{RootPath: dir, TitleMap: path, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK {
+		t.Fatalf("(userInput/Initialize) failed to read title map \nerror: %v", err)
+	}
+
+	// Paths relative to the root directory take precedence over names
+	for _, in := range []struct {
+		sourceDir, name string
+		title           TrackTitle
+		found           bool
+	}{
+		{"Show A", "english.ass", TrackTitle{Title: "Full Subtitles"}, true},
+		{"Show A", "Signs.ass", TrackTitle{"Signs, Songs", "eng"}, true},
+		{"Show B", "Signs.ass", TrackTitle{Title: "Signs"}, true},
+		{"Show B", "Other.ass", TrackTitle{}, false},
+	} {
+		title, found := input.TrackTitle(filepath.Join(dir, in.sourceDir), in.name)
+		if title != in.title || found != in.found {
+			t.Errorf(
+				"(titlemap/TrackTitle) unexpected result for \"%s/%s\" \nresult: %+v",
+				in.sourceDir,
+				in.name,
+				title,
+			)
+		}
+	}
+
+	// Malformed rows, invalid languages and missing files are reported
+	for _, contents := range []string{
+		"English.ass\n",
+		"English.ass,Title,eng,extra\n",
+		"English.ass,Title,english\n",
+		",Title\n",
+	} {
+		if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("(titlemap/LoadTitleMap) failed to create file: %v", err)
+		}
+
+		if _, err = LoadTitleMap(path, NameCase{}); err == nil {
+			t.Errorf("(titlemap/LoadTitleMap) malformed map accepted: %q", contents)
+		}
+	}
+
+	input = UserInput{TitleMap: filepath.Join(dir, "missing.csv"), IsTest: true}
+	if code, _ := input.Initialize(); code != TitleMapError {
+		t.Errorf("(userInput/Initialize) missing title map accepted, code: %d", code)
+	}
+}
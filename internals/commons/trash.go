@@ -0,0 +1,83 @@
+package commons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	trashMu sync.RWMutex
+
+	// Boolean indicating if items are to be moved to the trash instead of being
+	// deleted permanently
+	useTrash bool
+)
+
+// SetTrash decides if items discarded by the run are moved to the trash
+func SetTrash(enabled bool) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+
+	useTrash = enabled
+}
+
+// UseTrash returns a boolean indicating if items discarded are moved to the trash
+func UseTrash() bool {
+	trashMu.RLock()
+	defer trashMu.RUnlock()
+
+	return useTrash
+}
+
+/*
+Discard removes a file (or a directory, along with its contents) - the item is moved to
+the trash (or the recycle bin) of the platform instead if the user asks for it. Missing
+items are not treated as an error. If the item can't be moved to the trash, it is left
+as is and the error is returned; items are never deleted permanently in such a case.
+*/
+func Discard(path string) error {
+	if !UseTrash() {
+		return os.RemoveAll(path)
+	}
+
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if err = moveToTrash(abs); err != nil {
+		log.Debugf(
+			"(trash/Discard) failed to move to trash: \"%s\" \nerror: %v",
+			abs,
+			err,
+		)
+
+		return fmt.Errorf("failed to move \"%s\" to the trash: %v", path, err)
+	}
+
+	log.Debugf(`(trash/Discard) moved to trash: "%s"`, abs)
+	return nil
+}
+
+/*
+TrashName returns the name used for an item in the trash on the attempt supplied (from
+one), items with the same name present in the trash already are suffixed with the
+attempt, i.e. `movie.mkv` followed by `movie.2.mkv`.
+*/
+func trashName(name string, attempt int) string {
+	if attempt <= 1 {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(name, ext), attempt, ext)
+}
@@ -0,0 +1,50 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package commons
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/*
+MoveToTrash moves an item into the recycle bin through PowerShell - the Visual Basic
+file system API sends items to the recycle bin without showing a dialog.
+*/
+func moveToTrash(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	method := "DeleteFile"
+	if info.IsDir() {
+		method = "DeleteDirectory"
+	}
+
+	// Single quotes are escaped by doubling them in PowerShell strings
+	script := fmt.Sprintf(
+		"Add-Type -AssemblyName Microsoft.VisualBasic; "+
+			"[Microsoft.VisualBasic.FileIO.FileSystem]::%s('%s', "+
+			"'OnlyErrorDialogs', 'SendToRecycleBin')",
+		method,
+		strings.ReplaceAll(path, "'", "''"),
+	)
+
+	output, err := exec.Command(
+		"powershell",
+		"-NoProfile",
+		"-NonInteractive",
+		"-Command",
+		script,
+	).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
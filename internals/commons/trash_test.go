@@ -0,0 +1,99 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package commons
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrashName(t *testing.T) {
+	tests := map[int]string{
+		0: "movie.mkv",
+		1: "movie.mkv",
+		2: "movie.2.mkv",
+		3: "movie.3.mkv",
+	}
+
+	for attempt, expected := range tests {
+		if name := trashName("movie.mkv", attempt); name != expected {
+			t.Errorf("(trash/trashName) expected %q, got %q", expected, name)
+		}
+	}
+
+	if name := trashName("Subs", 2); name != "Subs.2" {
+		t.Errorf("(trash/trashName) unexpected name for directory: %q", name)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-trash-")
+	if err != nil {
+		t.Fatalf("(trash/Discard) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Items are deleted permanently by default
+	path := filepath.Join(dir, "movie.mkv")
+	_ = ioutil.WriteFile(path, []byte("movie"), 0600)
+	if err = Discard(path); err != nil {
+		t.Errorf("(trash/Discard) failed to remove: %v", err)
+	}
+
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("(trash/Discard) item not removed: %q", path)
+	}
+
+	// Missing items are never an error, even with the trash in use
+	SetTrash(true)
+	defer SetTrash(false)
+
+	if err = Discard(filepath.Join(dir, "missing.mkv")); err != nil {
+		t.Errorf("(trash/Discard) unexpected error for missing item: %v", err)
+	}
+}
+
+func TestFreedesktopTrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-trash-")
+	if err != nil {
+		t.Fatalf("(trash/freedesktopTrash) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	trash := filepath.Join(dir, "Trash")
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(dir, "movie.mkv")
+		_ = ioutil.WriteFile(path, []byte("movie"), 0600)
+
+		if err = freedesktopTrash(path, trash); err != nil {
+			t.Fatalf("(trash/freedesktopTrash) failed to move to trash: %v", err)
+		}
+
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("(trash/freedesktopTrash) item left in place: %q", path)
+		}
+	}
+
+	// Items with the same name are kept side by side, each with an info file
+	for _, name := range []string{"movie.mkv", "movie.2.mkv"} {
+		if _, err = os.Stat(filepath.Join(trash, "files", name)); err != nil {
+			t.Errorf("(trash/freedesktopTrash) item missing in trash: %v", err)
+		}
+
+		info, err := ioutil.ReadFile(filepath.Join(trash, "info", name+".trashinfo"))
+		if err != nil {
+			t.Errorf("(trash/freedesktopTrash) info file missing: %v", err)
+			continue
+		}
+
+		if !strings.Contains(string(info), "Path="+filepath.Join(dir, "movie.mkv")) {
+			t.Errorf("(trash/freedesktopTrash) unexpected info file: %q", info)
+		}
+	}
+}
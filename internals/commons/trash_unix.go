@@ -0,0 +1,156 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package commons
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+/*
+MoveToTrash moves an item into the trash of the user - `~/.Trash` on macOS, and the
+home trash as per the freedesktop.org specification elsewhere. Items on another file
+system than the home directory are moved into the trash at the top of that file
+system instead (`.Trashes/<uid>` on macOS, `.Trash-<uid>` elsewhere), items are never
+copied across file systems.
+*/
+func moveToTrash(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	trash := filepath.Join(home, ".Trash")
+	if runtime.GOOS != "darwin" {
+		trash = filepath.Join(home, ".local", "share", "Trash")
+		if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+			trash = filepath.Join(dataHome, "Trash")
+		}
+	}
+
+	if !sameDevice(path, home) {
+		top := topDir(path)
+		uid := fmt.Sprint(os.Getuid())
+
+		if runtime.GOOS == "darwin" {
+			trash = filepath.Join(top, ".Trashes", uid)
+		} else {
+			trash = filepath.Join(top, ".Trash-"+uid)
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		if err = os.MkdirAll(trash, 0700); err != nil {
+			return err
+		}
+
+		dest := filepath.Join(trash, filepath.Base(path))
+		for attempt := 2; ; attempt++ {
+			if _, err = os.Lstat(dest); err != nil {
+				break
+			}
+
+			dest = filepath.Join(trash, trashName(filepath.Base(path), attempt))
+		}
+
+		return os.Rename(path, dest)
+	}
+
+	return freedesktopTrash(path, trash)
+}
+
+/*
+FreedesktopTrash moves an item into a trash directory as per the freedesktop.org
+specification - the item is placed in `files`, with the original path and the time of
+deletion recorded in `info`, allowing file managers to restore the item.
+*/
+func freedesktopTrash(path, trash string) error {
+	files, info := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, dir := range []string{files, info} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// The info file reserves the name, it is created before the item is moved
+	var name, infoPath string
+	for attempt := 1; ; attempt++ {
+		name = trashName(filepath.Base(path), attempt)
+		infoPath = filepath.Join(info, name+".trashinfo")
+
+		file, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		_ = file.Close()
+		break
+	}
+
+	contents := fmt.Sprintf(
+		"[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(),
+		time.Now().Format("2006-01-02T15:04:05"),
+	)
+
+	if err := ioutil.WriteFile(infoPath, []byte(contents), 0600); err != nil {
+		_ = os.Remove(infoPath)
+		return err
+	}
+
+	if err := os.Rename(path, filepath.Join(files, name)); err != nil {
+		_ = os.Remove(infoPath)
+		return err
+	}
+
+	return nil
+}
+
+// SameDevice checks if two paths are present on the same file system
+func sameDevice(a, b string) bool {
+	devA, errA := device(a)
+	devB, errB := device(b)
+
+	return errA == nil && errB == nil && devA == devB
+}
+
+// Device returns the ID of the file system containing a path
+func device(path string) (uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, errors.New("unable to read the file system of the path")
+	}
+
+	return uint64(stat.Dev), nil
+}
+
+/*
+TopDir returns the top directory of the file system containing a path, i.e. the mount
+point - the highest directory on the same file system.
+*/
+func topDir(path string) string {
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || !sameDevice(parent, dir) {
+			return dir
+		}
+
+		dir = parent
+	}
+}
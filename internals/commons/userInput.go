@@ -7,13 +7,99 @@ package commons
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Policies to handle outputs with the same name across source directories
+const (
+	CollisionError  = "error"
+	CollisionSuffix = "suffix"
+	CollisionMirror = "mirror"
+)
+
+// Layouts for the outputs - stored in the result directory, or next to the media files
+const (
+	LayoutCentral   = "central"
+	LayoutSibling   = "sibling"
+	LayoutSubfolder = "subfolder"
+)
+
+// Strategies to suffix the names of colliding outputs, used with the `suffix` policy
+const (
+	SuffixCounter = "counter"
+	SuffixDir     = "dir"
+	SuffixHash    = "hash"
+)
+
+// Orders in which the source directories in the root directory are processed
+const (
+	OrderName     = "name"
+	OrderSizeAsc  = "size-asc"
+	OrderSizeDesc = "size-desc"
+	OrderMtime    = "mtime"
+)
+
+// Log level used for the FFmpeg processes unless supplied, hides the banner and the
+// details of the streams while keeping warnings
+const DefaultLogLevel = "warning"
+
+// Log levels accepted by FFmpeg, from the least verbose to the most verbose
+var ffmpegLogLevels = []string{
+	"quiet",
+	"panic",
+	"fatal",
+	"error",
+	"warning",
+	"info",
+	"verbose",
+	"debug",
+	"trace",
+}
+
+// Types of files attached to the output, used to set a description per type
+const (
+	AttachmentFont    = "font"
+	AttachmentChapter = "chapter"
+)
+
+// Handling of the character encoding of text subtitles; detected and converted to
+// UTF-8, or kept as is - any other value is the name of the encoding of the subtitles
+const (
+	EncodingAuto = "auto"
+	EncodingKeep = "keep"
+)
+
+// Position of the subtitle streams attached, relative to the subtitle streams present
+// in the media file already
+const (
+	SubPositionFront = "front"
+	SubPositionBack  = "back"
+)
+
+// Codecs the subtitle streams attached can be written with - picked by the format of
+// each subtitle file in auto mode, or forced for every subtitle file
+const (
+	SubCodecAuto    = "auto"
+	SubCodecCopy    = "copy"
+	SubCodecSrt     = "srt"
+	SubCodecAss     = "ass"
+	SubCodecMovText = "mov_text"
+)
+
+// Name of the marker dropped in source directories that failed, with `--touch-failed`
+const FailedMarkerName = ".autosub-failed"
+
+// Pattern for the name of a character encoding, as recognized by FFmpeg (i.e. iconv)
+var charsetPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._:-]*$`)
+
 /*
 UserInput is a simple structure to store and operate upon data passed by the user using
 CLI.
@@ -25,35 +111,332 @@ type UserInput struct {
 	// Path to the root directory containing the files
 	RootPath string
 
+	// Path to the directory in which the results are to be stored - if empty, the
+	// results will be stored in a directory inside the root directory
+	OutputPath string
+
 	// Path to ffmpeg executable
 	FFmpegPath string
 
 	// Path to ffprobe executable
 	FFprobePath string
 
+	// Path to mkvpropedit executable (from MKVToolNix), looked up for a run if track
+	// statistics tags are to be written, or the fast path can be taken
+	MkvpropeditPath string
+
+	// Variables (in the form `KEY=VALUE`) added to the environment of the FFmpeg and
+	// FFprobe processes, i.e. `FONTCONFIG_FILE` or `TMPDIR`
+	Env []string
+
 	// Indicates if logging is required or not. True indicates Logging is required.
 	Logging bool
 
+	// Path to the log file, the file in the state directory is used if empty
+	LogFile string
+
+	// Prints the stream map of each output (the streams it will contain) before the
+	// output is merged
+	Verbose bool
+
+	// Boolean indicating if logs are to be mirrored to the standard error, along
+	// with the log file
+	LogStderr bool
+
 	// Boolean containing value of the direct flag
 	IsDirect bool
 
 	// Boolean containing value of test flag
 	IsTest bool
 
+	// Boolean indicating if each media file in a source directory is to be processed,
+	// instead of failing for source directories with multiple media files
+	AllMedia bool
+
+	// Boolean indicating if samples and trailers are to be treated as media files, by
+	// default these are left out if the source directory has a larger media file
+	NoSampleFilter bool
+
+	// Boolean indicating if the streams and the format of each output are to be saved
+	// next to the output (as JSON, reported by FFprobe)
+	ExportMetadata bool
+
+	// Boolean indicating if archives (i.e. zip files) present in source directories
+	// are to be extracted, with the files inside grouped same as the rest
+	ExtractArchives bool
+
 	// Array of strings with each string being a name of the file that is to be ignored.
 	Exclusions []string
 
-	// Regex-friendly file names that are to be ignored.
-	RegexExclude string
+	// Regex patterns for file names that are to be ignored, a file matching any one
+	// of the patterns is ignored.
+	RegexExclusions []string
+
+	// Compiled regex expressions - will be slightly faster than the normal Version.
+	RegexRules []*regexp.Regexp
+
+	// Boolean indicating if exclusion rules are to be matched against the path
+	// relative to the root directory, and against directory names during traversal
+	MatchPaths bool
+
+	// Boolean indicating if names are to be compared case sensitively by every rule
+	// matching files and directories - including regex rules and ignore files
+	CaseSensitive bool
+
+	// Array of glob patterns, if present, only items matching these patterns (or the
+	// regex inclusion) will be processed.
+	Inclusions []string
+
+	// Regex-friendly names for items that are to be processed.
+	RegexInclude string
+
+	// Compiled regex expression for the inclusion pattern
+	IncludeRule *regexp.Regexp
+
+	// Additional extensions (without the period) for files to be treated as media
+	// files, along with the built-in extensions
+	MediaExt []string
+
+	// Name of the profile (from the configuration file) used for the run, if any
+	Profile string
+
+	// Boolean indicating if leftovers from earlier runs that did not complete are to
+	// be removed without asking
+	CleanStale bool
 
-	// Compiled regex expression - will be slightly faster than the normal Version.
-	RegexRule *regexp.Regexp
+	// Booleans indicating if a marker is to be dropped in source directories that
+	// failed, and if only the source directories with a marker are to be processed
+	TouchFailed bool
+	RetryFailed bool
+
+	// Boolean indicating if one output is to be created for each subtitle language,
+	// instead of merging every subtitle into a single output
+	SplitByLang bool
+
+	// Boolean indicating if files are to be moved to the trash (or the recycle bin)
+	// instead of being deleted permanently
+	UseTrash bool
+
+	// Boolean indicating if the temporary workspace of the run is to be kept once the
+	// run completes (or is cancelled), useful while debugging
+	KeepTemp bool
+
+	// Boolean indicating if track statistics tags (bitrate, duration, frame count,
+	// etc.) are to be written to the outputs through mkvpropedit
+	StatsTags bool
+
+	// Boolean indicating if outputs are always remuxed by FFmpeg - Matroska media
+	// files getting attachments alone are copied and edited through mkvpropedit
+	// otherwise
+	NoFastPath bool
+
+	// Boolean indicating if an MP4 variant is written next to each output, with text
+	// subtitles converted to `mov_text`
+	AlsoMP4 bool
+
+	// Booleans indicating if the resolved configuration is to be printed, or the
+	// configuration file validated - nothing else is done in either case
+	PrintConfig    bool
+	ValidateConfig bool
 
 	// Custom title for the subs file being attached
 	SubTitleString string
 
 	// Subtitle language
 	SubLang string
+
+	// Path to the CSV file mapping subtitle files to the titles (and languages) of
+	// their tracks, and the map read from it - keyed by the name (or path relative
+	// to the root directory) of the subtitle file
+	TitleMap  string
+	TitleMaps map[string]TrackTitle
+
+	// Metadata rules from the configuration file (and the profile selected), setting
+	// the metadata for subtitle files and attachments matching the rules
+	Rules []MetadataRule
+
+	// Path to the chapters file attached to every output instead of the chapter files
+	// found in the source directories - `{dir}` is replaced with the name of each
+	// source directory. Chapters are detected from the source directories if empty
+	ChaptersFile string
+
+	// Path to an executable deciding the category of the files in the source
+	// directories, files it leaves out are grouped through their extensions
+	Classifier string
+
+	// Duration (in seconds) to be merged in sample mode, sample mode is disabled if
+	// the value is zero
+	Sample int
+
+	// Maximum difference (in seconds) allowed between the duration of a subtitle file
+	// and the media file; the check is disabled if the value is zero (or negative)
+	SyncThreshold int
+
+	// Boolean indicating if source directories with subtitles out of sync with the
+	// media file are to be skipped
+	StrictSync bool
+
+	// Duration (in seconds) without progress after which an encode is considered to
+	// be stalled; stall detection is disabled if the value is zero (or negative)
+	StallTimeout int
+
+	// Boolean indicating if stalled encodes are to be killed
+	KillStalled bool
+
+	// Number of times a killed encode is to be retried
+	StallRetries int
+
+	// Seconds between two progress updates, such as `0.5` - a default is picked for the
+	// output in use if zero
+	StatsInterval float64
+
+	// Number of threads used by FFmpeg, and the maximum number of packets buffered
+	// while waiting for all streams to initialize - passed to FFmpeg as is, FFmpeg
+	// defaults are used if zero (or negative)
+	Threads         int
+	MuxingQueueSize int
+
+	// Maximum number of subtitle files and attachments merged into a media file, media
+	// files exceeding either fail - no limit if zero (or negative)
+	MaxSubs        int
+	MaxAttachments int
+
+	// Booleans indicating if prompts are to be accepted (or declined) without asking
+	AssumeYes bool
+	AssumeNo  bool
+
+	// Policy through which every prompt is routed, created while initializing
+	Prompt *PromptPolicy
+
+	// Daily wall-clock window (`HH:MM-HH:MM`) in which source directories are to be
+	// processed, and the parsed window - no restrictions if empty
+	ScheduleWindow string
+	Schedule       *Schedule
+
+	// Load average of the system (over a minute) above which source directories are
+	// not started, the run resumes once the load drops - disabled if zero (or negative)
+	MaxLoad float64
+
+	// Time allowed for each source directory, scaled by the duration of its media
+	// files - `duration × TimeoutFactor + TimeoutBase` (in seconds). No timeout is
+	// applied if both are zero (or negative)
+	TimeoutFactor float64
+	TimeoutBase   int
+
+	// Read throughput (in bytes per second) shared by every FFmpeg process in the run,
+	// such as `50M` - parsed into `IOLimitRate`, no limit is applied if blank
+	IOLimit     string
+	IOLimitRate int64
+
+	// Order in which the source directories are processed - by name, by size (either
+	// way), or the most recently modified first
+	Order string
+
+	// Log level for the FFmpeg processes merging the files, the output is captured
+	// and logged if the process fails
+	FFmpegLogLevel string
+
+	// Address (`host:port`) to serve the metrics for the run on, in the Prometheus
+	// text format - disabled if blank
+	MetricsAddr string
+
+	// Two-pass flow; the plan for the run is printed for the user to confirm before
+	// merging anything, or saved to `PlanFile` (if supplied) without merging. A plan
+	// saved earlier is applied through `ApplyPlan`, processing only the source
+	// directories present in the plan
+	Plan      bool
+	PlanFile  string
+	ApplyPlan string
+
+	// Regex patterns (and the compiled expressions) for the media files in
+	// extract-and-merge mode; subtitles, fonts and chapters are extracted from the
+	// donor and merged into the raw media file. Both patterns are required together
+	DonorRegex string
+	DonorRule  *regexp.Regexp
+	RawRegex   string
+	RawRule    *regexp.Regexp
+
+	// Boolean indicating if existing outputs are to be skipped, unless the inputs
+	// changed since the output was created
+	SkipExisting bool
+
+	// Boolean indicating if a diagnostic bundle is to be captured for failed commands
+	Diagnostics bool
+
+	// Languages (as present in the names of subtitle files) in the order in which
+	// subtitles are to be attached - subtitles in other languages are attached later
+	SubOrder []string
+
+	// Descriptions for the attached files, in the form `type=description`, parsed into
+	// a map of descriptions by the type of attachment
+	AttachmentDesc  []string
+	AttachmentDescs map[string]string
+
+	// Policy to handle outputs with the same name across source directories
+	OnCollision string
+
+	// Strategy to suffix the names of colliding outputs with, for the `suffix` policy
+	CollisionSuffix string
+
+	// Layout for the outputs; stored in the result directory (central), in the source
+	// directory itself (sibling), or in a folder inside the source directory
+	// (subfolder)
+	OutputLayout string
+
+	// Glob patterns for the files in a source directory to be copied as is into the
+	// result directory
+	CopyExtras []string
+
+	// Position of the subtitle streams attached, before (or after) the subtitle
+	// streams present in the media file already
+	SubPosition string
+
+	// Character encoding of text subtitles - detected automatically, kept as is, or the
+	// name of the encoding used. Subtitles are converted to UTF-8 before attaching
+	SubEncoding string
+
+	// Codec the subtitle streams attached are written with, picked by the format of
+	// each subtitle file unless forced
+	SubtitleCodec string
+
+	// Regex pattern (and the compiled expression) for the names of subtitle files
+	// meant for the hearing impaired - replaces the built-in `sdh`/`cc` markers
+	SDHRegex string
+	SDHRule  *regexp.Regexp
+
+	// Boolean indicating if every subtitle file is to be attached, instead of the one
+	// best matching the media file
+	KeepAllSubs bool
+
+	// Language for messages shown to the user, picked from the environment if empty
+	UILanguage string
+
+	// Path to the file caching the results of FFprobe across runs, and the boolean
+	// indicating if the cache is to be bypassed - no cache is used if the path is empty
+	ProbeCache   string
+	NoProbeCache bool
+
+	// Boolean indicating if results are to be written to the standard output as JSON
+	// lines - progress goes to the standard error without ANSI escapes, and prompts
+	// are declined unless `--yes` is used
+	Porcelain bool
+
+	// Booleans indicating if only warnings and errors are to be printed, and if colors
+	// are to be left out of the output (colors are never used in porcelain mode)
+	Quiet   bool
+	NoColor bool
+
+	// Boolean indicating if the run should never overwrite or delete existing files,
+	// nor write to the source directories - violations stop the run
+	Safe bool
+
+	// Remote root directory, if the root path is a URL. The remote root directory
+	// is to be staged locally, with the root path pointing to the local copy
+	Remote Remote
+
+	// Matchers for the ignore files present in the root and source directories, read
+	// once per directory and reset while initializing
+	ignoreFiles map[string]*Matcher
 }
 
 /*
@@ -63,10 +446,16 @@ This involves validating user input, compiling the regex pattern (if any) to ens
 that the pattern is valid, validating the root path, trimming spaces/trailing slashes
 from the list of exclusions, etc.
 
+Every field is validated, even if an invalid field is found - the error returned is a
+`*ValidationReport` listing each invalid field, and the exit code is the one for the
+first invalid field.
+
 Note: This function will safely exit in case root path is empty - this check is
 supposed to be made by the calling method
 */
 func (userInput *UserInput) Initialize() (int, error) {
+	report := &ValidationReport{}
+
 	// Trimming spaces from each value in the array, removing trailing slashes - do not
 	// convert cases, messes up if a value is a full path
 	for i := range userInput.Exclusions {
@@ -76,37 +465,513 @@ func (userInput *UserInput) Initialize() (int, error) {
 		)
 	}
 
-	// Compiling the regex string into a compiled regex expression - compiled regex
-	// expressions are easy to compare against.
-	var regex *regexp.Regexp
-	if exp, err := regexp.Compile(userInput.RegexExclude); err == nil {
-		// Doing it this way to make GoLint stop throwing errors :(
-		regex = exp
+	// Extensions are compared in lower case, without the period
+	for i := range userInput.MediaExt {
+		userInput.MediaExt[i] = strings.ToLower(
+			strings.TrimLeft(strings.TrimSpace(userInput.MediaExt[i]), "."),
+		)
+	}
+
+	// Compiling the regex strings into compiled regex expressions - compiled regex
+	// expressions are easy to compare against. Blank patterns are left as nil
+	// Each exclusion pattern is compiled independently, reporting every malformed one
+	userInput.RegexRules = nil
+	for _, pattern := range userInput.RegexExclusions {
+		if rule := userInput.compileRule(report, "--rexclude", pattern); rule != nil {
+			userInput.RegexRules = append(userInput.RegexRules, rule)
+		}
+	}
+
+	userInput.IncludeRule = userInput.compileRule(
+		report,
+		"--rinclude",
+		userInput.RegexInclude,
+	)
+
+	// Extract-and-merge mode requires both patterns, and picks a single media file
+	switch {
+	case (userInput.DonorRegex == "") != (userInput.RawRegex == ""):
+		report.add("--donor-regex", FlagConflict, errors.New(
+			"`--donor-regex` and `--raw-regex` must be used together",
+		))
+
+	case userInput.DonorRegex != "" && userInput.AllMedia:
+		report.add("--donor-regex", FlagConflict, errors.New(
+			"`--donor-regex` and `--raw-regex` can't be used with `--all-media`",
+		))
+
+	// Languages of the subtitles in the donor aren't known, these can't be split
+	case userInput.DonorRegex != "" && userInput.SplitByLang:
+		report.add("--donor-regex", FlagConflict, errors.New(
+			"`--donor-regex` and `--raw-regex` can't be used with `--split-by-lang`",
+		))
+	}
+
+	userInput.DonorRule = userInput.compileRule(
+		report,
+		"--donor-regex",
+		userInput.DonorRegex,
+	)
+	userInput.RawRule = userInput.compileRule(report, "--raw-regex", userInput.RawRegex)
+	userInput.SDHRule = userInput.compileRule(report, "--sdh-regex", userInput.SDHRegex)
+
+	// Validate glob patterns - `filepath.Match` returns an error only if the pattern
+	// is malformed
+	for i := range userInput.Inclusions {
+		userInput.Inclusions[i] = strings.TrimSpace(userInput.Inclusions[i])
+		if _, err := filepath.Match(userInput.Inclusions[i], ""); err != nil {
+			log.Debugf(
+				"(userInput/Initialize) invalid glob pattern: `%s` \nerror: %v",
+				userInput.Inclusions[i],
+				err,
+			)
+
+			report.add("--only", GlobError, fmt.Errorf(
+				"failed to parse the glob pattern `%s`: %v",
+				userInput.Inclusions[i],
+				err,
+			))
+		}
+	}
+
+	for i := range userInput.CopyExtras {
+		userInput.CopyExtras[i] = strings.TrimSpace(userInput.CopyExtras[i])
+		if _, err := filepath.Match(userInput.CopyExtras[i], ""); err != nil {
+			report.add("--copy-extras", GlobError, fmt.Errorf(
+				"failed to parse the glob pattern `%s`: %v",
+				userInput.CopyExtras[i],
+				err,
+			))
+		}
+	}
+
+	userInput.AttachmentDescs = make(map[string]string)
+	for _, desc := range userInput.AttachmentDesc {
+		parts := strings.SplitN(desc, "=", 2)
+		kind := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		if len(parts) != 2 || (kind != AttachmentFont && kind != AttachmentChapter) {
+			report.add("--attachment-desc", AttachmentError, fmt.Errorf(
+				"expected `%s=<description>` or `%s=<description>`, found `%s`",
+				AttachmentFont,
+				AttachmentChapter,
+				desc,
+			))
+
+			continue
+		}
+
+		userInput.AttachmentDescs[kind] = strings.TrimSpace(parts[1])
+	}
+
+	userInput.OnCollision = strings.ToLower(strings.TrimSpace(userInput.OnCollision))
+	switch userInput.OnCollision {
+	case "":
+		userInput.OnCollision = CollisionError
+
+	case CollisionError, CollisionSuffix, CollisionMirror:
+
+	default:
+		report.add("--on-collision", OutputCollision, fmt.Errorf(
+			"unknown policy `%s`, expected one of `%s`, `%s` or `%s`",
+			userInput.OnCollision,
+			CollisionError,
+			CollisionSuffix,
+			CollisionMirror,
+		))
+	}
+
+	userInput.CollisionSuffix = strings.ToLower(
+		strings.TrimSpace(userInput.CollisionSuffix),
+	)
+
+	switch userInput.CollisionSuffix {
+	case "":
+		userInput.CollisionSuffix = SuffixCounter
+
+	case SuffixCounter, SuffixDir, SuffixHash:
+
+	default:
+		report.add("--collision-suffix", OutputCollision, fmt.Errorf(
+			"unknown strategy `%s`, expected one of `%s`, `%s` or `%s`",
+			userInput.CollisionSuffix,
+			SuffixCounter,
+			SuffixDir,
+			SuffixHash,
+		))
+	}
+
+	userInput.OutputLayout = strings.ToLower(strings.TrimSpace(userInput.OutputLayout))
+	switch userInput.OutputLayout {
+	case "":
+		userInput.OutputLayout = LayoutCentral
+
+	case LayoutCentral:
+
+	case LayoutSibling, LayoutSubfolder:
+		// Outputs are written to the source directories, the result directory is
+		// not used at all
+		switch {
+		case userInput.Safe:
+			report.add("--output-layout", FlagConflict, fmt.Errorf(
+				"`%s` layout writes to the source directories, not allowed in "+
+					"safe mode",
+				userInput.OutputLayout,
+			))
+
+		case userInput.OutputPath != "":
+			report.add("--output-layout", FlagConflict, fmt.Errorf(
+				"`%s` layout can't be combined with `--output`",
+				userInput.OutputLayout,
+			))
+
+		case userInput.OutputLayout == LayoutSibling && len(userInput.CopyExtras) > 0:
+			report.add("--output-layout", FlagConflict, errors.New(
+				"extra files can't be copied into their own source directory",
+			))
+		}
+
+	default:
+		report.add("--output-layout", LayoutError, fmt.Errorf(
+			"unknown layout `%s`, expected one of `%s`, `%s` or `%s`",
+			userInput.OutputLayout,
+			LayoutCentral,
+			LayoutSibling,
+			LayoutSubfolder,
+		))
+	}
+
+	if userInput.Sample < 0 {
+		report.add("--sample", SampleError, fmt.Errorf(
+			"sample duration can not be negative, found `%d`",
+			userInput.Sample,
+		))
+	}
+
+	if userInput.IOLimit != "" {
+		rate, err := ParseRate(userInput.IOLimit)
+		if err != nil {
+			report.add("--io-limit", IOLimitError, err)
+		}
+
+		userInput.IOLimitRate = rate
+	}
+
+	userInput.Order = strings.ToLower(strings.TrimSpace(userInput.Order))
+	switch userInput.Order {
+	case "":
+		userInput.Order = OrderName
+
+	case OrderName, OrderSizeAsc, OrderSizeDesc, OrderMtime:
+
+	default:
+		report.add("--order", OrderError, fmt.Errorf(
+			"unknown order `%s`, expected one of `%s`, `%s`, `%s` or `%s`",
+			userInput.Order,
+			OrderName,
+			OrderSizeAsc,
+			OrderSizeDesc,
+			OrderMtime,
+		))
+	}
+
+	level := strings.ToLower(strings.TrimSpace(userInput.FFmpegLogLevel))
+	if userInput.FFmpegLogLevel = level; level == "" {
+		userInput.FFmpegLogLevel = DefaultLogLevel
+	}
+
+	known := false
+	for _, name := range ffmpegLogLevels {
+		known = known || name == userInput.FFmpegLogLevel
+	}
+
+	if !known {
+		report.add("--ffmpeg-loglevel", LogLevelError, fmt.Errorf(
+			"unknown log level `%s`, expected one of `%s`",
+			userInput.FFmpegLogLevel,
+			strings.Join(ffmpegLogLevels, "`, `"),
+		))
+	}
+
+	userInput.SubPosition = strings.ToLower(strings.TrimSpace(userInput.SubPosition))
+	switch userInput.SubPosition {
+	case "":
+		userInput.SubPosition = SubPositionBack
+
+	case SubPositionFront, SubPositionBack:
+
+	default:
+		report.add("--sub-position", SubPositionError, fmt.Errorf(
+			"unknown position `%s`, expected `%s` or `%s`",
+			userInput.SubPosition,
+			SubPositionFront,
+			SubPositionBack,
+		))
+	}
+
+	codec := strings.ToLower(strings.TrimSpace(userInput.SubtitleCodec))
+	switch userInput.SubtitleCodec = codec; codec {
+	case "":
+		userInput.SubtitleCodec = SubCodecAuto
+
+	case SubCodecAuto, SubCodecCopy, SubCodecSrt, SubCodecAss:
+
+	case SubCodecMovText:
+		// Outputs are always matroska, which can't hold `mov_text` streams
+		report.add("--subtitle-codec", SubCodecError, fmt.Errorf(
+			"`%s` subtitles can only be stored in MP4 containers, outputs are always "+
+				"matroska - use `%s` or `%s` instead",
+			SubCodecMovText,
+			SubCodecSrt,
+			SubCodecAss,
+		))
+
+	default:
+		report.add("--subtitle-codec", SubCodecError, fmt.Errorf(
+			"unknown codec `%s`, expected one of `%s`, `%s`, `%s` or `%s`",
+			userInput.SubtitleCodec,
+			SubCodecAuto,
+			SubCodecCopy,
+			SubCodecSrt,
+			SubCodecAss,
+		))
+	}
+
+	userInput.SubEncoding = strings.TrimSpace(userInput.SubEncoding)
+	switch strings.ToLower(userInput.SubEncoding) {
+	case "":
+		userInput.SubEncoding = EncodingAuto
+
+	case EncodingAuto, EncodingKeep:
+		userInput.SubEncoding = strings.ToLower(userInput.SubEncoding)
+
+	default:
+		if !charsetPattern.MatchString(userInput.SubEncoding) {
+			report.add("--sub-encoding", EncodingError, fmt.Errorf(
+				"invalid encoding `%s`, expected `%s`, `%s` or the name of an "+
+					"encoding (for example, `CP1251`)",
+				userInput.SubEncoding,
+				EncodingAuto,
+				EncodingKeep,
+			))
+		}
+	}
+
+	// Markers are written to the source directories, never modified in safe mode
+	if userInput.TouchFailed && userInput.Safe {
+		report.add("--touch-failed", FlagConflict, errors.New(
+			"`--touch-failed` can't be used with `--safe`",
+		))
+	}
+
+	// Answers can't be assumed to be both, yes and no
+	if userInput.AssumeYes && userInput.AssumeNo {
+		report.add("--yes", FlagConflict, errors.New(
+			"`--yes` and `--no` can't be used together",
+		))
+	}
+
+	for i := range userInput.SubOrder {
+		userInput.SubOrder[i] = strings.TrimSpace(userInput.SubOrder[i])
+	}
+
+	userInput.Prompt = NewPromptPolicy(
+		userInput.AssumeYes,
+		userInput.AssumeNo || userInput.Porcelain,
+	)
+	userInput.ignoreFiles = nil
+
+	SetConsole(userInput.Quiet, !userInput.NoColor && !userInput.Porcelain)
+
+	userInput.Schedule = nil
+	if userInput.ScheduleWindow != "" {
+		if schedule, err := ParseSchedule(userInput.ScheduleWindow); err != nil {
+			report.add("--schedule", ScheduleError, err)
+		} else {
+			userInput.Schedule = schedule
+		}
+	}
+
+	// Subtitle language should be a two or three-letter code if present, converted to
+	// the three-letter code used by FFmpeg
+	if userInput.SubLang != "" {
+		if code, err := LanguageCode(userInput.SubLang); err != nil {
+			report.add("--language", LanguageError, err)
+		} else {
+			userInput.SubLang = code
+		}
+	}
+
+	// Messages are shown in English if the language has no catalog, but an unknown
+	// language picked explicitly is an error
+	if userInput.UILanguage != "" && !HasCatalog(userInput.UILanguage) {
+		report.add("--lang-ui", LanguageError, fmt.Errorf(
+			"no translations available for `%s`",
+			userInput.UILanguage,
+		))
+	} else if userInput.UILanguage != "" {
+		SetUILanguage(userInput.UILanguage)
 	} else {
-		// fail if regex pattern can't be compiled
-		return RegexError, err
+		SetUILanguage(DetectUILanguage())
+	}
+
+	// Paths to the executables should point to an executable, if present - the
+	// executables not supplied are looked up in the PATH only for a run
+	for _, exe := range []struct{ flag, path string }{
+		{"--ffmpeg", userInput.FFmpegPath},
+		{"--ffprobe", userInput.FFprobePath},
+		{"--mkvpropedit", userInput.MkvpropeditPath},
+		{"--classifier", userInput.Classifier},
+	} {
+		if exe.path == "" {
+			continue
+		}
+
+		if _, err := exec.LookPath(exe.path); err != nil {
+			report.add(exe.flag, ExecNotFound, fmt.Errorf(
+				"executable not found at \"%s\"",
+				exe.path,
+			))
+		}
 	}
 
-	if userInput.RegexExclude != "" {
-		userInput.RegexRule = regex
+	// Variables for the FFmpeg processes should be in the form `KEY=VALUE`, the
+	// locale is always forced to the C locale
+	for _, variable := range userInput.Env {
+		key := envKey(variable)
+		switch {
+		case key == variable || key == "" || strings.ContainsAny(key, " \t"):
+			report.add("--env", EnvError, fmt.Errorf(
+				"expected `KEY=VALUE`, found `%s`",
+				variable,
+			))
+
+		case key == "LC_ALL" || key == "LANGUAGE":
+			report.add("--env", EnvError, fmt.Errorf(
+				"`%s` can't be set, FFmpeg always runs with the C locale",
+				key,
+			))
+		}
+	}
+
+	SetToolEnv(userInput.Env)
+	SetTrash(userInput.UseTrash)
+
+	// Title map is read once, names in the map follow the same case as the rules
+	userInput.TitleMaps = nil
+	if userInput.TitleMap != "" {
+		titles, err := LoadTitleMap(userInput.TitleMap, userInput.Casing())
+		if err != nil {
+			report.add("--title-map", TitleMapError, fmt.Errorf(
+				"failed to read title map \"%s\": %v",
+				userInput.TitleMap,
+				err,
+			))
+		}
+
+		userInput.TitleMaps = titles
+	}
+
+	userInput.compileRules(report)
+
+	userInput.MetricsAddr = strings.TrimSpace(userInput.MetricsAddr)
+	if userInput.MetricsAddr != "" {
+		if _, port, err := net.SplitHostPort(userInput.MetricsAddr); err != nil ||
+			port == "" {
+			report.add("--metrics-addr", MetricsError, fmt.Errorf(
+				"expected an address of the form `host:port`, found `%s`",
+				userInput.MetricsAddr,
+			))
+		}
+	}
+
+	// Plans are either formed or applied by a run, never both
+	if userInput.PlanFile != "" {
+		userInput.Plan = true
+	}
+
+	if userInput.ApplyPlan != "" {
+		path, err := filepath.Abs(userInput.ApplyPlan)
+		if err == nil {
+			var item os.FileInfo
+			if item, err = os.Stat(path); err == nil && item.IsDir() {
+				err = errors.New("path points to a directory")
+			}
+		}
+
+		switch {
+		case userInput.Plan:
+			report.add("--apply", PlanError, errors.New(
+				"can't be combined with `--plan`, a saved plan is applied as is",
+			))
+
+		case err != nil:
+			report.add("--apply", PlanError, fmt.Errorf(
+				"unable to read plan \"%s\": %v",
+				userInput.ApplyPlan,
+				err,
+			))
+		}
+
+		userInput.ApplyPlan = path
+	}
+
+	// Chapters files with a placeholder can only be checked for each source directory
+	if userInput.ChaptersFile != "" {
+		path, err := filepath.Abs(userInput.ChaptersFile)
+		if err == nil && !strings.Contains(path, ChaptersDirPlaceholder) {
+			var item os.FileInfo
+			if item, err = os.Stat(path); err == nil && item.IsDir() {
+				err = errors.New("path points to a directory")
+			}
+		}
+
+		if err != nil {
+			report.add("--chapters-file", ChaptersError, fmt.Errorf(
+				"unable to read chapters file \"%s\": %v",
+				userInput.ChaptersFile,
+				err,
+			))
+		}
+
+		userInput.ChaptersFile = path
+	}
+
+	// Remote root directories are staged locally before the run, the path is not
+	// validated locally
+	userInput.Remote = nil
+	if IsRemotePath(userInput.RootPath) {
+		if remote, err := ParseRemote(userInput.RootPath); err != nil {
+			report.add("root", RootDirectoryIncorrect, err)
+		} else {
+			userInput.Remote = remote
+		}
 	} else {
-		userInput.RegexRule = nil
+		// Normalize paths supplied by the user - blank paths are left as is
+		userInput.RootPath = NormalizePath(userInput.RootPath)
 	}
 
+	userInput.OutputPath = NormalizePath(userInput.OutputPath)
+
 	// log user input
 	userInput.log()
 
 	switch item, err := os.Stat(userInput.RootPath); {
+	case IsRemotePath(userInput.RootPath):
+		// Validated while parsing the URL
+
 	case userInput.RootPath == "" && userInput.IsTest:
 		// Allow an empty root path only if the test flag is present. If path to root
 		// directory is preset, it will be validated (even if `test` flag is used)
-		return StatusOK, nil
 
 	case userInput.RootPath == "":
 		// Explicitly handling this case for more specific exit code
 		log.Debugf("(userInput/Initilaize) path to root directory is empty!")
-		return RootDirectoryIncorrect,
-			errors.New("path to root directory not specified")
+		report.add(
+			"root",
+			RootDirectoryIncorrect,
+			errors.New("path to root directory not specified"),
+		)
 
 	case err != nil:
 		// Fail if root path is invalid
@@ -117,7 +982,10 @@ func (userInput *UserInput) Initialize() (int, error) {
 			err,
 		)
 
-		return UnexpectedError, err
+		report.add("root", UnexpectedError, fmt.Errorf(
+			"path to root directory is incorrect: \"%s\"",
+			userInput.RootPath,
+		))
 
 	case !item.IsDir():
 		// Fail if path to root directory points to a file instead
@@ -126,12 +994,45 @@ func (userInput *UserInput) Initialize() (int, error) {
 			userInput.RootPath,
 		)
 
-		return RootDirectoryIncorrect, errors.New("path to root directory invalid")
+		report.add(
+			"root",
+			RootDirectoryIncorrect,
+			errors.New("path to root directory invalid"),
+		)
+	}
 
-	default:
-		// Pass if the root path is correct
-		return StatusOK, err
+	if err := report.Err(); err != nil {
+		log.Debugf("(userInput/Initialize) invalid user input \n%v", err)
 	}
+
+	return report.Code(), report.Err()
+}
+
+/*
+CompileRule compiles a regex pattern supplied through a flag as per the case of names,
+adding the error to the report if the pattern is malformed. Returns nil for blank (or
+malformed) patterns.
+*/
+func (userInput *UserInput) compileRule(
+	report *ValidationReport,
+	flag, pattern string,
+) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+
+	exp, err := userInput.Casing().Compile(pattern)
+	if err != nil {
+		report.add(flag, RegexError, fmt.Errorf(
+			"failed to compile the regex pattern `%s`: %v",
+			pattern,
+			err,
+		))
+
+		return nil
+	}
+
+	return exp
 }
 
 /*
@@ -139,38 +1040,200 @@ IgnoreFile acts as a wrapper method that internally decides if a file is suppose
 be ignored or not based on the name of the file.
 
 This function will internally use the value of `userInput.Exclusions` and
-`userInput.RegexRule` to match against the name of the file. A response of true
-indicates that the file is to be skipped
+`userInput.RegexRules` to match against the name of the file. If `userInput.MatchPaths`
+is set, the rules are matched against the path of the file relative to the root
+directory as well. Patterns from the ignore files present in the root and the source
+directory are applied on top of these rules. A response of true indicates that the
+file is to be skipped
 */
 func (userInput *UserInput) IgnoreFile(sourceDir, fileName *string) bool {
-	// Match file name against regex pattern
-	if userInput.RegexRule != nil && userInput.RegexRule.MatchString(*fileName) {
+	names := []string{*fileName}
+	if userInput.MatchPaths {
+		names = append(
+			names,
+			userInput.relativePath(filepath.Join(*sourceDir, *fileName)),
+		)
+	}
+
+	for _, name := range names {
+		// Match file name against each regex pattern
+		for _, rule := range userInput.RegexRules {
+			if rule.MatchString(name) {
+				log.Debugf(
+					"(userInput/IgnoreFile) skip file; match against regex exclusion!"+
+						"\nregex: `%v` \nsource dir: `%v` \nfile name: `%v`",
+					rule,
+					*sourceDir,
+					name,
+				)
+
+				return true
+			}
+		}
+
+		// Compare file name against all the list of file names to be excluded
+		for _, exclude := range userInput.Exclusions {
+			if userInput.Casing().Equal(name, filepath.ToSlash(exclude)) {
+				log.Debugf(
+					"(userInput/IgnoreFile) skip file; match with exclusion rule!"+
+						"\nexclusion rule: `%v` \nsource dir: `%v` \nfile name: `%v`",
+					exclude,
+					*sourceDir,
+					name,
+				)
+
+				return true
+			}
+		}
+	}
+
+	// Ignore files are never processed, patterns in them are applied regardless -
+	// markers for failed source directories are never processed either
+	if *fileName == IgnoreFileName || *fileName == FailedMarkerName {
+		return true
+	}
+
+	return userInput.ignoredByFile(*sourceDir, *fileName, false)
+}
+
+/*
+IgnoreDir decides if a directory is to be skipped while traversing the root directory.
+Patterns from the ignore file in the root directory are always applied to directories,
+exclusion rules are applied only if `userInput.MatchPaths` is set, in which case the
+rules are matched against both, the name of the directory and its path relative to the
+root directory.
+*/
+func (userInput *UserInput) IgnoreDir(dirPath string) bool {
+	parent, name := filepath.Split(filepath.Clean(dirPath))
+	if userInput.ignoredByFile(parent, name, true) {
+		return true
+	}
+
+	if !userInput.MatchPaths {
+		return false
+	}
+
+	return userInput.IgnoreFile(&parent, &name)
+}
+
+/*
+IgnoredByFile matches an item against the patterns from the ignore files - the one in
+the root directory (matched against the path relative to the root directory), and the
+one in the directory containing the item (if different). The ignore files are merged,
+an item ignored by either one of them is ignored.
+*/
+func (userInput *UserInput) ignoredByFile(parentDir, name string, isDir bool) bool {
+	itemPath := filepath.Join(parentDir, name)
+	parentDir = filepath.Clean(parentDir)
+
+	if userInput.RootPath != "" && userInput.ignoreMatcher(userInput.RootPath).Match(
+		userInput.relativePath(itemPath),
+		isDir,
+	) {
 		log.Debugf(
-			"(userInput/IgnoreFile) skip file; match against regex exclusion! "+
-				"\nsource dir: `%v` \nfile name: `%v`",
-			*sourceDir,
-			*fileName,
+			`(userInput/ignoredByFile) skip item; match in root ignore file: "%s"`,
+			itemPath,
 		)
 
 		return true
 	}
 
-	// Compare file name against all the list of file names to be excluded
-	for _, exclude := range userInput.Exclusions {
-		if strings.EqualFold(*fileName, exclude) {
-			log.Debugf(
-				"(userInput/IgnoreFile) skip file; match with exclusion rule!"+
-					"\nexclusion rule: `%v` \nsource dir: `%v` \nfile name: `%v`",
-				exclude,
-				*sourceDir,
-				*fileName,
-			)
+	if parentDir != filepath.Clean(userInput.RootPath) &&
+		userInput.ignoreMatcher(parentDir).Match(name, isDir) {
+		log.Debugf(
+			`(userInput/ignoredByFile) skip item; match in ignore file: "%s"`,
+			itemPath,
+		)
+
+		return true
+	}
+
+	return false
+}
+
+/*
+IgnoreMatcher returns the matcher for the ignore file present in a directory, ignore
+files are read once and cached for the rest of the run. Ignore files that can't be read
+are skipped with a warning.
+*/
+func (userInput *UserInput) ignoreMatcher(dir string) *Matcher {
+	dir = filepath.Clean(dir)
+	if matcher, ok := userInput.ignoreFiles[dir]; ok {
+		return matcher
+	}
+
+	matcher, err := LoadMatcher(dir, userInput.Casing())
+	if err != nil {
+		log.Warnf(
+			`(userInput/ignoreMatcher) failed to read ignore file in: "%s" 
+error: %v`,
+			dir,
+			err,
+		)
+	}
+
+	if userInput.ignoreFiles == nil {
+		userInput.ignoreFiles = make(map[string]*Matcher)
+	}
 
+	userInput.ignoreFiles[dir] = matcher
+	return matcher
+}
+
+/*
+Casing returns the way names are compared by the rules matching files and directories,
+irrespective of case unless the user asks otherwise.
+*/
+func (userInput *UserInput) Casing() NameCase {
+	return NameCase{Sensitive: userInput.CaseSensitive}
+}
+
+/*
+RelativePath returns the path of an item relative to the root directory, using forward
+slashes as the separator irrespective of the platform - ensures the same rules work
+everywhere. Falls back to the name of the item if the path is outside the root.
+*/
+func (userInput *UserInput) relativePath(path string) string {
+	// Names starting with two periods (i.e. `..hidden`) are still inside the root
+	rel, err := filepath.Rel(userInput.RootPath, path)
+	if err != nil || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+/*
+IncludeItem decides if an item (source directory or file) is to be processed based on
+its name, using the values of `userInput.Inclusions` and `userInput.IncludeRule`.
+
+A response of true indicates that the item is to be processed - this will always be
+the case if no inclusion rules are present. Names are compared the same way as
+exclusions.
+*/
+func (userInput *UserInput) IncludeItem(name string) bool {
+	if len(userInput.Inclusions) == 0 && userInput.IncludeRule == nil {
+		return true
+	}
+
+	if userInput.IncludeRule != nil && userInput.IncludeRule.MatchString(name) {
+		return true
+	}
+
+	for _, include := range userInput.Inclusions {
+		// Patterns have been validated already
+		if userInput.Casing().Glob(include, name) {
 			return true
 		}
 	}
 
-	// No match occurred.
+	log.Debugf(
+		"(userInput/IncludeItem) skip item; no match with inclusion rules"+
+			"\nname: `%s`",
+		name,
+	)
+
 	return false
 }
 
@@ -183,18 +1246,166 @@ func (userInput *UserInput) log() {
 	log.Debugf(
 		"Logging user input: \n"+
 			`Root path: "%s"`+"\n"+
+			`Output path: "%s"`+"\n"+
 			`FFmpeg Executable: "%s"`+"\n"+
 			`FFprobe Executable: "%s"`+"\n"+
 			"Logging Enabled: %v\n"+
+			"Log File: `%s`\n"+
 			"Test Mode: %v\n"+
+			"All Media: %v\n"+
 			`Exclusions: ["%v"]`+"\n"+
-			"Regex Exclusions: `%v`",
+			"Regex Exclusions: `%v`\n"+
+			"Match Paths: %v\n"+
+			`Inclusions: ["%v"]`+"\n"+
+			"Regex Inclusions: `%v`\n"+
+			"Sample Duration: %ds\n"+
+			"Sync Threshold: %ds\n"+
+			"Strict Sync: %v\n"+
+			"Stall Timeout: %ds\n"+
+			"Kill Stalled: %v\n"+
+			"Stall Retries: %d\n"+
+			"Threads: %d\n"+
+			"Max Muxing Queue Size: %d\n"+
+			"Assume Yes: %v\n"+
+			"Assume No: %v\n"+
+			"Schedule: `%s`\n"+
+			"Donor Regex: `%v`\n"+
+			"Raw Regex: `%v`\n"+
+			`Subtitle Order: ["%v"]`+"\n"+
+			"Skip Existing: %v\n"+
+			"Diagnostics: %v\n"+
+			"Safe: %v\n"+
+			"Keep All Subs: %v\n"+
+			`Copy Extras: ["%v"]`+"\n"+
+			"On Collision: %s\n"+
+			"Attachment Descriptions: %v\n"+
+			"Porcelain: %v\n"+
+			"Probe Cache: `%s`\n"+
+			"No Probe Cache: %v\n"+
+			"Subtitle Encoding: `%s`\n"+
+			`Media Extensions: ["%v"]`+"\n"+
+			"Profile: `%s`\n"+
+			"Clean Stale: %v\n"+
+			"Subtitle Position: %s\n"+
+			"Max Subtitles: %d\n"+
+			"Max Attachments: %d\n"+
+			"Touch Failed: %v\n"+
+			"Retry Failed: %v\n"+
+			"Split By Language: %v\n"+
+			"Quiet: %v\n"+
+			"No Color: %v\n"+
+			`Environment: ["%v"]`+"\n"+
+			"Keep Temp: %v\n"+
+			"No Sample Filter: %v\n"+
+			"Case Sensitive: %v\n"+
+			"Stats Tags: %v\n"+
+			"Mkvpropedit Path: `%s`\n"+
+			"Title Map: `%s`\n"+
+			"Log to Stderr: %v\n"+
+			"Use Trash: %v\n"+
+			"Extract Archives: %v\n"+
+			"Max Load: %v\n"+
+			"Subtitle Codec: `%s`\n"+
+			"Export Metadata: %v\n"+
+			"Chapters File: `%s`\n"+
+			"Classifier: `%s`\n"+
+			"Order: %s\n"+
+			"SDH Regex: `%s`\n"+
+			"FFmpeg Log Level: %s\n"+
+			"Metadata Rules: %d\n"+
+			"Metrics Address: `%s`\n"+
+			"Plan: %v\n"+
+			"Plan File: `%s`\n"+
+			"Apply Plan: `%s`\n"+
+			"Collision Suffix: %s\n"+
+			"Timeout Factor: %v\n"+
+			"Timeout Base: %d\n"+
+			"Output Layout: %s\n"+
+			"Verbose: %v\n"+
+			"No Fast Path: %v\n"+
+			"IO Limit: %s\n"+
+			"Stats Interval: %vs\n"+
+			"Also MP4: %v",
 		userInput.RootPath,
+		userInput.OutputPath,
 		userInput.FFmpegPath,
 		userInput.FFprobePath,
 		userInput.Logging,
+		userInput.LogFile,
 		userInput.IsTest,
+		userInput.AllMedia,
 		strings.Join(userInput.Exclusions, `", "`),
-		userInput.RegexExclude,
+		strings.Join(userInput.RegexExclusions, "`, `"),
+		userInput.MatchPaths,
+		strings.Join(userInput.Inclusions, `", "`),
+		userInput.RegexInclude,
+		userInput.Sample,
+		userInput.SyncThreshold,
+		userInput.StrictSync,
+		userInput.StallTimeout,
+		userInput.KillStalled,
+		userInput.StallRetries,
+		userInput.Threads,
+		userInput.MuxingQueueSize,
+		userInput.AssumeYes,
+		userInput.AssumeNo,
+		userInput.ScheduleWindow,
+		userInput.DonorRegex,
+		userInput.RawRegex,
+		strings.Join(userInput.SubOrder, `", "`),
+		userInput.SkipExisting,
+		userInput.Diagnostics,
+		userInput.Safe,
+		userInput.KeepAllSubs,
+		strings.Join(userInput.CopyExtras, `", "`),
+		userInput.OnCollision,
+		userInput.AttachmentDescs,
+		userInput.Porcelain,
+		userInput.ProbeCache,
+		userInput.NoProbeCache,
+		userInput.SubEncoding,
+		strings.Join(userInput.MediaExt, `", "`),
+		userInput.Profile,
+		userInput.CleanStale,
+		userInput.SubPosition,
+		userInput.MaxSubs,
+		userInput.MaxAttachments,
+		userInput.TouchFailed,
+		userInput.RetryFailed,
+		userInput.SplitByLang,
+		userInput.Quiet,
+		userInput.NoColor,
+		strings.Join(userInput.Env, `", "`),
+		userInput.KeepTemp,
+		userInput.NoSampleFilter,
+		userInput.CaseSensitive,
+		userInput.StatsTags,
+		userInput.MkvpropeditPath,
+		userInput.TitleMap,
+		userInput.LogStderr,
+		userInput.UseTrash,
+		userInput.ExtractArchives,
+		userInput.MaxLoad,
+		userInput.SubtitleCodec,
+		userInput.ExportMetadata,
+		userInput.ChaptersFile,
+		userInput.Classifier,
+		userInput.Order,
+		userInput.SDHRegex,
+		userInput.FFmpegLogLevel,
+		len(userInput.Rules),
+		userInput.MetricsAddr,
+		userInput.Plan,
+		userInput.PlanFile,
+		userInput.ApplyPlan,
+		userInput.CollisionSuffix,
+		userInput.TimeoutFactor,
+		userInput.TimeoutBase,
+		userInput.OutputLayout,
+		userInput.Verbose,
+		userInput.NoFastPath,
+		userInput.IOLimit,
+		userInput.StatsInterval,
+		userInput.AlsoMP4,
 	)
 }
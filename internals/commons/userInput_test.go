@@ -31,7 +31,7 @@ func TestInitialize(t *testing.T) {
 	}
 
 	for in, result := range inputRegex {
-		userInput := UserInput{RegexExclude: in, IsTest: true}
+		userInput := UserInput{RegexExclusions: []string{in}, IsTest: true}
 		errCode, err := userInput.Initialize()
 
 		// fail test if return code is unexpected, or if `err` or `resultErr` do not
@@ -156,7 +156,7 @@ func TestIgnoreFile(t *testing.T) {
 		},
 
 		// Regex pattern to ignore files based on their extensions
-		RegexExclude: `(.*\.txt)|(.*\.mkv)|(.*\.jpg)`,
+		RegexExclusions: []string{`(.*\.txt)|(.*\.mkv)`, `.*\.jpg`},
 
 		IsTest: true, // Ensures root path isn't required
 	}
@@ -177,8 +177,10 @@ func TestIgnoreFile(t *testing.T) {
 
 		flag := false
 
-		if input.RegexRule != nil && input.RegexRule.MatchString(file) {
-			flag = true
+		for _, rule := range input.RegexRules {
+			if rule.MatchString(file) {
+				flag = true
+			}
 		}
 
 		for _, fileName := range input.Exclusions {
@@ -195,9 +197,229 @@ func TestIgnoreFile(t *testing.T) {
 				result,
 				flag,
 				file,
-				input.RegexExclude,
+				input.RegexExclusions,
 				input.Exclusions,
 			)
 		}
 	}
 }
+
+func TestIncludeItem(t *testing.T) {
+	for _, in := range []struct {
+		globs  []string
+		regex  string
+		name   string
+		result bool
+	}{
+		{nil, "", "anything", true}, // no rules, everything is included
+		{[]string{"*S02E*"}, "", "Show S02E01", true},
+		{[]string{"*s02e*"}, "", "Show S02E01", true},
+		{[]string{"*S02E*"}, "", "Show S01E01", false},
+		{[]string{"*S01*", "*S02*"}, "", "Show S01E01", true},
+		{nil, `E0[1-3]$`, "Show S01E02", true},
+		{nil, `E0[1-3]$`, "Show S01E04", false},
+		{[]string{"*.mkv"}, `^Extras`, "Extras", true},
+	} {
+		input := UserInput{Inclusions: in.globs, RegexInclude: in.regex, IsTest: true}
+		if code, err := input.Initialize(); code != StatusOK || err != nil {
+			t.Errorf(
+				"(userInput/Initialize) failed to initialize inclusion rules"+
+					"\nglobs: %v \nregex: `%s` \nerror: %v",
+				in.globs,
+				in.regex,
+				err,
+			)
+		}
+
+		if res := input.IncludeItem(in.name); res != in.result {
+			t.Errorf(
+				"(userInput/IncludeItem) unexpected result \nglobs: %v \nregex: `%s`"+
+					"\nname: `%s` \nexpected: %v \nresult: %v",
+				in.globs,
+				in.regex,
+				in.name,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Malformed patterns should be reported with the correct exit codes
+	for code, input := range map[int]UserInput{
+		GlobError:  {Inclusions: []string{"[a-"}, IsTest: true},
+		RegexError: {RegexInclude: "(]", IsTest: true},
+	} {
+		input := input // pin
+		if res, err := input.Initialize(); res != code || err == nil {
+			t.Errorf(
+				"(userInput/Initialize) malformed inclusion rule not reported"+
+					"\nexpected code: %d \nresult: %d \nerror: %v",
+				code,
+				res,
+				err,
+			)
+		}
+	}
+}
+
+func TestIgnoreDir(t *testing.T) {
+	// Root path is normalized into an absolute path while initializing
+	root := filepath.Join(string(filepath.Separator), "path", "to", "root")
+
+	for _, in := range []struct {
+		matchPaths bool
+		exclusions []string
+		regex      string
+		path       string
+		result     bool
+	}{
+		// Without an ignore file, directories are ignored only if paths are matched
+		{false, []string{"Extras"}, "", filepath.Join(root, "Extras"), false},
+		{true, []string{"extras"}, "", filepath.Join(root, "Extras"), true},
+		{true, nil, `^Extras/.*`, filepath.Join(root, "Extras"), false},
+		{true, nil, `^Extras(/.*)?$`, filepath.Join(root, "Extras"), true},
+		{true, nil, `^Extras`, filepath.Join(root, "Show S01E01"), false},
+	} {
+		input := UserInput{
+			RootPath:        root,
+			MatchPaths:      in.matchPaths,
+			Exclusions:      in.exclusions,
+			RegexExclusions: []string{in.regex},
+		}
+
+		// Root path does not exist, rules will be compiled regardless
+		_, _ = input.Initialize()
+
+		if res := input.IgnoreDir(in.path); res != in.result {
+			t.Errorf(
+				"(userInput/IgnoreDir) unexpected result \nmatch paths: %v "+
+					"\nexclusions: %v \nregex: `%s` \npath: `%s` \nexpected: %v"+
+					"\nresult: %v",
+				in.matchPaths,
+				in.exclusions,
+				in.regex,
+				in.path,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Files should be matched against their relative paths as well
+	input := UserInput{
+		RootPath:        root,
+		MatchPaths:      true,
+		RegexExclusions: []string{`^Extras/.*`},
+	}
+
+	_, _ = input.Initialize()
+
+	source, name := filepath.Join(root, "Extras"), "trailer.mkv"
+	if !input.IgnoreFile(&source, &name) {
+		t.Errorf("(userInput/IgnoreFile) failed to match relative path of the file")
+	}
+
+	input.MatchPaths = false
+	if input.IgnoreFile(&source, &name) {
+		t.Errorf("(userInput/IgnoreFile) relative path matched without `MatchPaths`")
+	}
+}
+
+func TestRegexExclusions(t *testing.T) {
+	// Each malformed pattern should be reported independently
+	input := UserInput{RegexExclusions: []string{"[e", `\.nfo$`, "(]"}, IsTest: true}
+
+	code, err := input.Initialize()
+	if code != RegexError || err == nil || !strings.Contains(err.Error(), "`[e`") ||
+		!strings.Contains(err.Error(), "`(]`") {
+		t.Errorf(
+			"(userInput/Initialize) malformed patterns not reported \ncode: %d"+
+				"\nerror: %v",
+			code,
+			err,
+		)
+	}
+
+	// Files matching any one of the patterns should be ignored
+	input = UserInput{RegexExclusions: []string{`\.nfo$`, `^sample`}, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK {
+		t.Fatalf("(userInput/Initialize) failed to initialize \nerror: %v", err)
+	}
+
+	source := "source-directory"
+	for name, result := range map[string]bool{
+		"movie.nfo":   true,
+		"sample.mkv":  true,
+		"movie.mkv":   false,
+		"nfo.example": false,
+	} {
+		name := name // pin
+		if res := input.IgnoreFile(&source, &name); res != result {
+			t.Errorf(
+				"(userInput/IgnoreFile) unexpected result \nfile: `%s`"+
+					"\nexpected: %v \nresult: %v",
+				name,
+				result,
+				res,
+			)
+		}
+	}
+}
+
+func TestCaseSensitive(t *testing.T) {
+	for _, sensitive := range []bool{false, true} {
+		input := UserInput{
+			Exclusions:      []string{"Movie.NFO"},
+			RegexExclusions: []string{`^sample`},
+			Inclusions:      []string{"*S02E*"},
+			CaseSensitive:   sensitive,
+			IsTest:          true,
+		}
+
+		if code, err := input.Initialize(); code != StatusOK {
+			t.Fatalf("(userInput/Initialize) failed to initialize \nerror: %v", err)
+		}
+
+		// Names differing only in case match unless names are case sensitive
+		source := "source-directory"
+		for _, name := range []string{"movie.nfo", "Sample.mkv"} {
+			name := name // pin
+			if res := input.IgnoreFile(&source, &name); res == sensitive {
+				t.Errorf(
+					"(userInput/IgnoreFile) unexpected result \nfile: `%s`"+
+						"\ncase sensitive: %v",
+					name,
+					sensitive,
+				)
+			}
+		}
+
+		if res := input.IncludeItem("Show s02e01"); res == sensitive {
+			t.Errorf(
+				"(userInput/IncludeItem) unexpected result \ncase sensitive: %v",
+				sensitive,
+			)
+		}
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "root")
+	input := UserInput{RootPath: root}
+
+	for path, expected := range map[string]string{
+		filepath.Join(root, "Show", "01.mkv"):       "Show/01.mkv",
+		filepath.Join(root, "..hidden", "01.mkv"):   "..hidden/01.mkv",
+		filepath.Join(os.TempDir(), "..", "01.mkv"): "01.mkv",
+		filepath.Join(os.TempDir(), "other.mkv"):    "other.mkv",
+	} {
+		if res := input.relativePath(path); res != expected {
+			t.Errorf(
+				"(userInput/relativePath) unexpected path \nexpected: `%s` "+
+					"\nfound: `%s`",
+				expected,
+				res,
+			)
+		}
+	}
+}
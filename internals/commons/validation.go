@@ -0,0 +1,83 @@
+package commons
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+FieldError is a simple structure describing a single invalid field in the user input,
+along with the exit code used for the error.
+*/
+type FieldError struct {
+	// Name of the field, i.e. the flag through which the value is supplied
+	Field string
+
+	// Exit code for the error
+	Code int
+
+	// Underlying error
+	Err error
+}
+
+func (fieldErr *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", fieldErr.Field, fieldErr.Err)
+}
+
+func (fieldErr *FieldError) Unwrap() error {
+	return fieldErr.Err
+}
+
+/*
+ValidationReport lists every invalid field found while validating the user input -
+allows all problems to be reported at once, instead of one per run.
+*/
+type ValidationReport struct {
+	Errors []*FieldError
+}
+
+/*
+Add adds an invalid field to the report
+*/
+func (report *ValidationReport) add(field string, code int, err error) {
+	report.Errors = append(
+		report.Errors,
+		&FieldError{Field: field, Code: code, Err: err},
+	)
+}
+
+/*
+Code returns the exit code for the report - the exit code of the first invalid field,
+`StatusOK` if the input is valid.
+*/
+func (report *ValidationReport) Code() int {
+	if report == nil || len(report.Errors) == 0 {
+		return StatusOK
+	}
+
+	return report.Errors[0].Code
+}
+
+/*
+Error lists the invalid fields, one field per line
+*/
+func (report *ValidationReport) Error() string {
+	lines := make([]string, 0, len(report.Errors))
+	for _, fieldErr := range report.Errors {
+		lines = append(lines, fieldErr.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+Err returns the report as an error, nil if the input is valid - avoids returning a nil
+pointer wrapped in a non-nil error interface.
+*/
+func (report *ValidationReport) Err() error {
+	if report == nil || len(report.Errors) == 0 {
+		return nil
+	}
+
+	return report
+}
@@ -0,0 +1,313 @@
+package commons
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidationReport(t *testing.T) {
+	report := &ValidationReport{}
+	if report.Code() != StatusOK || report.Err() != nil {
+		t.Errorf("(validation/ValidationReport) empty report treated as invalid")
+	}
+
+	report.add("--rexclude", RegexError, errors.New("bad pattern"))
+	report.add("root", RootDirectoryIncorrect, errors.New("bad root"))
+
+	if report.Code() != RegexError {
+		t.Errorf(
+			"(validation/Code) expected code of the first field \nresult: %d",
+			report.Code(),
+		)
+	}
+
+	if msg := report.Err().Error(); msg != "--rexclude: bad pattern\nroot: bad root" {
+		t.Errorf("(validation/Error) unexpected message \n%s", msg)
+	}
+}
+
+func TestInitializeReport(t *testing.T) {
+	// Every invalid field should be reported at once, in order
+	input := UserInput{
+		RegexExclusions: []string{"[e"},
+		Inclusions:      []string{"[a-"},
+		ScheduleWindow:  "25:00-26:00",
+		SubLang:         "english",
+		FFmpegPath:      "/path/to/missing/ffmpeg",
+		AssumeYes:       true,
+		AssumeNo:        true,
+	}
+
+	code, err := input.Initialize()
+
+	var report *ValidationReport
+	if !errors.As(err, &report) {
+		t.Fatalf("(userInput/Initialize) validation report not returned \n%v", err)
+	}
+
+	var fields []string
+	for _, fieldErr := range report.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+
+	expected := "--rexclude, --only, --yes, --schedule, --language, --ffmpeg, root"
+	if res := strings.Join(fields, ", "); res != expected || code != RegexError {
+		t.Errorf(
+			"(userInput/Initialize) unexpected report \nexpected: %s \nresult: %s"+
+				"\ncode: %d",
+			expected,
+			res,
+			code,
+		)
+	}
+
+	// Valid three-letter language codes are accepted, two-letter codes are converted
+	for in, expected := range map[string]string{"jpn": "jpn", "JA": "jpn"} {
+		input = UserInput{SubLang: in, IsTest: true}
+		if code, err := input.Initialize(); code != StatusOK || err != nil ||
+			input.SubLang != expected {
+			t.Errorf(
+				"(userInput/Initialize) valid language rejected: %s \nerror: %v",
+				in,
+				err,
+			)
+		}
+	}
+
+	// Descriptions are parsed by the type of attachment
+	input = UserInput{
+		AttachmentDesc: []string{"Font = Subtitle font", "chapter=Chapters"},
+		IsTest:         true,
+	}
+
+	if code, err := input.Initialize(); code != StatusOK || err != nil ||
+		input.AttachmentDescs[AttachmentFont] != "Subtitle font" ||
+		input.AttachmentDescs[AttachmentChapter] != "Chapters" {
+		t.Errorf(
+			"(userInput/Initialize) unexpected descriptions: %v \nerror: %v",
+			input.AttachmentDescs,
+			err,
+		)
+	}
+
+	input = UserInput{AttachmentDesc: []string{"video=Main"}, IsTest: true}
+	if code, _ := input.Initialize(); code != AttachmentError {
+		t.Errorf("(userInput/Initialize) unknown attachment type accepted")
+	}
+
+	// Subtitle encodings are detected by default, names of encodings are kept as is
+	for encoding, expected := range map[string]string{
+		"":          EncodingAuto,
+		" KEEP ":    EncodingKeep,
+		"CP1251":    "CP1251",
+		"Shift_JIS": "Shift_JIS",
+	} {
+		input = UserInput{SubEncoding: encoding, IsTest: true}
+		if code, err := input.Initialize(); code != StatusOK || err != nil ||
+			input.SubEncoding != expected {
+			t.Errorf(
+				"(userInput/Initialize) unexpected encoding for %q \nexpected: %q "+
+					"\nfound: %q \nerror: %v",
+				encoding,
+				expected,
+				input.SubEncoding,
+				err,
+			)
+		}
+	}
+
+	input = UserInput{SubEncoding: "utf 8; rm", IsTest: true}
+	if code, _ := input.Initialize(); code != EncodingError {
+		t.Errorf("(userInput/Initialize) invalid encoding accepted")
+	}
+
+	// Attached subtitles are placed after the existing ones by default
+	input = UserInput{IsTest: true}
+	if _, _ = input.Initialize(); input.SubPosition != SubPositionBack {
+		t.Errorf("(userInput/Initialize) unexpected position: %q", input.SubPosition)
+	}
+
+	input = UserInput{SubPosition: " Front ", IsTest: true}
+	if code, _ := input.Initialize(); code != StatusOK ||
+		input.SubPosition != SubPositionFront {
+		t.Errorf("(userInput/Initialize) unexpected position: %q", input.SubPosition)
+	}
+
+	input = UserInput{SubPosition: "middle", IsTest: true}
+	if code, _ := input.Initialize(); code != SubPositionError {
+		t.Errorf("(userInput/Initialize) invalid position accepted")
+	}
+
+	// Source directories are processed by name by default
+	input = UserInput{IsTest: true}
+	if _, _ = input.Initialize(); input.Order != OrderName {
+		t.Errorf("(userInput/Initialize) unexpected order: %q", input.Order)
+	}
+
+	input = UserInput{Order: " Size-Desc ", IsTest: true}
+	if code, _ := input.Initialize(); code != StatusOK || input.Order != OrderSizeDesc {
+		t.Errorf("(userInput/Initialize) unexpected order: %q", input.Order)
+	}
+
+	input = UserInput{Order: "random", IsTest: true}
+	if code, _ := input.Initialize(); code != OrderError {
+		t.Errorf("(userInput/Initialize) invalid order accepted")
+	}
+
+	// Subtitle codecs are picked automatically unless forced
+	input = UserInput{IsTest: true}
+	if _, _ = input.Initialize(); input.SubtitleCodec != SubCodecAuto {
+		t.Errorf("(userInput/Initialize) unexpected codec: %q", input.SubtitleCodec)
+	}
+
+	input = UserInput{SubtitleCodec: " ASS ", IsTest: true}
+	if code, _ := input.Initialize(); code != StatusOK ||
+		input.SubtitleCodec != SubCodecAss {
+		t.Errorf("(userInput/Initialize) unexpected codec: %q", input.SubtitleCodec)
+	}
+
+	for _, codec := range []string{SubCodecMovText, "webvtt"} {
+		input = UserInput{SubtitleCodec: codec, IsTest: true}
+		if code, _ := input.Initialize(); code != SubCodecError {
+			t.Errorf("(userInput/Initialize) invalid codec accepted: `%s`", codec)
+		}
+	}
+
+	// Log level for FFmpeg defaults to warnings, and should be known to FFmpeg
+	input = UserInput{IsTest: true}
+	if _, _ = input.Initialize(); input.FFmpegLogLevel != DefaultLogLevel {
+		t.Errorf("(userInput/Initialize) unexpected level: %q", input.FFmpegLogLevel)
+	}
+
+	input = UserInput{FFmpegLogLevel: " Error ", IsTest: true}
+	if code, _ := input.Initialize(); code != StatusOK ||
+		input.FFmpegLogLevel != "error" {
+		t.Errorf("(userInput/Initialize) unexpected level: %q", input.FFmpegLogLevel)
+	}
+
+	input = UserInput{FFmpegLogLevel: "loud", IsTest: true}
+	if code, _ := input.Initialize(); code != LogLevelError {
+		t.Errorf("(userInput/Initialize) invalid log level accepted")
+	}
+
+	// Metrics are served on an address of the form `host:port`
+	for addr, code := range map[string]int{
+		"127.0.0.1:9090": StatusOK,
+		":9090":          StatusOK,
+		"localhost":      MetricsError,
+		"localhost:":     MetricsError,
+	} {
+		input = UserInput{MetricsAddr: addr, IsTest: true}
+		if res, _ := input.Initialize(); res != code {
+			t.Errorf("(userInput/Initialize) unexpected code %d for `%s`", res, addr)
+		}
+	}
+
+	input = UserInput{CollisionSuffix: "random", IsTest: true}
+	if code, _ := input.Initialize(); code != OutputCollision {
+		t.Errorf("(userInput/Initialize) unknown collision suffix accepted")
+	}
+
+	// Outputs written to the source directories conflict with the result directory,
+	// safe mode, and (next to the media files) the extra files copied
+	for _, input = range []UserInput{
+		{OutputLayout: LayoutSibling, Safe: true, IsTest: true},
+		{OutputLayout: LayoutSubfolder, OutputPath: "/tmp", IsTest: true},
+		{OutputLayout: LayoutSibling, CopyExtras: []string{"*.nfo"}, IsTest: true},
+	} {
+		if code, _ := input.Initialize(); code != FlagConflict {
+			t.Errorf("(userInput/Initialize) conflict accepted: %s", input.OutputLayout)
+		}
+	}
+
+	input = UserInput{OutputLayout: "nearby", IsTest: true}
+	if code, _ := input.Initialize(); code != LayoutError {
+		t.Errorf("(userInput/Initialize) unknown output layout accepted")
+	}
+
+	input = UserInput{Sample: -30, IsTest: true}
+	if code, _ := input.Initialize(); code != SampleError {
+		t.Errorf("(userInput/Initialize) negative sample duration accepted")
+	}
+
+	input = UserInput{IOLimit: "fast", IsTest: true}
+	if code, _ := input.Initialize(); code != IOLimitError {
+		t.Errorf("(userInput/Initialize) malformed I/O limit accepted")
+	}
+
+	input = UserInput{IOLimit: "50M", IsTest: true}
+	if code, _ := input.Initialize(); code == IOLimitError ||
+		input.IOLimitRate != 50<<20 {
+		t.Errorf("(userInput/Initialize) I/O limit not parsed: %d", input.IOLimitRate)
+	}
+
+	// Saved plan should be readable, and can't be combined with a new plan
+	for _, input = range []UserInput{
+		{ApplyPlan: "/path/to/missing/plan.json", IsTest: true},
+		{ApplyPlan: os.TempDir(), IsTest: true},
+		{ApplyPlan: os.Args[0], PlanFile: "plan.json", IsTest: true},
+	} {
+		if code, _ := input.Initialize(); code != PlanError {
+			t.Errorf("(userInput/Initialize) plan accepted: `%s`", input.ApplyPlan)
+		}
+	}
+
+	// Pattern marking subtitles for the hearing impaired should be valid
+	input = UserInput{SDHRegex: "(]", IsTest: true}
+	if code, _ := input.Initialize(); code != RegexError || input.SDHRule != nil {
+		t.Errorf("(userInput/Initialize) malformed SDH pattern accepted")
+	}
+
+	// Languages of the subtitles in a donor are unknown, outputs can't be split
+	input = UserInput{
+		DonorRegex:  "WEB",
+		RawRegex:    "BD",
+		SplitByLang: true,
+		IsTest:      true,
+	}
+
+	if code, _ := input.Initialize(); code != FlagConflict {
+		t.Errorf("(userInput/Initialize) donor accepted with `--split-by-lang`")
+	}
+
+	// Markers can't be written to the source directories in safe mode
+	input = UserInput{TouchFailed: true, Safe: true, IsTest: true}
+	if code, _ := input.Initialize(); code != FlagConflict {
+		t.Errorf("(userInput/Initialize) `--touch-failed` accepted in safe mode")
+	}
+
+	// Variables for FFmpeg should be `KEY=VALUE`, and can't override the locale
+	defer SetToolEnv(nil)
+	for _, variable := range []string{"TMPDIR", "=/tmp", "LC_ALL=de_DE.UTF-8"} {
+		input = UserInput{Env: []string{variable}, IsTest: true}
+		if code, _ := input.Initialize(); code != EnvError {
+			t.Errorf("(userInput/Initialize) invalid variable accepted: `%s`", variable)
+		}
+	}
+
+	input = UserInput{Env: []string{"TMPDIR=/tmp", "EMPTY="}, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK {
+		t.Errorf("(userInput/Initialize) valid variables rejected \nerror: %v", err)
+	}
+}
+
+func TestStatsTagsValidation(t *testing.T) {
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+
+	// Executables are never looked up in the PATH while validating
+	if err := os.Setenv("PATH", ""); err != nil {
+		t.Fatalf("(userInput/Initialize) failed to modify PATH: %v", err)
+	}
+
+	input := UserInput{StatsTags: true, IsTest: true}
+	if code, err := input.Initialize(); code != StatusOK || input.MkvpropeditPath != "" {
+		t.Errorf("(userInput/Initialize) unexpected result, code: %d \nerror: %v", code, err)
+	}
+
+	input = UserInput{MkvpropeditPath: "/path/to/missing/mkvpropedit", IsTest: true}
+	if code, _ := input.Initialize(); code != ExecNotFound {
+		t.Errorf("(userInput/Initialize) invalid mkvpropedit path accepted")
+	}
+}
@@ -0,0 +1,38 @@
+package commons
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+/*
+StringWidth returns the number of columns taken up by a string when displayed on a
+terminal, unlike `len()` this isn't affected by the number of bytes per character.
+Characters combined with the ones before them (i.e. a variation selector) add nothing.
+*/
+func StringWidth(in string) int {
+	return runewidth.StringWidth(in)
+}
+
+/*
+Truncate trims characters from the end of a string until it fits in `width` columns.
+Characters combined with each other (i.e. emoji sequences) are never split apart.
+*/
+func Truncate(in string, width int) string {
+	return runewidth.Truncate(in, width, "")
+}
+
+/*
+TruncateLeft is the counterpart of `Truncate`, trimming characters from the start of
+the string instead.
+*/
+func TruncateLeft(in string, width int) string {
+	graphemes := uniseg.NewGraphemes(in)
+	for graphemes.Next() {
+		if start, _ := graphemes.Positions(); StringWidth(in[start:]) <= width {
+			return in[start:]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,67 @@
+package commons
+
+import "testing"
+
+func TestStringWidth(t *testing.T) {
+	for in, width := range map[string]int{
+		"":                   0,
+		"sample_640x360":     14,
+		"進撃の巨人":              10,
+		"신과함께":               8,
+		"ﾃｽﾄ":                3, // half-width katakana
+		"Ｆｕｌｌ":               8,
+		"cafe\u0301":         4, // combining accent
+		"tab\tnewline\n":     10,
+		"🐺 wolf":             7,
+		"mixed 日本語 text.mkv": 21,
+		"🚀 launch":           9,
+		"⚡ ☕ ✅":              8,
+		"☺ ✓ ★":              5, // symbols shown as text
+		"🪐 🥽":                5,
+		"❤\ufe0f":            1, // variation selector
+	} {
+		if res := StringWidth(in); res != width {
+			t.Errorf(
+				"(width/StringWidth) unexpected width \ninput: %q \nexpected: %d "+
+					"\nresult: %d",
+				in,
+				width,
+				res,
+			)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, in := range []struct {
+		input, left, right string
+		width              int
+	}{
+		{"fits", "fits", "fits", 4},
+		{"sample_640x360", "sample_", "640x360", 7},
+		{"進撃の巨人", "進撃", "巨人", 5},
+		{"café café", "café", "café", 4},
+		{"👨‍👩‍👧 family 👨‍👩‍👧", "👨‍👩‍👧 ", " 👨‍👩‍👧", 3},
+		{"anything", "", "", 0},
+	} {
+		if res := Truncate(in.input, in.width); res != in.left {
+			t.Errorf(
+				"(width/Truncate) unexpected result \ninput: %q \nexpected: %q "+
+					"\nresult: %q",
+				in.input,
+				in.left,
+				res,
+			)
+		}
+
+		if res := TruncateLeft(in.input, in.width); res != in.right {
+			t.Errorf(
+				"(width/TruncateLeft) unexpected result \ninput: %q \nexpected: %q "+
+					"\nresult: %q",
+				in.input,
+				in.right,
+				res,
+			)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Package config resolves the default values used by the flags, combining multiple
+sources - environment variables, the configuration file and the OS locale - in a fixed
+order of precedence;
+
+	flags > environment variables > configuration file > OS locale > built-in defaults
+
+Values resolved here are used as the defaults for the flags, as such, a value passed
+through a flag always takes precedence over the values resolved by this package.
+*/
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Environment variables used to supply default values
+const (
+	EnvLanguage = "AUTOSUB_LANGUAGE"
+	EnvFFmpeg   = "AUTOSUB_FFMPEG"
+	EnvOutput   = "AUTOSUB_OUTPUT"
+	EnvHistory  = "AUTOSUB_HISTORY"
+	EnvQueue    = "AUTOSUB_QUEUE"
+	EnvProgress = "AUTOSUB_PROGRESS"
+	EnvConfig   = "AUTOSUB_CONFIG"
+)
+
+// Subtitle language used if the language can't be resolved through any other source
+const defaultLanguage = "eng"
+
+/*
+Defaults is a simple structure containing the default values for the flags. A blank
+value indicates the value is not supplied by the source.
+*/
+type Defaults struct {
+	// Subtitle language
+	Language string `json:"language,omitempty"`
+
+	// Path to the FFmpeg executable
+	FFmpegPath string `json:"ffmpeg,omitempty"`
+
+	// Path to the FFprobe executable
+	FFprobePath string `json:"ffprobe,omitempty"`
+
+	// Path to the directory in which the results are to be stored
+	OutputPath string `json:"output,omitempty"`
+}
+
+/*
+Source is a named source of default values, the name is used to report the source a
+value was resolved from.
+*/
+type Source struct {
+	Name     string
+	Defaults Defaults
+}
+
+/*
+Resolve combines the default values from each source, the sources should be supplied in
+the order of decreasing precedence - for each value, the first non-blank value is used.
+*/
+func Resolve(sources ...Defaults) (res Defaults) {
+	for _, src := range sources {
+		res.Language = firstOf(res.Language, src.Language)
+		res.FFmpegPath = firstOf(res.FFmpegPath, src.FFmpegPath)
+		res.FFprobePath = firstOf(res.FFprobePath, src.FFprobePath)
+		res.OutputPath = firstOf(res.OutputPath, src.OutputPath)
+	}
+
+	log.Debugf(
+		"(config/Resolve) resolved default values \nlanguage: `%s` \nffmpeg: `%s`"+
+			"\nffprobe: `%s` \noutput: `%s`",
+		res.Language,
+		res.FFmpegPath,
+		res.FFprobePath,
+		res.OutputPath,
+	)
+
+	return res
+}
+
+// Values returns the default values from each source, retaining the order
+func Values(sources []Source) []Defaults {
+	res := make([]Defaults, 0, len(sources))
+	for _, src := range sources {
+		res = append(res, src.Defaults)
+	}
+
+	return res
+}
+
+/*
+Origin returns the name of the source the value for a key is resolved from, i.e. the
+first source with a non-blank value - the keys are the same as the ones used in the
+configuration file. Returns a blank string if no source supplies the value.
+*/
+func Origin(key string, sources []Source) string {
+	for _, src := range sources {
+		if src.Defaults.value(key) != "" {
+			return src.Name
+		}
+	}
+
+	return ""
+}
+
+// Value returns the value for a key, keys are the same as the configuration file
+func (defaults Defaults) value(key string) string {
+	switch key {
+	case "language":
+		return defaults.Language
+	case "ffmpeg":
+		return defaults.FFmpegPath
+	case "ffprobe":
+		return defaults.FFprobePath
+	case "output":
+		return defaults.OutputPath
+	}
+
+	return ""
+}
+
+/*
+FromEnv fetches the default values supplied through environment variables
+*/
+func FromEnv() Defaults {
+	return Defaults{
+		Language:   strings.TrimSpace(os.Getenv(EnvLanguage)),
+		FFmpegPath: strings.TrimSpace(os.Getenv(EnvFFmpeg)),
+		OutputPath: strings.TrimSpace(os.Getenv(EnvOutput)),
+	}
+}
+
+/*
+FromLocale fetches the subtitle language from the OS locale, using the same environment
+variables as POSIX systems; i.e. `LC_ALL`, `LC_MESSAGES` and `LANG` - in this order.
+
+The language is converted into the three-letter code used by FFmpeg. The language will
+be left blank if the locale isn't set, or the language isn't recognized.
+*/
+func FromLocale() Defaults {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+
+		// Locales are of the form `language[_territory][.codeset][@modifier]`
+		parts := strings.FieldsFunc(locale, func(r rune) bool {
+			return r == '_' || r == '-' || r == '.' || r == '@'
+		})
+
+		if len(parts) > 0 {
+			if code, err := commons.LanguageCode(parts[0]); err == nil {
+				return Defaults{Language: code}
+			}
+		}
+
+		log.Debugf(
+			"(config/FromLocale) unrecognized language in locale \n%s: `%s`",
+			env,
+			locale,
+		)
+
+		// The first locale variable set decides the language, skip the rest
+		break
+	}
+
+	return Defaults{}
+}
+
+/*
+Builtin returns the built-in default values, the paths to the executables are the ones
+located implicitly (if any).
+*/
+func Builtin(ffmpegPath, ffprobePath string) Defaults {
+	return Defaults{
+		Language:    defaultLanguage,
+		FFmpegPath:  ffmpegPath,
+		FFprobePath: ffprobePath,
+	}
+}
+
+// FirstOf returns the first string if it is not blank, returns the second one otherwise
+func firstOf(first, second string) string {
+	if first != "" {
+		return first
+	}
+
+	return second
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+/*
+SetEnv sets environment variables for a test, returning a function to restore the
+original values.
+*/
+func setEnv(t *testing.T, vars map[string]string) (restore func()) {
+	original := make(map[string]*string)
+	for key, val := range vars {
+		if old, ok := os.LookupEnv(key); ok {
+			old := old // pin
+			original[key] = &old
+		} else {
+			original[key] = nil
+		}
+
+		if err := os.Setenv(key, val); err != nil {
+			t.Errorf("(config/setEnv) failed to set `%s` \nerror: %v", key, err)
+		}
+	}
+
+	return func() {
+		for key, val := range original {
+			if val == nil {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, *val)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	res := Resolve(
+		Defaults{OutputPath: "env output"},
+		Defaults{Language: "fre"},
+		Builtin("ffmpeg path", "ffprobe path"),
+	)
+
+	expected := Defaults{
+		Language:    "fre",
+		FFmpegPath:  "ffmpeg path",
+		FFprobePath: "ffprobe path",
+		OutputPath:  "env output",
+	}
+
+	if res != expected {
+		t.Errorf(
+			"(config/Resolve) unexpected result \nexpected: %+v \nresult: %+v",
+			expected,
+			res,
+		)
+	}
+
+	// Built-in defaults should be used in the absence of other sources
+	if res := Resolve(Builtin("", "")); res.Language != defaultLanguage {
+		t.Errorf(
+			"(config/Resolve) default language not used \nresult: `%s`",
+			res.Language,
+		)
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	sources := []Source{
+		{Name: "environment", Defaults: Defaults{OutputPath: "env output"}},
+		{Name: "config file", Defaults: Defaults{
+			Language:   "fre",
+			OutputPath: "file output",
+		}},
+		{Name: "built-in", Defaults: Builtin("ffmpeg path", "")},
+	}
+
+	for key, expected := range map[string]string{
+		"output":   "environment",
+		"language": "config file",
+		"ffmpeg":   "built-in",
+		"ffprobe":  "",
+	} {
+		if origin := Origin(key, sources); origin != expected {
+			t.Errorf(
+				"(config/Origin) unexpected source for `%s` \nexpected: %q \nfound: %q",
+				key,
+				expected,
+				origin,
+			)
+		}
+	}
+
+	if res := Resolve(Values(sources)...); res.OutputPath != "env output" {
+		t.Errorf("(config/Values) unexpected result \nresult: %+v", res)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	defer setEnv(t, map[string]string{
+		EnvLanguage: " jpn ",
+		EnvFFmpeg:   "/opt/ffmpeg",
+		EnvOutput:   "",
+	})()
+
+	if res := FromEnv(); res.Language != "jpn" || res.FFmpegPath != "/opt/ffmpeg" ||
+		res.OutputPath != "" {
+		t.Errorf("(config/FromEnv) unexpected result \nresult: %+v", res)
+	}
+}
+
+func TestFromLocale(t *testing.T) {
+	for _, in := range []struct {
+		lcAll, lcMessages, lang string
+		result                  string
+	}{
+		{"", "", "fr_FR.UTF-8", "fre"},
+		{"", "de_DE", "fr_FR.UTF-8", "ger"},
+		{"ja_JP.UTF-8", "de_DE", "fr_FR.UTF-8", "jpn"},
+		{"", "", "C.UTF-8", ""},
+		{"", "", "POSIX", ""},
+		{"C", "", "fr_FR.UTF-8", ""}, // first locale set decides the language
+		{"", "", "", ""},
+		{"", "", "_", ""},
+	} {
+		restore := setEnv(t, map[string]string{
+			"LC_ALL":      in.lcAll,
+			"LC_MESSAGES": in.lcMessages,
+			"LANG":        in.lang,
+		})
+
+		if res := FromLocale(); res.Language != in.result {
+			t.Errorf(
+				"(config/FromLocale) unexpected language \nlocale: %+v"+
+					"\nexpected: `%s` \nresult: `%s`",
+				in,
+				in.result,
+				res.Language,
+			)
+		}
+
+		restore()
+	}
+}
@@ -0,0 +1,318 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+File is the structure of the configuration file, a JSON file containing the default
+values for the flags, along with named profiles;
+
+	{
+		"language": "eng",
+		"profiles": {
+			"anime": {
+				"language": "jpn",
+				"subtitle": "Full Subs",
+				"exclude": ["NCOP", "NCED"],
+				"media-ext": ["avi"],
+				"rules": [{"match": "(?i)signs", "title": "Signs & Songs"}]
+			}
+		},
+		"rules": [
+			{"match": "(?i)\\bsdh\\b", "disposition": "+hearing_impaired"}
+		],
+		"libraries": [
+			{"name": "anime", "root": "/media/anime", "profile": "anime"}
+		]
+	}
+
+Values at the top level act as defaults for every run, while the values in a profile
+are used only when the profile is selected through the `--profile` flag. Libraries are
+run through the `sync` command. Metadata rules at the top level apply to every run,
+the rules in the profile selected are applied after them.
+*/
+type File struct {
+	Defaults
+
+	// Named profiles, selected through the `--profile` flag
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// Root directories maintained through the `sync` command
+	Libraries []Library `json:"libraries,omitempty"`
+
+	// Rules setting the metadata for subtitle files and attachments
+	Rules []commons.MetadataRule `json:"rules,omitempty"`
+}
+
+/*
+Library is a root directory maintained through the `sync` command, along with the
+options for the root directory - the profile (if any) is applied first, followed by the
+additional arguments. The name defaults to the root directory.
+*/
+type Library struct {
+	Name    string   `json:"name,omitempty"`
+	Root    string   `json:"root"`
+	Profile string   `json:"profile,omitempty"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Title returns the name of the library, or the root directory if it has no name
+func (library Library) Title() string {
+	if name := strings.TrimSpace(library.Name); name != "" {
+		return name
+	}
+
+	return library.Root
+}
+
+/*
+Profile is a named preset in the configuration file, each value replaces the value of
+the flag with the same name - unless the flag is used explicitly. Blank values are left
+untouched.
+*/
+type Profile struct {
+	// Subtitle language, and the title for subtitle streams
+	Language string `json:"language,omitempty"`
+	Subtitle string `json:"subtitle,omitempty"`
+
+	// Directory to store the results in
+	Output string `json:"output,omitempty"`
+
+	// Exclusions, as file names and as regex patterns
+	Exclude  []string `json:"exclude,omitempty"`
+	Rexclude []string `json:"rexclude,omitempty"`
+
+	// Additional extensions recognized as media files
+	MediaExt []string `json:"media-ext,omitempty"`
+
+	// Languages in the order subtitles are to be attached
+	SubOrder []string `json:"sub-order,omitempty"`
+
+	// Metadata rules, applied after the rules at the top level of the file
+	Rules []commons.MetadataRule `json:"rules,omitempty"`
+}
+
+/*
+LoadFile reads the configuration file present at the path, a missing file is treated
+the same as an empty file. Unknown keys are rejected - ensures a typo in the file does
+not go unnoticed.
+*/
+func LoadFile(path string) (*File, error) {
+	file := &File{}
+	if path == "" {
+		return file, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Debugf(`(config/LoadFile) no configuration file at "%s"`, path)
+		return file, nil
+	} else if err != nil {
+		return file, fmt.Errorf(`failed to read configuration file "%s": %v`, path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(file); err != nil {
+		return &File{}, fmt.Errorf(`invalid configuration file "%s": %v`, path, err)
+	}
+
+	log.Debugf(
+		"(config/LoadFile) loaded configuration file \"%s\" \nprofiles: %v",
+		path,
+		file.ProfileNames(),
+	)
+
+	return file, nil
+}
+
+/*
+FromFile fetches the default values present at the top level of the configuration
+file, values in the profiles are not included.
+*/
+func FromFile(file *File) Defaults {
+	if file == nil {
+		return Defaults{}
+	}
+
+	return Defaults{
+		Language:    strings.TrimSpace(file.Language),
+		FFmpegPath:  strings.TrimSpace(file.FFmpegPath),
+		FFprobePath: strings.TrimSpace(file.FFprobePath),
+		OutputPath:  strings.TrimSpace(file.OutputPath),
+	}
+}
+
+// ProfileNames returns the names of the profiles in the file, in alphabetical order
+func (file *File) ProfileNames() []string {
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+/*
+Profile returns the profile with the name supplied, profile names are matched
+irrespective of case. Fails if the file contains no such profile.
+*/
+func (file *File) Profile(name string) (Profile, error) {
+	for key, profile := range file.Profiles {
+		if strings.EqualFold(key, strings.TrimSpace(name)) {
+			return profile, nil
+		}
+	}
+
+	if len(file.Profiles) == 0 {
+		return Profile{}, fmt.Errorf(
+			"unknown profile `%s`, no profiles are present in the configuration file",
+			name,
+		)
+	}
+
+	return Profile{}, fmt.Errorf(
+		"unknown profile `%s`, expected one of `%s`",
+		name,
+		strings.Join(file.ProfileNames(), "`, `"),
+	)
+}
+
+/*
+SelectLibraries returns the libraries with the names supplied, in the order they are
+present in the file - all libraries are returned if no names are supplied. Library
+names are matched irrespective of case, fails if a name matches none of the libraries.
+*/
+func (file *File) SelectLibraries(names []string) ([]Library, error) {
+	if len(names) == 0 {
+		return file.Libraries, nil
+	}
+
+	selected := make([]Library, 0, len(names))
+	for _, library := range file.Libraries {
+		for _, name := range names {
+			if strings.EqualFold(library.Title(), strings.TrimSpace(name)) {
+				selected = append(selected, library)
+				break
+			}
+		}
+	}
+
+	for _, name := range names {
+		found := false
+		for _, library := range selected {
+			found = found || strings.EqualFold(library.Title(), strings.TrimSpace(name))
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown library `%s`", name)
+		}
+	}
+
+	return selected, nil
+}
+
+/*
+Validate checks the values present in the file, along with the values in each profile -
+every invalid value is reported, instead of stopping at the first one. Values are
+checked the same way as the corresponding flags.
+*/
+func (file *File) Validate() (errs []error) {
+	if file.Language != "" {
+		if _, err := commons.LanguageCode(file.Language); err != nil {
+			errs = append(errs, fmt.Errorf("language: %v", err))
+		}
+	}
+
+	errs = append(errs, validateRules("rules", file.Rules)...)
+
+	for _, name := range file.ProfileNames() {
+		profile := file.Profiles[name]
+		errs = append(errs, validateRules("profiles."+name+".rules", profile.Rules)...)
+
+		if profile.Language != "" {
+			if _, err := commons.LanguageCode(profile.Language); err != nil {
+				errs = append(errs, fmt.Errorf("profiles.%s.language: %v", name, err))
+			}
+		}
+
+		for _, pattern := range profile.Rexclude {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf(
+					"profiles.%s.rexclude: failed to compile `%s`: %v",
+					name,
+					pattern,
+					err,
+				))
+			}
+		}
+
+		for _, ext := range profile.MediaExt {
+			if strings.Trim(ext, ". ") == "" {
+				errs = append(errs, fmt.Errorf(
+					"profiles.%s.media-ext: blank extension",
+					name,
+				))
+			}
+		}
+	}
+
+	errs = append(errs, file.validateLibraries()...)
+	return errs
+}
+
+// ValidateRules checks each metadata rule, errors are prefixed with the key supplied
+func validateRules(key string, rules []commons.MetadataRule) (errs []error) {
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s[%d]: %v", key, i, err))
+		}
+	}
+
+	return errs
+}
+
+/*
+ValidateLibraries checks each library in the file - the root directory is required,
+names must be unique, and the profile (if any) must be present in the file.
+*/
+func (file *File) validateLibraries() (errs []error) {
+	titles := map[string]bool{}
+	for i, library := range file.Libraries {
+		if strings.TrimSpace(library.Root) == "" {
+			errs = append(errs, fmt.Errorf("libraries[%d].root: blank root", i))
+			continue
+		}
+
+		title := strings.ToLower(library.Title())
+		if titles[title] {
+			errs = append(errs, fmt.Errorf(
+				"libraries[%d].name: duplicate library `%s`",
+				i,
+				library.Title(),
+			))
+		}
+
+		titles[title] = true
+
+		if library.Profile != "" {
+			if _, err := file.Profile(library.Profile); err != nil {
+				errs = append(errs, fmt.Errorf("libraries[%d].profile: %v", i, err))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(config/LoadFile) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// A missing file is the same as an empty file
+	path := filepath.Join(dir, configFile)
+	if file, err := LoadFile(path); err != nil || len(file.Profiles) != 0 {
+		t.Errorf("(config/LoadFile) unexpected result for missing file: %v", err)
+	}
+
+	content := `{
+		"language": "fre",
+		"output": " /media/output ",
+		"profiles": {
+			"anime": {
+				"language": "jpn",
+				"subtitle": "Full Subs",
+				"exclude": ["NCOP", "NCED"],
+				"media-ext": ["avi"]
+			},
+			"movies": {"sub-order": ["eng", "spa"]}
+		}
+	}`
+
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("(config/LoadFile) failed to create file: %v", err)
+	}
+
+	file, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("(config/LoadFile) failed to load file: %v", err)
+	}
+
+	defaults := FromFile(file)
+	if defaults.Language != "fre" || defaults.OutputPath != "/media/output" {
+		t.Errorf("(config/FromFile) unexpected defaults \nresult: %+v", defaults)
+	}
+
+	if names := file.ProfileNames(); !reflect.DeepEqual(
+		names,
+		[]string{"anime", "movies"},
+	) {
+		t.Errorf("(config/ProfileNames) unexpected profiles \nresult: %v", names)
+	}
+
+	// Profile names are matched irrespective of case
+	profile, err := file.Profile("Anime")
+	if err != nil || profile.Language != "jpn" || profile.Subtitle != "Full Subs" ||
+		!reflect.DeepEqual(profile.Exclude, []string{"NCOP", "NCED"}) ||
+		!reflect.DeepEqual(profile.MediaExt, []string{"avi"}) {
+		t.Errorf(
+			"(config/Profile) unexpected profile \nresult: %+v \nerror: %v",
+			profile,
+			err,
+		)
+	}
+
+	if _, err = file.Profile("music"); err == nil {
+		t.Errorf("(config/Profile) expected failure for unknown profile")
+	}
+
+	// Unknown keys are rejected
+	if err = ioutil.WriteFile(
+		path,
+		[]byte(`{"profiles": {"anime": {"langauge": "jpn"}}}`),
+		0644,
+	); err != nil {
+		t.Fatalf("(config/LoadFile) failed to create file: %v", err)
+	}
+
+	if _, err = LoadFile(path); err == nil {
+		t.Errorf("(config/LoadFile) expected failure for unknown key")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	file := &File{
+		Defaults: Defaults{Language: "english"},
+		Profiles: map[string]Profile{
+			"anime": {Language: "jpn", Rexclude: []string{`(?i)NC(OP|ED)`}},
+			"broken": {
+				Language: "jp",
+				Rexclude: []string{`(`},
+				MediaExt: []string{"."},
+				Rules:    []commons.MetadataRule{{Match: "signs", Kind: "font"}},
+			},
+		},
+		Rules: []commons.MetadataRule{
+			{Match: `(?i)\bsdh\b`, Disposition: "+hearing_impaired"},
+			{Match: "("},
+		},
+	}
+
+	// Every invalid value is reported
+	if errs := file.Validate(); len(errs) != 6 {
+		t.Errorf("(config/Validate) expected 6 errors, found %d: %v", len(errs), errs)
+	}
+
+	// Two-letter codes are accepted as well
+	delete(file.Profiles, "broken")
+	file.Language = "en"
+	file.Rules = file.Rules[:1]
+	if errs := file.Validate(); len(errs) != 0 {
+		t.Errorf("(config/Validate) unexpected errors for valid file: %v", errs)
+	}
+}
+
+func TestLibraries(t *testing.T) {
+	file := &File{
+		Profiles: map[string]Profile{"anime": {Language: "jpn"}},
+		Libraries: []Library{
+			{Name: "Anime", Root: "/media/anime", Profile: "anime"},
+			{Root: "/media/movies", Args: []string{"--language", "eng"}},
+		},
+	}
+
+	if errs := file.Validate(); len(errs) != 0 {
+		t.Errorf("(config/Validate) unexpected errors for valid libraries: %v", errs)
+	}
+
+	// Libraries without a name are matched through the root directory
+	selected, err := file.SelectLibraries([]string{"/media/movies", "anime"})
+	if err != nil || len(selected) != 2 || selected[0].Title() != "Anime" {
+		t.Errorf(
+			"(config/SelectLibraries) unexpected libraries \nresult: %+v \nerror: %v",
+			selected,
+			err,
+		)
+	}
+
+	if selected, _ = file.SelectLibraries(nil); len(selected) != 2 {
+		t.Errorf("(config/SelectLibraries) expected all libraries: %+v", selected)
+	}
+
+	if _, err = file.SelectLibraries([]string{"music"}); err == nil {
+		t.Errorf("(config/SelectLibraries) expected failure for unknown library")
+	}
+
+	// Blank root, duplicate name and unknown profile are reported
+	file.Libraries = append(
+		file.Libraries,
+		Library{Name: "empty"},
+		Library{Name: "anime", Root: "/media/other"},
+		Library{Root: "/media/music", Profile: "music"},
+	)
+
+	if errs := file.Validate(); len(errs) != 3 {
+		t.Errorf("(config/Validate) expected 3 errors, found %d: %v", len(errs), errs)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	restore := setEnv(t, map[string]string{
+		EnvConfig: "/custom/config.json",
+	})
+	defer restore()
+
+	if path := FilePath("auto-sub"); path != "/custom/config.json" {
+		t.Errorf("(config/FilePath) expected path from environment, found %q", path)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the files recording the results of each run, and the queue for a paused
+// run - both are stored in the config directory
+const (
+	historyFile = "history.jsonl"
+	queueFile   = "queue.json"
+)
+
+// Name of the configuration file, stored in the config directory
+const configFile = "config.json"
+
+// Name of the file caching the results of FFprobe, stored in the cache directory
+const probeCacheFile = "probe-cache.json"
+
+// Name of the log file, stored in the state directory (logs directory on macOS)
+const logFile = "logs.txt"
+
+// Name of the file recording the progress of the ongoing run, stored in the state
+// directory
+const progressFile = "progress.json"
+
+// Operating system the application is running on - a variable to allow tests to
+// imitate other platforms
+var goos = runtime.GOOS
+
+/*
+ConfigDir returns the directory in which the configuration (and files the user might
+want to keep, such as the run history) are stored, using the platform conventions;
+
+	Linux/BSD: $XDG_CONFIG_HOME/<app> (defaults to ~/.config/<app>)
+	macOS:     ~/Library/Application Support/<app>
+	Windows:   %APPDATA%\<app>
+
+Returns a blank path if the directory can't be determined.
+*/
+func ConfigDir(app string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Debugf("(config/ConfigDir) failed to locate config directory: %v", err)
+		return ""
+	}
+
+	return filepath.Join(dir, app)
+}
+
+/*
+CacheDir returns the directory in which files that can be recreated at any time are
+stored, using the platform conventions;
+
+	Linux/BSD: $XDG_CACHE_HOME/<app> (defaults to ~/.cache/<app>)
+	macOS:     ~/Library/Caches/<app>
+	Windows:   %LOCALAPPDATA%\<app>
+
+Returns a blank path if the directory can't be determined.
+*/
+func CacheDir(app string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Debugf("(config/CacheDir) failed to locate cache directory: %v", err)
+		return ""
+	}
+
+	return filepath.Join(dir, app)
+}
+
+/*
+StateDir returns the directory in which files written by the application that are
+not worth backing up (such as the logs) are stored, using the platform conventions;
+
+	Linux/BSD: $XDG_STATE_HOME/<app> (defaults to ~/.local/state/<app>)
+	macOS:     ~/Library/Application Support/<app>
+	Windows:   %APPDATA%\<app>
+
+Returns a blank path if the directory can't be determined.
+*/
+func StateDir(app string) string {
+	if goos == "windows" || goos == "darwin" || goos == "plan9" {
+		// No separate location for state, the config directory is used instead
+		return ConfigDir(app)
+	}
+
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, app)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Debugf("(config/StateDir) failed to locate home directory: %v", err)
+		return ""
+	}
+
+	return filepath.Join(home, ".local", "state", app)
+}
+
+/*
+LogPath returns the default path to the log file - a file in the state directory, or in
+`~/Library/Logs/<app>` on macOS. Returns a blank path if the directory can't be
+determined.
+*/
+func LogPath(app string) string {
+	if goos == "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			log.Debugf("(config/LogPath) failed to locate home directory: %v", err)
+			return ""
+		}
+
+		return filepath.Join(home, "Library", "Logs", app, logFile)
+	}
+
+	return joinDir(StateDir(app), logFile)
+}
+
+/*
+FilePath returns the path to the configuration file - the path can be supplied through
+an environment variable, defaults to a file in the config directory. Returns a blank
+path if the config directory can't be determined.
+*/
+func FilePath(app string) string {
+	return envPath(EnvConfig, joinDir(ConfigDir(app), configFile))
+}
+
+/*
+HistoryPath returns the path to the file recording the results of each run - the path
+can be supplied through an environment variable, defaults to a file in the config
+directory. Returns a blank path if the config directory can't be determined.
+*/
+func HistoryPath(app string) string {
+	return envPath(EnvHistory, joinDir(ConfigDir(app), historyFile))
+}
+
+/*
+QueuePath returns the path to the file containing the queue of source directories for
+the ongoing (or paused) run - same as the history, the path can be supplied through an
+environment variable, defaults to a file in the config directory.
+*/
+func QueuePath(app string) string {
+	return envPath(EnvQueue, joinDir(ConfigDir(app), queueFile))
+}
+
+/*
+ProgressPath returns the path to the file recording the progress of the ongoing run -
+same as the queue, the path can be supplied through an environment variable, defaults
+to a file in the state directory.
+*/
+func ProgressPath(app string) string {
+	return envPath(EnvProgress, joinDir(StateDir(app), progressFile))
+}
+
+/*
+ProbeCachePath returns the default path to the file caching the results of FFprobe
+across runs, a file in the cache directory. Returns a blank path if the cache directory
+can't be determined.
+*/
+func ProbeCachePath(app string) string {
+	return joinDir(CacheDir(app), probeCacheFile)
+}
+
+// EnvPath returns the path supplied through the environment variable, if any
+func envPath(env, fallback string) string {
+	if path := strings.TrimSpace(os.Getenv(env)); path != "" {
+		return path
+	}
+
+	return fallback
+}
+
+// JoinDir joins the file name to the directory, unless the directory is blank
+func joinDir(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, name)
+}
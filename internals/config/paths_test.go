@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryPath(t *testing.T) {
+	restore := setEnv(t, map[string]string{
+		EnvHistory:        "/custom/history.jsonl",
+		"XDG_CONFIG_HOME": "/config",
+		"HOME":            "/home/user",
+	})
+	defer restore()
+
+	if path := HistoryPath("auto-sub"); path != "/custom/history.jsonl" {
+		t.Errorf("(config/HistoryPath) expected path from environment, found %q", path)
+	}
+
+	_ = os.Unsetenv(EnvHistory)
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skipf("(config/HistoryPath) config directory not available: %v", err)
+	}
+
+	if path, expected := HistoryPath("auto-sub"), filepath.Join(
+		dir,
+		"auto-sub",
+		historyFile,
+	); path != expected {
+		t.Errorf(
+			"(config/HistoryPath) unexpected path \nexpected: %q \nfound: %q",
+			expected,
+			path,
+		)
+	}
+}
+
+func TestQueuePath(t *testing.T) {
+	restore := setEnv(t, map[string]string{
+		EnvQueue: "/custom/queue.json",
+	})
+	defer restore()
+
+	if path := QueuePath("auto-sub"); path != "/custom/queue.json" {
+		t.Errorf("(config/QueuePath) expected path from environment, found %q", path)
+	}
+
+	_ = os.Unsetenv(EnvQueue)
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skipf("(config/QueuePath) config directory not available: %v", err)
+	}
+
+	if path, expected := QueuePath("auto-sub"), filepath.Join(
+		dir,
+		"auto-sub",
+		queueFile,
+	); path != expected {
+		t.Errorf(
+			"(config/QueuePath) unexpected path \nexpected: %q \nfound: %q",
+			expected,
+			path,
+		)
+	}
+}
+
+func TestProbeCachePath(t *testing.T) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		t.Skipf("(config/ProbeCachePath) cache directory not available: %v", err)
+	}
+
+	if path, expected := ProbeCachePath("auto-sub"), filepath.Join(
+		dir,
+		"auto-sub",
+		probeCacheFile,
+	); path != expected {
+		t.Errorf(
+			"(config/ProbeCachePath) unexpected path \nexpected: %q \nfound: %q",
+			expected,
+			path,
+		)
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	restore := setEnv(t, map[string]string{
+		"XDG_STATE_HOME": "/state",
+		"HOME":           "/home/user",
+		EnvProgress:      "",
+	})
+	defer restore()
+
+	defer func(original string) {
+		goos = original
+	}(goos)
+
+	goos = "linux"
+	if dir := StateDir("auto-sub"); dir != filepath.Join("/state", "auto-sub") {
+		t.Errorf("(config/StateDir) expected directory from environment, found %q", dir)
+	}
+
+	// Relative paths are ignored, as per the XDG specification
+	_ = os.Setenv("XDG_STATE_HOME", "relative/state")
+
+	expected := filepath.Join("/home/user", ".local", "state", "auto-sub")
+	if dir := StateDir("auto-sub"); dir != expected {
+		t.Errorf(
+			"(config/StateDir) unexpected default directory \nexpected: %q "+
+				"\nfound: %q",
+			expected,
+			dir,
+		)
+	}
+
+	if path := LogPath("auto-sub"); path != filepath.Join(expected, logFile) {
+		t.Errorf("(config/LogPath) unexpected path for log file: %q", path)
+	}
+
+	if path := ProgressPath("auto-sub"); path != filepath.Join(expected, progressFile) {
+		t.Errorf("(config/ProgressPath) unexpected path for progress: %q", path)
+	}
+
+	_ = os.Setenv(EnvProgress, "/custom/progress.json")
+	if path := ProgressPath("auto-sub"); path != "/custom/progress.json" {
+		t.Errorf("(config/ProgressPath) expected path from environment, found %q", path)
+	}
+
+	// Logs on macOS are stored along with the logs from other applications
+	goos = "darwin"
+
+	expected = filepath.Join("/home/user", "Library", "Logs", "auto-sub", logFile)
+	if path := LogPath("auto-sub"); path != expected {
+		t.Errorf(
+			"(config/LogPath) unexpected path on macOS \nexpected: %q \nfound: %q",
+			expected,
+			path,
+		)
+	}
+}
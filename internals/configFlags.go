@@ -0,0 +1,224 @@
+package internals
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the sources a flag value can be resolved from, as reported while printing
+// the resolved configuration
+const (
+	sourceFlag    = "flag"
+	sourceProfile = "profile"
+	sourceEnv     = "environment"
+	sourceFile    = "config file"
+	sourceLocale  = "locale"
+	sourceBuiltin = "built-in"
+)
+
+// Sources for the default values of the flags, in the order of precedence
+var defaultSources []config.Source
+
+// Flags that are never part of the resolved configuration - these don't affect a run
+var skipFlags = []string{
+	"help",
+	"version",
+	"print-config",
+	"validate-config",
+}
+
+/*
+ResolvedValue is the value for a flag in the resolved configuration, along with the
+source the value was resolved from.
+*/
+type resolvedValue struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+/*
+ResolvedConfig is the configuration for a run, after combining the flags, the profile
+(if any), environment variables, the configuration file and the built-in defaults.
+*/
+type resolvedConfig struct {
+	ConfigFile string                   `json:"config_file"`
+	Profile    string                   `json:"profile,omitempty"`
+	Flags      map[string]resolvedValue `json:"flags"`
+}
+
+/*
+ResolveConfig forms the resolved configuration from the flags of the command, should be
+called once the profile (if any) is applied.
+*/
+func resolveConfig(command *cobra.Command, input *commons.UserInput) resolvedConfig {
+	res := resolvedConfig{
+		ConfigFile: config.FilePath(title),
+		Profile:    input.Profile,
+		Flags:      make(map[string]resolvedValue),
+	}
+
+	// Flags set through the profile, if any - errors are reported while applying
+	var profile config.Profile
+	if input.Profile != "" && configErr == nil {
+		profile, _ = configFile.Profile(input.Profile)
+	}
+
+	profileJSON, _ := json.Marshal(profile)
+	profileKeys := make(map[string]interface{})
+	_ = json.Unmarshal(profileJSON, &profileKeys)
+
+	visit := func(flag *pflag.Flag) {
+		for _, name := range skipFlags {
+			if flag.Name == name {
+				return
+			}
+		}
+
+		source := sourceBuiltin
+		if _, ok := profileKeys[flag.Name]; ok {
+			source = sourceProfile
+		} else if origin := config.Origin(flag.Name, defaultSources); origin != "" {
+			source = origin
+		}
+
+		if flag.Changed {
+			source = sourceFlag
+		}
+
+		res.Flags[flag.Name] = resolvedValue{Value: flagValue(flag), Source: source}
+	}
+
+	command.Flags().VisitAll(visit)
+	command.PersistentFlags().VisitAll(visit)
+
+	return res
+}
+
+/*
+FlagValue returns the value of a flag in its natural type - lists as arrays, booleans
+and integers as such, and the rest as strings.
+*/
+func flagValue(flag *pflag.Flag) interface{} {
+	if slice, ok := flag.Value.(interface{ GetSlice() []string }); ok {
+		return append([]string{}, slice.GetSlice()...)
+	}
+
+	value := flag.Value.String()
+	switch flag.Value.Type() {
+	case "bool":
+		if res, err := strconv.ParseBool(value); err == nil {
+			return res
+		}
+
+	case "int":
+		if res, err := strconv.Atoi(value); err == nil {
+			return res
+		}
+	}
+
+	return value
+}
+
+/*
+PrintConfig writes the resolved configuration to the stream as indented JSON, a
+machine-friendly format - useful to find out the source a value is picked from.
+*/
+func printConfig(out io.Writer, resolved resolvedConfig) error {
+	content, err := json.MarshalIndent(resolved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, string(content))
+	return err
+}
+
+/*
+ValidateConfig checks the configuration file, without running - returns the exit code
+along with a message describing the result. A missing configuration file is reported as
+an error, ensures the check does not pass if the file is misplaced.
+*/
+func validateConfig(path string) (int, string) {
+	if path == "" {
+		return commons.ConfigError, "Unable to locate the configuration file"
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return commons.ConfigError, fmt.Sprintf(
+			"Configuration file not found \n\tPath: \"%s\"",
+			path,
+		)
+	}
+
+	file, err := config.LoadFile(path)
+	if err != nil {
+		return commons.ConfigError, err.Error()
+	}
+
+	if errs := file.Validate(); len(errs) > 0 {
+		msg := fmt.Sprintf("Invalid configuration file \n\tPath: \"%s\"", path)
+		for _, err := range errs {
+			msg += fmt.Sprintf("\n\t%v", err)
+		}
+
+		return commons.ConfigError, msg
+	}
+
+	profiles := strings.Join(file.ProfileNames(), ", ")
+	if profiles == "" {
+		profiles = "none"
+	}
+
+	return commons.StatusOK, fmt.Sprintf(
+		"Configuration file is valid \n\tPath: \"%s\" \n\tProfiles: %s",
+		path,
+		profiles,
+	)
+}
+
+/*
+HandleConfigFlags handles the flags to print the resolved configuration, and to
+validate the configuration file - the application exits once done. Does nothing if
+neither flag is used.
+*/
+func handleConfigFlags(command *cobra.Command, input *commons.UserInput) {
+	if input.ValidateConfig {
+		exitCode, msg := validateConfig(config.FilePath(title))
+		log.Debugf("(configFlags/handleConfigFlags) validated configuration file")
+
+		if exitCode == commons.StatusOK {
+			commons.Success("%s\n\n", msg)
+		} else {
+			commons.Error("%s\n\n", msg)
+		}
+
+		os.Exit(exitCode)
+	}
+
+	if input.PrintConfig {
+		// The resolved configuration includes the executables used for a run
+		locateBinaries(input)
+
+		if err := printConfig(command.OutOrStdout(), resolveConfig(
+			command,
+			input,
+		)); err != nil {
+			log.Warnf("(configFlags/handleConfigFlags) failed to print: %v", err)
+			os.Exit(commons.UnexpectedError)
+		}
+
+		os.Exit(commons.StatusOK)
+	}
+}
@@ -0,0 +1,84 @@
+package internals
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(configFlags) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// A missing file fails the check
+	path := filepath.Join(dir, "config.json")
+	if code, _ := validateConfig(path); code != commons.ConfigError {
+		t.Errorf("(configFlags/validateConfig) expected failure for missing file")
+	}
+
+	for _, test := range []struct {
+		content string
+		code    int
+	}{
+		{`{"profiles": {"anime": {"language": "jpn"}}}`, commons.StatusOK},
+		{`{"profiles": {"anime": {"language": "japanese"}}}`, commons.ConfigError},
+		{`{"profiles": {"anime": {"lang": "jpn"}}}`, commons.ConfigError},
+		{`{"profiles": `, commons.ConfigError},
+	} {
+		if err = ioutil.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatalf("(configFlags) failed to create file: %v", err)
+		}
+
+		if code, msg := validateConfig(path); code != test.code {
+			t.Errorf(
+				"(configFlags/validateConfig) unexpected exit code for %s"+
+					"\nexpected: %d \nfound: %d \nmessage: %s",
+				test.content,
+				test.code,
+				code,
+				msg,
+			)
+		}
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	resolved := resolvedConfig{
+		ConfigFile: "/config.json",
+		Profile:    "anime",
+		Flags: map[string]resolvedValue{
+			"language": {Value: "jpn", Source: sourceProfile},
+			"exclude":  {Value: []string{"NCOP"}, Source: sourceFlag},
+			"threads":  {Value: 4, Source: sourceBuiltin},
+		},
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := printConfig(buffer, resolved); err != nil {
+		t.Fatalf("(configFlags/printConfig) failed to print: %v", err)
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &res); err != nil {
+		t.Fatalf("(configFlags/printConfig) output is not valid JSON: %v", err)
+	}
+
+	flags, _ := res["flags"].(map[string]interface{})
+	language, _ := flags["language"].(map[string]interface{})
+	if res["profile"] != "anime" || !reflect.DeepEqual(
+		language,
+		map[string]interface{}{"value": "jpn", "source": sourceProfile},
+	) {
+		t.Errorf("(configFlags/printConfig) unexpected output \n%s", buffer.String())
+	}
+}
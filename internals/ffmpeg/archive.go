@@ -0,0 +1,235 @@
+package ffmpeg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extensions for archives that are looked into with `--extract-archives`
+var archiveExt = []string{
+	"zip",
+}
+
+// Prefix for the directories archives are extracted into, in the temporary workspace
+const extractedArchivePrefix = "archive-"
+
+// Limit on the total size of the files extracted from a single archive, guards against
+// archives that expand to an unreasonable size
+const maxArchiveSize = 512 << 20
+
+/*
+Directories in the temporary workspace that the archives in the source directory being
+processed are extracted into, mapped by the path to the archive. Files inside an
+archive are named with their path inside the archive prefixed with the name of the
+archive, `joinPath` resolves such paths to the extracted copies.
+*/
+var extractedArchives = map[string]string{}
+
+/*
+ArchiveFiles extracts an archive present in a source directory, and groups the files
+inside it same as the files in the source directory - the files are named with their
+path relative to the source directory (through the archive), i.e. `Subs.zip/eng.srt`.
+Files inside a folder of the archive are used as per-episode subtitles, files nested
+deeper, media files and archives are left out. Archives that fail to extract are
+skipped, with a warning added to the summary.
+*/
+func archiveFiles(
+	sourceDir string,
+	archive os.FileInfo,
+	userInput *commons.UserInput,
+	dirIncluded bool,
+) map[int][]os.FileInfo {
+	path := filepath.Join(sourceDir, archive.Name())
+	dir, err := extractArchive(path)
+	if err != nil {
+		log.Debugf(
+			"(archive/archiveFiles) failed to extract archive: \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+
+		runSummary.warn(`failed to extract archive "%s", skipped: %v`, path, err)
+		return nil
+	}
+
+	groups := make(map[int][]os.FileInfo)
+	err = filepath.Walk(dir, func(item string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, item)
+		if err != nil {
+			return err
+		}
+
+		// Folders inside the archive act as per-episode folders, same as the ones in
+		// subtitle folders - files nested any deeper are not used
+		if strings.Count(filepath.ToSlash(rel), "/") > 1 {
+			return nil
+		}
+
+		category, _ := classifyFile(filepath.Dir(item), info, userInput, dirIncluded)
+		switch category {
+		case categoryIgnored:
+			return nil
+
+		case categoryMedia, categoryArchive:
+			log.Debugf(
+				`(archive/archiveFiles) skipping "%s" in archive: "%s"`,
+				rel,
+				path,
+			)
+
+			return nil
+		}
+
+		groups[category] = append(groups[category], nestedFile{
+			FileInfo: info,
+			name:     filepath.Join(archive.Name(), rel),
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		log.Debugf(
+			"(archive/archiveFiles) failed to read extracted archive: \"%s\" "+
+				"\nerror: %v",
+			path,
+			err,
+		)
+	}
+
+	return groups
+}
+
+/*
+ExtractArchive extracts an archive into a new directory in the temporary workspace of
+the run, returning the path to the directory - archives are extracted once, until
+released through `releaseArchives`. Entries escaping the directory are rejected, and
+the modification times of the entries are kept, ensuring snapshots of the outputs
+remain valid across runs.
+*/
+func extractArchive(path string) (dir string, err error) {
+	if dir, ok := extractedArchives[path]; ok {
+		return dir, nil
+	}
+
+	reader, err := zip.OpenReader(commons.NormalizePath(path))
+	if err != nil {
+		return "", err
+	}
+
+	defer reader.Close()
+
+	if dir, err = commons.Temp().Dir(extractedArchivePrefix); err != nil {
+		return "", err
+	}
+
+	var total int64
+	for _, entry := range reader.File {
+		name := filepath.FromSlash(entry.Name)
+		clean := filepath.Clean(name)
+		if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || clean == ".." ||
+			strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			err = fmt.Errorf("unsafe path in archive: %q", entry.Name)
+			break
+		}
+
+		dest := filepath.Join(dir, name)
+		if entry.FileInfo().IsDir() {
+			if err = os.MkdirAll(dest, 0700); err != nil {
+				break
+			}
+
+			continue
+		}
+
+		var written int64
+		written, err = extractEntry(entry, dest, maxArchiveSize-total)
+		if total += written; err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		commons.Temp().Release(dir)
+		return "", err
+	}
+
+	log.Debugf(`(archive/extractArchive) extracted "%s" into "%s"`, path, dir)
+	extractedArchives[path] = dir
+
+	return dir, nil
+}
+
+/*
+ExtractEntry writes a single file from an archive to the destination, failing if the
+file is larger than the limit supplied - returns the number of bytes written.
+*/
+func extractEntry(entry *zip.File, dest string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return 0, err
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return 0, err
+	}
+
+	defer src.Close()
+
+	file, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sizes recorded in the archive can't be trusted, the data read is limited instead
+	written, err := io.Copy(file, io.LimitReader(src, limit+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil && written > limit {
+		err = fmt.Errorf("archive exceeds %d MiB when extracted", maxArchiveSize>>20)
+	}
+
+	if err == nil && !entry.Modified.IsZero() {
+		err = os.Chtimes(dest, entry.Modified, entry.Modified)
+	}
+
+	return written, err
+}
+
+/*
+ExtractedPath resolves a path through an archive (i.e. `<source>/Subs.zip/eng.srt`) to
+the extracted copy of the file, other paths are returned as is.
+*/
+func extractedPath(path string) string {
+	for archive, dir := range extractedArchives {
+		if strings.HasPrefix(path, archive+string(filepath.Separator)) {
+			return filepath.Join(dir, strings.TrimPrefix(path, archive))
+		}
+	}
+
+	return path
+}
+
+/*
+ReleaseArchives removes the extracted copies of the archives, should be called once
+the source directory is processed.
+*/
+func releaseArchives() {
+	for archive, dir := range extractedArchives {
+		commons.Temp().Release(dir)
+		delete(extractedArchives, archive)
+	}
+}
@@ -0,0 +1,159 @@
+package ffmpeg
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+// Creates a zip archive at the path, with the contents supplied for each entry
+func createArchive(t *testing.T, path string, entries map[string]string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("(archive/createArchive) failed to create archive: %v", err)
+	}
+
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, contents := range entries {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		header.Modified = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("(archive/createArchive) failed to add entry: %v", err)
+		}
+
+		_, _ = entry.Write([]byte(contents))
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("(archive/createArchive) failed to write archive: %v", err)
+	}
+}
+
+func TestArchiveFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(archive/archiveFiles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer commons.SetTemp(nil)
+	commons.SetTemp(commons.NewTempManager(false))
+	defer commons.Temp().Cleanup()
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "Movie.mkv"), []byte{}, 0644)
+	createArchive(t, filepath.Join(dir, "Subs.zip"), map[string]string{
+		"English.srt":      "english",
+		"Fonts/Arial.ttf":  "font",
+		"A/B/Deep.srt":     "deep",
+		"Sample.mkv":       "sample",
+		"Nested/Inner.zip": "archive",
+		"readme.txt":       "notes",
+	})
+
+	// Archives are ignored unless they are to be extracted
+	_, subtitles, _, _ := groupFiles(dir, &commons.UserInput{})
+	if len(subtitles) != 0 {
+		t.Errorf("(archive/groupFiles) archive extracted without the flag")
+	}
+
+	input := &commons.UserInput{ExtractArchives: true}
+	mediaFiles, subtitles, attachments, _ := groupFiles(dir, input)
+	if len(mediaFiles) != 1 || len(subtitles) != 1 || len(attachments) != 1 {
+		t.Fatalf(
+			"(archive/groupFiles) unexpected groups \nmedia: %d \nsubs: %d "+
+				"\nattachments: %d",
+			len(mediaFiles),
+			len(subtitles),
+			len(attachments),
+		)
+	}
+
+	// Files are named through the archive, and resolved to the extracted copies
+	names := []string{subtitles[0].Name(), attachments[0].Name()}
+	sort.Strings(names)
+	if names[0] != filepath.Join("Subs.zip", "English.srt") ||
+		names[1] != filepath.Join("Subs.zip", "Fonts", "Arial.ttf") {
+		t.Errorf("(archive/archiveFiles) unexpected names: %v", names)
+	}
+
+	path := joinPath(dir, subtitles[0].Name())
+	if contents, err := ioutil.ReadFile(path); err != nil ||
+		string(contents) != "english" {
+		t.Errorf("(archive/joinPath) unexpected path: %q \nerror: %v", path, err)
+	}
+
+	// Modification times are kept from the archive
+	if !subtitles[0].ModTime().Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("(archive/extractArchive) unexpected time: %v", subtitles[0].ModTime())
+	}
+
+	// Extracted copies are removed once released
+	releaseArchives()
+	if _, err = os.Stat(path); !os.IsNotExist(err) || len(extractedArchives) != 0 {
+		t.Errorf("(archive/releaseArchives) extracted copy not removed: %q", path)
+	}
+
+	if resolved := joinPath(dir, subtitles[0].Name()); resolved == path {
+		t.Errorf("(archive/joinPath) path resolved after release: %q", resolved)
+	}
+}
+
+func TestExtractArchiveUnsafe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(archive/extractArchive) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer commons.SetTemp(nil)
+	commons.SetTemp(commons.NewTempManager(false))
+	defer commons.Temp().Cleanup()
+
+	// Entries escaping the directory fail the archive as a whole
+	path := filepath.Join(dir, "Subs.zip")
+	createArchive(t, path, map[string]string{"../../escape.srt": "escape"})
+
+	if _, err = extractArchive(path); err == nil {
+		t.Errorf("(archive/extractArchive) unsafe entry extracted")
+	}
+
+	if len(extractedArchives) != 0 {
+		t.Errorf("(archive/extractArchive) failed archive registered")
+	}
+
+	// Names merely starting with two periods stay inside the directory
+	createArchive(t, path, map[string]string{"..hidden.srt": "hidden"})
+	if _, err = extractArchive(path); err != nil {
+		t.Errorf("(archive/extractArchive) safe entry rejected \nerror: %v", err)
+	}
+
+	releaseArchives()
+
+	// Invalid archives are skipped with a warning
+	runSummary = &Summary{}
+	_ = ioutil.WriteFile(path, []byte("not an archive"), 0644)
+	groups := archiveFiles(dir, fileInfo(t, path), &commons.UserInput{}, true)
+	if len(groups) != 0 || len(runSummary.Warnings) != 1 {
+		t.Errorf("(archive/archiveFiles) invalid archive not skipped")
+	}
+}
+
+// Returns the file info for the path, failing the test if the path can't be read
+func fileInfo(t *testing.T, path string) os.FileInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("(archive/fileInfo) failed to read file: %v", err)
+	}
+
+	return info
+}
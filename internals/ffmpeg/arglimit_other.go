@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package ffmpeg
+
+/*
+ArgLimit is the limit (in characters) for the command line of a command - on Windows,
+the entire command line is limited to 32767 characters.
+*/
+const argLimit = 32767
@@ -0,0 +1,11 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package ffmpeg
+
+/*
+ArgLimit is the limit (in bytes) for the arguments of a command - ARG_MAX is 256 KiB on
+macOS, and includes the environment. Linux allows more, a single limit keeps results
+the same across platforms.
+*/
+const argLimit = 128 * 1024
@@ -0,0 +1,113 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+// MIME types for the files attached to the output, by extension
+var attachmentMimetypes = map[string]string{
+	".xml": "text/xml",
+	".ttf": "application/x-truetype-font",
+	".otf": "application/vnd.ms-opentype",
+}
+
+/*
+AttachmentPlan is a file to be attached to the output, along with the metadata for the
+attachment stream - the description is optional.
+*/
+type attachmentPlan struct {
+	path        string
+	mimetype    string
+	description string
+}
+
+/*
+PlanAttachments returns the files to be attached to the output in the order in which
+they are attached - chapters first, followed by the fonts. The description for each
+type of attachment is the one supplied by the user (if any), unless a metadata rule
+sets a title for the file.
+*/
+func planAttachments(
+	sourceDir string,
+	input *commons.UserInput,
+	chapters,
+	fonts []os.FileInfo,
+) []attachmentPlan {
+	plans := make([]attachmentPlan, 0, len(chapters)+len(fonts))
+
+	for _, group := range []struct {
+		kind  string
+		files []os.FileInfo
+	}{
+		{commons.AttachmentChapter, chapters},
+		{commons.AttachmentFont, fonts},
+	} {
+		for _, file := range group.files {
+			// Titles from the metadata rules replace the description for the type
+			description := input.AttachmentDescs[group.kind]
+			rule, _ := input.ApplyRules(commons.RuleAttachment, file.Name())
+			if rule.Title != "" {
+				description = rule.Title
+			}
+
+			plans = append(plans, attachmentPlan{
+				path:        joinPath(sourceDir, file.Name()),
+				mimetype:    attachmentMimetype(file.Name()),
+				description: description,
+			})
+		}
+	}
+
+	return plans
+}
+
+/*
+AttachmentMimetype returns the MIME type for an attachment through its extension, files
+with an unknown extension are treated as generic binary files.
+*/
+func attachmentMimetype(name string) string {
+	if mimetype, ok := attachmentMimetypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return mimetype
+	}
+
+	return "application/octet-stream"
+}
+
+/*
+Args returns the FFmpeg arguments to attach the file, the stream is the index of the
+attachment stream in the output (used to set the metadata for the stream).
+*/
+func (plan attachmentPlan) args(stream int) []string {
+	args := []string{
+		"-attach",
+		plan.path,
+		fmt.Sprintf("-metadata:s:t:%d", stream),
+		"mimetype=" + plan.mimetype,
+	}
+
+	if plan.description != "" {
+		args = append(
+			args,
+			fmt.Sprintf("-metadata:s:t:%d", stream),
+			"description="+plan.description,
+		)
+	}
+
+	// FFmpeg names the attachment after the file, names that aren't valid UTF-8 are
+	// replaced with their sanitized form
+	if name := filepath.Base(plan.path); !utf8.ValidString(name) {
+		args = append(
+			args,
+			fmt.Sprintf("-metadata:s:t:%d", stream),
+			"filename="+commons.SanitizeText(name),
+		)
+	}
+
+	return args
+}
@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestPlanAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(attachment/planAttachments) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var files []os.FileInfo
+	for _, name := range []string{"font.TTF", "chapters.xml", "font.otf"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(attachment/planAttachments) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		files = append(files, info)
+	}
+
+	input := &commons.UserInput{
+		AttachmentDescs: map[string]string{commons.AttachmentFont: "Subtitle font"},
+	}
+
+	// Chapters are attached before the fonts, irrespective of the order supplied
+	plans := planAttachments(dir, input, files[1:2], []os.FileInfo{files[0], files[2]})
+	expected := []attachmentPlan{
+		{joinPath(dir, "chapters.xml"), "text/xml", ""},
+		{joinPath(dir, "font.TTF"), "application/x-truetype-font", "Subtitle font"},
+		{joinPath(dir, "font.otf"), "application/vnd.ms-opentype", "Subtitle font"},
+	}
+
+	if !reflect.DeepEqual(plans, expected) {
+		t.Errorf(
+			"(attachment/planAttachments) unexpected plan \nexpected: %+v \nfound: %+v",
+			expected,
+			plans,
+		)
+	}
+
+	// Metadata is set for the stream supplied, description only if present
+	if args := plans[0].args(3); !reflect.DeepEqual(args, []string{
+		"-attach",
+		joinPath(dir, "chapters.xml"),
+		"-metadata:s:t:3",
+		"mimetype=text/xml",
+	}) {
+		t.Errorf("(attachment/args) unexpected arguments: %v", args)
+	}
+
+	if args := plans[1].args(4); len(args) != 6 ||
+		args[4] != "-metadata:s:t:4" || args[5] != "description=Subtitle font" {
+		t.Errorf("(attachment/args) unexpected arguments: %v", args)
+	}
+
+	if mimetype := attachmentMimetype("unknown.bin"); mimetype !=
+		"application/octet-stream" {
+		t.Errorf("(attachment/attachmentMimetype) unexpected type: %s", mimetype)
+	}
+}
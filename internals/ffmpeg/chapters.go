@@ -0,0 +1,45 @@
+package ffmpeg
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ChaptersOverride returns the chapters file supplied by the user for a source directory,
+used instead of the chapter files found in the source directory - the boolean returned
+is false if chapters are to be detected from the source directory. Missing chapters
+files are skipped (no chapters are attached), with a warning added to the summary.
+*/
+func chaptersOverride(
+	sourceDir string,
+	input *commons.UserInput,
+) (chapters []os.FileInfo, ok bool) {
+	path := input.ChaptersPath(sourceDir)
+	if path == "" {
+		return nil, false
+	}
+
+	item, err := os.Stat(path)
+	if err != nil || item.IsDir() {
+		log.Debugf(
+			`(chapters/chaptersOverride) unable to read chapters file: "%s"`+
+				"\nerror: %v",
+			path,
+			err,
+		)
+
+		runSummary.warn(
+			`chapters file "%s" not found, no chapters attached for "%s"`,
+			path,
+			sourceDir,
+		)
+
+		return nil, true
+	}
+
+	// Files outside the source directory are named with their absolute path
+	return []os.FileInfo{nestedFile{FileInfo: item, name: path}}, true
+}
@@ -0,0 +1,48 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestChaptersOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(chapters/chaptersOverride) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "Season 1")
+	_ = os.Mkdir(source, 0755)
+	for _, name := range []string{"Episode.mkv", "Episode.srt", "Found.xml"} {
+		_ = ioutil.WriteFile(filepath.Join(source, name), []byte{}, 0644)
+	}
+
+	chaptersFile := filepath.Join(dir, "Season 1.xml")
+	_ = ioutil.WriteFile(chaptersFile, []byte("<Chapters/>"), 0644)
+
+	// Chapter files found are used unless a chapters file is supplied
+	_, _, _, chapters := groupFiles(source, &commons.UserInput{})
+	if len(chapters) != 1 || chapters[0].Name() != "Found.xml" {
+		t.Errorf("(chapters/groupFiles) chapter files not detected")
+	}
+
+	input := &commons.UserInput{ChaptersFile: filepath.Join(dir, "{dir}.xml")}
+	_, _, _, chapters = groupFiles(source, input)
+	if len(chapters) != 1 || joinPath(source, chapters[0].Name()) != chaptersFile {
+		t.Errorf("(chapters/groupFiles) chapters file not used: %v", chapters)
+	}
+
+	// Missing chapters files leave the source directory without chapters
+	runSummary = &Summary{}
+	input.ChaptersFile = filepath.Join(dir, "missing-{dir}.xml")
+	_, _, _, chapters = groupFiles(source, input)
+	if len(chapters) != 0 || len(runSummary.Warnings) != 1 {
+		t.Errorf("(chapters/groupFiles) missing chapters file not reported")
+	}
+}
@@ -0,0 +1,261 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Stages of a run recorded in the checkpoint
+const (
+	stageIdle      = "idle"
+	stagePreparing = "preparing"
+	stageEncoding  = "encoding"
+)
+
+// Minimum gap between two saves of the progress of an encode, changes in the stage are
+// saved immediately
+const checkpointInterval = 5 * time.Second
+
+// ErrNoCheckpoint is returned while loading a checkpoint if no checkpoint was left
+var ErrNoCheckpoint = errors.New("no checkpoint left by an earlier run")
+
+/*
+Checkpoint records the progress of the ongoing run - the source directory and the file
+being worked on, the stage and the frames encoded. The checkpoint is saved to the disk
+as the run progresses and removed once the run ends, a checkpoint left on the disk
+means the previous run died midway (crashed, was killed, or the system went down).
+*/
+type Checkpoint struct {
+	SourceDir   string    `json:"sourceDir,omitempty"`
+	File        string    `json:"file,omitempty"`
+	Stage       string    `json:"stage"`
+	Frames      int64     `json:"frames,omitempty"`
+	TotalFrames int64     `json:"totalFrames,omitempty"`
+	Updated     time.Time `json:"updated"`
+
+	// Path to the file containing the checkpoint, the checkpoint is not saved if blank
+	path string
+
+	// Checkpoint left by the previous run (if any), reported in the summary
+	previous *Checkpoint
+
+	// Time of the last save, progress is saved at most once every interval
+	saved time.Time
+
+	// Progress is recorded from the goroutine tracking the encode
+	mutex sync.Mutex
+}
+
+// Checkpoint for the ongoing run, nil if the progress is not being recorded
+var runCheckpoint *Checkpoint
+
+/*
+NewCheckpoint creates the checkpoint for a run saved to the path supplied, along with
+the checkpoint left by the previous run (if any) - reported once the run completes.
+*/
+func NewCheckpoint(path string, previous *Checkpoint) *Checkpoint {
+	return &Checkpoint{
+		Stage:    stageIdle,
+		path:     path,
+		previous: previous,
+	}
+}
+
+/*
+LoadCheckpoint reads the checkpoint left by an earlier run, returns `ErrNoCheckpoint`
+if the checkpoint does not exist.
+*/
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	if path == "" {
+		return nil, ErrNoCheckpoint
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoCheckpoint
+	} else if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{}
+	if err = json.Unmarshal(content, checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+/*
+UseCheckpoint sets the checkpoint used to record the next run, a nil checkpoint
+disables recording
+*/
+func UseCheckpoint(checkpoint *Checkpoint) {
+	runCheckpoint = checkpoint
+}
+
+/*
+Remove deletes the saved checkpoint, used once the run ends - a missing checkpoint is
+not treated as an error.
+*/
+func (checkpoint *Checkpoint) Remove() error {
+	if checkpoint == nil || checkpoint.path == "" {
+		return nil
+	}
+
+	if err := os.Remove(checkpoint.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+/*
+String describes the point the run was at, i.e. `encoding "movie.mkv" at frame 1200 of
+4800 (25%) in "/path/to/source", last updated Jan 2 15:04:05`
+*/
+func (checkpoint *Checkpoint) String() string {
+	var res string
+	switch {
+	case checkpoint.Stage == stageEncoding && checkpoint.TotalFrames > 0:
+		res = fmt.Sprintf(
+			"encoding \"%s\" at frame %d of %d (%d%%) in \"%s\"",
+			filepath.Base(checkpoint.File),
+			checkpoint.Frames,
+			checkpoint.TotalFrames,
+			checkpoint.Frames*100/checkpoint.TotalFrames,
+			checkpoint.SourceDir,
+		)
+
+	case checkpoint.Stage == stageEncoding:
+		res = fmt.Sprintf(
+			"encoding \"%s\" at frame %d in \"%s\"",
+			filepath.Base(checkpoint.File),
+			checkpoint.Frames,
+			checkpoint.SourceDir,
+		)
+
+	case checkpoint.Stage == stagePreparing:
+		res = fmt.Sprintf("preparing \"%s\"", checkpoint.SourceDir)
+
+	default:
+		res = "between source directories"
+	}
+
+	if checkpoint.Updated.IsZero() {
+		return res
+	}
+
+	return fmt.Sprintf("%s, last updated %s", res, checkpoint.Updated.Format(
+		"Jan 2 15:04:05",
+	))
+}
+
+/*
+Crashed returns the checkpoint left by the previous run if it died midway, nil
+otherwise
+*/
+func (checkpoint *Checkpoint) crashed() *Checkpoint {
+	if checkpoint == nil {
+		return nil
+	}
+
+	return checkpoint.previous
+}
+
+/*
+Begin records the source directory being worked on, a blank source directory marks
+the run as idle - i.e. in between two source directories.
+*/
+func (checkpoint *Checkpoint) begin(sourceDir string) {
+	if checkpoint == nil {
+		return
+	}
+
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+
+	checkpoint.SourceDir, checkpoint.File = sourceDir, ""
+	checkpoint.Frames, checkpoint.TotalFrames = 0, 0
+
+	checkpoint.Stage = stagePreparing
+	if sourceDir == "" {
+		checkpoint.Stage = stageIdle
+	}
+
+	checkpoint.save(timeNow())
+}
+
+/*
+Encoding records the media file being encoded, the progress of the encode is recorded
+through `progress()`
+*/
+func (checkpoint *Checkpoint) encoding(file string, totalFrames int64) {
+	if checkpoint == nil {
+		return
+	}
+
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+
+	checkpoint.File, checkpoint.Stage = file, stageEncoding
+	checkpoint.Frames, checkpoint.TotalFrames = 0, totalFrames
+	checkpoint.save(timeNow())
+}
+
+/*
+Progress records the frames encoded so far, the checkpoint is saved only if enough
+time has passed since the last save
+*/
+func (checkpoint *Checkpoint) progress(frames int64, now time.Time) {
+	if checkpoint == nil {
+		return
+	}
+
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+
+	checkpoint.Frames = frames
+	if now.Sub(checkpoint.saved) >= checkpointInterval {
+		checkpoint.save(now)
+	}
+}
+
+/*
+Save writes the checkpoint to the disk, replacing the checkpoint saved earlier - same
+as the queue, the checkpoint is written to a temporary file first. Failures are logged
+without affecting the run. The caller is expected to hold the lock.
+*/
+func (checkpoint *Checkpoint) save(now time.Time) {
+	if checkpoint.path == "" {
+		return
+	}
+
+	checkpoint.Updated, checkpoint.saved = now, now
+	content, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(checkpoint.path), 0755)
+	}
+
+	if err == nil {
+		temp := checkpoint.path + ".tmp"
+		if err = ioutil.WriteFile(temp, content, 0644); err == nil {
+			err = os.Rename(temp, checkpoint.path)
+		}
+	}
+
+	if err != nil {
+		log.Debugf(
+			"(checkpoint/save) failed to save checkpoint to \"%s\" \nerror: %v",
+			checkpoint.path,
+			err,
+		)
+	}
+}
@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(checkpoint/Checkpoint) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Missing checkpoint indicates the previous run did not die midway
+	path := filepath.Join(dir, "nested", "progress.json")
+	if _, err = LoadCheckpoint(path); err != ErrNoCheckpoint {
+		t.Errorf(
+			"(checkpoint/LoadCheckpoint) expected `ErrNoCheckpoint` \nfound: %v",
+			err,
+		)
+	}
+
+	checkpoint := NewCheckpoint(path, nil)
+	checkpoint.begin("/root/dir 01")
+	checkpoint.encoding("/root/dir 01/movie.mkv", 4800)
+
+	// Progress is saved only once enough time has passed since the last save
+	checkpoint.progress(600, checkpoint.saved.Add(time.Second))
+	if loaded, _ := LoadCheckpoint(path); loaded == nil || loaded.Frames != 0 {
+		t.Errorf("(checkpoint/progress) progress saved too soon \n%+v", loaded)
+	}
+
+	checkpoint.progress(1200, checkpoint.saved.Add(checkpointInterval))
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("(checkpoint/LoadCheckpoint) failed to load checkpoint: %v", err)
+	}
+
+	expected := `encoding "movie.mkv" at frame 1200 of 4800 (25%) in "/root/dir 01"`
+	if res := loaded.String(); !strings.HasPrefix(res, expected) {
+		t.Errorf(
+			"(checkpoint/String) unexpected description \nexpected: %s \nresult: %s",
+			expected,
+			res,
+		)
+	}
+
+	// Checkpoint left behind is reported by the next run
+	if NewCheckpoint(path, loaded).crashed() != loaded {
+		t.Errorf("(checkpoint/crashed) checkpoint from previous run not reported")
+	}
+
+	checkpoint.begin("")
+	if loaded, _ = LoadCheckpoint(path); loaded == nil || loaded.Stage != stageIdle ||
+		!strings.HasPrefix(loaded.String(), "between source directories") {
+		t.Errorf("(checkpoint/begin) run not marked as idle \n%+v", loaded)
+	}
+
+	if err = checkpoint.Remove(); err != nil {
+		t.Errorf("(checkpoint/Remove) failed to remove checkpoint: %v", err)
+	}
+
+	if _, err = LoadCheckpoint(path); err != ErrNoCheckpoint {
+		t.Errorf("(checkpoint/Remove) checkpoint present after removal: %v", err)
+	}
+
+	// Runs without a checkpoint are left as is
+	var missing *Checkpoint
+	missing.begin("/root/dir 01")
+	missing.progress(1200, time.Now())
+	if missing.crashed() != nil || missing.Remove() != nil {
+		t.Errorf("(checkpoint/Checkpoint) missing checkpoint not ignored")
+	}
+}
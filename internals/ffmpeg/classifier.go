@@ -0,0 +1,215 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Answers a classifier can give for a file, mapped to the category for the file
+var classifierAnswers = map[string]int{
+	"media":      categoryMedia,
+	"subtitle":   categorySubtitle,
+	"attachment": categoryAttachment,
+	"chapter":    categoryChapter,
+	"ignore":     categoryIgnored,
+}
+
+// Answer leaving the file to the built-in rules, same as a blank answer
+const classifierAuto = "auto"
+
+// Time a classifier gets to answer for a file, the classifier is stopped otherwise
+var classifierTimeout = 10 * time.Second
+
+// Time a classifier gets to exit once its input is closed, before it is killed
+const classifierExitTimeout = 2 * time.Second
+
+/*
+Classifier is a custom classifier supplied through `--classifier`, run as a single
+process for the entire run. Paths to the files are written to the standard input of
+the process one per line, the process answers with the category for each file on a
+line of its own, in the same order.
+*/
+type classifier struct {
+	path    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	answers chan string
+	stderr  bytes.Buffer
+	failed  bool
+}
+
+// Classifier for the ongoing run, started when the first file is classified
+var runClassifier *classifier
+
+/*
+CustomCategory asks the classifier supplied by the user (if any) for the category of a
+file, the boolean returned is false if the file is left to the built-in rules. Failing
+classifiers are stopped with a warning in the summary, the built-in rules are used for
+the rest of the run.
+*/
+func customCategory(
+	sourceDir, name string,
+	userInput *commons.UserInput,
+) (category int, ok bool) {
+	if userInput.Classifier == "" {
+		return categoryIgnored, false
+	}
+
+	if runClassifier == nil || runClassifier.path != userInput.Classifier {
+		stopClassifier()
+		runClassifier = startClassifier(userInput.Classifier)
+	}
+
+	path := filepath.Join(sourceDir, name)
+	if runClassifier.failed || strings.ContainsAny(path, "\r\n") {
+		// Paths with line breaks can't be written as a single line
+		return categoryIgnored, false
+	}
+
+	answer, err := runClassifier.classify(path)
+	if err != nil {
+		runClassifier.fail(err)
+		return categoryIgnored, false
+	}
+
+	if answer == "" || answer == classifierAuto {
+		return categoryIgnored, false
+	}
+
+	category, known := classifierAnswers[answer]
+	if !known {
+		runClassifier.fail(fmt.Errorf("unknown category %q for %q", answer, path))
+		return categoryIgnored, false
+	}
+
+	log.Debugf(`(classifier/customCategory) "%s" classified as %q`, path, answer)
+	return category, true
+}
+
+/*
+StartClassifier starts the classifier process, classifiers that fail to start are
+marked as failed - the built-in rules are used instead.
+*/
+func startClassifier(path string) *classifier {
+	res := &classifier{path: path, answers: make(chan string)}
+	res.cmd = exec.Command(path)
+	res.cmd.Stderr = &res.stderr
+
+	stdout, err := res.cmd.StdoutPipe()
+	if err == nil {
+		res.stdin, err = res.cmd.StdinPipe()
+	}
+
+	if err == nil {
+		err = res.cmd.Start()
+	}
+
+	if err != nil {
+		res.fail(err)
+		return res
+	}
+
+	log.Debugf(`(classifier/startClassifier) started classifier: "%s"`, path)
+
+	go func() {
+		defer close(res.answers)
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			res.answers <- strings.ToLower(strings.TrimSpace(scanner.Text()))
+		}
+	}()
+
+	return res
+}
+
+// Classify writes the path to the classifier, and waits for the answer
+func (c *classifier) classify(path string) (string, error) {
+	if _, err := io.WriteString(c.stdin, path+"\n"); err != nil {
+		return "", err
+	}
+
+	timer := time.NewTimer(classifierTimeout)
+	defer timer.Stop()
+
+	select {
+	case answer, open := <-c.answers:
+		if !open {
+			return "", errors.New("classifier exited")
+		}
+
+		return answer, nil
+
+	case <-timer.C:
+		return "", fmt.Errorf("no answer for %q in %v", path, classifierTimeout)
+	}
+}
+
+/*
+Fail stops the classifier, adding a warning to the summary - the built-in rules are
+used for the remaining files.
+*/
+func (c *classifier) fail(err error) {
+	c.failed = true
+	c.stop()
+
+	if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+		err = fmt.Errorf("%v \n%s", err, msg)
+	}
+
+	runSummary.warn(
+		`classifier "%s" failed, using the built-in rules instead: %v`,
+		c.path,
+		err,
+	)
+}
+
+/*
+Stop closes the input of the classifier allowing it to exit, the process is killed if
+it does not exit in time.
+*/
+func (c *classifier) stop() {
+	if c.cmd == nil || c.cmd.Process == nil || c.stdin == nil {
+		return
+	}
+
+	_ = c.stdin.Close()
+	c.stdin = nil
+
+	// Answers no longer read are drained, allowing the process to exit
+	go func(answers chan string) {
+		for range answers {
+		}
+	}(c.answers)
+
+	done := make(chan error, 1)
+	go func() { done <- c.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		log.Debugf("(classifier/stop) classifier exited \nerror: %v", err)
+
+	case <-time.After(classifierExitTimeout):
+		log.Debugf("(classifier/stop) classifier did not exit, killing it")
+		_ = c.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// StopClassifier stops the classifier for the run (if any), once the run completes
+func stopClassifier() {
+	if runClassifier != nil {
+		runClassifier.stop()
+		runClassifier = nil
+	}
+}
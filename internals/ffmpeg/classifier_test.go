@@ -0,0 +1,82 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+// Classifier treating lyrics as subtitles and leaving out notes, the category for any
+// other file is decided by the name of the file
+const classifierScript = `#!/bin/sh
+while read -r path; do
+	case "$path" in
+		*.txt) echo subtitle ;;
+		*.nfo) echo IGNORE ;;
+		*.bad) echo unknown ;;
+		*) echo auto ;;
+	esac
+done
+`
+
+func TestCustomCategory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skipf("(classifier/customCategory) shell scripts can't be run")
+	}
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(classifier/customCategory) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer stopClassifier()
+
+	script := filepath.Join(dir, "classify.sh")
+	_ = ioutil.WriteFile(script, []byte(classifierScript), 0755)
+
+	source := filepath.Join(dir, "Movie")
+	_ = os.Mkdir(source, 0755)
+	for _, name := range []string{"Movie.mkv", "Lyrics.txt", "English.srt", "x.nfo"} {
+		_ = ioutil.WriteFile(filepath.Join(source, name), []byte{}, 0644)
+	}
+
+	// Files are grouped through the classifier, the rest through their extensions
+	runSummary = &Summary{}
+	input := &commons.UserInput{Classifier: script}
+	mediaFiles, subtitles, _, _ := groupFiles(source, input)
+	if len(mediaFiles) != 1 || len(subtitles) != 2 || len(runSummary.Warnings) != 0 {
+		t.Errorf(
+			"(classifier/groupFiles) unexpected groups \nmedia: %d \nsubs: %d"+
+				"\nwarnings: %v",
+			len(mediaFiles),
+			len(subtitles),
+			runSummary.Warnings,
+		)
+	}
+
+	file := fileInfo(t, filepath.Join(source, "x.nfo"))
+	if _, reason := classifyFile(source, file, input, true); reason !=
+		"ignored by classifier" {
+		t.Errorf("(classifier/classifyFile) unexpected reason: %q", reason)
+	}
+
+	// Unknown answers stop the classifier, the built-in rules are used for the rest
+	_ = ioutil.WriteFile(filepath.Join(source, "A.bad"), []byte{}, 0644)
+	_, subtitles, _, _ = groupFiles(source, input)
+	if len(subtitles) != 1 || len(runSummary.Warnings) != 1 || !runClassifier.failed {
+		t.Errorf("(classifier/groupFiles) failing classifier not stopped")
+	}
+
+	// Classifiers that can't be started are reported the same way
+	runSummary = &Summary{}
+	input.Classifier = filepath.Join(dir, "missing")
+	if _, ok := customCategory(source, "Lyrics.txt", input); ok ||
+		len(runSummary.Warnings) != 1 {
+		t.Errorf("(classifier/customCategory) missing classifier not reported")
+	}
+}
@@ -0,0 +1,113 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Codec used to copy a subtitle stream as is
+const codecCopy = "copy"
+
+/*
+Subtitle formats the output container (matroska) can't hold as is, mapped to the codec
+the subtitles are converted into - by the extension of the subtitle file. Subtitles in
+any other format are copied over as is.
+
+WebVTT and SAMI are converted to SubRip, the closest format supported by matroska that
+most players recognize - styling is lost, while the text and the timing are retained.
+Image-based subtitles (PGS) can't be converted, and are supported by matroska anyway.
+*/
+var subsCodecs = map[string]string{
+	"vtt": "srt",
+	"smi": "srt",
+}
+
+// Image-based subtitle formats, these can't be converted into text subtitles
+var imageSubsExt = []string{
+	"sup",
+	"pgs",
+}
+
+/*
+SubtitleCodec decides the codec for the stream of a subtitle file in the output, i.e.
+the codec the subtitles are converted into - or `copy` if the subtitles can be copied
+over as is. The codec forced by the user (if any) is used for every subtitle file, the
+choice is expected to be checked through `checkSubtitleCodecs` already.
+*/
+func subtitleCodec(sub os.FileInfo, forced string) string {
+	if forced != "" && forced != commons.SubCodecAuto {
+		return forced
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(sub.Name()), "."))
+	if codec, ok := subsCodecs[ext]; ok {
+		return codec
+	}
+
+	return codecCopy
+}
+
+/*
+CheckSubtitleCodecs ensures the codec forced by the user can be used for every subtitle
+file to be merged into a media file, returning the exit code for the failure (if any) -
+image-based subtitles can't be converted into text subtitles, and formats matroska
+can't hold can't be copied as is. Subtitle containers are always copied as is.
+*/
+func checkSubtitleCodecs(
+	sourceDir string,
+	input *commons.UserInput,
+	subtitles []os.FileInfo,
+) int {
+	forced := input.SubtitleCodec
+	if forced == "" || forced == commons.SubCodecAuto {
+		return commons.StatusOK
+	}
+
+	for _, sub := range subtitles {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(sub.Name()), "."))
+
+		var err error
+		switch {
+		case checkExt(sub.Name(), subsContainerExt):
+			continue
+
+		case forced == codecCopy && subsCodecs[ext] != "":
+			err = fmt.Errorf(
+				"%s subtitles can't be stored in matroska as is - use "+
+					"`--subtitle-codec %s`, or `auto` to convert them as required",
+				strings.ToUpper(ext),
+				subsCodecs[ext],
+			)
+
+		case forced != codecCopy && checkExt(sub.Name(), imageSubsExt):
+			err = fmt.Errorf(
+				"image-based subtitles (%s) can't be converted to %s - use "+
+					"`--subtitle-codec copy`, or `auto` to copy them as is",
+				strings.ToUpper(ext),
+				forced,
+			)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		path := joinPath(sourceDir, sub.Name())
+		log.Debugf(
+			"(codec/checkSubtitleCodecs) codec %s unusable for \"%s\" \nerror: %v",
+			forced,
+			path,
+			err,
+		)
+
+		commons.Error("%v \n\tPath: \"%s\"\n\n", err, path)
+		return commons.SubCodecError
+	}
+
+	return commons.StatusOK
+}
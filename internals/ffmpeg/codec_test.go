@@ -0,0 +1,134 @@
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestSubtitleCodec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(codec/subtitleCodec) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+
+	// Existing subtitle stream in the media file, subtitle files follow it
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{subtitles: 1}
+	})
+
+	stat := func(name string) os.FileInfo {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(codec/subtitleCodec) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		return info
+	}
+
+	subs := []os.FileInfo{
+		stat("English.srt"),
+		stat("Signs.ASS"),
+		stat("Web.vtt"),
+		stat("Korean.smi"),
+		stat("Forced.sup"),
+	}
+
+	expected := []string{codecCopy, codecCopy, "srt", "srt", codecCopy}
+	for i, sub := range subs {
+		if codec := subtitleCodec(sub, commons.SubCodecAuto); codec != expected[i] {
+			t.Errorf(
+				"(codec/subtitleCodec) unexpected codec for %q"+
+					"\nexpected: %s \nfound: %s",
+				sub.Name(),
+				expected[i],
+				codec,
+			)
+		}
+	}
+
+	args := strings.Join(generateCmd(
+		context.Background(),
+		dir,
+		&commons.UserInput{},
+		filepath.Join(dir, "out.mkv"),
+		stat("video.mkv"),
+		nil,
+		subs,
+		nil,
+		nil,
+	).Args, " ")
+
+	// Streams in the output are offset by the existing subtitle stream
+	for _, arg := range []string{
+		"-c copy",
+		"-c:s:1 copy",
+		"-c:s:3 srt",
+		"-c:s:4 srt",
+		"-c:s:5 copy",
+	} {
+		if !strings.Contains(args, arg) {
+			t.Errorf("(codec/generateCmd) missing `%s` \ncommand: %s", arg, args)
+		}
+	}
+
+	// Codecs forced by the user are used for every subtitle file
+	if codec := subtitleCodec(subs[0], commons.SubCodecAss); codec != "ass" {
+		t.Errorf("(codec/subtitleCodec) forced codec not used: %s", codec)
+	}
+}
+
+func TestCheckSubtitleCodecs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(codec/checkSubtitleCodecs) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var subs []os.FileInfo
+	for _, name := range []string{"English.srt", "Web.vtt", "Forced.sup", "All.mks"} {
+		path := filepath.Join(dir, name)
+		_ = ioutil.WriteFile(path, nil, 0644)
+
+		info, _ := os.Stat(path)
+		subs = append(subs, info)
+	}
+
+	for _, in := range []struct {
+		codec string
+		subs  []os.FileInfo
+		code  int
+	}{
+		{commons.SubCodecAuto, subs, commons.StatusOK},
+		{commons.SubCodecCopy, []os.FileInfo{subs[0], subs[2]}, commons.StatusOK},
+		{commons.SubCodecCopy, subs, commons.SubCodecError},
+		{commons.SubCodecSrt, []os.FileInfo{subs[0], subs[1]}, commons.StatusOK},
+		{commons.SubCodecAss, subs, commons.SubCodecError},
+
+		// Subtitle containers are always copied as is
+		{commons.SubCodecSrt, []os.FileInfo{subs[3]}, commons.StatusOK},
+	} {
+		input := &commons.UserInput{SubtitleCodec: in.codec}
+		if code := checkSubtitleCodecs(dir, input, in.subs); code != in.code {
+			t.Errorf(
+				"(codec/checkSubtitleCodecs) unexpected code for %s \nexpected: %d "+
+					"\nfound: %d",
+				in.codec,
+				in.code,
+				code,
+			)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package ffmpeg
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Output names adjusted to avoid collisions across source directories, mapping the path
+of an output (as it would be without the adjustment) to the name to be used instead.
+Reset every time the outputs for a run are planned.
+*/
+var outputPlan = map[string]string{}
+
+/*
+PlanOutputs computes the outputs for each source directory before the run starts, and
+handles outputs with the same name across source directories (irrespective of case) as
+per the collision policy;
+  - error: the run fails before processing anything, listing the collisions
+  - suffix: outputs are suffixed as per `--collision-suffix`, see `suffixOutputs()`
+  - mirror: outputs are stored in a directory named after their source directory
+
+The boolean returned indicates if the mirrored layout is to be used.
+*/
+func planOutputs(
+	input *commons.UserInput,
+	resDir string,
+	items []os.FileInfo,
+) (mirror bool, err error) {
+	outputPlan = map[string]string{}
+
+	// Source directories for each output name, in the order of traversal
+	owners := make(map[string][]plannedOutput)
+	var order []string
+
+	for _, item := range items {
+		sourcePath := filepath.Join(input.RootPath, item.Name())
+		if !item.IsDir() || isResultDir(sourcePath, resDir) ||
+			input.IgnoreDir(sourcePath) {
+			continue
+		}
+
+		for _, name := range plannedOutputs(sourcePath, input) {
+			key := strings.ToLower(name)
+			if _, ok := owners[key]; !ok {
+				order = append(order, key)
+			}
+
+			owners[key] = append(owners[key], plannedOutput{sourcePath, name})
+		}
+	}
+
+	var collisions []string
+	for _, key := range order {
+		if len(owners[key]) > 1 {
+			collisions = append(collisions, key)
+		}
+	}
+
+	if len(collisions) == 0 {
+		return false, nil
+	}
+
+	log.Debugf(
+		"(collision/planOutputs) output names collide across source directories"+
+			"\ncollisions: %v \npolicy: %s",
+		collisions,
+		input.OnCollision,
+	)
+
+	switch input.OnCollision {
+	case commons.CollisionMirror:
+		return true, nil
+
+	case commons.CollisionSuffix:
+		for _, key := range collisions {
+			suffixOutputs(input, owners[key], owners)
+		}
+
+		return false, nil
+
+	default:
+		msg := "output names collide across source directories, use " +
+			"`--on-collision` to resolve them automatically"
+
+		for _, key := range collisions {
+			dirs := make([]string, 0, len(owners[key]))
+			for _, out := range owners[key] {
+				dirs = append(dirs, fmt.Sprintf("%q", filepath.Base(out.sourceDir)))
+			}
+
+			msg += fmt.Sprintf(
+				"\n\t\"%s\" from %s",
+				owners[key][0].name,
+				strings.Join(dirs, ", "),
+			)
+		}
+
+		return false, fmt.Errorf("%s", msg)
+	}
+}
+
+/*
+PlannedOutput is an output to be created from a source directory, identified by name
+*/
+type plannedOutput struct {
+	sourceDir string
+	name      string
+}
+
+/*
+SuffixOutputs adjusts the names of outputs colliding with each other as per the suffix
+strategy supplied;
+  - counter: outputs from the later source directories get a numbered suffix, i.e.
+    `name (2).mkv` - the first output keeps its name
+  - dir: each output is suffixed with the name of its source directory, i.e.
+    `name [Show B].mkv`
+  - hash: each output is suffixed with a short hash of the path to its source
+    directory (relative to the root directory), i.e. `name [3f2a9c1d].mkv`
+
+Suffixes from the directory or the hash remain the same across runs, irrespective of
+the source directories added (or removed) since - keeping `--skip-existing` useful. A
+numbered suffix is used if the suffixed name is taken already.
+*/
+func suffixOutputs(
+	input *commons.UserInput,
+	colliding []plannedOutput,
+	owners map[string][]plannedOutput,
+) {
+	next := 2
+	numbered := func(out plannedOutput) string {
+		ext := filepath.Ext(out.name)
+		name := ""
+
+		// Pick the first suffix not used by any other output
+		for ; name == "" || owners[strings.ToLower(name)] != nil; next++ {
+			name = fmt.Sprintf(
+				"%s (%d)%s",
+				strings.TrimSuffix(out.name, ext),
+				next,
+				ext,
+			)
+		}
+
+		return name
+	}
+
+	if input.CollisionSuffix == "" || input.CollisionSuffix == commons.SuffixCounter {
+		for _, out := range colliding[1:] {
+			outputPlan[joinPath(out.sourceDir, out.name)] = numbered(out)
+		}
+
+		return
+	}
+
+	for _, out := range colliding {
+		suffix := filepath.Base(out.sourceDir)
+		if input.CollisionSuffix == commons.SuffixHash {
+			rel, err := filepath.Rel(input.RootPath, out.sourceDir)
+			if err != nil {
+				rel = out.sourceDir
+			}
+
+			sum := sha1.Sum([]byte(filepath.ToSlash(rel)))
+			suffix = hex.EncodeToString(sum[:])[:8]
+		}
+
+		ext := filepath.Ext(out.name)
+		name := fmt.Sprintf("%s [%s]%s", strings.TrimSuffix(out.name, ext), suffix, ext)
+		if owners[strings.ToLower(name)] != nil {
+			name = numbered(out)
+		}
+
+		// Reserved, ensures the suffixed names can't collide with each other
+		owners[strings.ToLower(name)] = []plannedOutput{out}
+		outputPlan[joinPath(out.sourceDir, out.name)] = name
+	}
+}
+
+/*
+PlannedOutputs returns the names of the outputs to be created for a source directory,
+the same way as they are determined while processing the source directory. Source
+directories that will fail to be processed have no outputs.
+*/
+func plannedOutputs(sourceDir string, input *commons.UserInput) []string {
+	mediaFiles, _, _, _ := groupFiles(sourceDir, input)
+
+	if input.DonorRule != nil {
+		raw, _, err := donorFiles(mediaFiles, input)
+		if err != nil {
+			return nil
+		}
+
+		return []string{outputName(raw.Name(), input)}
+	}
+
+	if len(mediaFiles) == 0 || (len(mediaFiles) > 1 && !input.AllMedia) {
+		return nil
+	}
+
+	return outputNames(mediaFiles, input)
+}
+
+/*
+PlannedName returns the name to be used for an output of a source directory - the
+name is adjusted if it collides with an output from another source directory.
+*/
+func plannedName(sourceDir, name string) string {
+	if planned, ok := outputPlan[joinPath(sourceDir, name)]; ok {
+		return planned
+	}
+
+	return name
+}
@@ -0,0 +1,135 @@
+package ffmpeg
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestPlanOutputs(t *testing.T) {
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(collision/planOutputs) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{
+		filepath.Join("Show A", "episode.mkv"),
+		filepath.Join("Show B", "Episode.mp4"),
+		filepath.Join("Show C", "episode.mkv"),
+		filepath.Join("Show D", "unique.mkv"),
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(collision/planOutputs) failed to create directory: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(collision/planOutputs) failed to create file: %v", err)
+		}
+	}
+
+	items, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatalf("(collision/planOutputs) failed to read directory: %v", err)
+	}
+
+	defer func() {
+		outputPlan = map[string]string{}
+	}()
+
+	resDir := filepath.Join(root, "output")
+	input := &commons.UserInput{RootPath: root, OnCollision: commons.CollisionError}
+
+	// Collisions are listed by default, irrespective of case
+	if _, err := planOutputs(input, resDir, items); err == nil ||
+		!strings.Contains(err.Error(), `"Show A", "Show B", "Show C"`) {
+		t.Errorf("(collision/planOutputs) expected collisions to be listed: %v", err)
+	}
+
+	input.OnCollision = commons.CollisionMirror
+	if mirror, err := planOutputs(input, resDir, items); !mirror || err != nil {
+		t.Errorf("(collision/planOutputs) expected mirrored layout \nerror: %v", err)
+	}
+
+	input.OnCollision = commons.CollisionSuffix
+	if mirror, err := planOutputs(input, resDir, items); mirror || err != nil {
+		t.Errorf("(collision/planOutputs) unexpected result \nerror: %v", err)
+	}
+
+	for dir, expected := range map[string]string{
+		"Show A": "episode.mkv",
+		"Show B": "Episode (2).mkv",
+		"Show C": "episode (3).mkv",
+	} {
+		name := "episode.mkv"
+		if dir == "Show B" {
+			name = "Episode.mkv"
+		}
+
+		if res := plannedName(filepath.Join(root, dir), name); res != expected {
+			t.Errorf(
+				"(collision/plannedName) unexpected name for %q \nexpected: %q "+
+					"\nfound: %q",
+				dir,
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Suffixes from the source directory (or its path) are added to every output
+	hash := func(dir string) string {
+		sum := sha1.Sum([]byte(dir))
+		return hex.EncodeToString(sum[:])[:8]
+	}
+
+	for strategy, expected := range map[string]map[string]string{
+		commons.SuffixDir: {
+			"Show A": "episode [Show A].mkv",
+			"Show B": "Episode [Show B].mkv",
+			"Show C": "episode [Show C].mkv",
+		},
+		commons.SuffixHash: {
+			"Show A": "episode [" + hash("Show A") + "].mkv",
+			"Show B": "Episode [" + hash("Show B") + "].mkv",
+			"Show C": "episode [" + hash("Show C") + "].mkv",
+		},
+	} {
+		input.CollisionSuffix = strategy
+		if _, err := planOutputs(input, resDir, items); err != nil {
+			t.Errorf("(collision/planOutputs) unexpected error: %v", err)
+		}
+
+		for dir, name := range expected {
+			original := "episode.mkv"
+			if dir == "Show B" {
+				original = "Episode.mkv"
+			}
+
+			if res := plannedName(filepath.Join(root, dir), original); res != name {
+				t.Errorf(
+					"(collision/suffixOutputs) unexpected name for %q with `%s`"+
+						"\nexpected: %q \nfound: %q",
+					dir,
+					strategy,
+					name,
+					res,
+				)
+			}
+		}
+	}
+
+	// Without collisions, names are left as is
+	if res := plannedName(filepath.Join(root, "Show D"), "unique.mkv"); res !=
+		"unique.mkv" {
+		t.Errorf("(collision/plannedName) unexpected name: %q", res)
+	}
+}
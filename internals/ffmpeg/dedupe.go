@@ -0,0 +1,97 @@
+package ffmpeg
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+DuplicateFile is a subtitle file left out for having the same contents as another
+subtitle file in the source directory
+*/
+type duplicateFile struct {
+	file     os.FileInfo
+	original os.FileInfo
+}
+
+/*
+DedupeSubtitles leaves out subtitle files with the same contents as a subtitle file
+earlier in the list - directories at times carry the same subtitle twice under
+different names, attaching both would only clutter the output with redundant tracks.
+
+Contents are only hashed for files sharing their size with another file, files that
+can't be read are kept as is. Returns the subtitle files to be kept (in order), along
+with the duplicates left out.
+*/
+func dedupeSubtitles(
+	sourceDir string,
+	subtitles []os.FileInfo,
+) (kept []os.FileInfo, duplicates []duplicateFile) {
+	if len(subtitles) < 2 {
+		return subtitles, nil
+	}
+
+	sizes := make(map[int64]int)
+	for _, sub := range subtitles {
+		sizes[sub.Size()]++
+	}
+
+	// First subtitle file with each hash, the ones following are duplicates
+	originals := make(map[[sha256.Size]byte]os.FileInfo)
+
+	for _, sub := range subtitles {
+		if sizes[sub.Size()] < 2 {
+			kept = append(kept, sub)
+			continue
+		}
+
+		sum, err := hashFile(joinPath(sourceDir, sub.Name()))
+		if err != nil {
+			log.Debugf(
+				"(dedupe/dedupeSubtitles) failed to hash \"%s\" \nerror: %v",
+				sub.Name(),
+				err,
+			)
+
+			kept = append(kept, sub)
+			continue
+		}
+
+		if original, ok := originals[sum]; ok {
+			log.Debugf(
+				`(dedupe/dedupeSubtitles) "%s" is a duplicate of "%s"`,
+				sub.Name(),
+				original.Name(),
+			)
+
+			duplicates = append(duplicates, duplicateFile{sub, original})
+			continue
+		}
+
+		originals[sum] = sub
+		kept = append(kept, sub)
+	}
+
+	return kept, duplicates
+}
+
+// HashFile returns the SHA-256 hash of the contents of a file
+func hashFile(path string) (sum [sha256.Size]byte, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return sum, err
+	}
+
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
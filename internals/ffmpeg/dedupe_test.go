@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDedupeSubtitles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(dedupe/dedupeSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := []struct {
+		name     string
+		contents string
+	}{
+		{"English.srt", "1\n00:00:01,000 --> 00:00:02,000\nHello\n"},
+		{"Spanish.srt", "1\n00:00:01,000 --> 00:00:02,000\nHola!\n"}, // same size
+		{"English (copy).srt", "1\n00:00:01,000 --> 00:00:02,000\nHello\n"},
+		{"Signs.ass", "[Script Info]\n"},
+		{
+			filepath.Join("Subs", "English.srt"),
+			"1\n00:00:01,000 --> 00:00:02,000\nHello\n",
+		},
+	}
+
+	var subtitles []os.FileInfo
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, []byte(file.contents), 0644); err != nil {
+			t.Fatalf("(dedupe/dedupeSubtitles) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		subtitles = append(subtitles, nestedFile{info, file.name})
+	}
+
+	kept, duplicates := dedupeSubtitles(dir, subtitles)
+	if len(kept) != 3 || kept[0].Name() != "English.srt" ||
+		kept[1].Name() != "Spanish.srt" || kept[2].Name() != "Signs.ass" {
+		t.Errorf("(dedupe/dedupeSubtitles) unexpected files kept: %v", kept)
+	}
+
+	if len(duplicates) != 2 || duplicates[0].file.Name() != "English (copy).srt" ||
+		duplicates[1].original.Name() != "English.srt" {
+		t.Errorf("(dedupe/dedupeSubtitles) unexpected duplicates: %+v", duplicates)
+	}
+
+	// Duplicates are listed in the summary
+	summary := &Summary{}
+	summary.duplicate(filepath.Join(dir, "English (copy).srt"), "English.srt")
+	if res := summary.String(); !strings.Contains(res, "Duplicate Subtitles:") ||
+		!strings.Contains(res, `(same as "English.srt")`) {
+		t.Errorf("(summary/duplicate) duplicate missing from summary \n%s", res)
+	}
+
+	// Single files are never hashed
+	if kept, duplicates = dedupeSubtitles(dir, subtitles[:1]); len(kept) != 1 ||
+		duplicates != nil {
+		t.Errorf("(dedupe/dedupeSubtitles) single subtitle file not kept")
+	}
+}
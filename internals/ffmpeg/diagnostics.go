@@ -0,0 +1,236 @@
+package ffmpeg
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Name of the directory (inside the output directory) storing diagnostic bundles
+	diagnosticsDir = "diagnostics"
+
+	// Number of lines from the end of the FFmpeg output kept in a diagnostic bundle
+	stderrTail = 200
+)
+
+/*
+WriteDiagnostics captures a diagnostic bundle for a failed FFmpeg command - the exact
+command, FFprobe output (in JSON) for each input, OS and FFmpeg versions, and the last
+few lines from the output of FFmpeg.
+
+The bundle is zipped into
+`<output>/diagnostics/<source-dir> - <media-file> (attempt <n>).zip`, keeping bundles
+for each media file (and each attempt) apart. Returns the path to the bundle, a bundle
+that could not be completed is removed.
+*/
+func writeDiagnostics(
+	ctx context.Context,
+	sourceDir, resDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	attempt int,
+	cmd *exec.Cmd,
+	stderr string,
+) (path string, err error) {
+	dir := joinPath(resDir, diagnosticsDir)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	media := filepath.Base(mediaFile.Name())
+	bundle := joinPath(dir, commons.SafeName(
+		fmt.Sprintf(
+			"%s - %s (attempt %d)",
+			filepath.Base(sourceDir),
+			strings.TrimSuffix(media, filepath.Ext(media)),
+			attempt+1,
+		),
+		".zip",
+	))
+
+	// Existing bundles are never overwritten in safe mode
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if input.Safe {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	file, err := os.OpenFile(bundle, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	// Incomplete bundles can't be opened, these are removed
+	defer func() {
+		_ = file.Close()
+		if err != nil {
+			_ = os.Remove(bundle)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+
+	entries := []struct {
+		name     string
+		contents string
+	}{
+		{"command.txt", quoteArgs(cmd.Args) + "\n"},
+		{"environment.txt", environment(ctx, input)},
+		{"stderr.txt", tailLines(stderr, stderrTail)},
+	}
+
+	for i, in := range cmdInputs(cmd.Args) {
+		entries = append(entries, struct {
+			name     string
+			contents string
+		}{
+			fmt.Sprintf("probe/%02d - %s.json", i, filepath.Base(in)),
+			probeJSON(ctx, input.FFprobePath, in),
+		})
+	}
+
+	for _, entry := range entries {
+		writer, err := archive.Create(entry.name)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err = writer.Write([]byte(entry.contents)); err != nil {
+			return "", err
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return "", err
+	}
+
+	log.Debugf(
+		`(diagnostics/writeDiagnostics) diagnostic bundle created: "%s"`,
+		bundle,
+	)
+
+	return bundle, nil
+}
+
+/*
+CmdInputs returns the paths passed as inputs (through `-i`) to an FFmpeg command
+*/
+func cmdInputs(args []string) (inputs []string) {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-i" {
+			inputs = append(inputs, args[i+1])
+		}
+	}
+
+	return inputs
+}
+
+/*
+QuoteArgs joins the arguments of a command, quoting the arguments as required - allows
+the command to be copied and run directly.
+*/
+func quoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'[]()&;|$") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+/*
+Environment describes the environment in which FFmpeg was run - the OS and the versions
+of the FFmpeg and FFprobe executables in use, along with the names of the variables set
+through `--env` (values are left out, these may be private).
+*/
+func environment(ctx context.Context, input *commons.UserInput) string {
+	variables := ""
+	if len(input.Env) > 0 {
+		names := make([]string, 0, len(input.Env))
+		for _, variable := range input.Env {
+			names = append(names, strings.SplitN(variable, "=", 2)[0])
+		}
+
+		variables = fmt.Sprintf("Variables: %s\n", strings.Join(names, ", "))
+	}
+
+	return fmt.Sprintf(
+		"OS: %s/%s\nFFmpeg: %s\nFFmpeg version: %s\nFFprobe: %s\nFFprobe version: %s\n",
+		runtime.GOOS,
+		runtime.GOARCH,
+		input.FFmpegPath,
+		execVersion(ctx, input.FFmpegPath),
+		input.FFprobePath,
+		execVersion(ctx, input.FFprobePath),
+	) + variables
+}
+
+/*
+ExecVersion returns the first line from the output of `<executable> -version`, or the
+error encountered while running the command.
+*/
+func execVersion(ctx context.Context, executable string) string {
+	output, err := commons.ToolCommand(ctx, executable, "-version").Output()
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+
+	return strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+}
+
+/*
+ProbeJSON returns the output of FFprobe (in JSON) for a file, containing the format
+and streams present in the file - or the error encountered while probing the file.
+*/
+func probeJSON(ctx context.Context, ffprobePath, path string) string {
+	// Command being fired:
+	// `ffprobe -v error -show_format -show_streams -of json <file>`
+	output, err := commons.ToolCommand(
+		ctx,
+		ffprobePath,
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		path,
+	).Output()
+
+	if err != nil {
+		return fmt.Sprintf(
+			"{\"error\": %s}\n",
+			strconv.Quote(fmt.Sprintf("failed to probe %q: %v", path, err)),
+		)
+	}
+
+	return string(output)
+}
+
+/*
+TailLines returns the last `count` lines of a string. FFmpeg uses carriage returns to
+redraw the progress line, these are treated as line breaks.
+*/
+func tailLines(in string, count int) string {
+	in = strings.ReplaceAll(in, "\r\n", "\n")
+	lines := strings.FieldsFunc(in, func(r rune) bool {
+		return r == '\n' || r == '\r'
+	})
+
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,186 @@
+package ffmpeg
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestTailLines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 250; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+
+	for in, expected := range map[string]string{
+		"first\nsecond\n":               "first\nsecond\n",
+		"frame=1\rframe=2\r\nerror\r\n": "frame=1\nframe=2\nerror\n",
+		strings.Join(lines, "\n"):       strings.Join(lines[50:], "\n") + "\n",
+	} {
+		if res := tailLines(in, stderrTail); res != expected {
+			t.Errorf(
+				"(diagnostics/tailLines) unexpected result \ninput: %q "+
+					"\nexpected: %q \nresult: %q",
+				in,
+				expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestQuoteArgs(t *testing.T) {
+	res := quoteArgs([]string{"ffmpeg", "-i", "/media/test 01/video.mkv", "-map", ""})
+	expected := `ffmpeg -i "/media/test 01/video.mkv" -map ""`
+
+	if res != expected {
+		t.Errorf(
+			"(diagnostics/quoteArgs) unexpected result \nexpected: %s \nresult: %s",
+			expected,
+			res,
+		)
+	}
+}
+
+func TestWriteDiagnostics(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	resDir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(diagnostics/writeDiagnostics) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(resDir)
+
+	// Skip running the executables
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&exec.Cmd{}),
+		"Output",
+		func(*exec.Cmd) ([]byte, error) { return []byte("test output\n"), nil },
+	)
+
+	cmd := exec.Command("ffmpeg", "-i", "video.mkv", "-i", "subs.ass", "out.mkv")
+	input := &commons.UserInput{FFmpegPath: "ffmpeg", FFprobePath: "ffprobe"}
+	path, err := writeDiagnostics(
+		context.Background(),
+		"/media/test 01",
+		resDir,
+		input,
+		nestedFile{name: "Season 1/Episode 01.mkv"},
+		1,
+		cmd,
+		"error: invalid data\n",
+	)
+
+	if err != nil {
+		t.Fatalf("(diagnostics/writeDiagnostics) unexpected error: %v", err)
+	}
+
+	// Bundles are named after the media file and the attempt
+	expected := filepath.Join(
+		resDir,
+		diagnosticsDir,
+		"test 01 - Episode 01 (attempt 2).zip",
+	)
+
+	if path != expected {
+		t.Errorf(
+			"(diagnostics/writeDiagnostics) unexpected path \nexpected: %s "+
+				"\nresult: %s",
+			expected,
+			path,
+		)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("(diagnostics/writeDiagnostics) failed to open bundle: %v", err)
+	}
+
+	defer archive.Close()
+
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+
+	contents := []string{
+		"command.txt",
+		"environment.txt",
+		"stderr.txt",
+		"probe/00 - video.mkv.json",
+		"probe/01 - subs.ass.json",
+	}
+
+	if !reflect.DeepEqual(names, contents) {
+		t.Errorf(
+			"(diagnostics/writeDiagnostics) unexpected bundle contents "+
+				"\nexpected: %v \nresult: %v",
+			contents,
+			names,
+		)
+	}
+
+	// Bundles that can't be completed are removed
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&zip.Writer{}),
+		"Close",
+		func(*zip.Writer) error { return errors.New("no space left on device") },
+	)
+
+	path, err = writeDiagnostics(
+		context.Background(),
+		"/media/test 01",
+		resDir,
+		input,
+		nestedFile{name: "Episode 02.mkv"},
+		0,
+		cmd,
+		"",
+	)
+
+	failed := filepath.Join(
+		resDir,
+		diagnosticsDir,
+		"test 01 - Episode 02 (attempt 1).zip",
+	)
+
+	if _, statErr := os.Stat(failed); err == nil || path != "" ||
+		!os.IsNotExist(statErr) {
+		t.Errorf(
+			"(diagnostics/writeDiagnostics) incomplete bundle kept \nerror: %v",
+			err,
+		)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&exec.Cmd{}),
+		"Output",
+		func(*exec.Cmd) ([]byte, error) { return []byte("test output\n"), nil },
+	)
+
+	// Names of the variables are listed, values are left out
+	env := environment(context.Background(), &commons.UserInput{
+		Env: []string{"TMPDIR=/scratch", "TOKEN=secret"},
+	})
+
+	if !strings.HasSuffix(env, "Variables: TMPDIR, TOKEN\n") ||
+		strings.Contains(env, "secret") {
+		t.Errorf("(diagnostics/environment) unexpected environment: %s", env)
+	}
+}
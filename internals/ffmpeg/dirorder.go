@@ -0,0 +1,102 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+OrderSourceDirs sorts the items in the root directory in the order the source
+directories are to be processed - by name (the order items are read in), by the total
+size of the files inside, or the directory with the most recently modified file first.
+Items with the same size (or time) are kept in order of their names.
+*/
+func orderSourceDirs(
+	input *commons.UserInput,
+	resDir string,
+	items []os.FileInfo,
+) []os.FileInfo {
+	if input.Order == "" || input.Order == commons.OrderName {
+		return items
+	}
+
+	type stats struct {
+		size    int64
+		modTime time.Time
+	}
+
+	ordered := append([]os.FileInfo{}, items...)
+	dirStats := make(map[string]stats, len(ordered))
+	for _, item := range ordered {
+		if item.IsDir() {
+			size, modTime := sourceDirStats(
+				filepath.Join(input.RootPath, item.Name()),
+				resDir,
+			)
+			dirStats[item.Name()] = stats{size, modTime}
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		first, second := dirStats[ordered[i].Name()], dirStats[ordered[j].Name()]
+		switch input.Order {
+		case commons.OrderSizeAsc:
+			return first.size < second.size
+
+		case commons.OrderSizeDesc:
+			return first.size > second.size
+
+		case commons.OrderMtime:
+			return first.modTime.After(second.modTime)
+		}
+
+		return false
+	})
+
+	log.Debugf(
+		"(dirorder/orderSourceDirs) source directories ordered by %s",
+		input.Order,
+	)
+
+	return ordered
+}
+
+/*
+SourceDirStats returns the total size of the files inside a directory (including any
+nested directories), and the modification time of the most recently modified file.
+Files that can't be read are left out, along with the result directory (if nested).
+*/
+func sourceDirStats(dir, resDir string) (size int64, modTime time.Time) {
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Debugf(
+				"(dirorder/sourceDirStats) skipping unreadable item: \"%s\" "+
+					"\nerror: %v",
+				path,
+				err,
+			)
+
+			return nil
+		}
+
+		if info.IsDir() && isResultDir(path, resDir) {
+			return filepath.SkipDir
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+			if info.ModTime().After(modTime) {
+				modTime = info.ModTime()
+			}
+		}
+
+		return nil
+	})
+
+	return size, modTime
+}
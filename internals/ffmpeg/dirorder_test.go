@@ -0,0 +1,76 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestOrderSourceDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(dirorder/orderSourceDirs) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Directories named in the reverse order of their size, the modification times
+	// are in no particular order
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, spec := range map[string]struct {
+		size int
+		age  time.Duration
+	}{
+		"A": {300, 2 * time.Hour},
+		"B": {200, 0},
+		"C": {100, time.Hour},
+	} {
+		path := filepath.Join(dir, name, "Subs", "media.mkv")
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		_ = ioutil.WriteFile(path, make([]byte, spec.size), 0644)
+		_ = os.Chtimes(path, base.Add(-spec.age), base.Add(-spec.age))
+	}
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)
+
+	// Outputs in a result directory nested inside a source directory aren't counted
+	resDir := filepath.Join(dir, "C", "output")
+	_ = os.MkdirAll(resDir, 0755)
+	_ = ioutil.WriteFile(filepath.Join(resDir, "media.mkv"), make([]byte, 1000), 0644)
+
+	items, _ := ioutil.ReadDir(dir)
+	for order, expected := range map[string]string{
+		"":                    "A B C notes.txt",
+		commons.OrderName:     "A B C notes.txt",
+		commons.OrderSizeAsc:  "notes.txt C B A",
+		commons.OrderSizeDesc: "A B C notes.txt",
+		commons.OrderMtime:    "B C A notes.txt",
+	} {
+		input := &commons.UserInput{RootPath: dir, Order: order}
+
+		var names []string
+		for _, item := range orderSourceDirs(input, resDir, items) {
+			names = append(names, item.Name())
+		}
+
+		if res := strings.Join(names, " "); res != expected {
+			t.Errorf(
+				"(dirorder/orderSourceDirs) unexpected order for %q \nexpected: %s"+
+					"\nfound: %s",
+				order,
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Items read from the root directory are left as is
+	if items[0].Name() != "A" || items[3].Name() != "notes.txt" {
+		t.Errorf("(dirorder/orderSourceDirs) items modified in place")
+	}
+}
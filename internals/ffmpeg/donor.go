@@ -0,0 +1,49 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+/*
+DonorFiles picks the media files used in extract-and-merge mode from the media files
+present in a source directory - the donor (a subbed release) from which subtitles,
+fonts and chapters are extracted, and the raw media file these are merged into.
+
+Each pattern should match exactly one media file, a media file matching the donor
+pattern is never picked as the raw media file.
+*/
+func donorFiles(mediaFiles []os.FileInfo, input *commons.UserInput) (
+	raw, donor os.FileInfo,
+	err error,
+) {
+	var raws, donors []os.FileInfo
+	for _, file := range mediaFiles {
+		switch {
+		case input.DonorRule.MatchString(file.Name()):
+			donors = append(donors, file)
+		case input.RawRule.MatchString(file.Name()):
+			raws = append(raws, file)
+		}
+	}
+
+	switch {
+	case len(donors) != 1:
+		return nil, nil, fmt.Errorf(
+			"expected one media file matching the donor pattern, found %d: %s",
+			len(donors),
+			commons.Stringify(&donors),
+		)
+
+	case len(raws) != 1:
+		return nil, nil, fmt.Errorf(
+			"expected one media file matching the raw pattern, found %d: %s",
+			len(raws),
+			commons.Stringify(&raws),
+		)
+	}
+
+	return raws[0], donors[0], nil
+}
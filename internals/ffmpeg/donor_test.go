@@ -0,0 +1,128 @@
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestDonorFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(donor/donorFiles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"[Raws] Show - 01.mkv",
+		"[Subs] Show - 01.mkv",
+		"[Subs] Show - 01 (v2).mkv",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(donor/donorFiles) failed to create file: %v", err)
+		}
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	for _, in := range []struct {
+		donor, raw string
+		success    bool
+	}{
+		{`\(v2\)`, `^\[Raws\]`, true},
+		{`^\[Subs\]`, `^\[Raws\]`, false}, // multiple donors
+		{`\(v2\)`, `^\[Missing\]`, false}, // no raw media file
+		{`\(v2\)`, `Show`, false},         // multiple raw media files
+	} {
+		input := &commons.UserInput{DonorRegex: in.donor, RawRegex: in.raw}
+		if code, _ := input.Initialize(); code != commons.RootDirectoryIncorrect {
+			t.Fatalf("(donor/donorFiles) unexpected exit code: %d", code)
+		}
+
+		raw, donor, err := donorFiles(files, input)
+		if (err == nil) != in.success {
+			t.Errorf(
+				"(donor/donorFiles) unexpected result \ndonor: `%s` \nraw: `%s`"+
+					"\nerror: %v",
+				in.donor,
+				in.raw,
+				err,
+			)
+
+			continue
+		}
+
+		if in.success && (raw.Name() != "[Raws] Show - 01.mkv" ||
+			donor.Name() != "[Subs] Show - 01 (v2).mkv") {
+			t.Errorf(
+				"(donor/donorFiles) incorrect files picked \nraw: %s \ndonor: %s",
+				raw.Name(),
+				donor.Name(),
+			)
+		}
+	}
+}
+
+func TestGenerateCmdDonor(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(donor/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"raw.mkv", "donor.mkv", "extra.ass", "font.ttf"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(donor/generateCmd) failed to create file: %v", err)
+		}
+	}
+
+	// Each file contains a single subtitle and attachment stream
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{subtitles: 1, attachments: 1}
+	})
+
+	stat := func(name string) os.FileInfo {
+		info, _ := os.Stat(filepath.Join(dir, name))
+		return info
+	}
+
+	cmd := generateCmd(
+		context.Background(),
+		dir,
+		&commons.UserInput{FFmpegPath: "ffmpeg"},
+		filepath.Join(dir, "out.mkv"),
+		stat("raw.mkv"),
+		stat("donor.mkv"),
+		[]os.FileInfo{stat("extra.ass")},
+		[]os.FileInfo{stat("font.ttf")},
+		nil,
+	)
+
+	args := strings.Join(cmd.Args, " ")
+	for _, expected := range []string{
+		"-i " + filepath.Join(dir, "donor.mkv"),
+		"-map 0 -map 1 -map 2:s? -map 2:t? -map_chapters 2",
+
+		// Subtitle file follows the stream in the raw media file, the font follows
+		// the attachments in both media files
+		"-metadata:s:s:1 title=extra",
+		"-metadata:s:t:2 mimetype=application/x-truetype-font",
+	} {
+		if !strings.Contains(args, expected) {
+			t.Errorf(
+				"(donor/generateCmd) missing arguments \nexpected: `%s` \ncommand: %s",
+				expected,
+				args,
+			)
+		}
+	}
+}
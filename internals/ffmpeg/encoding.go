@@ -0,0 +1,298 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Character encodings recognized while detecting the encoding of a subtitle file, the
+// names are the ones used by FFmpeg (i.e. iconv)
+const (
+	charsetUTF8    = "UTF-8"
+	charsetUTF16LE = "UTF-16LE"
+	charsetUTF16BE = "UTF-16BE"
+	charsetGBK     = "GBK"
+	charsetSJIS    = "SHIFT_JIS"
+	charsetCP1251  = "CP1251"
+	charsetCP1252  = "CP1252"
+)
+
+// Extensions for text subtitles - only these are checked for their encoding, the rest
+// are binary formats (or containers) that are copied over as is
+var textSubsExt = []string{
+	"srt",
+	"ass",
+	"vtt",
+}
+
+// Byte order marks for the unicode encodings, FFmpeg recognizes these on its own
+var byteOrderMarks = map[string][]byte{
+	charsetUTF8:    {0xEF, 0xBB, 0xBF},
+	charsetUTF16LE: {0xFF, 0xFE},
+	charsetUTF16BE: {0xFE, 0xFF},
+}
+
+// Path to the converted copy of a subtitle file by the path to the original file,
+// converted copies are attached instead of the original files
+var convertedSubs = map[string]string{}
+
+/*
+DetectEncoding guesses the character encoding of the contents of a text file, through
+a few simple heuristics (similar to chardet);
+
+  - Unicode files are recognized through the byte order mark, or as valid UTF-8.
+  - Files that are valid as a double-byte encoding are GBK or Shift_JIS, depending on
+    the range most of the lead bytes fall in.
+  - The rest are Cyrillic (CP1251) if non-ASCII bytes make up most of the letters,
+    western (CP1252) otherwise - accented letters are rare in western languages.
+*/
+func detectEncoding(content []byte) string {
+	for _, charset := range []string{charsetUTF8, charsetUTF16LE, charsetUTF16BE} {
+		if bytes.HasPrefix(content, byteOrderMarks[charset]) {
+			return charset
+		}
+	}
+
+	if utf8.Valid(content) {
+		return charsetUTF8
+	}
+
+	if charset := detectDoubleByte(content); charset != "" {
+		return charset
+	}
+
+	// Letters are counted in the single-byte encodings
+	var ascii, high int
+	for _, b := range content {
+		switch {
+		case b >= 0xC0:
+			high++
+		case (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z'):
+			ascii++
+		}
+	}
+
+	if high > ascii {
+		return charsetCP1251
+	}
+
+	return charsetCP1252
+}
+
+/*
+DetectDoubleByte checks if the content is valid in the double-byte encodings, i.e. each
+byte outside the ASCII range is the lead byte of a pair. Returns a blank string if the
+content is not valid as a double-byte encoding.
+
+Common Chinese characters use lead bytes from 0xB0 in GBK, while the Japanese kana use
+lead bytes below 0xA0 in Shift_JIS - the range most of the lead bytes fall in decides
+the encoding.
+*/
+func detectDoubleByte(content []byte) string {
+	var pairs, chinese int
+	for i := 0; i < len(content); i++ {
+		lead := content[i]
+		if lead < 0x80 {
+			continue
+		}
+
+		if lead == 0x80 || lead == 0xFF || i+1 >= len(content) {
+			return ""
+		}
+
+		trail := content[i+1]
+		if trail < 0x40 || trail == 0x7F || trail == 0xFF {
+			return ""
+		}
+
+		pairs++
+		if lead >= 0xB0 && lead <= 0xF7 && trail >= 0xA1 {
+			chinese++
+		}
+
+		i++
+	}
+
+	switch {
+	case pairs == 0:
+		return ""
+	case 2*chinese > pairs:
+		return charsetGBK
+	default:
+		return charsetSJIS
+	}
+}
+
+/*
+SubtitleEncoding returns the encoding of a subtitle file, as per the setting chosen by
+the user. Files that are valid UTF-8 are never converted, even if an encoding was set
+by the user. Returns a blank string if the file is not to be converted.
+*/
+func subtitleEncoding(input *commons.UserInput, content []byte) string {
+	if input.SubEncoding == commons.EncodingKeep {
+		return ""
+	}
+
+	charset := detectEncoding(content)
+	if input.SubEncoding != commons.EncodingAuto && input.SubEncoding != "" &&
+		charset != charsetUTF8 {
+		charset = input.SubEncoding
+	}
+
+	switch charset {
+	case charsetUTF8, charsetUTF16LE, charsetUTF16BE:
+		// Recognized by FFmpeg as is
+		return ""
+	default:
+		return charset
+	}
+}
+
+/*
+ConvertSubtitles converts text subtitles that aren't encoded as UTF-8 (as per the
+setting chosen by the user) into UTF-8, storing the converted copies in a directory in
+the temporary workspace of the run - the copies are attached instead of the original
+files. Subtitles that fail to convert are attached as is, with a warning added to the
+summary.
+
+The function returned removes the converted copies, should be called once the copies
+are no longer required.
+*/
+func convertSubtitles(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	subtitles []os.FileInfo,
+) (cleanup func()) {
+	tempDir := ""
+	cleanup = func() {
+		for _, sub := range subtitles {
+			delete(convertedSubs, joinPath(sourceDir, sub.Name()))
+		}
+
+		if tempDir != "" {
+			commons.Temp().Release(tempDir)
+		}
+	}
+
+	if input.SubEncoding == commons.EncodingKeep {
+		return cleanup
+	}
+
+	for i, sub := range subtitles {
+		if !checkExt(sub.Name(), textSubsExt) {
+			continue
+		}
+
+		path := joinPath(sourceDir, sub.Name())
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Debugf(
+				"(encoding/convertSubtitles) failed to read file: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+
+			continue
+		}
+
+		charset := subtitleEncoding(input, content)
+		if charset == "" {
+			continue
+		}
+
+		if tempDir == "" {
+			if tempDir, err = commons.Temp().Dir(convertedSubsPrefix); err != nil {
+				log.Warnf(
+					"(encoding/convertSubtitles) failed to create directory "+
+						"\nerror: %v",
+					err,
+				)
+
+				return cleanup
+			}
+		}
+
+		// Subtitles in nested directories can share a name, copies are prefixed by
+		// their index to keep them apart - the extension is left as is for FFmpeg
+		converted := filepath.Join(
+			tempDir,
+			fmt.Sprintf("%d-%s", i, filepath.Base(sub.Name())),
+		)
+
+		if err = convertEncoding(ctx, input, charset, path, converted); err != nil {
+			runSummary.warn(
+				`failed to convert subtitles "%s" from %s, attached as is`,
+				path,
+				charset,
+			)
+
+			continue
+		}
+
+		commons.Info(
+			"Converted subtitles from %s to UTF-8 \n\tPath: \"%s\"\n\n",
+			charset,
+			path,
+		)
+
+		convertedSubs[path] = converted
+	}
+
+	return cleanup
+}
+
+/*
+ConvertEncoding uses FFmpeg to convert a text subtitle file from the encoding supplied
+to UTF-8 - the format of the subtitles remains unchanged.
+*/
+func convertEncoding(
+	ctx context.Context,
+	input *commons.UserInput,
+	charset, path, converted string,
+) error {
+	// Command being fired:
+	// `ffmpeg -v error -sub_charenc <charset> -i <input> <output>`
+	output, err := commons.ToolCommand(
+		ctx,
+		input.FFmpegPath,
+		"-v", "error",
+		"-sub_charenc", charset,
+		"-i", path,
+		converted,
+	).CombinedOutput()
+
+	if err != nil {
+		log.Debugf(
+			`(encoding/convertEncoding) failed to convert file: "%s" from %s`+
+				"\nerror: %v \noutput: %s",
+			path,
+			charset,
+			err,
+			output,
+		)
+	}
+
+	return err
+}
+
+/*
+SubtitlePath returns the path to the subtitle file to be attached, i.e. the converted
+copy of the subtitle file if present, the original file otherwise.
+*/
+func subtitlePath(sourceDir string, sub os.FileInfo) string {
+	path := joinPath(sourceDir, sub.Name())
+	if converted, ok := convertedSubs[path]; ok {
+		return converted
+	}
+
+	return path
+}
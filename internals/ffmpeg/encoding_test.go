@@ -0,0 +1,240 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	for name, test := range map[string]struct {
+		content  []byte
+		expected string
+	}{
+		"ascii": {[]byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"), charsetUTF8},
+		"utf-8": {[]byte("Привет, мир"), charsetUTF8},
+		"utf-16 bom": {
+			[]byte{0xFF, 0xFE, 'H', 0x00, 'i', 0x00},
+			charsetUTF16LE,
+		},
+		// "Привет, мир" in CP1251
+		"cp1251": {
+			[]byte{0xCF, 0xF0, 0xE8, 0xE2, 0xE5, 0xF2, ',', ' ', 0xEC, 0xE8, 0xF0},
+			charsetCP1251,
+		},
+		// "Café crème" in CP1252
+		"cp1252": {
+			[]byte{'C', 'a', 'f', 0xE9, ' ', 'c', 'r', 0xE8, 'm', 'e'},
+			charsetCP1252,
+		},
+		// "你好，世界" in GBK
+		"gbk": {
+			[]byte{0xC4, 0xE3, 0xBA, 0xC3, 0xA3, 0xAC, 0xCA, 0xC0, 0xBD, 0xE7},
+			charsetGBK,
+		},
+		// "こんにちは" in Shift_JIS
+		"shift_jis": {
+			[]byte{0x82, 0xB1, 0x82, 0xF1, 0x82, 0xC9, 0x82, 0xBF, 0x82, 0xCD},
+			charsetSJIS,
+		},
+	} {
+		if res := detectEncoding(test.content); res != test.expected {
+			t.Errorf(
+				"(encoding/detectEncoding) unexpected encoding for %s \nexpected: %s "+
+					"\nfound: %s",
+				name,
+				test.expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestSubtitleEncoding(t *testing.T) {
+	// "Привет мир" in CP1251
+	cp1251 := []byte{0xCF, 0xF0, 0xE8, 0xE2, 0xE5, 0xF2, ' ', 0xEC, 0xE8, 0xF0}
+	utf := []byte("Привет мир")
+
+	for _, test := range []struct {
+		encoding string
+		content  []byte
+		expected string
+	}{
+		{commons.EncodingAuto, cp1251, charsetCP1251},
+		{commons.EncodingAuto, utf, ""},
+		{commons.EncodingKeep, cp1251, ""},
+		{"KOI8-R", cp1251, "KOI8-R"},
+		{"KOI8-R", utf, ""},
+	} {
+		input := &commons.UserInput{SubEncoding: test.encoding}
+		if res := subtitleEncoding(input, test.content); res != test.expected {
+			t.Errorf(
+				"(encoding/subtitleEncoding) unexpected encoding with `%s` "+
+					"\nexpected: %q \nfound: %q",
+				test.encoding,
+				test.expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestConvertSubtitles(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(encoding/convertSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string][]byte{
+		"cp1251.srt": {0xCF, 0xF0, 0xE8, 0xE2, 0xE5, 0xF2, ' ', 0xEC, 0xE8, 0xF0},
+		"utf8.srt":   []byte("Привет"),
+		"image.sup":  {0xCF, 0xF0, 0xE8},
+	} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("(encoding/convertSubtitles) failed to create file: %v", err)
+		}
+	}
+
+	subtitles, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("(encoding/convertSubtitles) failed to read directory: %v", err)
+	}
+
+	// Converting writes the output file, as FFmpeg would
+	var converted []string
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			output := c.Args[len(c.Args)-1]
+			converted = append(converted, output)
+
+			return nil, ioutil.WriteFile(output, []byte("Привет"), 0644)
+		},
+	)
+
+	// Converted copies are stored in the temporary workspace of the run
+	commons.SetTemp(commons.NewTempManager(false))
+	defer commons.Temp().Cleanup()
+
+	input := &commons.UserInput{SubEncoding: commons.EncodingAuto}
+	cleanup := convertSubtitles(context.Background(), dir, input, subtitles)
+
+	// Only the subtitle file not encoded as UTF-8 is converted
+	for _, sub := range subtitles {
+		path := subtitlePath(dir, sub)
+		expected := sub.Name() == "cp1251.srt"
+		if isConverted := path != joinPath(dir, sub.Name()); isConverted != expected {
+			t.Errorf(
+				"(encoding/convertSubtitles) unexpected path for %q \npath: %q",
+				sub.Name(),
+				path,
+			)
+		}
+	}
+
+	if len(converted) != 1 {
+		t.Fatalf("(encoding/convertSubtitles) expected one conversion: %v", converted)
+	}
+
+	// Converted copies are removed by the cleanup
+	cleanup()
+	if _, err = os.Stat(converted[0]); !os.IsNotExist(err) {
+		t.Errorf("(encoding/convertSubtitles) converted copy not removed: %v", err)
+	}
+
+	if len(convertedSubs) != 0 {
+		t.Errorf("(encoding/convertSubtitles) converted paths not reset")
+	}
+
+	// Subtitles that fail to convert are attached as is, with a warning
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(*exec.Cmd) ([]byte, error) { return nil, errors.New("test error") },
+	)
+
+	runSummary = &Summary{}
+	defer func() {
+		runSummary = &Summary{}
+	}()
+
+	cleanup = convertSubtitles(context.Background(), dir, input, subtitles)
+	defer cleanup()
+
+	if len(convertedSubs) != 0 || len(runSummary.Warnings) != 1 {
+		t.Errorf(
+			"(encoding/convertSubtitles) failed conversion not reported \n%v",
+			runSummary.Warnings,
+		)
+	}
+}
+
+func TestConvertSubtitlesNested(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(encoding/convertSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Subtitles with the same name, in different subtitle folders
+	var subtitles []os.FileInfo
+	for _, name := range []string{"Subs/English.srt", "Subs/Signs/English.srt"} {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(encoding/convertSubtitles) failed to create directory: %v", err)
+		}
+
+		err = ioutil.WriteFile(path, []byte{0xCF, 0xF0, 0xE8, 0xE2, 0xE5, 0xF2}, 0644)
+		if err != nil {
+			t.Fatalf("(encoding/convertSubtitles) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		subtitles = append(subtitles, nestedFile{info, filepath.FromSlash(name)})
+	}
+
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			return nil, ioutil.WriteFile(c.Args[len(c.Args)-1], nil, 0644)
+		},
+	)
+
+	commons.SetTemp(commons.NewTempManager(false))
+	defer commons.Temp().Cleanup()
+
+	input := &commons.UserInput{SubEncoding: "cp1251"}
+	cleanup := convertSubtitles(context.Background(), dir, input, subtitles)
+	defer cleanup()
+
+	first, second := subtitlePath(dir, subtitles[0]), subtitlePath(dir, subtitles[1])
+	if first == second || filepath.Ext(first) != ".srt" ||
+		filepath.Ext(second) != ".srt" {
+		t.Errorf(
+			"(encoding/convertSubtitles) converted copies collide \nfirst: %q"+
+				"\nsecond: %q",
+			first,
+			second,
+		)
+	}
+}
@@ -0,0 +1,145 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Overhead of the container over the streams copied into it, as a fraction of the size
+// of the inputs - generous, since the outputs are merely remuxed
+const containerOverhead = 0.01
+
+// Error returned if the run is stopped for lack of free space
+var errInsufficientSpace = errors.New("not enough free space for the outputs")
+
+/*
+EstimateOutputs estimates the total size of the outputs for the source directories
+supplied, along with the number of media files - each output is roughly the size of the
+media file, plus the subtitles, attachments and chapters merged into it. Source
+directories that are to be skipped (or left out of the plan applied) are left out.
+
+Archives are never extracted for the estimate, each archive is counted once at its own
+size instead.
+*/
+func estimateOutputs(
+	input *commons.UserInput,
+	sourceDirs []string,
+) (total int64, mediaCount int) {
+	for _, sourceDir := range sourceDirs {
+		if runQueue.finished(filepath.Base(sourceDir)) ||
+			(input.RetryFailed && !hasFailedMarker(sourceDir)) ||
+			(!input.IsDirect && input.IgnoreDir(sourceDir)) ||
+			!runPlan.includes(sourceDir) {
+			continue
+		}
+
+		plain := *input
+		plain.ExtractArchives = false
+
+		mediaFiles, subtitles, attachments, chapters := groupFiles(sourceDir, &plain)
+		extras := filesSize(attachments) + filesSize(chapters)
+
+		for _, mediaFile := range mediaFiles {
+			subs := filesSize(subtitlesFor(mediaFile, subtitles))
+			total += mediaFile.Size() + subs + extras
+			mediaCount++
+		}
+
+		if len(mediaFiles) > 0 {
+			total += archivesSize(sourceDir, input)
+		}
+	}
+
+	return total + int64(float64(total)*containerOverhead), mediaCount
+}
+
+/*
+ArchivesSize returns the total size of the archives in a source directory that are to
+be extracted, zero unless archives are looked into.
+*/
+func archivesSize(sourceDir string, input *commons.UserInput) (size int64) {
+	if !input.ExtractArchives {
+		return 0
+	}
+
+	files, err := ioutil.ReadDir(sourceDir)
+	if err != nil {
+		return 0
+	}
+
+	dirIncluded := input.IncludeItem(filepath.Base(sourceDir))
+	for _, file := range files {
+		category, _ := classifyFile(sourceDir, file, input, dirIncluded)
+		if category == categoryArchive {
+			size += file.Size()
+		}
+	}
+
+	return size
+}
+
+/*
+CheckSpace prints the estimated size of the outputs for the run, comparing it against
+the space available in the result directory - the user is asked before continuing if
+the outputs may not fit. Returns an error if the run is to be stopped.
+*/
+func checkSpace(input *commons.UserInput, resDir string, sourceDirs []string) error {
+	estimate, mediaCount := estimateOutputs(input, sourceDirs)
+	if mediaCount == 0 {
+		return nil
+	}
+
+	// Outputs are written next to the source directories outside the central layout
+	if !centralLayout(input) {
+		resDir = input.RootPath
+	}
+
+	update := Updates{}
+	free, known := freeSpace(resDir)
+	if !known {
+		log.Debugf(`(estimate/checkSpace) free space unknown for: "%s"`, resDir)
+		commons.Info(
+			"Estimated output size: %s for %d media file(s)\n\n",
+			update.readableFileSize(float64(estimate)),
+			mediaCount,
+		)
+
+		return nil
+	}
+
+	commons.Info(
+		"Estimated output size: %s for %d media file(s), %s free\n\n",
+		update.readableFileSize(float64(estimate)),
+		mediaCount,
+		update.readableFileSize(float64(free)),
+	)
+
+	if estimate <= free {
+		return nil
+	}
+
+	log.Debugf(
+		"(estimate/checkSpace) estimate exceeds free space \nestimate: %d \nfree: %d",
+		estimate,
+		free,
+	)
+
+	commons.Warn(
+		"The outputs may not fit in the result directory\n\tPath: \"%s\"\n\n",
+		resDir,
+	)
+
+	if !input.Prompt.Confirm(fmt.Sprintf(
+		"Short by %s, continue anyway?",
+		update.readableFileSize(float64(estimate-free)),
+	)) {
+		return errInsufficientSpace
+	}
+
+	return nil
+}
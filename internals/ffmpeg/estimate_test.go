@@ -0,0 +1,96 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestEstimateOutputs(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(estimate/estimateOutputs) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]int{
+		"01/video.mkv":   1000,
+		"01/English.srt": 100,
+		"01/font.ttf":    50,
+		"01/Subs.zip":    300, // counted only if archives are looked into
+		"02/video.mp4":   2000,
+		"02/English.ass": 200,
+		"03/notes.txt":   10, // no media file, left out
+	}
+
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("(estimate/estimateOutputs) failed to create file: %v", err)
+		}
+	}
+
+	sourceDirs := []string{
+		filepath.Join(dir, "01"),
+		filepath.Join(dir, "02"),
+		filepath.Join(dir, "03"),
+	}
+
+	input := &commons.UserInput{}
+	estimate, mediaCount := estimateOutputs(input, sourceDirs)
+
+	// Inputs add up to 3350 bytes, along with the container overhead (rounded down)
+	if mediaCount != 2 || estimate != 3350+33 {
+		t.Errorf(
+			"(estimate/estimateOutputs) unexpected estimate \nestimate: %d"+
+				"\nmedia files: %d",
+			estimate,
+			mediaCount,
+		)
+	}
+
+	// Archives are counted at their own size, without being extracted
+	defer func(summary *Summary) { runSummary = summary }(runSummary)
+	runSummary = &Summary{}
+
+	input.ExtractArchives = true
+	estimate, _ = estimateOutputs(input, sourceDirs)
+	if estimate != 3650+36 || len(extractedArchives) != 0 ||
+		len(runSummary.Warnings) != 0 {
+		t.Errorf(
+			"(estimate/estimateOutputs) unexpected estimate with archives "+
+				"\nestimate: %d \nextracted: %v \nwarnings: %v",
+			estimate,
+			extractedArchives,
+			runSummary.Warnings,
+		)
+	}
+
+	input.ExtractArchives = false
+
+	// Plenty of space, or free space unknown - the run continues
+	monkey.Patch(freeSpace, func(string) (int64, bool) { return 1 << 30, true })
+	if err = checkSpace(input, dir, sourceDirs); err != nil {
+		t.Errorf("(estimate/checkSpace) unexpected error: %v", err)
+	}
+
+	monkey.Patch(freeSpace, func(string) (int64, bool) { return 0, false })
+	if err = checkSpace(input, dir, sourceDirs); err != nil {
+		t.Errorf("(estimate/checkSpace) unexpected error: %v", err)
+	}
+
+	// Not enough space, the prompt is declined without a prompt policy
+	monkey.Patch(freeSpace, func(string) (int64, bool) { return 1000, true })
+	if err = checkSpace(input, dir, sourceDirs); err != errInsufficientSpace {
+		t.Errorf("(estimate/checkSpace) expected run to stop \nerror: %v", err)
+	}
+}
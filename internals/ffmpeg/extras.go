@@ -0,0 +1,156 @@
+package ffmpeg
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+CopyExtras copies the files in a source directory matching the glob patterns supplied
+by the user into the directory for the extras, preserving their path relative to the
+source directory - files are copied as is, along with their modification time.
+
+Patterns without a slash are matched against the name of a file, patterns with a
+slash are matched against the path relative to the source directory. Items ignored by
+the exclusion rules are skipped, and existing files are never overwritten. The result
+directory of the run is never copied, even if nested inside the source directory.
+*/
+func copyExtras(sourceDir, resDir, destDir string, input *commons.UserInput) {
+	if len(input.CopyExtras) == 0 {
+		return
+	}
+
+	err := filepath.Walk(sourceDir, func(
+		path string,
+		info os.FileInfo,
+		err error,
+	) error {
+		if err != nil {
+			return err
+		}
+
+		if path == sourceDir {
+			return nil
+		}
+
+		parent, name := filepath.Split(path)
+		if info.IsDir() {
+			// Never copy the result directory (or the extras) into itself,
+			// directories matching the exclusion rules (by name) are skipped entirely
+			if isResultDir(path, resDir) || isResultDir(path, destDir) ||
+				input.IgnoreDir(path) ||
+				input.IgnoreFile(&parent, &name) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil || !matchExtra(input.CopyExtras, rel, input.Casing()) ||
+			input.IgnoreFile(&parent, &name) {
+			return nil
+		}
+
+		dest := filepath.Join(destDir, rel)
+		if _, err := os.Lstat(dest); err == nil {
+			runSummary.warn("extra file not copied, \"%s\" exists already", dest)
+			return nil
+		}
+
+		if err := copyFile(path, dest, info); err != nil {
+			runSummary.warn("failed to copy extra file \"%s\": %v", path, err)
+		} else {
+			runSummary.created(dest)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Warnf(
+			"(extras/copyExtras) failed to walk source directory: \"%s\" \nerror: %v",
+			sourceDir,
+			err,
+		)
+	}
+}
+
+/*
+ExtrasDir returns the directory the extra files of a source directory are copied into,
+when traversing the root directory - the outputs of every source directory share the
+result directory in the central layout, extras are placed in a directory named after
+their source directory to keep extras with the same name (i.e. `poster.jpg`) apart.
+The result directory for the source directory is used as is otherwise.
+*/
+func extrasDir(input *commons.UserInput, resDir, name string, mirror bool) string {
+	if centralLayout(input) && !mirror {
+		return joinPath(resDir, name)
+	}
+
+	return resDir
+}
+
+/*
+MatchExtra returns a boolean indicating if a file (path relative to the source
+directory) matches any of the glob patterns, names are compared as per the case supplied
+*/
+func matchExtra(patterns []string, rel string, casing commons.NameCase) bool {
+	rel = filepath.ToSlash(rel)
+	name := filepath.Base(rel)
+
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+
+		if casing.Glob(pattern, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+CopyFile copies a file to the destination, creating the directories required - the
+destination is never overwritten. The modification time of the file is retained.
+*/
+func copyFile(src, dest string, info os.FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(
+		dest,
+		os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		info.Mode().Perm(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
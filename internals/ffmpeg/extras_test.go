@@ -0,0 +1,131 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestCopyExtras(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(extras/copyExtras) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	source, resDir := filepath.Join(dir, "Show"), filepath.Join(dir, "output")
+	modTime := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, name := range []string{
+		"Show.nfo",
+		"episode.mkv",
+		filepath.Join("Screens", "01.png"),
+		filepath.Join("Screens", "thumbs", "01.png"),
+		filepath.Join("Extras", "notes.nfo"),
+		filepath.Join("out", "episode.nfo"),
+	} {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(extras/copyExtras) failed to create directory: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("(extras/copyExtras) failed to create file: %v", err)
+		}
+
+		_ = os.Chtimes(path, modTime, modTime)
+	}
+
+	// An existing file is never overwritten
+	existing := filepath.Join(resDir, "Screens", "01.png")
+	_ = os.MkdirAll(filepath.Dir(existing), 0755)
+	_ = ioutil.WriteFile(existing, []byte("existing"), 0644)
+
+	defer func() {
+		runSummary = &Summary{}
+	}()
+
+	runSummary = &Summary{}
+	input := &commons.UserInput{
+		RootPath:   dir,
+		CopyExtras: []string{"*.NFO", "Screens/*.png"},
+		Exclusions: []string{"Extras"},
+	}
+
+	// The result directory of the run is nested inside the source directory
+	copyExtras(source, filepath.Join(source, "out"), resDir, input)
+
+	for name, expected := range map[string]string{
+		"Show.nfo":                         "Show.nfo",
+		filepath.Join("Screens", "01.png"): "existing",
+	} {
+		contents, err := ioutil.ReadFile(filepath.Join(resDir, name))
+		if err != nil || string(contents) != expected {
+			t.Errorf(
+				"(extras/copyExtras) unexpected contents for %q \nexpected: %q "+
+					"\nfound: %q \nerror: %v",
+				name,
+				expected,
+				contents,
+				err,
+			)
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(resDir, "Show.nfo")); err != nil ||
+		!info.ModTime().Equal(modTime) {
+		t.Errorf("(extras/copyExtras) modification time not retained")
+	}
+
+	// Files not matching the patterns, ignored, or in the result directory are not
+	// copied
+	for _, name := range []string{
+		"episode.mkv",
+		filepath.Join("Screens", "thumbs", "01.png"),
+		filepath.Join("Extras", "notes.nfo"),
+		filepath.Join("out", "episode.nfo"),
+	} {
+		if _, err := os.Stat(filepath.Join(resDir, name)); !os.IsNotExist(err) {
+			t.Errorf("(extras/copyExtras) unexpected file copied: %q", name)
+		}
+	}
+
+	if len(runSummary.Warnings) != 1 {
+		t.Errorf(
+			"(extras/copyExtras) expected a warning for the existing file, found: %v",
+			runSummary.Warnings,
+		)
+	}
+}
+
+func TestExtrasDir(t *testing.T) {
+	resDir := filepath.Join(os.TempDir(), "output")
+
+	// Extras from each source directory are kept apart in a shared result directory
+	input := &commons.UserInput{OutputLayout: commons.LayoutCentral}
+	if res := extrasDir(input, resDir, "Show A", false); res !=
+		filepath.Join(resDir, "Show A") {
+		t.Errorf("(extras/extrasDir) extras placed in a shared directory: %s", res)
+	}
+
+	// Result directories for a single source directory are used as is
+	mirrored := filepath.Join(resDir, "Show A")
+	for _, res := range []string{
+		extrasDir(input, mirrored, "Show A", true),
+		extrasDir(
+			&commons.UserInput{OutputLayout: commons.LayoutSubfolder},
+			mirrored,
+			"Show A",
+			false,
+		),
+	} {
+		if res != mirrored {
+			t.Errorf("(extras/extrasDir) unexpected directory: %s", res)
+		}
+	}
+}
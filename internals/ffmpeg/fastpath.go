@@ -0,0 +1,172 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Prefix for the directories holding the option files passed to mkvpropedit
+const optionFilePrefix = "auto-sub-options"
+
+/*
+FastPath checks if the output for a media file can be formed without remuxing it - a
+Matroska media file getting attachments (fonts or chapters) alone is copied over as is,
+and the attachments are added to the copy through mkvpropedit.
+
+The fast path is never taken if disabled by the user, if mkvpropedit wasn't found, or
+in sample mode (the output is cut short by FFmpeg).
+*/
+func fastPath(
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	donor os.FileInfo,
+	subtitles,
+	attachments,
+	chapters []os.FileInfo,
+) bool {
+	switch {
+	case input.NoFastPath, input.MkvpropeditPath == "", input.Sample > 0:
+		return false
+	case donor != nil, len(subtitles) > 0, len(attachments)+len(chapters) == 0:
+		return false
+	}
+
+	return strings.EqualFold(filepath.Ext(mediaFile.Name()), ".mkv")
+}
+
+/*
+AttachInPlace copies the media file to the partial output, adding the attachments to
+the copy through mkvpropedit - the attachments are added in the order (and with the
+metadata) FFmpeg would have used. The partial output is removed if the fast path
+fails, leaving the media file to be remuxed by FFmpeg instead.
+*/
+func attachInPlace(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	partial string,
+	attachments,
+	chapters []os.FileInfo,
+) error {
+	path := joinPath(sourceDir, mediaFile.Name())
+	if err := copyFile(path, partial, mediaFile); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+
+	err := addAttachments(ctx, sourceDir, input, partial, attachments, chapters)
+	if err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+
+	log.Debugf(
+		`(fastpath/attachInPlace) attachments added without remuxing: "%s"`,
+		path,
+	)
+
+	return nil
+}
+
+/*
+AttachLater checks if the attachments for a media file can be added to the output once
+FFmpeg completes, when the FFmpeg command would be too long to run otherwise - only
+Matroska outputs can be edited through mkvpropedit.
+*/
+func attachLater(
+	input *commons.UserInput,
+	output string,
+	attachments,
+	chapters []os.FileInfo,
+) bool {
+	return input.MkvpropeditPath != "" && len(attachments)+len(chapters) > 0 &&
+		strings.EqualFold(filepath.Ext(output), ".mkv")
+}
+
+/*
+AddAttachments adds the attachments to a Matroska file through mkvpropedit, in the
+order (and with the metadata) FFmpeg would have used. Arguments too long for the
+platform are passed through an option file (`mkvpropedit @options.json`) stored in the
+temporary workspace of the run.
+*/
+func addAttachments(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	file string,
+	attachments,
+	chapters []os.FileInfo,
+) error {
+	// Command being fired:
+	// `mkvpropedit <file> --attachment-name <name> --attachment-mime-type <type>
+	// [--attachment-description <description>] --add-attachment <path> ...`
+	args := []string{file}
+	plans := planAttachments(sourceDir, input, chapters, attachments)
+	for _, attachment := range plans {
+		name := commons.SanitizeText(filepath.Base(attachment.path))
+		args = append(
+			args,
+			"--attachment-name", name,
+			"--attachment-mime-type", attachment.mimetype,
+		)
+
+		if attachment.description != "" {
+			args = append(args, "--attachment-description", attachment.description)
+		}
+
+		args = append(args, "--add-attachment", attachment.path)
+	}
+
+	if commandLength(args)+len(input.MkvpropeditPath)+1 > argLimit {
+		options, err := writeOptionFile(args)
+		if err != nil {
+			return err
+		}
+
+		defer commons.Temp().Release(filepath.Dir(options))
+		args = []string{"@" + options}
+	}
+
+	output, err := commons.ToolCommand(ctx, input.MkvpropeditPath, args...).
+		CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mkvpropedit failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+/*
+WriteOptionFile writes the arguments for a MKVToolNix tool into an option file (a JSON
+array of the arguments) in the temporary workspace of the run, returning the path to
+the file - the directory holding the file should be released once no longer required.
+*/
+func writeOptionFile(args []string) (string, error) {
+	dir, err := commons.Temp().Dir(optionFilePrefix)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := json.Marshal(args)
+	if err != nil {
+		commons.Temp().Release(dir)
+		return "", err
+	}
+
+	path := filepath.Join(dir, "options.json")
+	if err = ioutil.WriteFile(path, contents, 0644); err != nil {
+		commons.Temp().Release(dir)
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,229 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestFastPath(t *testing.T) {
+	media := nestedFile{name: "Episode 01.MKV"}
+	fonts := []os.FileInfo{nestedFile{name: "font.ttf"}}
+	subs := []os.FileInfo{nestedFile{name: "English.srt"}}
+
+	input := &commons.UserInput{MkvpropeditPath: "mkvpropedit"}
+	if !fastPath(input, media, nil, nil, fonts, nil) {
+		t.Errorf("(fastpath/fastPath) fast path not taken for attachments alone")
+	}
+
+	for name, eligible := range map[string]bool{
+		"subtitles":      fastPath(input, media, nil, subs, fonts, nil),
+		"no attachments": fastPath(input, media, nil, nil, nil, nil),
+		"donor":          fastPath(input, media, media, nil, fonts, nil),
+		"not matroska": fastPath(
+			input,
+			nestedFile{name: "Episode 01.mp4"},
+			nil,
+			nil,
+			fonts,
+			nil,
+		),
+		"disabled": fastPath(
+			&commons.UserInput{MkvpropeditPath: "mkvpropedit", NoFastPath: true},
+			media,
+			nil,
+			nil,
+			fonts,
+			nil,
+		),
+		"sample": fastPath(
+			&commons.UserInput{MkvpropeditPath: "mkvpropedit", Sample: 30},
+			media,
+			nil,
+			nil,
+			fonts,
+			nil,
+		),
+		"no mkvpropedit": fastPath(&commons.UserInput{}, media, nil, nil, fonts, nil),
+	} {
+		if eligible {
+			t.Errorf("(fastpath/fastPath) fast path taken with %s", name)
+		}
+	}
+}
+
+func TestAttachInPlace(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(fastpath/attachInPlace) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var files []os.FileInfo
+	for _, name := range []string{"video.mkv", "font.ttf", "chapters.xml"} {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("(fastpath/attachInPlace) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		files = append(files, info)
+	}
+
+	var args []string
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			args = c.Args
+			return nil, nil
+		},
+	)
+
+	input := &commons.UserInput{
+		MkvpropeditPath: "mkvpropedit",
+		AttachmentDescs: map[string]string{commons.AttachmentFont: "Font"},
+	}
+
+	partial := filepath.Join(dir, "output", "video.mkv.part")
+	err = attachInPlace(
+		context.Background(),
+		dir,
+		input,
+		files[0],
+		partial,
+		files[1:2],
+		files[2:],
+	)
+
+	if err != nil {
+		t.Fatalf("(fastpath/attachInPlace) unexpected error: %v", err)
+	}
+
+	// Chapters are attached ahead of the fonts, as with FFmpeg
+	expected := []string{
+		"mkvpropedit",
+		partial,
+		"--attachment-name", "chapters.xml",
+		"--attachment-mime-type", "text/xml",
+		"--add-attachment", filepath.Join(dir, "chapters.xml"),
+		"--attachment-name", "font.ttf",
+		"--attachment-mime-type", "application/x-truetype-font",
+		"--attachment-description", "Font",
+		"--add-attachment", filepath.Join(dir, "font.ttf"),
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf(
+			"(fastpath/attachInPlace) unexpected command \nexpected: %v \nfound: %v",
+			expected,
+			args,
+		)
+	}
+
+	if contents, _ := ioutil.ReadFile(partial); string(contents) != "video.mkv" {
+		t.Errorf("(fastpath/attachInPlace) media file not copied to the partial output")
+	}
+
+	// Partial output is removed if mkvpropedit fails
+	_ = os.Remove(partial)
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(*exec.Cmd) ([]byte, error) {
+			return []byte("Error: not a Matroska file\n"), errors.New("exit status 2")
+		},
+	)
+
+	err = attachInPlace(
+		context.Background(),
+		dir,
+		input,
+		files[0],
+		partial,
+		files[1:2],
+		nil,
+	)
+
+	if _, statErr := os.Stat(partial); err == nil || !os.IsNotExist(statErr) {
+		t.Errorf("(fastpath/attachInPlace) partial output kept \nerror: %v", err)
+	}
+}
+
+func TestAddAttachments(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	commons.SetTemp(commons.NewTempManager(false))
+	defer commons.Temp().Cleanup()
+
+	// Enough fonts (with long names) to exceed the limit for the arguments
+	var fonts []os.FileInfo
+	for i := 0; i < 400; i++ {
+		name := fmt.Sprintf("Fonts/%s-%03d.ttf", strings.Repeat("x", 300), i)
+		fonts = append(fonts, nestedFile{name: name})
+	}
+
+	var args, options []string
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			args = c.Args
+			contents, err := ioutil.ReadFile(strings.TrimPrefix(c.Args[1], "@"))
+			if err == nil {
+				err = json.Unmarshal(contents, &options)
+			}
+
+			return nil, err
+		},
+	)
+
+	input := &commons.UserInput{MkvpropeditPath: "mkvpropedit"}
+	err := addAttachments(
+		context.Background(),
+		os.TempDir(),
+		input,
+		"output.mkv.part",
+		fonts,
+		nil,
+	)
+
+	// Arguments are passed through an option file, removed once used
+	if err != nil || len(args) != 2 || !strings.HasPrefix(args[1], "@") ||
+		len(options) != 1+len(fonts)*6 || options[0] != "output.mkv.part" {
+		t.Fatalf(
+			"(fastpath/addAttachments) option file not used \nerror: %v "+
+				"\narguments: %d \noptions: %d",
+			err,
+			len(args),
+			len(options),
+		)
+	}
+
+	if _, err = os.Stat(strings.TrimPrefix(args[1], "@")); !os.IsNotExist(err) {
+		t.Errorf("(fastpath/addAttachments) option file not removed")
+	}
+
+	// Attachments are added later to Matroska outputs alone
+	if !attachLater(input, "output.MKV", fonts, nil) ||
+		attachLater(input, "output.mp4", fonts, nil) ||
+		attachLater(&commons.UserInput{}, "output.mkv", fonts, nil) {
+		t.Errorf("(fastpath/attachLater) unexpected result")
+	}
+}
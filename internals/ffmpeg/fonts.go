@@ -0,0 +1,272 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Name IDs in the `name` table of a font, identifying the family of the font - ASS
+// subtitles refer to fonts through these
+var fontNameIDs = map[uint16]bool{
+	1:  true, // font family
+	4:  true, // full name
+	16: true, // typographic family
+}
+
+// Pattern for font overrides in ASS dialogue lines, i.e. `{\fnArial}`
+var fontOverride = regexp.MustCompile(`\\fn([^\\}]*)`)
+
+/*
+CheckFonts cross-checks the fonts referenced in ASS subtitle files against the fonts
+being attached, warning in the summary for each subtitle file referencing fonts that
+are missing - players fall back to a default font silently in such cases.
+
+Fonts are matched by their family (or full) name, or the name of the font file.
+*/
+func checkFonts(sourceDir string, subtitles, attachments []os.FileInfo) {
+	var available map[string]bool
+	for _, sub := range subtitles {
+		if !checkExt(sub.Name(), []string{"ass"}) {
+			continue
+		}
+
+		path := joinPath(sourceDir, sub.Name())
+		referenced, err := assFonts(path)
+		if err != nil {
+			log.Debugf(
+				"(fonts/checkFonts) failed to read subtitles: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+
+			continue
+		}
+
+		// Fonts are read only if required, and only once
+		if available == nil {
+			available = attachedFonts(sourceDir, attachments)
+		}
+
+		var missing []string
+		for _, font := range referenced {
+			if !available[strings.ToLower(font)] {
+				missing = append(missing, font)
+			}
+		}
+
+		if len(missing) > 0 {
+			runSummary.warn(
+				`fonts used by "%s" are not attached: %s`,
+				path,
+				strings.Join(missing, ", "),
+			)
+		}
+	}
+}
+
+/*
+AssFonts returns the fonts referenced in an ASS subtitle file - through the styles, and
+the font overrides in the dialogue lines. Names are de-duplicated irrespective of case,
+and returned in alphabetical order.
+*/
+func assFonts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	fonts := map[string]string{}
+	add := func(font string) {
+		// Fonts prefixed with `@` are the same fonts, used for vertical text
+		font = strings.TrimPrefix(strings.TrimSpace(font), "@")
+		if font != "" {
+			if _, ok := fonts[strings.ToLower(font)]; !ok {
+				fonts[strings.ToLower(font)] = font
+			}
+		}
+	}
+
+	// Index of the font name in style lines, set through the format line of the
+	// styles section - the second field by default
+	fontField, styles := 1, false
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		switch {
+		case strings.HasPrefix(line, "["):
+			styles = strings.Contains(strings.ToLower(line), "styles")
+
+		case styles && strings.HasPrefix(line, "Format:"):
+			fields := strings.Split(strings.TrimPrefix(line, "Format:"), ",")
+			for i, field := range fields {
+				if strings.EqualFold(strings.TrimSpace(field), "Fontname") {
+					fontField = i
+				}
+			}
+
+		case styles && strings.HasPrefix(line, "Style:"):
+			fields := strings.Split(strings.TrimPrefix(line, "Style:"), ",")
+			if fontField < len(fields) {
+				add(fields[fontField])
+			}
+
+		case strings.HasPrefix(line, "Dialogue:"):
+			for _, match := range fontOverride.FindAllStringSubmatch(line, -1) {
+				add(match[1])
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fonts))
+	for _, font := range fonts {
+		names = append(names, font)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+/*
+AttachedFonts returns the names of the fonts being attached, in lower case - along with
+the names of the font files (minus the extension). Fonts that can't be read are matched
+through the name of the file alone.
+*/
+func attachedFonts(sourceDir string, attachments []os.FileInfo) map[string]bool {
+	available := map[string]bool{}
+	for _, attachment := range attachments {
+		if !checkExt(attachment.Name(), attachmentExt) {
+			continue
+		}
+
+		name := filepath.Base(attachment.Name())
+		available[strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))] = true
+
+		path := joinPath(sourceDir, attachment.Name())
+		names, err := fontNames(path)
+		if err != nil {
+			log.Debugf(
+				"(fonts/attachedFonts) failed to read font: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+		}
+
+		for _, name := range names {
+			available[strings.ToLower(name)] = true
+		}
+	}
+
+	return available
+}
+
+/*
+FontNames reads the family and full names of a TrueType/OpenType font from the `name`
+table of the font.
+*/
+func fontNames(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	errMalformed := errors.New("malformed font file")
+	read16 := func(offset int) (uint16, bool) {
+		if offset < 0 || offset+2 > len(data) {
+			return 0, false
+		}
+
+		return binary.BigEndian.Uint16(data[offset:]), true
+	}
+
+	read32 := func(offset int) (uint32, bool) {
+		if offset < 0 || offset+4 > len(data) {
+			return 0, false
+		}
+
+		return binary.BigEndian.Uint32(data[offset:]), true
+	}
+
+	// Locate the `name` table through the table directory
+	numTables, ok := read16(4)
+	if !ok {
+		return nil, errMalformed
+	}
+
+	table := -1
+	for i := 0; i < int(numTables); i++ {
+		record := 12 + i*16
+		if record+16 > len(data) {
+			return nil, errMalformed
+		}
+
+		if string(data[record:record+4]) == "name" {
+			offset, _ := read32(record + 8)
+			table = int(offset)
+			break
+		}
+	}
+
+	if table < 0 {
+		return nil, errors.New("no name table in font file")
+	}
+
+	count, ok := read16(table + 2)
+	if !ok {
+		return nil, errMalformed
+	}
+
+	storage, _ := read16(table + 4)
+
+	var names []string
+	for i := 0; i < int(count); i++ {
+		record := table + 6 + i*12
+		platform, _ := read16(record)
+		nameID, _ := read16(record + 6)
+		length, _ := read16(record + 8)
+		offset, ok := read16(record + 10)
+		if !ok {
+			return names, errMalformed
+		}
+
+		start := table + int(storage) + int(offset)
+		if !fontNameIDs[nameID] || start+int(length) > len(data) {
+			continue
+		}
+
+		raw := data[start : start+int(length)]
+		switch platform {
+		case 0, 3:
+			// Unicode and Windows platforms, names are in UTF-16 (big endian)
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[j*2:])
+			}
+
+			names = append(names, string(utf16.Decode(units)))
+		case 1:
+			// Macintosh platform, names are (mostly) in ASCII
+			names = append(names, string(raw))
+		}
+	}
+
+	return names, nil
+}
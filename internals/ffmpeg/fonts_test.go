@@ -0,0 +1,135 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// Minimal TrueType font containing only a `name` table, with the family name supplied
+func testFont(family string) []byte {
+	units := utf16.Encode([]rune(family))
+
+	var buf bytes.Buffer
+	write := func(values ...interface{}) {
+		for _, value := range values {
+			_ = binary.Write(&buf, binary.BigEndian, value)
+		}
+	}
+
+	// Offset table, and the record for the `name` table placed right after it
+	write(uint32(0x00010000), uint16(1), uint16(0), uint16(0), uint16(0))
+	buf.WriteString("name")
+	write(uint32(0), uint32(28), uint32(0))
+
+	// Name table, a single record for the family name on the Windows platform
+	write(uint16(0), uint16(1), uint16(18))
+	write(uint16(3), uint16(1), uint16(0x409), uint16(1), uint16(len(units)*2))
+	write(uint16(0), units)
+
+	return buf.Bytes()
+}
+
+func TestAssFonts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(fonts/assFonts) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	content := strings.Join([]string{
+		"\ufeff[Script Info]",
+		"Title: Test",
+		"",
+		"[V4+ Styles]",
+		"Format: Name, Fontname, Fontsize, PrimaryColour",
+		"Style: Default,Open Sans,48,&H00FFFFFF",
+		"Style: Signs,@Noto Sans JP,36,&H00FFFFFF",
+		"Style: Alt,open sans,40,&H00FFFFFF",
+		"",
+		"[Events]",
+		"Format: Layer, Start, End, Style, Text",
+		`Dialogue: 0,0:00:01.00,0:00:02.00,Default,{\fnComic Neue\b1}Hello`,
+		`Dialogue: 0,0:00:03.00,0:00:04.00,Default,{\fn}Reset`,
+	}, "\n")
+
+	path := filepath.Join(dir, "English.ass")
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("(fonts/assFonts) failed to create file: %v", err)
+	}
+
+	fonts, err := assFonts(path)
+	expected := []string{"Comic Neue", "Noto Sans JP", "Open Sans"}
+	if err != nil || !reflect.DeepEqual(fonts, expected) {
+		t.Errorf(
+			"(fonts/assFonts) unexpected fonts \nexpected: %v \nfound: %v \nerror: %v",
+			expected,
+			fonts,
+			err,
+		)
+	}
+}
+
+func TestCheckFonts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(fonts/checkFonts) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	runSummary = &Summary{}
+	defer func() { runSummary = &Summary{} }()
+
+	create := func(name string, content []byte) os.FileInfo {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("(fonts/checkFonts) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		return info
+	}
+
+	sub := create("English.ass", []byte(strings.Join([]string{
+		"[V4+ Styles]",
+		"Format: Name, Fontname",
+		"Style: Default,Open Sans",
+		"Style: Signs,Comic Neue",
+		"[Events]",
+		`Dialogue: 0,0:00:01.00,0:00:02.00,Default,{\fnLato}Hello`,
+	}, "\n")))
+
+	// Fonts are matched through the name table, or the name of the file
+	fonts := []os.FileInfo{
+		create("OpenSans-Regular.ttf", testFont("Open Sans")),
+		create("Comic Neue.otf", []byte("not a font")),
+	}
+
+	if names, err := fontNames(filepath.Join(dir, fonts[0].Name())); err != nil ||
+		!reflect.DeepEqual(names, []string{"Open Sans"}) {
+		t.Errorf("(fonts/fontNames) unexpected names: %v \nerror: %v", names, err)
+	}
+
+	checkFonts(dir, []os.FileInfo{sub, create("English.srt", nil)}, fonts)
+	if len(runSummary.Warnings) != 1 ||
+		!strings.HasSuffix(runSummary.Warnings[0], "are not attached: Lato") {
+		t.Errorf("(fonts/checkFonts) unexpected warnings: %v", runSummary.Warnings)
+	}
+
+	// Nothing is reported once every font is attached
+	runSummary = &Summary{}
+	fonts = append(fonts, create("Lato.ttf", testFont("Lato")))
+
+	checkFonts(dir, []os.FileInfo{sub}, fonts)
+	if len(runSummary.Warnings) != 0 {
+		t.Errorf("(fonts/checkFonts) unexpected warnings: %v", runSummary.Warnings)
+	}
+}
@@ -0,0 +1,179 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+VideoStream contains the values reported by FFprobe for the first video stream of a
+file, values that aren't available are zero
+*/
+type videoStream struct {
+	frames   int64   // frame count from the container, or the `NUMBER_OF_FRAMES` tag
+	packets  int64   // packets counted, only with `-count_packets`
+	rate     float64 // average frame rate
+	duration float64 // duration of the stream (in seconds)
+}
+
+/*
+ProbeVideoStream uses FFprobe to fetch the values for the first video stream of a file.
+Packets are counted only if requested - this requires reading the entire file.
+*/
+func probeVideoStream(
+	ctx context.Context,
+	ffprobePath, path string,
+	countPackets bool,
+) (videoStream, error) {
+	// Command being fired:
+	// `ffprobe -v error -select_streams v:0 -show_entries <entries>
+	// -of default=noprint_wrappers=1 [-count_packets] <file>`
+	args := []string{
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries",
+		"stream=nb_frames,nb_read_packets,avg_frame_rate,duration" +
+			":stream_tags=NUMBER_OF_FRAMES",
+		"-of", "default=noprint_wrappers=1",
+	}
+
+	if countPackets {
+		args = append(args, "-count_packets")
+	}
+
+	output, err := commons.ToolCommand(ctx, ffprobePath, append(args, path)...).Output()
+	if err != nil {
+		log.Debugf(
+			"(frames/probeVideoStream) failed to probe file: \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+
+		return videoStream{}, err
+	}
+
+	return parseVideoStream(string(output)), nil
+}
+
+/*
+ParseVideoStream parses the `key=value` lines printed by FFprobe for a video stream.
+Values that aren't available (i.e. `N/A`) are left out, tags may carry a language
+suffix (i.e. `TAG:NUMBER_OF_FRAMES-eng`) as written by mkvmerge.
+*/
+func parseVideoStream(output string) (stream videoStream) {
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimPrefix(strings.ToLower(parts[0]), "tag:")
+		value := strings.ReplaceAll(strings.TrimSpace(parts[1]), ",", ".")
+
+		switch {
+		case key == "nb_frames", strings.HasPrefix(key, "number_of_frames"):
+			if frames, err := strconv.ParseInt(value, 10, 64); err == nil &&
+				frames > stream.frames {
+				stream.frames = frames
+			}
+
+		case key == "nb_read_packets":
+			if packets, err := strconv.ParseInt(value, 10, 64); err == nil {
+				stream.packets = packets
+			}
+
+		case key == "avg_frame_rate":
+			stream.rate = parseFrameRate(value)
+
+		case key == "duration":
+			if duration, err := strconv.ParseFloat(value, 64); err == nil {
+				stream.duration = duration
+			}
+		}
+	}
+
+	return stream
+}
+
+/*
+ParseFrameRate parses a frame rate in the form printed by FFprobe, i.e. `24000/1001`.
+Returns zero if the frame rate is unknown (i.e. `0/0`) or invalid.
+*/
+func parseFrameRate(value string) float64 {
+	parts := strings.SplitN(value, "/", 2)
+
+	num, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0
+	}
+
+	den := 1.0
+	if len(parts) == 2 {
+		if den, err = strconv.ParseFloat(parts[1], 64); err != nil || den == 0 {
+			return 0
+		}
+	}
+
+	if rate := num / den; rate > 0 && !math.IsInf(rate, 0) {
+		return rate
+	}
+
+	return 0
+}
+
+/*
+FrameCount fetches the total number of frames in the first video stream of the media
+file, trying each method until one succeeds - the frame count from the container,
+counting the packets with FFprobe, counting the frames with FFmpeg, and finally
+estimating the count from the duration and the average frame rate.
+
+Variable frame rate files (i.e. WebM) often carry no frame count, and counting may
+fail for broken files; the estimate is close enough for the progress shown.
+*/
+func (update *Updates) frameCount(mediaFile string) (int64, error) {
+	ctx := update.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ffprobe := update.userInput.FFprobePath
+	stream, _ := probeVideoStream(ctx, ffprobe, mediaFile, false)
+	if stream.frames > 0 {
+		log.Debugf(`(frames/frameCount) frame count from container: "%s"`, mediaFile)
+		return stream.frames, nil
+	}
+
+	if counted, err := probeVideoStream(ctx, ffprobe, mediaFile, true); err == nil &&
+		counted.packets > 0 {
+		log.Debugf(`(frames/frameCount) frame count from packets: "%s"`, mediaFile)
+		return counted.packets, nil
+	}
+
+	if frames, err := update.getTotalFrames(mediaFile); err == nil && frames > 0 {
+		return frames, nil
+	}
+
+	// Streams in Matroska files have no duration of their own, the duration of the
+	// file is used instead
+	duration := stream.duration
+	if duration <= 0 {
+		duration, _ = probeDuration(ctx, ffprobe, mediaFile)
+	}
+
+	if stream.rate > 0 && duration > 0 {
+		log.Debugf(
+			`(frames/frameCount) frame count estimated from duration: "%s"`,
+			mediaFile,
+		)
+
+		return int64(math.Round(duration * stream.rate)), nil
+	}
+
+	return 0, errors.New("unable to determine the frame count")
+}
@@ -0,0 +1,146 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestParseVideoStream(t *testing.T) {
+	output := "avg_frame_rate=24000/1001\nduration=N/A\nnb_frames=N/A\n" +
+		"nb_read_packets=1234\nTAG:NUMBER_OF_FRAMES-eng=34070\n"
+
+	expected := videoStream{frames: 34070, packets: 1234, rate: 24000.0 / 1001}
+	if stream := parseVideoStream(output); stream != expected {
+		t.Errorf(
+			"(frames/parseVideoStream) unexpected values \nexpected: %+v \nfound: %+v",
+			expected,
+			stream,
+		)
+	}
+
+	output = "avg_frame_rate=0/0\nduration=12,5\nnb_frames=300\n"
+	expected = videoStream{frames: 300, duration: 12.5}
+	if stream := parseVideoStream(output); stream != expected {
+		t.Errorf(
+			"(frames/parseVideoStream) unexpected values \nexpected: %+v \nfound: %+v",
+			expected,
+			stream,
+		)
+	}
+}
+
+func TestParseFrameRate(t *testing.T) {
+	for value, rate := range map[string]float64{
+		"25/1":    25,
+		"30":      30,
+		"0/0":     0,
+		"invalid": 0,
+		"-25/1":   0,
+	} {
+		if res := parseFrameRate(value); res != rate {
+			t.Errorf(
+				"(frames/parseFrameRate) unexpected frame rate for `%s` \nexpected: %v"+
+					"\nfound: %v",
+				value,
+				rate,
+				res,
+			)
+		}
+	}
+}
+
+func TestFrameCount(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	update := Updates{userInput: &commons.UserInput{FFprobePath: "ffprobe"}}
+
+	// Values returned by each step, steps fail unless set
+	var probed, counted videoStream
+	var ffmpegFrames int64
+	var duration float64
+
+	monkey.Patch(probeVideoStream, func(
+		_ context.Context,
+		_, _ string,
+		countPackets bool,
+	) (videoStream, error) {
+		if countPackets {
+			return counted, nil
+		}
+
+		return probed, nil
+	})
+
+	// FFmpeg prints the frame count to `stderr`
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&exec.Cmd{}),
+		"Run",
+		func(cmd *exec.Cmd) error {
+			if ffmpegFrames == 0 {
+				return errors.New("test failure")
+			}
+
+			_, err := fmt.Fprintf(cmd.Stderr, "frame=  %d fps=0.0", ffmpegFrames)
+			return err
+		},
+	)
+
+	monkey.Patch(probeDuration, func(context.Context, string, string) (float64, error) {
+		return duration, nil
+	})
+
+	// Nothing works, the frame count is unknown
+	if frames, err := update.frameCount("video.webm"); frames != 0 || err == nil {
+		t.Errorf("(frames/frameCount) expected failure \nframes: %d", frames)
+	}
+
+	// Each step is used only if the steps before it fail
+	probed = videoStream{rate: 25}
+	duration = 10
+	steps := []struct {
+		apply  func()
+		frames int64
+	}{
+		{func() {}, 250},
+		{func() { ffmpegFrames = 260 }, 260},
+		{func() { counted = videoStream{packets: 270} }, 270},
+		{func() { probed.frames = 280 }, 280},
+	}
+
+	for i, step := range steps {
+		step.apply()
+		if frames, err := update.frameCount("video.webm"); frames != step.frames ||
+			err != nil {
+			t.Errorf(
+				"(frames/frameCount) unexpected frame count at step %d \nexpected: %d"+
+					"\nfound: %d \nerror: %v",
+				i,
+				step.frames,
+				frames,
+				err,
+			)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	update := Updates{totalFrames: 200}
+	if res := update.percent(50, time.Second); res != 25 {
+		t.Errorf("(Updates/percent) unexpected progress from frames: %v", res)
+	}
+
+	// Duration is used if the frame count is unknown
+	update = Updates{duration: 40 * time.Second}
+	if res := update.percent(50, 10*time.Second); res != 25 {
+		t.Errorf("(Updates/percent) unexpected progress from duration: %v", res)
+	}
+}
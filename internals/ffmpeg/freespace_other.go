@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package ffmpeg
+
+/*
+FreeSpace can't query the file system on this platform, the free space is treated as
+unknown - the estimate is printed without being checked.
+*/
+func freeSpace(string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package ffmpeg
+
+import "syscall"
+
+/*
+FreeSpace returns the space (in bytes) available to the user on the file system
+containing the directory, the boolean is false if it can't be determined.
+*/
+func freeSpace(dir string) (int64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false
+	}
+
+	//nolint:unconvert // field types differ across platforms
+	return int64(stat.Bavail) * int64(stat.Bsize), true
+}
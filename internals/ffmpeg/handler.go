@@ -8,15 +8,17 @@ these files together as required.
 package ffmpeg
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/demon-rem/auto-sub/internals/commons"
 	log "github.com/sirupsen/logrus"
@@ -46,6 +48,15 @@ var (
 		"sup",
 		"pgs",
 		"vtt",
+		"smi",
+		"mks",
+	}
+
+	// Subtitle containers - subtitle files that can contain multiple subtitle streams
+	// (and attachments), all of which are copied over as is. Should be present in
+	// `subsExt` as well.
+	subsContainerExt = []string{
+		"mks",
 	}
 
 	attachmentExt = []string{
@@ -58,6 +69,15 @@ var (
 	chaptersExt = []string{
 		"xml",
 	}
+
+	// Extensions for junk files - incomplete downloads, backups, etc. Files with these
+	// extensions are always ignored, regardless of the extension(s) preceding it.
+	junkExt = []string{
+		"part",
+		"tmp",
+		"bak",
+		"torrent",
+	}
 )
 
 /*
@@ -71,6 +91,7 @@ In case of failure, the function will internally print error message to the scre
 returning an error code as the result.
 */
 func TraverseRoot(
+	ctx context.Context, // cancels the run, FFmpeg/FFprobe processes are killed
 	input *commons.UserInput, // user input
 	resDir string, // full path to output directory
 ) (exitCode int, err error) {
@@ -81,37 +102,97 @@ func TraverseRoot(
 		resDir,
 	)
 
-	// Check if result directory exists in the root directory, if not, attempt to
-	// create one - return error if the latter fails
-	item, err := os.Stat(resDir)
-	if os.IsNotExist(err) {
-		log.Debugf(
-			"(ffmpeg/TraverseRoot) creating result dir in: `%v`",
-			input.RootPath,
-		)
+	// Reset the summary - ensures results from a previous run (if any) are discarded
+	runSummary = &Summary{ResultDir: commons.NormalizePath(resDir)}
+	if !centralLayout(input) {
+		// Outputs are stored next to the source directories
+		runSummary.ResultDir = commons.NormalizePath(input.RootPath)
+	}
+	start := time.Now()
 
-		if err = os.Mkdir(resDir, os.ModeDir); err != nil {
-			log.Warnf(
-				`(ffmpeg/TraverseRoot) failed to create directory: "%s"`+
-					"\nerror traceback: `%v`\n",
-				resDir,
+	// Progress of the run is recorded as it goes, reporting where the previous run
+	// died (if it did)
+	if previous := runCheckpoint.crashed(); previous != nil {
+		runSummary.warn("previous run stopped unexpectedly while %s", previous)
+	}
+
+	runCheckpoint.begin("")
+
+	// Results of FFprobe are cached across runs (unless disabled), saved once the run
+	// completes
+	runProbeCache = openProbeCache(input)
+	defer func() {
+		runProbeCache.save()
+		runProbeCache = nil
+	}()
+
+	// FFmpeg processes share the read throughput allowed for the run (if limited)
+	runThrottle = openThrottle(input)
+	defer func() {
+		runThrottle.close()
+		runThrottle = nil
+	}()
+
+	// Custom classifier (if any) is started for the first file, stopped with the run
+	defer stopClassifier()
+
+	// Paths are normalized, ensures the result directory is recognized while
+	// traversing the root directory
+	resDir = commons.NormalizePath(resDir)
+
+	// Safe mode never writes to the source directories
+	if input.Safe {
+		if err = checkSafeResultDir(input, resDir); err != nil {
+			log.Debugf("(ffmpeg/TraverseRoot) safe mode violation \nerror: %v", err)
+			return commons.SafetyViolation, err
+		}
+	}
+
+	// Outputs are stored next to their source directories outside the central layout,
+	// the result directory is neither created nor checked
+	var item os.FileInfo
+	if centralLayout(input) {
+		// Check if result directory exists in the root directory, if not, attempt to
+		// create one - return error if the latter fails
+		item, err = os.Stat(resDir)
+		if os.IsNotExist(err) {
+			log.Debugf(
+				"(ffmpeg/TraverseRoot) creating result dir in: `%v`",
+				input.RootPath,
+			)
+
+			// Note: `os.ModeDir` does not carry any permission bits, the directory
+			// needs to be writable for the results to be stored in it
+			if err = os.Mkdir(resDir, os.ModeDir|0755); err != nil {
+				log.Warnf(
+					`(ffmpeg/TraverseRoot) failed to create directory: "%s"`+
+						"\nerror traceback: `%v`\n",
+					resDir,
+					err,
+				)
+
+				return commons.UnexpectedError,
+					errors.New("unable to create destination directory")
+			}
+
+			// Result directory was created by the application, remove it in case no
+			// output is written to it by the end of the run - nothing is removed in
+			// safe mode
+			if !input.Safe {
+				defer removeEmptyDir(resDir)
+			}
+		} else if err != nil || !item.IsDir() {
+			// Error if the check failed, or root path points to non-directory item
+			log.Debugf(
+				"(ffmpeg/TraverseRoot) failed to check for result directory "+
+					"\nerror: %v \nitem type: %+v",
 				err,
+				item,
 			)
 
 			return commons.UnexpectedError,
-				errors.New("unable to create destination directory")
+				errors.New("an unexpected internal error occurred")
 		}
-	} else if err != nil || !item.IsDir() {
-		// Error if the check failed, or root path points to non-directory item
-		log.Debugf(
-			"(ffmpeg/TraverseRoot) failed to check for result directory "+
-				"\nerror: %v \nitem type: %+v",
-			err,
-			item,
-		)
-
-		return commons.UnexpectedError,
-			errors.New("an unexpected internal error occurred")
 	}
 
 	// Iterate through the root directory, fetching a list of all items present in it
@@ -127,14 +208,104 @@ func TraverseRoot(
 	}
 
 	if input.IsDirect {
+		// Leftovers from earlier runs that did not complete - a result directory
+		// created by this run can't contain any
+		if item != nil {
+			cleanStale(input, resDir, start)
+		}
+
+		// Nothing left to do if the run being resumed completed the root directory,
+		// only failed source directories are to be processed, or the root directory
+		// is not in the plan applied
+		runQueue.plan([]string{directJob})
+		if runQueue.finished(directJob) ||
+			input.RetryFailed && !hasFailedMarker(input.RootPath) ||
+			!runPlan.includes(input.RootPath) {
+			runSummary.print()
+			return runResult(ctx)
+		}
+
+		// Ensure the outputs fit before starting, asks the user otherwise
+		if err = checkSpace(input, resDir, []string{input.RootPath}); err != nil {
+			return commons.InsufficientSpace, err
+		}
+
+		// Outputs are stored in the root directory itself outside the central layout
+		dirResDir, dirErr := layoutResDir(input, input.RootPath, resDir)
+		if dirErr != nil {
+			return commons.UnexpectedError, dirErr
+		}
+
+		// Pause the run outside the schedule window (if any), or while the system is
+		// busy with other work
+		if !waitForSchedule(ctx, input) || !waitForLoad(ctx, input) {
+			runSummary.print()
+			return runResult(ctx)
+		}
+
 		// The root directory is to be used as the source directory
-		sourceDir(
+		runQueue.set(directJob, jobRunning)
+		runCheckpoint.begin(input.RootPath)
+		runMetrics.working(1)
+		exitCode = sourceDir(
+			ctx,
 			input.RootPath,
-			resDir,
+			dirResDir,
 			input,
 		)
 
-		return commons.StatusOK, nil
+		runSummary.record(input.RootPath, exitCode)
+		runQueue.complete(directJob, exitCode, ctx.Err() != nil)
+		runCheckpoint.begin("")
+		runMetrics.working(-1)
+		updateMarker(input, input.RootPath, exitCode)
+		if exitCode == commons.StatusOK {
+			copyExtras(input.RootPath, resDir, dirResDir, input)
+		}
+
+		if !centralLayout(input) && !input.Safe {
+			removeEmptyDir(dirResDir)
+		}
+
+		runSummary.print()
+		if exitCode == commons.SafetyViolation {
+			return exitCode, errSafeMode
+		}
+
+		return runResult(ctx)
+	}
+
+	// Outputs are planned before processing anything, collisions across source
+	// directories are handled as per the policy supplied by the user - outputs can't
+	// collide outside the central layout
+	mirror := false
+	if centralLayout(input) {
+		if mirror, err = planOutputs(input, resDir, files); err != nil {
+			return commons.OutputCollision, err
+		}
+	}
+
+	// Leftovers from earlier runs are recognized through the outputs planned, after
+	// collisions are resolved
+	if item != nil {
+		cleanStale(input, resDir, start)
+	}
+
+	// Each source directory is a job in the queue (if any), jobs completed by a run
+	// being resumed are skipped
+	var names, sourceDirs []string
+	for _, f := range files {
+		if f.IsDir() && !isResultDir(filepath.Join(input.RootPath, f.Name()), resDir) {
+			names = append(names, f.Name())
+			sourceDirs = append(sourceDirs, filepath.Join(input.RootPath, f.Name()))
+		}
+	}
+
+	runQueue.plan(names)
+
+	// Ensure the outputs fit before starting, asks the user otherwise
+	if err = checkSpace(input, resDir, sourceDirs); err != nil {
+		return commons.InsufficientSpace, err
 	}
 
 	// Variable to keep a track of source directories preset in the root directory;
@@ -142,33 +313,194 @@ func TraverseRoot(
 	dirsFound := 0
 
 	// Iterate through the items present in root directory, treating each directory
-	// as a source directory!
-	for _, f := range files {
+	// as a source directory! Outputs are planned by name, only the order in which the
+	// source directories are processed changes
+	for _, f := range orderSourceDirs(input, resDir, files) {
 		if !f.IsDir() {
 			continue
 		}
 
+		if ctx.Err() != nil {
+			// Run cancelled, skip the remaining source directories
+			break
+		}
+
 		dirsFound++ // increment for each directory found
 		sourcePath := filepath.Join(input.RootPath, f.Name())
 
-		if sourcePath == resDir {
+		if isResultDir(sourcePath, resDir) {
 			// Don't use the directory containing results as a source directory
 			continue
 		}
 
+		if runQueue.finished(f.Name()) {
+			log.Debugf(
+				`(ffmpeg/TraverseRoot) source directory completed earlier: "%s"`,
+				sourcePath,
+			)
+
+			continue
+		}
+
+		// Only source directories marked as failed are to be processed
+		if input.RetryFailed && !hasFailedMarker(sourcePath) {
+			log.Debugf(
+				`(ffmpeg/TraverseRoot) source directory not marked as failed: "%s"`,
+				sourcePath,
+			)
+
+			runQueue.set(f.Name(), jobSkipped)
+			continue
+		}
+
+		if input.IgnoreDir(sourcePath) {
+			// Directory matches the exclusion rules, the function call logs internally
+			runSummary.skip(sourcePath)
+			runQueue.set(f.Name(), jobSkipped)
+			continue
+		}
+
+		// Skip directories that do not match the inclusion rules, unless they contain
+		// a media file that does - `groupFiles` filters media files internally
+		if !input.IncludeItem(f.Name()) {
+			mediaFiles, _, _, _ := groupFiles(sourcePath, input)
+			releaseArchives()
+			if len(mediaFiles) == 0 {
+				log.Debugf(
+					`(ffmpeg/TraverseRoot) skip source directory: "%s"`,
+					sourcePath,
+				)
+
+				runSummary.skip(sourcePath)
+				runQueue.set(f.Name(), jobSkipped)
+				continue
+			}
+		}
+
+		// Source directories left out of the plan applied to the run are skipped
+		if !runPlan.includes(sourcePath) {
+			log.Debugf(
+				`(ffmpeg/TraverseRoot) source directory not in the plan: "%s"`,
+				sourcePath,
+			)
+
+			runSummary.skip(sourcePath)
+			runQueue.set(f.Name(), jobSkipped)
+			continue
+		}
+
+		// Pause the run outside the schedule window (if any), or while the system is
+		// busy with other work - only source directories that are to be processed
+		// wait, skipped ones are recorded right away
+		if !waitForSchedule(ctx, input) || !waitForLoad(ctx, input) {
+			break
+		}
+
+		// Outputs for each source directory are stored in a separate directory in the
+		// mirrored layout, or next to the source directory outside the central layout
+		dirResDir := resDir
+		var dirErr error
+
+		switch {
+		case mirror:
+			dirResDir = joinPath(resDir, f.Name())
+			dirErr = os.MkdirAll(dirResDir, 0755)
+
+		case !centralLayout(input):
+			dirResDir, dirErr = layoutResDir(input, sourcePath, resDir)
+		}
+
+		if dirErr != nil {
+			log.Warnf(
+				`(ffmpeg/TraverseRoot) failed to create directory: "%s"`+
+					"\nerror: %v",
+				dirResDir,
+				dirErr,
+			)
+
+			runSummary.record(sourcePath, commons.UnexpectedError)
+			runQueue.set(f.Name(), jobFailed)
+			continue
+		}
+
 		// The method call will handle the rest of the part for the source directory
-		sourceDir(sourcePath, resDir, input)
+		runQueue.set(f.Name(), jobRunning)
+		runCheckpoint.begin(sourcePath)
+		runMetrics.working(1)
+		exitCode = sourceDir(ctx, sourcePath, dirResDir, input)
+		runSummary.record(sourcePath, exitCode)
+		runQueue.complete(f.Name(), exitCode, ctx.Err() != nil)
+		runCheckpoint.begin("")
+		runMetrics.working(-1)
+		updateMarker(input, sourcePath, exitCode)
+		if exitCode == commons.StatusOK {
+			copyExtras(
+				sourcePath,
+				resDir,
+				extrasDir(input, dirResDir, f.Name(), mirror),
+				input,
+			)
+		}
+
+		if (mirror || !centralLayout(input)) && !input.Safe {
+			removeEmptyDir(dirResDir)
+		}
+
+		if exitCode == commons.SafetyViolation {
+			// Safety violations stop the run, skip the remaining source directories
+			runSummary.print()
+			return exitCode, errSafeMode
+		}
 	}
 
-	if dirsFound == 0 {
+	if dirsFound == 0 && ctx.Err() == nil {
 		// Fail if the root directory does not contain any source directories
 		return commons.RootDirectoryIncorrect,
 			errors.New("root directory does not contain any source directories")
 	}
 
+	runSummary.print()
+	return runResult(ctx)
+}
+
+/*
+RunResult returns the result for a run once the summary has been printed - the run is
+reported as interrupted if the context was cancelled midway.
+*/
+func runResult(ctx context.Context) (exitCode int, err error) {
+	if ctx.Err() != nil {
+		log.Debugf("(ffmpeg/runResult) run cancelled \nerror: %v", ctx.Err())
+		return commons.Interrupted, errors.New("run cancelled")
+	}
+
 	return commons.StatusOK, nil
 }
 
+/*
+RemoveEmptyDir removes a directory only if it is empty - used to clean up the result
+directory if it was created during a run that produced no output (for example, when
+every source directory fails). Ensures the root directory remains clean for retries.
+*/
+func removeEmptyDir(dir string) {
+	if items, err := ioutil.ReadDir(dir); err != nil || len(items) != 0 {
+		// Directory contains output (or can't be read), leave it as is.
+		return
+	}
+
+	if err := os.Remove(dir); err != nil {
+		log.Debugf(
+			`(ffmpeg/removeEmptyDir) failed to remove empty directory: "%s"`+
+				"\nerror: %v",
+			dir,
+			err,
+		)
+
+		return
+	}
+
+	log.Debugf(`(ffmpeg/removeEmptyDir) removed empty directory: "%s"`, dir)
+}
+
 /*
 SourceDir is the central function that makes calls to FFmpeg to soft-sub media file(s)
 with extras found in the source directory.
@@ -176,7 +508,18 @@ with extras found in the source directory.
 Once the command is fired, the function will then internally monitor the encoding
 progress via a goroutine.
 */
-func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int) {
+func sourceDir(
+	ctx context.Context,
+	sourceDir, resDir string,
+	input *commons.UserInput,
+) (exitCode int) {
+	// Logs for each source directory are placed in a section of their own
+	endSection := logSection(sourceDir)
+	defer func() { endSection(exitCode) }()
+
+	// Archives extracted while grouping the files are removed once done
+	defer releaseArchives()
+
 	log.Debugf(`(ffmpeg/sourceDir) processing source directory: "%s"`, sourceDir)
 
 	// Fetch grouped list of files present in the source directory
@@ -185,6 +528,19 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 		input,
 	)
 
+	// Attach subtitles in the order of languages preferred by the user (if any),
+	// exact duplicates are left out - the first copy in order is kept
+	subtitles = orderSubtitles(subtitles, input.SubOrder)
+
+	var duplicates []duplicateFile
+	subtitles, duplicates = dedupeSubtitles(sourceDir, subtitles)
+	for _, duplicate := range duplicates {
+		runSummary.duplicate(
+			joinPath(sourceDir, duplicate.file.Name()),
+			duplicate.original.Name(),
+		)
+	}
+
 	log.Debugf(
 		`(ffmpeg/sourceDir) grouped files for source directory "%s"`+
 			"\nMediafile: %s \nChapters: %s \nSubtitles: %s \nAttachments: %s",
@@ -195,23 +551,57 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 		commons.Stringify(&attachments),
 	)
 
+	// In extract-and-merge mode, the streams from the donor are merged into the raw
+	// media file, along with any additional files present
+	if input.DonorRule != nil {
+		raw, donor, err := donorFiles(mediaFiles, input)
+		if err != nil {
+			log.Debugf(
+				`(ffmpeg/sourceDir) donor/raw media files not found in: "%s"`+
+					"\nerror: %v",
+				sourceDir,
+				err,
+			)
+
+			commons.Error("%v \n\tPath: \"%s\"\n\n", err, sourceDir)
+			return commons.SourceDirectoryError
+		}
+
+		// Time allowed for the source directory is scaled by the duration
+		jobCtx, cancel := withJobTimeout(ctx, sourceDir, input, []os.FileInfo{raw})
+		defer cancel()
+
+		return mergeOutputs(
+			jobCtx,
+			sourceDir,
+			resDir,
+			input,
+			raw,
+			donor,
+			joinPath(resDir, plannedName(sourceDir, outputName(raw.Name(), input))),
+			subtitlesFor(raw, subtitles),
+			attachments,
+			chapters,
+		)
+	}
+
 	/*
 		Performing basic checks on list of file(s) found, ensuring the directory
-		contains exactly one media file, and at least one attachment/subtitle/chapter
-		file, etc.
+		contains exactly one media file (unless all media files are to be processed),
+		and at least one attachment/subtitle/chapter file, etc.
 
 		Force-stop in case any thing is missing
 	*/
 	switch {
 	case len(mediaFiles) == 0:
 		log.Debugf(`(ffmpeg/sourceDir) no media file in path: "%s"`, sourceDir)
-		commons.Printf(
-			`Error: failed to locate any media file \n\tPath: "%s"`,
+		commons.Error(
+			"Failed to locate any media file\n\t"+`Path: "%s"`,
 			sourceDir,
 		)
 
 		return commons.SourceDirectoryError
-	case len(mediaFiles) > 1:
+	case len(mediaFiles) > 1 && !input.AllMedia:
 		log.Debugf(
 			"(ffmpeg/sourceDir) mutiple media files found in source directory"+
 				"\ndirectory: `%v` \nfiles: %v",
@@ -219,8 +609,8 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 			commons.Stringify(&mediaFiles),
 		)
 
-		commons.Printf(
-			"Error: multiple media files in source directory\n\t"+`Path: "%s"`+
+		commons.Error(
+			"Multiple media files in source directory\n\t"+`Path: "%s"`+
 				"\n\nFiles found: \n%s",
 			sourceDir,
 			commons.Stringify(&mediaFiles),
@@ -230,12 +620,12 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 	case len(subtitles) == 0 && len(attachments) == 0 && len(chapters) == 0:
 		// There should be at least one subtitle/chapter/attachment file
 		log.Debugf(
-			`"(ffmpeg/sourceDir) failed to locate additional files.\npath: "%v"`,
+			"(ffmpeg/sourceDir) failed to locate additional files \npath: \"%v\"",
 			sourceDir,
 		)
 
-		commons.Printf(
-			"Error: failed to find any additional files in source directory\n"+
+		commons.Error(
+			"Failed to find any additional files in source directory\n"+
 				`Path: "%s"`,
 			sourceDir,
 		)
@@ -243,34 +633,507 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 		return commons.SourceDirectoryError
 	}
 
-	// Generate the FFmpeg command to run for the source directory
-	cmd := generateCmd(
+	// Merge the extra files into each media file - the source directory fails if
+	// any one of the media files fails, the rest are processed regardless
+	exitCode = commons.StatusOK
+	outputs := outputNames(mediaFiles, input)
+
+	// Time allowed for the source directory is scaled by the duration of the media
+	// files, the remaining media files fail once it runs out
+	jobCtx, cancel := withJobTimeout(ctx, sourceDir, input, mediaFiles)
+	defer cancel()
+
+	for i, mediaFile := range mediaFiles {
+		code := mergeOutputs(
+			jobCtx,
+			sourceDir,
+			resDir,
+			input,
+			mediaFile,
+			nil,
+			joinPath(resDir, plannedName(sourceDir, outputs[i])),
+			subtitlesFor(mediaFile, subtitles),
+			attachments,
+			chapters,
+		)
+
+		exitCode = combineStatus(exitCode, code)
+	}
+
+	return exitCode
+}
+
+/*
+MediaFileCmd merges the extra files found in a source directory into a single media
+file, storing the result at the output path. The donor is the media file streams are
+extracted from in extract-and-merge mode, nil otherwise.
+*/
+func mediaFileCmd(
+	ctx context.Context,
+	sourceDir, resDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	donor os.FileInfo,
+	output string,
+	subtitles,
+	attachments,
+	chapters []os.FileInfo,
+) (exitCode int) {
+	// The way the FFmpeg process failed, if it did
+	var failure *ProcessFailure
+
+	defer func() {
+		commons.EmitResult(fileResult{
+			Event:     "file",
+			SourceDir: sourceDir,
+			MediaFile: mediaFile.Name(),
+			Output:    output,
+			Status:    resultStatus(exitCode),
+			ExitCode:  exitCode,
+			Process:   failure,
+		})
+	}()
+
+	// Attach the subtitle file best matching the media file, unless every subtitle
+	// file is to be kept
+	subtitles = selectSubtitles(sourceDir, input, mediaFile, subtitles)
+
+	// Names that aren't valid UTF-8 are used as is, the titles formed from them are
+	// sanitized - reported, since the titles won't match the names exactly
+	reportInvalidNames(sourceDir, mediaFile, subtitles, attachments, chapters)
+
+	// Media files with more subtitles/attachments than allowed are skipped
+	if code := checkLimits(
 		sourceDir,
 		input,
-		resDir,
+		mediaFile,
+		subtitles,
+		attachments,
+	); code != commons.StatusOK {
+		return code
+	}
+
+	// Subtitles that can't be written with the codec forced by the user fail
+	if code := checkSubtitleCodecs(sourceDir, input, subtitles); code !=
+		commons.StatusOK {
+		return code
+	}
+
+	// Fonts used by ASS subtitles should be attached, missing fonts are reported
+	checkFonts(sourceDir, subtitles, attachments)
+
+	// Compare subtitle durations against the media file, warnings (if any) are
+	// added to the summary - skip the media file on a mismatch if sync is enforced
+	if !checkSync(ctx, sourceDir, input, mediaFile, subtitles) && input.StrictSync {
+		log.Debugf(
+			`(ffmpeg/mediaFileCmd) subtitles out of sync for file: "%s"`,
+			joinPath(sourceDir, mediaFile.Name()),
+		)
+
+		commons.Error(
+			"Subtitle duration does not match the media file\n\t"+
+				`Path: "%s"`+"\n\n",
+			joinPath(sourceDir, mediaFile.Name()),
+		)
+
+		return commons.SourceDirectoryError
+	}
+
+	// The output is never one of the inputs, even if the user points the result
+	// directory at the source directory - checked before an existing output is removed
+	inputs := [][]os.FileInfo{{mediaFile}, subtitles, attachments, chapters}
+	if donor != nil {
+		inputs = append(inputs, []os.FileInfo{donor})
+	}
+
+	if code := checkSameFile(sourceDir, output, inputs...); code != commons.StatusOK {
+		return code
+	}
+
+	hash := inputsHash(input, mediaFile, donor, subtitles, attachments, chapters)
+
+	// Files replaced by the output are never removed by undoing the run
+	runSummary.replacing(output, output+snapshotExt, output+metadataExt)
+
+	// FFmpeg won't overwrite an existing output, ask the user before removing it -
+	// existing outputs are skipped directly if required, unless the inputs changed
+	// since the output was created. Samples are always recreated
+	if _, err := os.Stat(output); err == nil {
+		if input.SkipExisting && input.Sample == 0 && readSnapshot(output) == hash {
+			log.Debugf(`(ffmpeg/mediaFileCmd) skip up-to-date output: "%s"`, output)
+			return statusSkipped
+		}
+
+		if input.Safe {
+			log.Debugf(`(ffmpeg/mediaFileCmd) output exists in safe mode: "%s"`, output)
+			commons.Error(
+				"Output file already exists, can't be overwritten in safe mode"+
+					"\n\tPath: \"%s\"\n\n",
+				output,
+			)
+
+			return commons.SafetyViolation
+		}
+
+		if input.SkipExisting {
+			log.Debugf(
+				`(ffmpeg/mediaFileCmd) inputs changed, recreating output: "%s"`,
+				output,
+			)
+
+			commons.Info(
+				"Inputs changed since the output was created, reprocessing"+
+					"\n\tPath: \"%s\"\n\n",
+				joinPath(sourceDir, mediaFile.Name()),
+			)
+		} else if !input.Prompt.Confirm(
+			fmt.Sprintf("Output file %q already exists, overwrite?", output),
+		) {
+			log.Debugf(`(ffmpeg/mediaFileCmd) skip existing output: "%s"`, output)
+			return statusSkipped
+		}
 
-		// grouped list of files present inside the source directory
-		mediaFiles[0], // flow-of-control ensures the array has exactly one item
+		if err := commons.Discard(output); err != nil {
+			log.Debugf(
+				`(ffmpeg/mediaFileCmd) failed to remove existing output: "%s"`+
+					"\nerror: %v",
+				output,
+				err,
+			)
+
+			return commons.UnexpectedError
+		}
+	}
+
+	// The snapshot (and the metadata) is saved again once the output is created
+	removeSnapshot(output)
+	removeMetadata(output)
+
+	// Text subtitles are converted to UTF-8 (if required) before being attached
+	cleanup := convertSubtitles(ctx, sourceDir, input, subtitles)
+	defer cleanup()
+
+	// The output is written to a partial file, renamed to the output once the encode
+	// completes - a crash never leaves behind an output that looks complete
+	partial := partialPath(output)
+	if exitCode := discardPartial(input, partial); exitCode != commons.StatusOK {
+		return exitCode
+	}
+
+	// The streams in the output are traced ahead of the first attempt alone
+	traceStreams(
+		ctx,
+		sourceDir,
+		input,
+		output,
+		mediaFile,
+		donor,
 		subtitles,
 		attachments,
 		chapters,
 	)
 
-	/*
-		Two buffers; will be used to read command output as the command runs
+	// Attachments alone are added to Matroska media files through a copy edited in
+	// place, FFmpeg is run only if the fast path can't be taken (or fails)
+	if fastPath(input, mediaFile, donor, subtitles, attachments, chapters) {
+		err := attachInPlace(
+			ctx,
+			sourceDir,
+			input,
+			mediaFile,
+			partial,
+			attachments,
+			chapters,
+		)
+
+		if err == nil {
+			return finishOutput(ctx, input, partial, output, hash)
+		}
+
+		log.Debugf(
+			"(ffmpeg/mediaFileCmd) fast path failed for: \"%s\", remuxing \nerror: %v",
+			joinPath(sourceDir, mediaFile.Name()),
+			err,
+		)
+
+		if exitCode := discardPartial(input, partial); exitCode != commons.StatusOK {
+			return exitCode
+		}
+	}
+
+	// Run FFmpeg for the media file, retrying stalled encodes if required
+	for attempt := 0; ; attempt++ {
+		// Generate the FFmpeg command to run for the media file - a command can't be
+		// reused, generating a new one for each attempt
+		cmd := generateCmd(
+			ctx,
+			sourceDir,
+			input,
+			partial,
+
+			// grouped list of files present inside the source directory
+			mediaFile,
+			donor,
+			subtitles,
+			attachments,
+			chapters,
+		)
+
+		// Attachments are added once FFmpeg completes if the command would be too
+		// long to run otherwise, commands still too long fail right away
+		attachAfter := commandLength(cmd.Args) > argLimit &&
+			attachLater(input, output, attachments, chapters)
+		if attachAfter {
+			log.Debugf(
+				`(ffmpeg/mediaFileCmd) command too long for "%s", attaching later`,
+				joinPath(sourceDir, mediaFile.Name()),
+			)
+
+			cmd = generateCmd(
+				ctx,
+				sourceDir,
+				input,
+				partial,
+				mediaFile,
+				donor,
+				subtitles,
+				nil,
+				nil,
+			)
+		}
+
+		if code := checkCommandLength(
+			cmd.Args,
+			joinPath(sourceDir, mediaFile.Name()),
+		); code != commons.StatusOK {
+			return code
+		}
+
+		stalled, process, err := encode(
+			ctx,
+			sourceDir,
+			resDir,
+			input,
+			mediaFile,
+			attempt,
+			cmd,
+		)
+		failure = process
+
+		switch {
+		case err == nil && attachAfter:
+			err = addAttachments(ctx, sourceDir, input, partial, attachments, chapters)
+			if err != nil {
+				commons.Error(
+					"Failed to add attachments to the output \n\tPath: \"%s\""+
+						"\n\tError: %v\n\n",
+					joinPath(sourceDir, mediaFile.Name()),
+					err,
+				)
+
+				return failedOutput(input, partial, commons.SourceDirectoryError)
+			}
+
+			return finishOutput(ctx, input, partial, output, hash)
+
+		case err == nil:
+			return finishOutput(ctx, input, partial, output, hash)
+
+		case timedOut(ctx):
+			// Source directory ran past its timeout, the process was killed
+			runSummary.processFailed(failure)
+			runSummary.warn(
+				`encode killed once its source directory timed out: "%s"`,
+				joinPath(sourceDir, mediaFile.Name()),
+			)
+
+			return failedOutput(input, partial, commons.TimedOut)
+
+		case ctx.Err() != nil:
+			// Run cancelled, the process was killed
+			runSummary.processFailed(failure)
+			return failedOutput(input, partial, commons.Interrupted)
+
+		case !stalled || !input.KillStalled || attempt >= input.StallRetries:
+			runSummary.processFailed(failure)
+			return failedOutput(input, partial, commons.UnexpectedError)
+		}
+
+		if exitCode := discardPartial(input, partial); exitCode != commons.StatusOK {
+			return exitCode
+		}
+
+		commons.Warn(
+			"Retrying stalled encode (%d/%d) \n\tPath: \"%s\"\n\n",
+			attempt+1,
+			input.StallRetries,
+			joinPath(sourceDir, mediaFile.Name()),
+		)
+	}
+}
+
+/*
+ReportInvalidNames adds a warning to the summary for each file (going into the output
+of a media file) with a name that isn't valid UTF-8, along with the name used for it
+in the metadata of the output.
+*/
+func reportInvalidNames(
+	sourceDir string,
+	mediaFile os.FileInfo,
+	groups ...[]os.FileInfo,
+) {
+	for _, files := range append(groups, []os.FileInfo{mediaFile}) {
+		for _, file := range files {
+			if utf8.ValidString(file.Name()) {
+				continue
+			}
+
+			runSummary.warn(
+				`name is not valid UTF-8, sanitized to "%s" in the metadata: "%s"`,
+				commons.SanitizeText(filepath.Base(file.Name())),
+				commons.SanitizeText(joinPath(sourceDir, file.Name())),
+			)
+		}
+	}
+}
+
+/*
+FinishOutput puts a complete partial output in place - the track statistics tags are
+written (if required) before the partial output is renamed, followed by the snapshot
+of the inputs, the metadata and the MP4 variant of the output.
+*/
+func finishOutput(
+	ctx context.Context,
+	input *commons.UserInput,
+	partial, output, hash string,
+) int {
+	// Statistics tags are written before the output is put in place, failing to write
+	// them leaves the output usable
+	if input.StatsTags {
+		if err := writeStatsTags(ctx, input, partial); err != nil {
+			runSummary.warn(`track statistics not written to "%s": %v`, output, err)
+		}
+	}
+
+	if err := os.Rename(partial, output); err != nil {
+		log.Debugf(
+			"(ffmpeg/finishOutput) failed to rename partial output: \"%s\" \nerror: %v",
+			partial,
+			err,
+		)
+
+		return commons.UnexpectedError
+	}
+
+	writeSnapshot(output, hash)
 
-		One of buffer will be used to actively track (and update) the progress using a
-		goroutine in the background - this buffer will be cleared by the background
-		thread when required.
+	// Metadata is exported for the output in place, failing to export it leaves the
+	// output as is
+	if input.ExportMetadata {
+		if err := exportMetadata(ctx, input, output); err != nil {
+			runSummary.warn(`metadata not exported for "%s": %v`, output, err)
+		}
+	}
+
+	// MP4 variant is formed from the output in a second pass, failing to write it
+	// leaves the output as is
+	if input.AlsoMP4 {
+		if err := writeMP4(ctx, input, output); err != nil {
+			runSummary.warn(`MP4 variant not written for "%s": %v`, output, err)
+		}
+	}
+
+	runSummary.merged(output)
+	return commons.StatusOK
+}
 
-		Second buffer will be used as a log dump, i.e. to log the output if needed in
-		case of a crash.
+/*
+DiscardPartial removes the partial output (if any) before an encode, FFmpeg won't
+overwrite an existing file - the partial output is kept aside in safe mode instead.
+Returns the exit code for the failure, if the partial output can't be removed.
+*/
+func discardPartial(input *commons.UserInput, partial string) int {
+	if input.Safe {
+		if failedOutput(input, partial, commons.StatusOK) != commons.StatusOK {
+			return commons.SafetyViolation
+		}
+
+		return commons.StatusOK
+	}
+
+	if err := commons.Discard(partial); err != nil {
+		log.Debugf(
+			`(ffmpeg/discardPartial) failed to remove partial output: "%s"`+
+				"\nerror: %v",
+			partial,
+			err,
+		)
+
+		return commons.UnexpectedError
+	}
+
+	return commons.StatusOK
+}
+
+/*
+Encode runs the FFmpeg command for a source directory, monitoring the encoding progress
+via a goroutine while the command runs.
+
+The boolean returned indicates if the encode stalled at some point, the way the FFmpeg
+process failed is returned along with the error (if any).
+*/
+func encode(
+	ctx context.Context,
+	sourceDir, resDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	attempt int, // zero for the first attempt
+	cmd *exec.Cmd,
+) (stalled bool, failure *ProcessFailure, err error) {
+	/*
+		The progress is reported by FFmpeg on `stdout` (through `-progress pipe:1`),
+		and will be parsed as the command runs, to track (and update) the progress
+		using a goroutine in the background.
+
+		Output from `stderr` is collected in a buffer used as a log dump, i.e. to log
+		the output if needed in case of a crash.
 	*/
-	var progBuf strings.Builder
+	progress := &progressParser{}
 	var logBuf strings.Builder
 
-	// Redirecting output from `stderr` to both buffers at once.
-	cmd.Stderr = io.MultiWriter(&progBuf, &logBuf)
+	cmd.Stdout = progress
+	cmd.Stderr = &logBuf
+
+	// Starting the command, the process is required to kill the encode if it stalls
+	started := time.Now()
+	if err = cmd.Start(); err != nil {
+		log.Debugf("(ffmpeg/encode) failed to start ffmpeg command \nerror: %v", err)
+
+		failure = classifyFailure(ctx, err, false, "")
+		failure.Path = joinPath(sourceDir, mediaFile.Name())
+		return false, failure, err
+	}
+
+	// Read throughput of the process is limited right away, if required
+	runThrottle.limit(cmd.Process)
+
+	// An instance of the updates structure; will perform updates in the background
+	updateThread := Updates{
+		ctx:         ctx,
+		userInput:   input,
+		filePath:    joinPath(sourceDir, mediaFile.Name()),
+		fileName:    mediaFile.Name(),
+		sourceDir:   sourceDir,
+		resDir:      resDir,
+		totalFrames: 0,
+		process:     cmd.Process,
+
+		// The output is the last argument of the command
+		output: cmd.Args[len(cmd.Args)-1],
+	}
+
+	// Initializing the updates variable; performs internal household chores
+	updateThread.Initialize()
 
 	// Channel to send signal to the background thread performing updates. The channel
 	// ensures that flow-of-control is retained by this function as long as updates
@@ -280,13 +1143,14 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 	// Deferred function call to ensure the goroutine stops before this function ends
 	defer func(sig *chan bool) {
 		log.Debugf(
-			"(ffmpeg/sourceDir) wrapping up progress thread for source "+
+			"(ffmpeg/encode) wrapping up progress thread for source "+
 				`directory: "%s"`,
 			sourceDir,
 		)
 
 		// Emitting a signal; informs the goroutine that that the ffmpeg command has
-		// completed its execution.
+		// completed its execution - along with the reason, if it failed.
+		updateThread.failure = err
 		*sig <- true
 
 		// Receive a value from the signal - acts as an indicator from the goroutine
@@ -296,42 +1160,68 @@ func sourceDir(sourceDir, resDir string, input *commons.UserInput) (exitCode int
 		// Finally, close the channel as well.
 		close(*sig)
 
+		// The goroutine has stopped, safe to check if the encode stalled
+		stalled = updateThread.stalled
+		if err != nil {
+			failure = classifyFailure(ctx, err, stalled, logBuf.String())
+			failure.Path = updateThread.filePath
+		}
+
 		log.Debugf(
-			`(ffmpeg/sourceDir) completed processing source directory: "%s"`,
+			`(ffmpeg/encode) completed processing source directory: "%s"`,
 			sourceDir,
 		)
 	}(&signal)
 
-	// An instance of the updates structure; will perform updates in the background
-	updateThread := Updates{
-		userInput:   input,
-		filePath:    filepath.Join(sourceDir, mediaFiles[0].Name()),
-		fileName:    mediaFiles[0].Name(),
-		sourceDir:   sourceDir,
-		resDir:      resDir,
-		totalFrames: 0,
-	}
-
-	// Initializing the updates variable; performs internal household chores
-	updateThread.Initialize()
-
 	// Firing a goroutine; this function will track (and update) progress of the running
 	// command
-	go updateThread.DisplayUpdates(&progBuf, signal)
+	go updateThread.DisplayUpdates(progress, signal)
 
-	// Running the command. This statement will block the main thread until the
-	// ffmpeg process completes in the background. Will be the slowest step in the
-	// function
-	if err := cmd.Run(); err != nil {
+	// Waiting for the command to complete. This statement will block the main thread
+	// until the ffmpeg process completes in the background. Will be the slowest step
+	// in the function
+	if err = cmd.Wait(); err != nil {
+		output, removed := filterNoise(logBuf.String())
 		log.Debugf(
-			"(ffmpeg/sourceDir) ffmpeg command failed while running in "+
-				"background \nerror: %v \n\nlog buffer: %s",
+			"(ffmpeg/encode) ffmpeg command failed while running in "+
+				"background \nerror: %v \n\nlog buffer (%d benign warnings "+
+				"removed): %s",
 			err,
-			logBuf.String(),
+			removed,
+			output,
 		)
+
+		// Capture a diagnostic bundle unless the run was cancelled by the user
+		if input.Diagnostics && ctx.Err() == nil {
+			path, diagErr := writeDiagnostics(
+				ctx,
+				sourceDir,
+				resDir,
+				input,
+				mediaFile,
+				attempt,
+				cmd,
+				logBuf.String(),
+			)
+
+			if diagErr != nil {
+				log.Warnf(
+					"(ffmpeg/encode) failed to create diagnostic bundle \nerror: %v",
+					diagErr,
+				)
+			} else {
+				commons.Info("Diagnostics saved to \"%s\"\n\n", path)
+			}
+		}
+
+		return false, nil, err
 	}
 
-	return commons.StatusOK
+	// Frames encoded are recorded in the run history
+	state, _ := progress.latest()
+	runSummary.encoded(state.frames, time.Since(started))
+
+	return false, nil, nil
 }
 
 /*
@@ -339,17 +1229,18 @@ CheckExt is a helper function designed to check if a file contains a extension f
 list of extensions.
 
 Strings to be treated as extensions may (or may not) contain period as a prefix;
-regardless, they will be treated as file extension(s).
+regardless, they will be treated as file extension(s). Only the last extension of the
+file is considered, i.e. `movie.srt.bak` will NOT be treated as a `.srt` file.
 */
 func checkExt(fileName string, extensions []string) bool {
+	// Extract the actual extension of the file - extensions are lowercase, convert the
+	// extension to lower case to ensure case insensitive comparisons.
+	fileExt := strings.ToLower(filepath.Ext(fileName))
+
 	for _, ext := range extensions {
 		// Trim any number of period(s) - (if present) from the left of the extension,
 		// and add one. Converts `mp4` -> `.mp4`; while `.mp4` remains unaffected
-		ext = "." + strings.TrimLeft(ext, ".")
-
-		// Check if the file name ends with this extension - extensions are lowercase,
-		// convert the file name to lower case to ensure case insensitive comparisons.
-		if strings.HasSuffix(strings.ToLower(fileName), ext) {
+		if fileExt == "."+strings.TrimLeft(ext, ".") {
 			return true
 		}
 	}
@@ -358,6 +1249,119 @@ func checkExt(fileName string, extensions []string) bool {
 	return false
 }
 
+/*
+Categories a file present in a source directory can be grouped into.
+*/
+const (
+	categoryIgnored = iota
+	categoryMedia
+	categorySubtitle
+	categoryAttachment
+	categoryChapter
+	categoryArchive
+)
+
+/*
+ClassifyFile decides the category for a file present in a source directory, checking
+if the file is to be ignored using the ignore rules, and grouping the file if its
+extension matches a recognized extension.
+
+For ignored files, the reason for which the file is being ignored is returned as well.
+The boolean parameter indicates if the source directory matches the inclusion rules;
+if it does not, media files will be filtered individually.
+*/
+func classifyFile(
+	sourceDir string,
+	file os.FileInfo,
+	userInput *commons.UserInput,
+	dirIncluded bool,
+) (category int, reason string) {
+	fName := file.Name()
+
+	switch {
+	case file.IsDir() && isSubsDir(fName):
+		// Subtitle folders are looked into separately
+		return categoryIgnored, "subtitle folder, subtitles listed separately"
+
+	case file.IsDir():
+		// Ignore directories
+		return categoryIgnored, "directory"
+
+	case userInput.IgnoreFile(&sourceDir, &fName):
+		// Check if file name is to be skipped - the function call will log internally
+		// if a file is to be skipped!
+		return categoryIgnored, "matches exclusion rules"
+	}
+
+	// Custom classifiers (if any) take precedence over the extensions, media files are
+	// still filtered through the inclusion rules
+	if category, ok := customCategory(sourceDir, fName, userInput); ok {
+		switch {
+		case category == categoryIgnored:
+			return categoryIgnored, "ignored by classifier"
+
+		case category == categoryMedia && !dirIncluded &&
+			!userInput.IncludeItem(fName):
+			return categoryIgnored, "does not match inclusion rules"
+		}
+
+		return category, ""
+	}
+
+	switch {
+	case checkExt(fName, junkExt):
+		// Junk files are to be ignored, even if the name contains a recognized
+		// extension (for example, `movie.mkv.part`)
+		log.Debugf(
+			"(ffmpeg/classifyFile) ignoring junk file: \"%s\"",
+			filepath.Join(sourceDir, fName),
+		)
+
+		return categoryIgnored, "junk file"
+
+	case checkExt(fName, archiveExt):
+		// Archives are looked into only if the user asks for it
+		if !userInput.ExtractArchives {
+			return categoryIgnored, "archive, use --extract-archives to look inside"
+		}
+
+		return categoryArchive, ""
+
+	/*
+		If the file is not to be ignored, attempt to group the file as a media file,
+		subtitle, attachment or chapter(s) - skip if none matches
+	*/
+
+	case userInput.OutputLayout == commons.LayoutSibling && isSiblingOutput(fName):
+		// Outputs of earlier runs are stored next to the media files in the sibling
+		// layout, merging them again would only nest the outputs
+		return categoryIgnored, "output of an earlier run"
+
+	case checkExt(fName, videoExt) || checkExt(fName, userInput.MediaExt):
+		if !dirIncluded && !userInput.IncludeItem(fName) {
+			return categoryIgnored, "does not match inclusion rules"
+		}
+
+		return categoryMedia, ""
+
+	case checkExt(fName, subsExt):
+		return categorySubtitle, ""
+
+	case checkExt(fName, attachmentExt):
+		return categoryAttachment, ""
+
+	case checkExt(fName, chaptersExt):
+		return categoryChapter, ""
+	}
+
+	log.Debugf(
+		"(ffmpeg/classifyFile) failed to group file: \"%s\"",
+		filepath.Join(sourceDir, fName),
+	)
+
+	return categoryIgnored, "unrecognized extension"
+}
+
 /*
 GroupFiles is a helper function designed to traverse a source directory, grouping all
 the file(s) present in the directory based on their extensions.
@@ -383,77 +1387,136 @@ func groupFiles(sourceDir string, userInput *commons.UserInput) (
 		return nil, nil, nil, nil
 	}
 
+	// If the source directory matches the inclusion rules, all media files inside it
+	// are to be processed - otherwise, media files are filtered individually
+	dirIncluded := userInput.IncludeItem(filepath.Base(sourceDir))
+
 	// Iterate through files present in the source directory - check if a file is to be
 	// ignored using the ignore rules, if not, group the file if its extension matches
 	// a recognized extension
 	for _, file := range files {
-		if file.IsDir() {
-			// Ignore directories - jump to the next item.
-			continue
-		}
-
-		if fName := file.Name(); userInput.IgnoreFile(&sourceDir, &fName) {
-			// Check if file name is to be skipped - jump to next iteration if current
-			// file is to be skipped; the function call will log internally if a file
-			// is to be skipped!
-			continue
-		}
-
-		/*
-			If the file is not to be ignored, attempt to group the file as a
-			media file, subtitle, attachment or chapter(s) - skip if none matches
-		*/
+		category, _ := classifyFile(sourceDir, file, userInput, dirIncluded)
 
-		switch {
-		case checkExt(file.Name(), videoExt):
+		switch category {
+		case categoryMedia:
 			mediaFiles = append(mediaFiles, file)
 
-		case checkExt(file.Name(), subsExt):
+		case categorySubtitle:
 			subtitles = append(subtitles, file)
 
-		case checkExt(file.Name(), attachmentExt):
+		case categoryAttachment:
 			attachments = append(attachments, file)
 
-		case checkExt(file.Name(), chaptersExt):
+		case categoryChapter:
 			chapters = append(chapters, file)
 
-		default:
-			log.Debugf(
-				"(ffmpeg/groupFiles) failed to group file: \"%s\"",
-				filepath.Join(sourceDir, file.Name()),
-			)
+		case categoryArchive:
+			// Files inside archives are grouped same as the rest
+			groups := archiveFiles(sourceDir, file, userInput, dirIncluded)
+			subtitles = append(subtitles, groups[categorySubtitle]...)
+			attachments = append(attachments, groups[categoryAttachment]...)
+			chapters = append(chapters, groups[categoryChapter]...)
 		}
 	}
 
+	// Samples and trailers present along with the media file are left out
+	mediaFiles, samples := filterSamples(mediaFiles, userInput)
+	if len(samples) > 0 {
+		log.Debugf(
+			"(ffmpeg/groupFiles) samples left out in: \"%s\" \nsamples: %s",
+			sourceDir,
+			commons.Stringify(&samples),
+		)
+	}
+
+	// Subtitle files can be present in subtitle folders as well
+	subtitles = append(subtitles, nestedSubtitles(sourceDir, userInput)...)
+
+	// Chapters file supplied by the user replaces the chapter files found
+	if override, ok := chaptersOverride(sourceDir, userInput); ok {
+		chapters = override
+	}
+
+	// Subtitles and attachments can be left out through the metadata rules
+	subtitles = excludeByRules(userInput, commons.RuleSubtitle, subtitles)
+	attachments = excludeByRules(userInput, commons.RuleAttachment, attachments)
+
 	return mediaFiles, subtitles, attachments, chapters
 }
 
 /*
-GenerateCmd is the central function which will generate the ffmpeg command to soft-sub
-the media file along with additional chapters/attachments, this function will form and
-return the command, the calling-method will be responsible for running the command
+GenerateCmd forms the ffmpeg command to soft-sub the media file along with additional
+chapters/attachments through the plan for the command, the calling-method will be
+responsible for running the command.
+
+Commands exceeding the limit for the platform are run from the source directory, with
+relative paths - the calling-method should check the length of the command regardless.
 */
 func generateCmd(
+	ctx context.Context, // kills the command if cancelled
 	sourceDir string,
 	userInput *commons.UserInput,
-	outDir string,
+	output string, // full path to the output file
 
 	mediaFile os.FileInfo,
+	donor os.FileInfo,
 	subsFound,
 	attachmentFound,
 	chaptersFound []os.FileInfo,
 ) (cmd *exec.Cmd) {
-	// String array containing the command, each argument must be an individual element
-	// in the array.
-	//
-	// Code beyond this point simply pertains to forming FFmpeg command. If unsure,
+	plan := planCmd(
+		ctx,
+		sourceDir,
+		userInput,
+		output,
+		mediaFile,
+		donor,
+		subsFound,
+		attachmentFound,
+		chaptersFound,
+	)
+
+	if length := commandLength(plan.Args()); length > argLimit {
+		log.Debugf(
+			"(ffmpeg/generateCmd) command exceeds %d bytes (%d), using relative paths",
+			argLimit,
+			length,
+		)
+
+		plan.relativeTo(sourceDir)
+	}
+
+	return plan.command(ctx, userInput.FFmpegPath)
+}
+
+/*
+PlanCmd is the central function which will plan the ffmpeg command to soft-sub the
+media file along with additional chapters/attachments, this function will form and
+return the plan for the command - the context is used only to probe the inputs.
+
+In extract-and-merge mode, the subtitle streams, fonts and chapters present in the
+donor are copied over as well - the donor is nil otherwise.
+*/
+func planCmd(
+	ctx context.Context, // kills the command if cancelled
+	sourceDir string,
+	userInput *commons.UserInput,
+	output string, // full path to the output file
+
+	mediaFile os.FileInfo,
+	donor os.FileInfo,
+	subsFound,
+	attachmentFound,
+	chaptersFound []os.FileInfo,
+) (plan *cmdPlan) {
+	// Code beyond this point simply pertains to planning FFmpeg command. If unsure,
 	// check FFmpeg documentation at: https://ffmpeg.org/ffmpeg.html
 	//
 	// Note: Use full-path for any input/source files used in the command, arguments
 	// passed are NOT to be wrapped in double-quotes.
-	cmdRaw := []string{
-		"-i",
-		filepath.Join(sourceDir, mediaFile.Name()),
+	plan = &cmdPlan{
+		logLevel: userInput.FFmpegLogLevel,
+		inputs:   []string{joinPath(sourceDir, mediaFile.Name())},
 	}
 
 	/*
@@ -466,15 +1529,20 @@ func generateCmd(
 		the command.
 	*/
 	for _, sub := range subsFound {
-		cmdRaw = append(
-			cmdRaw,
-			"-i",
+		plan.inputs = append(
+			plan.inputs,
 
-			// full path to the subtitle file
-			filepath.Join(sourceDir, sub.Name()),
+			// full path to the subtitle file, or its copy converted to UTF-8
+			subtitlePath(sourceDir, sub),
 		)
 	}
 
+	// The donor is the last input, only its subtitles, fonts and chapters are used
+	donorIndex := len(subsFound) + 1
+	if donor != nil {
+		plan.inputs = append(plan.inputs, joinPath(sourceDir, donor.Name()))
+	}
+
 	/*
 		Adding copy markers to the command - these ensure the input	stream(s) are
 		copied as original (no implicit stream selection or processing) done by FFmpeg.
@@ -486,24 +1554,65 @@ func generateCmd(
 		subtitle stream, etc
 	*/
 
-	cmdRaw = append(
-		cmdRaw,
-
-		// Ensure streams from the original file are being copied directly
-		"-c",
-		"copy",
-	)
+	// Ensure streams from the original file are being copied directly
+	plan.codecs = append(plan.codecs, streamOption{"-c", codecCopy})
 
 	/*
 		Mapping the input streams - extension of the above `-c copy` flag; ensures in
 		case of multiple subtitles being soft-subbed, all of them are mapped correctly.
+
+		Streams are placed in the order they are mapped, to place the subtitle files
+		before the subtitle streams present in the media file, the streams in the media
+		file are mapped by type - optional maps (with `?`) allow missing types.
+
+		Outputs split by language contain the subtitle files in the language alone,
+		subtitle (and data) streams in the media file are left out.
 	*/
-	for i := 0; i < len(subsFound)+1; i++ {
-		cmdRaw = append(
-			cmdRaw,
-			"-map",
-			strconv.Itoa(i),
+	subsFront := userInput.SubPosition == commons.SubPositionFront
+	split := splitOutputs(userInput, subsFound)
+	if subsFront || split {
+		plan.maps = append(plan.maps, "0:v?", "0:a?")
+	} else {
+		plan.maps = append(plan.maps, "0")
+	}
+
+	for i := 1; i < len(subsFound)+1; i++ {
+		plan.maps = append(plan.maps, strconv.Itoa(i))
+	}
+
+	switch {
+	case split:
+		plan.maps = append(plan.maps, "0:t?")
+	case subsFront:
+		plan.maps = append(plan.maps, "0:s?", "0:d?", "0:t?")
+	}
+
+	if donor != nil {
+		// Optional maps (with `?`) allow a donor without subtitles or fonts
+		plan.maps = append(
+			plan.maps,
+			fmt.Sprintf("%d:s?", donorIndex),
+			fmt.Sprintf("%d:t?", donorIndex),
 		)
+
+		plan.chapters = strconv.Itoa(donorIndex)
+	}
+
+	/*
+		Streams already present in the media file (and subtitle containers) are copied
+		over as is - keeping a track of the number of subtitle/attachment streams in
+		the output ensures metadata is added to the correct streams.
+	*/
+	mediaStreams := probeStreams(
+		ctx,
+		userInput.FFprobePath,
+		joinPath(sourceDir, mediaFile.Name()),
+	)
+
+	// Subtitle files placed in front are the first subtitle streams in the output
+	outStreams := mediaStreams
+	if subsFront || split {
+		outStreams = streamCount{}
 	}
 
 	/*
@@ -511,98 +1620,239 @@ func generateCmd(
 		metadata to them, this step involves setting titles for the subtitle files,
 		and language.
 	*/
-	for i, sub := range subsFound {
+	for _, sub := range subsFound {
+		if checkExt(sub.Name(), subsContainerExt) {
+			// Subtitle containers retain the metadata present in them
+			streams := probeStreams(
+				ctx,
+				userInput.FFprobePath,
+				joinPath(sourceDir, sub.Name()),
+			)
+
+			outStreams.subtitles += streams.subtitles
+			outStreams.attachments += streams.attachments
+			continue
+		}
+
+		i := outStreams.subtitles
+		outStreams.subtitles++
+
 		var title string
 
 		if userInput.SubTitleString == "" {
 			// If a custom title is not to be used, use the name of the subtitle
 			// file minus its extension.
-			title = strings.TrimSuffix(sub.Name(), filepath.Ext(sub.Name()))
+			// Subtitle files in subtitle folders use the name of the file as well
+			name := filepath.Base(sub.Name())
+			title = strings.TrimSuffix(name, filepath.Ext(name))
 		} else {
 			title = userInput.SubTitleString
 		}
 
-		cmdRaw = append(
-			cmdRaw,
+		// Metadata rules replace the defaults, while titles (and languages) from the
+		// title map take precedence over both
+		language := userInput.SubLang
+		rule, _ := userInput.ApplyRules(commons.RuleSubtitle, sub.Name())
+		if rule.Title != "" {
+			title = rule.Title
+		}
+
+		if rule.Language != "" {
+			language = rule.Language
+		}
+
+		if mapped, ok := userInput.TrackTitle(sourceDir, sub.Name()); ok {
+			if mapped.Title != "" {
+				title = mapped.Title
+			}
+
+			if mapped.Language != "" {
+				language = mapped.Language
+			}
+		}
 
-			// The first argument decides the (subtitle) stream for which metadata is
-			// being added, the defines the metadata to be added (and its value)
+		// Overrides the copy marker for the stream, subtitles in formats that can't
+		// be held by the output are converted
+		plan.codecs = append(plan.codecs, streamOption{
+			fmt.Sprintf("-c:s:%d", i),
+			subtitleCodec(sub, userInput.SubtitleCodec),
+		})
+
+		// The flag decides the (subtitle) stream for which metadata is being added,
+		// the value defines the metadata to be added (and its value) - titles from
+		// names that aren't valid UTF-8 are sanitized
+		plan.metadata = append(plan.metadata, streamOption{
 			fmt.Sprintf("-metadata:s:s:%d", i),
-			fmt.Sprintf("title=%s", title),
-		)
+			fmt.Sprintf("title=%s", commons.SanitizeText(title)),
+		})
 
 		// Setting language only if present - if not `language` will be a blank string
-		if userInput.SubLang != "" {
-			cmdRaw = append(
-				cmdRaw,
-
-				// Same step as above, first argument selects the stream, the second
-				// argument defines the metadata to be added and its value
+		if language != "" {
+			// Same step as above, the flag selects the stream, the value defines
+			// the metadata to be added and its value
+			plan.metadata = append(plan.metadata, streamOption{
 				fmt.Sprintf("-metadata:s:s:%d", i),
-				fmt.Sprintf("language=%s", userInput.SubLang),
-			)
+				fmt.Sprintf("language=%s", language),
+			})
+		}
+
+		// Subtitles for the hearing impaired are flagged, allowing players to tell
+		// them apart - other dispositions (i.e. the default track) are kept
+		if isSDH(sub, userInput) {
+			plan.dispositions = append(plan.dispositions, streamOption{
+				fmt.Sprintf("-disposition:s:%d", i),
+				"+hearing_impaired",
+			})
+		}
+
+		if rule.Disposition != "" {
+			plan.dispositions = append(plan.dispositions, streamOption{
+				fmt.Sprintf("-disposition:s:%d", i),
+				rule.Disposition,
+			})
 		}
 	}
 
-	/*
-		Adding chapters found.
-	*/
-	streams := 0
-	for _, chapter := range chaptersFound {
-		cmdRaw = append(
-			cmdRaw,
-			"-attach",
-			filepath.Join(sourceDir, chapter.Name()),
+	// Streams in the media file follow the subtitle files placed in front
+	switch {
+	case split:
+		outStreams.attachments += mediaStreams.attachments
+	case subsFront:
+		outStreams.subtitles += mediaStreams.subtitles
+		outStreams.attachments += mediaStreams.attachments
+	}
 
-			// Metadata for a chapter file
-			fmt.Sprintf("-metadata:s:t:%d", streams),
-			"mimetype=text/xml",
+	// Streams from the donor are placed after the subtitle files
+	if donor != nil {
+		streams := probeStreams(
+			ctx,
+			userInput.FFprobePath,
+			joinPath(sourceDir, donor.Name()),
 		)
 
-		streams++
+		outStreams.subtitles += streams.subtitles
+		outStreams.attachments += streams.attachments
 	}
 
 	/*
-		Adding attachments found to the source file
+		Adding chapters and attachments found - attached files are placed after the
+		attachment streams already present, in the order planned.
 	*/
-	for _, attachment := range attachmentFound {
-		cmdRaw = append(
-			cmdRaw,
-			"-attach",
-			filepath.Join(sourceDir, attachment.Name()),
+	plan.attachments = planAttachments(
+		sourceDir,
+		userInput,
+		chaptersFound,
+		attachmentFound,
+	)
+	plan.attachmentIndex = outStreams.attachments
+
+	// Tuning options passed through to FFmpeg, FFmpeg defaults are used if not set
+	if userInput.Threads > 0 {
+		plan.options = append(
+			plan.options,
+			"-threads",
+			strconv.Itoa(userInput.Threads),
+		)
+	}
+
+	if userInput.MuxingQueueSize > 0 {
+		plan.options = append(
+			plan.options,
+			"-max_muxing_queue_size",
+			strconv.Itoa(userInput.MuxingQueueSize),
+		)
+	}
 
-			// Metadata for an attachment file
-			fmt.Sprintf("-metadata:s:t:%v", streams),
-			"mimetype=application/x-truetype-font",
+	// In sample mode, limit the duration of the output - produces a short output
+	// quickly, useful to validate the settings being used.
+	if userInput.Sample > 0 {
+		plan.options = append(
+			plan.options,
+			"-t",
+			strconv.Itoa(userInput.Sample),
 		)
+	}
+
+	// At the end, naming the output file - the output is written to a partial file
+	// first, the format can't be guessed from the extension
+	plan.format = "matroska"
+	plan.output = output
+
+	// Return the final plan formed
+	return plan
+}
+
+/*
+OutputNames returns the names of the output files for a list of media files present in
+a source directory. Media files that would end up with the same output name (for
+example, `movie.mp4` and `movie.mkv`) are suffixed with their original extension, i.e.
+`movie [mp4].mkv` and `movie [mkv].mkv`.
+*/
+func outputNames(mediaFiles []os.FileInfo, userInput *commons.UserInput) []string {
+	names := make([]string, len(mediaFiles))
+	count := make(map[string]int)
 
-		streams++
+	for i, file := range mediaFiles {
+		names[i] = outputName(file.Name(), userInput)
+		count[strings.ToLower(names[i])]++
 	}
 
-	// At the end, naming the output file - using the same name as the original file,
-	// while changing the extension to be `.mkv` - ensures that the resultant container
-	// is matroska; allowing multiple subtitles and attachments as required.
-	cmdRaw = append(
-		cmdRaw,
-		filepath.Join(
-			outDir,
-			fmt.Sprintf(
-				"%s.mkv",
+	for i, file := range mediaFiles {
+		if count[strings.ToLower(names[i])] < 2 {
+			continue
+		}
 
-				// Trim extension from original file name
-				strings.TrimSuffix(
-					mediaFile.Name(),
-					filepath.Ext(mediaFile.Name()),
-				),
+		ext := filepath.Ext(file.Name())
+		names[i] = outputName(
+			fmt.Sprintf(
+				"%s [%s]%s",
+				strings.TrimSuffix(file.Name(), ext),
+				strings.ToLower(strings.TrimPrefix(ext, ".")),
+				ext,
 			),
-		),
-	)
+			userInput,
+		)
+	}
 
-	cmd = exec.Command(
-		userInput.FFmpegPath, // path to the FFmpeg executable
-		cmdRaw...,
-	)
+	return names
+}
+
+/*
+JoinPath joins the path to a directory with the name of an item present in it, and
+normalizes the result - used for every input/output path passed to FFmpeg. Paths
+through an extracted archive are resolved to the extracted copy, and items outside the
+directory (named with an absolute path, i.e. `--chapters-file`) are used as is.
+*/
+func joinPath(dir, name string) string {
+	if filepath.IsAbs(name) {
+		return commons.NormalizePath(name)
+	}
+
+	return commons.NormalizePath(extractedPath(filepath.Join(dir, name)))
+}
+
+/*
+OutputName returns the name of the output file for a media file.
+
+Uses the same name as the original file, while changing the extension to be `.mkv` -
+ensures that the resultant container is matroska; allowing multiple subtitles and
+attachments as required. Outputs generated in sample mode are suffixed with `.sample`
+to ensure they can't be mistaken for a complete output. Names that can't be created
+on the platform (or are too long) are made safe through `commons.SafeName`.
+*/
+func outputName(mediaFile string, userInput *commons.UserInput) string {
+	// Trim extension from original file name
+	name := strings.TrimSuffix(mediaFile, filepath.Ext(mediaFile))
+
+	// Outputs stored next to the media files are marked, keeping them apart from
+	// the media files in later runs
+	if userInput.OutputLayout == commons.LayoutSibling {
+		name += siblingInfix
+	}
+
+	if userInput.Sample > 0 {
+		return commons.SafeName(name, ".sample.mkv")
+	}
 
-	// Return the final command formed
-	return cmd
+	return commons.SafeName(name, ".mkv")
 }
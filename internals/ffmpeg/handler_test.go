@@ -1,6 +1,7 @@
 package ffmpeg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -87,7 +88,75 @@ func TestCheckExt(t *testing.T) {
 	}
 }
 
+/*
+TestCheckExtMultiPart ensures only the last extension of a file name is considered,
+regardless of case.
+*/
+func TestCheckExtMultiPart(t *testing.T) {
+	for _, in := range []struct {
+		fileName   string
+		extensions []string
+		result     bool
+	}{
+		{"EPISODE.MKV", videoExt, true},
+		{"Movie.Mp4", videoExt, true},
+		{"movie.en.forced.srt", subsExt, true},
+		{"movie.en.forced.srt.bak", subsExt, false},
+		{"archive.mkv.torrent", videoExt, false},
+		{"archive.mkv.torrent", junkExt, true},
+		{"episode.mkv.PART", junkExt, true},
+		{"mkv", videoExt, false},
+		{"font.otf", attachmentExt, true},
+	} {
+		if res := checkExt(in.fileName, in.extensions); res != in.result {
+			t.Errorf(
+				"(handler/checkExt) unexpected result \nfile name: `%s` "+
+					"\nexpected: %v \nresult: %v",
+				in.fileName,
+				in.result,
+				res,
+			)
+		}
+	}
+}
+
 //nolint:gocyclo // will edit this test to reduce its complexity later
+/*
+TestClassifyMediaExt ensures the extensions supplied by the user are recognized as media
+files, along with the built-in extensions.
+*/
+func TestClassifyMediaExt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/classifyFile) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Episode 01.AVI")
+	if err = ioutil.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatalf("(handler/classifyFile) failed to create file: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("(handler/classifyFile) failed to stat file: %v", err)
+	}
+
+	if category, _ := classifyFile(dir, info, &commons.UserInput{}, true); category ==
+		categoryMedia {
+		t.Errorf("(handler/classifyFile) unknown extension grouped as media file")
+	}
+
+	input := &commons.UserInput{MediaExt: []string{"avi"}}
+	if category, _ := classifyFile(dir, info, input, true); category != categoryMedia {
+		t.Errorf(
+			"(handler/classifyFile) expected media file, found category %d",
+			category,
+		)
+	}
+}
+
 func TestGroupFiles(t *testing.T) {
 	// Form path to `testdata` directory
 	testdata := ""
@@ -137,7 +206,7 @@ func TestGroupFiles(t *testing.T) {
 		sourceDir := filepath.Join(testdata, dir.Name())
 
 		// Forcing to ignore `exe` files to achieve complete coverage for the function
-		input := &commons.UserInput{RegexExclude: `.*\.exe`}
+		input := &commons.UserInput{RegexExclusions: []string{`.*\.exe`}}
 		_, _ = input.Initialize()
 
 		// Run the function to sort the files present in the directory
@@ -259,8 +328,11 @@ func TestTraverseRoot(t *testing.T) {
 
 	// Test to ensure the function fails if result directory points to an existing
 	// non-directory item
-	if errCode, err := TraverseRoot(&in, filepath.Join(root, ".gitkeep")); errCode !=
-		commons.UnexpectedError || err == nil {
+	if errCode, err := TraverseRoot(
+		context.Background(),
+		&in,
+		filepath.Join(root, ".gitkeep"),
+	); errCode != commons.UnexpectedError || err == nil {
 		t.Errorf(
 			"(handler/TraverseRoot) function does not fail even if path to "+
 				"result directory points to an existing file \nerror: %v \nstatus: %d",
@@ -280,11 +352,16 @@ func TestTraverseRoot(t *testing.T) {
 
 	// Patch function call to `sourceDir` to isolate the function being tested
 	defer monkey.Unpatch(sourceDir)
-	monkey.Patch(sourceDir, func(string, string, *commons.UserInput) int {
+	monkey.Patch(sourceDir, func(
+		context.Context,
+		string,
+		string,
+		*commons.UserInput,
+	) int {
 		return commons.StatusOK
 	})
 
-	if errCode, err := TraverseRoot(&in, root); err == nil ||
+	if errCode, err := TraverseRoot(context.Background(), &in, root); err == nil ||
 		errCode != commons.UnexpectedError {
 		t.Errorf(
 			"(handler/TraverseRoot) function does not force stop even when " +
@@ -304,7 +381,7 @@ func TestTraverseRoot(t *testing.T) {
 		return errors.New("failing `os.Mkdir()` through a patch for tests")
 	})
 
-	if errCode, err := TraverseRoot(&in, root); err == nil ||
+	if errCode, err := TraverseRoot(context.Background(), &in, root); err == nil ||
 		errCode != commons.UnexpectedError {
 		t.Errorf(
 			"(handler/TraverseRoot) function does not fail even when result " +
@@ -333,7 +410,7 @@ func TestTraverseRoot(t *testing.T) {
 		return nil
 	})
 
-	if _, _ = TraverseRoot(&in, createPath); !flag {
+	if _, _ = TraverseRoot(context.Background(), &in, createPath); !flag {
 		t.Errorf(
 			"(handler/TraverseRoot) function did not attempt to create result " +
 				"directory if it does not exist",
@@ -366,19 +443,21 @@ func TestGenerateCmd(t *testing.T) {
 	}
 
 	cmd := exec.Cmd{}
-	monkey.PatchInstanceMethod(
-		reflect.TypeOf(&cmd),
-		"Run",
-		func(*exec.Cmd) error {
-			return nil
-		},
-	)
+	for _, method := range []string{"Start", "Wait"} {
+		monkey.PatchInstanceMethod(
+			reflect.TypeOf(&cmd),
+			method,
+			func(*exec.Cmd) error {
+				return nil
+			},
+		)
+	}
 
 	update := Updates{}
 	monkey.PatchInstanceMethod(
 		reflect.TypeOf(&update),
 		"DisplayUpdates",
-		func(_ *Updates, _ *strings.Builder, sig chan bool) {
+		func(_ *Updates, _ progressSource, sig chan bool) {
 			ticker := time.NewTicker(time.Second)
 			for range ticker.C {
 				select {
@@ -400,9 +479,545 @@ func TestGenerateCmd(t *testing.T) {
 
 		// For every directory, run the sourceDir method
 		sourceDir(
+			context.Background(),
 			filepath.Join(testdata, item.Name()),
 			testdata,
 			&commons.UserInput{},
 		)
 	}
 }
+
+func TestGenerateCmdTuning(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "video.mkv"), nil, 0644); err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create file: %v", err)
+	}
+
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{}
+	})
+
+	media, _ := os.Stat(filepath.Join(dir, "video.mkv"))
+	for _, in := range []struct {
+		threads, queueSize int
+		present, absent    []string
+	}{
+		{0, 0, nil, []string{"-threads", "-max_muxing_queue_size"}},
+		{4, 0, []string{"-threads 4"}, []string{"-max_muxing_queue_size"}},
+		{0, 1024, []string{"-max_muxing_queue_size 1024"}, []string{"-threads"}},
+		{-1, -1, nil, []string{"-threads", "-max_muxing_queue_size"}},
+	} {
+		args := strings.Join(generateCmd(
+			context.Background(),
+			dir,
+			&commons.UserInput{Threads: in.threads, MuxingQueueSize: in.queueSize},
+			filepath.Join(dir, "out.mkv"),
+			media,
+			nil,
+			nil,
+			nil,
+			nil,
+		).Args, " ")
+
+		for _, arg := range in.present {
+			if !strings.Contains(args, arg) {
+				t.Errorf("(handler/generateCmd) missing `%s` \ncommand: %s", arg, args)
+			}
+		}
+
+		for _, arg := range in.absent {
+			if strings.Contains(args, arg) {
+				t.Errorf(
+					"(handler/generateCmd) unexpected `%s` \ncommand: %s",
+					arg,
+					args,
+				)
+			}
+		}
+	}
+}
+
+func TestGenerateCmdSubPosition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+
+	// Two subtitle streams present in the media file already
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{subtitles: 2}
+	})
+
+	for _, name := range []string{"video.mkv", "English.srt"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(handler/generateCmd) failed to create file: %v", err)
+		}
+	}
+
+	media, _ := os.Stat(filepath.Join(dir, "video.mkv"))
+	sub, _ := os.Stat(filepath.Join(dir, "English.srt"))
+
+	for position, expected := range map[string][]string{
+		commons.SubPositionBack: {"-map 0 -map 1 ", "-metadata:s:s:2 title=English"},
+		commons.SubPositionFront: {
+			"-map 0:v? -map 0:a? -map 1 -map 0:s? -map 0:d? -map 0:t? ",
+			"-metadata:s:s:0 title=English",
+		},
+	} {
+		args := strings.Join(generateCmd(
+			context.Background(),
+			dir,
+			&commons.UserInput{SubPosition: position},
+			filepath.Join(dir, "out.mkv"),
+			media,
+			nil,
+			[]os.FileInfo{sub},
+			nil,
+			nil,
+		).Args, " ")
+
+		for _, arg := range expected {
+			if !strings.Contains(args, arg) {
+				t.Errorf(
+					"(handler/generateCmd) missing `%s` with position %s \ncommand: %s",
+					arg,
+					position,
+					args,
+				)
+			}
+		}
+	}
+
+	// Outputs split by language leave out the subtitle streams in the media file
+	args := strings.Join(generateCmd(
+		context.Background(),
+		dir,
+		&commons.UserInput{SplitByLang: true},
+		filepath.Join(dir, "out.eng.mkv"),
+		media,
+		nil,
+		[]os.FileInfo{sub},
+		nil,
+		nil,
+	).Args, " ")
+
+	if !strings.Contains(args, "-map 0:v? -map 0:a? -map 1 -map 0:t? -") ||
+		!strings.Contains(args, "-metadata:s:s:0 title=English") ||
+		strings.Contains(args, "0:s?") {
+		t.Errorf("(handler/generateCmd) unexpected split command: %s", args)
+	}
+}
+
+func TestGenerateCmdTitleMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{}
+	})
+
+	for _, name := range []string{"video.mkv", "English.srt", "Signs.srt"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(handler/generateCmd) failed to create file: %v", err)
+		}
+	}
+
+	media, _ := os.Stat(filepath.Join(dir, "video.mkv"))
+	english, _ := os.Stat(filepath.Join(dir, "English.srt"))
+	signs, _ := os.Stat(filepath.Join(dir, "Signs.srt"))
+
+	// Mapped titles and languages replace the defaults, only for the mapped files
+	input := &commons.UserInput{
+		SubLang: "jpn",
+		TitleMaps: map[string]commons.TrackTitle{
+			"signs.srt": {Title: "Signs & Songs", Language: "eng"},
+		},
+	}
+
+	args := strings.Join(generateCmd(
+		context.Background(),
+		dir,
+		input,
+		filepath.Join(dir, "out.mkv"),
+		media,
+		nil,
+		[]os.FileInfo{english, signs},
+		nil,
+		nil,
+	).Args, " ")
+
+	for _, arg := range []string{
+		"-metadata:s:s:0 title=English -metadata:s:s:0 language=jpn",
+		"-metadata:s:s:1 title=Signs & Songs -metadata:s:s:1 language=eng",
+	} {
+		if !strings.Contains(args, arg) {
+			t.Errorf("(handler/generateCmd) missing `%s` \ncommand: %s", arg, args)
+		}
+	}
+}
+
+func TestGenerateCmdInvalidNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+	defer func(original *Summary) { runSummary = original }(runSummary)
+
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{}
+	})
+
+	// Names in Latin-1, as created on older systems
+	names := []string{"video.mkv", "Fran\xe7ais.srt", "Caf\xe9.ttf"}
+	var files []os.FileInfo
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Skipf("(handler/generateCmd) names are not raw bytes here: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		files = append(files, info)
+	}
+
+	args := generateCmd(
+		context.Background(),
+		dir,
+		&commons.UserInput{},
+		filepath.Join(dir, "out.mkv"),
+		files[0],
+		nil,
+		files[1:2],
+		files[2:],
+		nil,
+	).Args
+
+	// Names are passed to FFmpeg as is, the metadata formed from them is sanitized
+	joined := strings.Join(args, " ")
+	for _, arg := range []string{
+		"-metadata:s:s:0 title=Français",
+		"-metadata:s:t:0 filename=Café.ttf",
+		filepath.Join(dir, "Fran\xe7ais.srt"),
+	} {
+		if !strings.Contains(joined, arg) {
+			t.Errorf("(handler/generateCmd) missing %q \ncommand: %q", arg, joined)
+		}
+	}
+
+	runSummary = &Summary{}
+	reportInvalidNames(dir, files[0], files[1:2], files[2:])
+	if len(runSummary.Warnings) != 2 ||
+		!strings.Contains(runSummary.Warnings[0], `sanitized to "Français.srt"`) {
+		t.Errorf(
+			"(handler/reportInvalidNames) unexpected warnings: %q",
+			runSummary.Warnings,
+		)
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	for _, in := range []struct {
+		name   string
+		sample int
+		result string
+	}{
+		{"movie.mp4", 0, "movie.mkv"},
+		{"movie.mkv", -10, "movie.mkv"},
+		{"episode 01.webm", 30, "episode 01.sample.mkv"},
+		{"no-extension", 0, "no-extension.mkv"},
+	} {
+		res := outputName(in.name, &commons.UserInput{Sample: in.sample})
+		if res != in.result {
+			t.Errorf(
+				"(handler/outputName) unexpected output name \ninput: \"%s\" "+
+					"\nsample: %d \nexpected: \"%s\" \nfound: \"%s\"",
+				in.name,
+				in.sample,
+				in.result,
+				res,
+			)
+		}
+	}
+
+	// Long names are truncated, while keeping the sample suffix
+	long := strings.Repeat("a", 300) + ".mp4"
+	res := outputName(long, &commons.UserInput{Sample: 30})
+	if len(res) >= len(long) || !strings.HasSuffix(res, ".sample.mkv") {
+		t.Errorf("(handler/outputName) long name not truncated: %q", res)
+	}
+}
+
+func TestRemoveEmptyDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/removeEmptyDir) failed to create temp dir \nerror: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	empty := filepath.Join(root, "empty")
+	filled := filepath.Join(root, "filled")
+	for _, dir := range []string{empty, filled} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("(handler/removeEmptyDir) failed to create dir \nerror: %v", err)
+		}
+	}
+
+	if err := ioutil.WriteFile(
+		filepath.Join(filled, "output.mkv"),
+		[]byte("test"),
+		0600,
+	); err != nil {
+		t.Fatalf("(handler/removeEmptyDir) failed to create file \nerror: %v", err)
+	}
+
+	removeEmptyDir(empty)
+	removeEmptyDir(filled)
+	removeEmptyDir(filepath.Join(root, "non-existent")) // should be ignored
+
+	if _, err := os.Stat(empty); !os.IsNotExist(err) {
+		t.Errorf("(handler/removeEmptyDir) empty directory not removed")
+	}
+
+	if _, err := os.Stat(filled); err != nil {
+		t.Errorf(
+			"(handler/removeEmptyDir) directory containing output removed "+
+				"\nerror: %v",
+			err,
+		)
+	}
+}
+
+func TestOutputNames(t *testing.T) {
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/outputNames) failed to create temp dir \nerror: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	names := []string{"movie 720p.mkv", "movie.MP4", "movie.mkv", "other.webm"}
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(root, name), nil, 0600); err != nil {
+			t.Fatalf("(handler/outputNames) failed to create file \nerror: %v", err)
+		}
+	}
+
+	// `ioutil.ReadDir` sorts the items by name
+	mediaFiles, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatalf("(handler/outputNames) failed to read temp dir \nerror: %v", err)
+	}
+
+	expected := []string{
+		"movie 720p.mkv",
+		"movie [mp4].mkv",
+		"movie [mkv].mkv",
+		"other.mkv",
+	}
+
+	res := outputNames(mediaFiles, &commons.UserInput{})
+	if strings.Join(res, ", ") != strings.Join(expected, ", ") {
+		t.Errorf(
+			"(handler/outputNames) unexpected output names \nexpected: %v \nfound: %v",
+			expected,
+			res,
+		)
+	}
+}
+
+func TestRunResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if code, err := runResult(ctx); code != commons.StatusOK || err != nil {
+		t.Errorf(
+			"(handler/runResult) unexpected result for active context"+
+				"\nexit code: %d \nerror: %v",
+			code,
+			err,
+		)
+	}
+
+	cancel()
+	if code, err := runResult(ctx); code != commons.Interrupted || err == nil {
+		t.Errorf(
+			"(handler/runResult) cancelled run not reported"+
+				"\nexit code: %d \nerror: %v",
+			code,
+			err,
+		)
+	}
+}
+
+func TestTraverseRootWaits(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	// Only the source directory marked as failed is processed
+	for _, name := range []string{"Failed", "Completed"} {
+		if err = os.Mkdir(filepath.Join(root, name), 0755); err != nil {
+			t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+		}
+	}
+
+	marker := filepath.Join(root, "Failed", commons.FailedMarkerName)
+	if err = ioutil.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create file: %v", err)
+	}
+
+	monkey.Patch(sourceDir, func(
+		context.Context,
+		string,
+		string,
+		*commons.UserInput,
+	) int {
+		return commons.StatusOK
+	})
+
+	waits := 0
+	monkey.Patch(waitForSchedule, func(context.Context, *commons.UserInput) bool {
+		waits++
+		return true
+	})
+
+	monkey.Patch(waitForLoad, func(context.Context, *commons.UserInput) bool {
+		return true
+	})
+
+	input := &commons.UserInput{
+		RootPath:    root,
+		Order:       commons.OrderName,
+		RetryFailed: true,
+	}
+
+	resDir := filepath.Join(root, "output")
+	if _, err = TraverseRoot(context.Background(), input, resDir); err != nil {
+		t.Fatalf("(handler/TraverseRoot) unexpected error: %v", err)
+	}
+
+	// Source directories skipped are never held back by the schedule
+	if waits != 1 {
+		t.Errorf("(handler/TraverseRoot) expected a single wait, found %d", waits)
+	}
+}
+
+func TestTraverseRootDirectSchedule(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer func() { timeNow = time.Now }()
+
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	processed := false
+	monkey.Patch(sourceDir, func(
+		context.Context,
+		string,
+		string,
+		*commons.UserInput,
+	) int {
+		processed = true
+		return commons.StatusOK
+	})
+
+	schedule, err := commons.ParseSchedule("01:00-07:00")
+	if err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to parse schedule \nerror: %v", err)
+	}
+
+	timeNow = func() time.Time { return time.Date(2021, 1, 1, 8, 0, 0, 0, time.Local) }
+
+	input := &commons.UserInput{
+		RootPath: root,
+		IsDirect: true,
+		Schedule: schedule,
+	}
+
+	// Outside the window, the root directory is only processed after the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resDir := filepath.Join(root, "output")
+	if exitCode, _ := TraverseRoot(ctx, input, resDir); exitCode != commons.Interrupted {
+		t.Errorf("(handler/TraverseRoot) unexpected exit code: %d", exitCode)
+	}
+
+	if processed {
+		t.Errorf("(handler/TraverseRoot) root directory processed outside the window")
+	}
+}
+
+func TestTraverseRootDirectLoad(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer func(interval time.Duration) {
+		loadPollInterval = interval
+		systemLoad = commons.SystemLoad
+	}(loadPollInterval)
+
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	processed := false
+	monkey.Patch(sourceDir, func(
+		context.Context,
+		string,
+		string,
+		*commons.UserInput,
+	) int {
+		processed = true
+		return commons.StatusOK
+	})
+
+	loadPollInterval = time.Millisecond
+	systemLoad = func() (float64, error) { return 8, nil }
+
+	input := &commons.UserInput{
+		RootPath: root,
+		IsDirect: true,
+		MaxLoad:  4,
+	}
+
+	// Above the limit, the root directory is only processed once the load drops
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resDir := filepath.Join(root, "output")
+	if exitCode, _ := TraverseRoot(ctx, input, resDir); exitCode != commons.Interrupted {
+		t.Errorf("(handler/TraverseRoot) unexpected exit code: %d", exitCode)
+	}
+
+	if processed {
+		t.Errorf("(handler/TraverseRoot) root directory processed above the load limit")
+	}
+}
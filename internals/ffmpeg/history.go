@@ -0,0 +1,242 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Maximum size of a single record in the history file
+const maxRecordSize = 64 << 20
+
+/*
+HistoryRecord contains the results of a single run, one record is appended to the
+history file once a run completes. Records are stored as JSON, one record per line.
+*/
+type HistoryRecord struct {
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration_seconds"`
+	Root     string    `json:"root"`
+	ExitCode int       `json:"exit_code"`
+
+	// Source directories processed, skipped and failed
+	Processed int `json:"processed"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+
+	// Media files merged, and the total size of the outputs (in bytes)
+	Files int   `json:"files_merged"`
+	Bytes int64 `json:"bytes_produced"`
+
+	// Frames encoded, and the time spent encoding them (in seconds)
+	Frames     int64   `json:"frames"`
+	EncodeTime float64 `json:"encode_seconds"`
+
+	// Files created by the run, removed if the run is undone
+	Created []CreatedFile `json:"created,omitempty"`
+
+	// Directory holding the outputs of the run, blank for runs from earlier versions
+	ResultDir string `json:"result_dir,omitempty"`
+}
+
+/*
+CreatedFile is a file created by a run, along with its size and the modification time
+(in nanoseconds) once created - the file is removed by `undo` only if it is unchanged
+since.
+*/
+type CreatedFile struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+
+	// Boolean indicating if the file replaced a file that existed before the run
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+/*
+HistoryStats contains the statistics aggregated across the records in the history
+*/
+type HistoryStats struct {
+	Runs       int       `json:"runs"`
+	First      time.Time `json:"first_run"`
+	Last       time.Time `json:"last_run"`
+	Processed  int       `json:"processed"`
+	Skipped    int       `json:"skipped"`
+	Failed     int       `json:"failed"`
+	Files      int       `json:"files_merged"`
+	Bytes      int64     `json:"bytes_produced"`
+	Frames     int64     `json:"frames"`
+	EncodeTime float64   `json:"encode_seconds"`
+}
+
+/*
+RecordHistory appends a record for the run that just completed to the history file,
+using the summary for the run. The directory containing the history file is created
+if required.
+*/
+func RecordHistory(path string, start time.Time, root string, exitCode int) error {
+	record := HistoryRecord{
+		Start:      start,
+		Duration:   time.Since(start).Seconds(),
+		Root:       root,
+		ExitCode:   exitCode,
+		Processed:  len(runSummary.Processed),
+		Skipped:    len(runSummary.Skipped),
+		Failed:     len(runSummary.Failed),
+		Files:      runSummary.Files,
+		Bytes:      runSummary.Bytes,
+		Frames:     runSummary.Frames,
+		EncodeTime: runSummary.EncodeTime.Seconds(),
+		Created:    runSummary.Created,
+		ResultDir:  runSummary.ResultDir,
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+/*
+ReadHistory reads the records present in the history file, a missing history file is
+treated as an empty history. Malformed lines are skipped.
+*/
+func ReadHistory(path string) ([]HistoryRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var records []HistoryRecord
+	// Records list the files created by the run, a record can be far longer than the
+	// default limit for a line
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxRecordSize)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Warnf(
+				"(history/ReadHistory) skip malformed record at line %d \nerror: %v",
+				line,
+				err,
+			)
+
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+/*
+AggregateHistory combines the records from the history into cumulative statistics
+*/
+func AggregateHistory(records []HistoryRecord) (stats HistoryStats) {
+	for i, record := range records {
+		if i == 0 || record.Start.Before(stats.First) {
+			stats.First = record.Start
+		}
+
+		if record.Start.After(stats.Last) {
+			stats.Last = record.Start
+		}
+
+		stats.Runs++
+		stats.Processed += record.Processed
+		stats.Skipped += record.Skipped
+		stats.Failed += record.Failed
+		stats.Files += record.Files
+		stats.Bytes += record.Bytes
+		stats.Frames += record.Frames
+		stats.EncodeTime += record.EncodeTime
+	}
+
+	return stats
+}
+
+/*
+FailureRate returns the percentage of source directories that failed, out of the ones
+that were processed or failed
+*/
+func (stats HistoryStats) FailureRate() float64 {
+	attempted := stats.Processed + stats.Failed
+	if attempted == 0 {
+		return 0
+	}
+
+	return 100 * float64(stats.Failed) / float64(attempted)
+}
+
+/*
+AverageFPS returns the average speed of the encodes, in frames encoded per second
+*/
+func (stats HistoryStats) AverageFPS() float64 {
+	if stats.EncodeTime <= 0 {
+		return 0
+	}
+
+	return float64(stats.Frames) / stats.EncodeTime
+}
+
+/*
+String forms the text displayed to the user for the history command
+*/
+func (stats HistoryStats) String() string {
+	if stats.Runs == 0 {
+		return "No runs recorded yet\n\n"
+	}
+
+	updates := Updates{}
+	produced := updates.readableFileSize(float64(stats.Bytes))
+
+	return strings.Join([]string{
+		fmt.Sprintf(
+			"Runs: %d (%s to %s)",
+			stats.Runs,
+			stats.First.Format("2006-01-02"),
+			stats.Last.Format("2006-01-02"),
+		),
+		fmt.Sprintf("\tFiles Merged: %d", stats.Files),
+		fmt.Sprintf("\tTotal Produced: %s", produced),
+		fmt.Sprintf("\tAverage Speed: %.2f fps", stats.AverageFPS()),
+		fmt.Sprintf(
+			"\tSource Directories: %d processed, %d skipped, %d failed",
+			stats.Processed,
+			stats.Skipped,
+			stats.Failed,
+		),
+		fmt.Sprintf("\tFailure Rate: %.2f%%", stats.FailureRate()),
+	}, "\n") + "\n\n"
+}
@@ -0,0 +1,100 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(history/RecordHistory) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Missing history file is an empty history
+	path := filepath.Join(dir, "nested", "history.jsonl")
+	if records, err := ReadHistory(path); err != nil || len(records) != 0 {
+		t.Errorf("(history/ReadHistory) expected empty history \nerror: %v", err)
+	}
+
+	defer func() {
+		runSummary = &Summary{}
+	}()
+
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i, summary := range []*Summary{
+		{
+			Processed:  []string{"a", "b", "c"},
+			Failed:     []string{"d"},
+			Files:      3,
+			Bytes:      3 << 30,
+			Frames:     6000,
+			EncodeTime: 60 * time.Second,
+		},
+		{
+			Processed:  []string{"e"},
+			Skipped:    []string{"f"},
+			Files:      1,
+			Bytes:      1 << 30,
+			Frames:     2000,
+			EncodeTime: 40 * time.Second,
+		},
+	} {
+		runSummary = summary
+		if err := RecordHistory(
+			path,
+			start.AddDate(0, 0, i),
+			"/root",
+			0,
+		); err != nil {
+			t.Fatalf("(history/RecordHistory) failed to record run: %v", err)
+		}
+	}
+
+	// Malformed lines are skipped
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = file.WriteString("{not json\n")
+	_ = file.Close()
+
+	records, err := ReadHistory(path)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("(history/ReadHistory) expected 2 records \nerror: %v", err)
+	}
+
+	stats := AggregateHistory(records)
+	if stats.Runs != 2 || stats.Files != 4 || stats.Bytes != 4<<30 ||
+		!stats.First.Equal(start) || !stats.Last.Equal(start.AddDate(0, 0, 1)) {
+		t.Errorf("(history/AggregateHistory) unexpected stats: %+v", stats)
+	}
+
+	if fps := stats.AverageFPS(); fps != 80 {
+		t.Errorf("(history/AverageFPS) expected 80, found %v", fps)
+	}
+
+	if rate := stats.FailureRate(); rate != 20 {
+		t.Errorf("(history/FailureRate) expected 20, found %v", rate)
+	}
+
+	for _, expected := range []string{
+		"Runs: 2 (2020-10-01 to 2020-10-02)",
+		"Files Merged: 4",
+		"Total Produced: 4.00 GiB",
+		"Average Speed: 80.00 fps",
+		"Failure Rate: 20.00%",
+	} {
+		if res := stats.String(); !strings.Contains(res, expected) {
+			t.Errorf(
+				"(history/String) missing text in output \nexpected: `%s` "+
+					"\noutput: %s",
+				expected,
+				res,
+			)
+		}
+	}
+}
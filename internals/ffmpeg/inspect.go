@@ -0,0 +1,232 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Inspect is a public function that walks the root directory in the same way as
+`TraverseRoot()`, but instead of running FFmpeg, it prints the grouping result for each
+source directory - along with the files being ignored, and the reason for the same.
+
+Designed to diagnose why a file was (or wasn't) picked up, no changes are made to the
+disk.
+*/
+func Inspect(
+	input *commons.UserInput, // user input
+	resDir string, // full path to output directory - will be skipped if present
+) (exitCode int, err error) {
+	log.Debugf(`(ffmpeg/Inspect) inspecting root directory: "%s"`, input.RootPath)
+	defer stopClassifier()
+
+	if input.IsDirect {
+		commons.Printf("%s", inspectDir(input.RootPath, input))
+		return commons.StatusOK, nil
+	}
+
+	files, err := ioutil.ReadDir(input.RootPath)
+	if err != nil {
+		log.Debugf(
+			"(ffmpeg/Inspect) failed to fetch items present in root directory"+
+				"\nerror: `%v`",
+			err,
+		)
+
+		return commons.UnexpectedError, errors.New("unable to read root directory")
+	}
+
+	dirsFound := 0
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		dirsFound++
+		sourcePath := filepath.Join(input.RootPath, f.Name())
+
+		switch {
+		case isResultDir(sourcePath, resDir):
+			commons.Printf(
+				"Source directory: \"%s\"\n\tSkipped: result directory\n\n",
+				sourcePath,
+			)
+
+		case input.IgnoreDir(sourcePath):
+			commons.Printf(
+				"Source directory: \"%s\"\n\tSkipped: matches exclusion rules\n\n",
+				sourcePath,
+			)
+
+		case !input.IncludeItem(f.Name()):
+			// Directory may still be processed if it contains a media file matching
+			// the inclusion rules
+			if mediaFiles, _, _, _ := groupFiles(sourcePath, input); len(
+				mediaFiles,
+			) == 0 {
+				commons.Printf(
+					"Source directory: \"%s\"\n\tSkipped: does not match "+
+						"inclusion rules\n\n",
+					sourcePath,
+				)
+
+				continue
+			}
+
+			commons.Printf("%s", inspectDir(sourcePath, input))
+
+		default:
+			commons.Printf("%s", inspectDir(sourcePath, input))
+		}
+	}
+
+	if dirsFound == 0 {
+		return commons.RootDirectoryIncorrect,
+			errors.New("root directory does not contain any source directories")
+	}
+
+	return commons.StatusOK, nil
+}
+
+/*
+InspectDir forms the report for a single source directory - listing the files in each
+category, the files ignored (with reasons), and the result expected if the directory
+were to be processed.
+*/
+func inspectDir(sourceDir string, input *commons.UserInput) string {
+	files, err := ioutil.ReadDir(sourceDir)
+	if err != nil {
+		return fmt.Sprintf(
+			"Source directory: \"%s\"\n\tError: unable to read directory\n\n",
+			sourceDir,
+		)
+	}
+
+	dirIncluded := input.IncludeItem(filepath.Base(sourceDir))
+	defer releaseArchives()
+
+	// Files present in each category, with a list of reasons for ignored files
+	groups := make(map[int][]os.FileInfo)
+	var ignored []string
+
+	for _, file := range files {
+		category, reason := classifyFile(sourceDir, file, input, dirIncluded)
+		if category == categoryIgnored {
+			ignored = append(
+				ignored,
+				fmt.Sprintf("\t - \"%s\": %s", file.Name(), reason),
+			)
+			continue
+		}
+
+		if category == categoryArchive {
+			archived := archiveFiles(sourceDir, file, input, dirIncluded)
+			for category, items := range archived {
+				groups[category] = append(groups[category], items...)
+			}
+
+			continue
+		}
+
+		groups[category] = append(groups[category], file)
+	}
+
+	mediaFiles, samples := filterSamples(groups[categoryMedia], input)
+	for _, sample := range samples {
+		ignored = append(
+			ignored,
+			fmt.Sprintf("\t - \"%s\": sample or trailer", sample.Name()),
+		)
+	}
+
+	subtitles := orderSubtitles(
+		append(groups[categorySubtitle], nestedSubtitles(sourceDir, input)...),
+		input.SubOrder,
+	)
+
+	subtitles, duplicates := dedupeSubtitles(sourceDir, subtitles)
+	for _, duplicate := range duplicates {
+		ignored = append(ignored, fmt.Sprintf(
+			"\t - \"%s\": duplicate of \"%s\"",
+			duplicate.file.Name(),
+			duplicate.original.Name(),
+		))
+	}
+	attachments := groups[categoryAttachment]
+	chapters := groups[categoryChapter]
+
+	if override, ok := chaptersOverride(sourceDir, input); ok {
+		for _, chapter := range chapters {
+			ignored = append(
+				ignored,
+				fmt.Sprintf("\t - \"%s\": replaced by chapters file", chapter.Name()),
+			)
+		}
+
+		chapters = override
+	}
+
+	contents := []string{
+		fmt.Sprintf("Source directory: \"%s\"", sourceDir),
+		"\tMedia Files: " + commons.Stringify(&mediaFiles),
+		"\tSubtitles: " + commons.Stringify(&subtitles),
+		"\tAttachments: " + commons.Stringify(&attachments),
+		"\tChapters: " + commons.Stringify(&chapters),
+	}
+
+	// Subtitle file to be attached, if a single one is picked for the media file
+	var candidates []os.FileInfo
+	if len(mediaFiles) == 1 {
+		candidates = subtitlesFor(mediaFiles[0], subtitles)
+	}
+
+	if !input.KeepAllSubs && len(candidates) > 1 {
+		best := rankSubtitles(mediaFiles[0].Name(), candidates, input.SubOrder)[0]
+		contents = append(contents, fmt.Sprintf(
+			"\tBest Match: \"%s\" (score %.2f)",
+			best.file.Name(),
+			best.score,
+		))
+	}
+
+	if len(ignored) > 0 {
+		contents = append(contents, "\tIgnored:")
+		contents = append(contents, ignored...)
+	}
+
+	// Same checks as the ones performed while processing a source directory
+	result := "ready"
+	switch {
+	case input.DonorRule != nil:
+		// Extra files are optional in extract-and-merge mode
+		if raw, donor, err := donorFiles(mediaFiles, input); err != nil {
+			result = "error, " + err.Error()
+		} else {
+			result = fmt.Sprintf(
+				"ready, merging streams from \"%s\" into \"%s\"",
+				donor.Name(),
+				raw.Name(),
+			)
+		}
+
+	case len(mediaFiles) == 0:
+		result = "error, no media file found"
+
+	case len(mediaFiles) > 1 && !input.AllMedia:
+		result = "error, multiple media files found"
+
+	case len(subtitles) == 0 && len(attachments) == 0 && len(chapters) == 0:
+		result = "error, no additional files found"
+	}
+
+	contents = append(contents, "\tResult: "+result)
+
+	return strings.Join(contents, "\n") + "\n\n"
+}
@@ -0,0 +1,49 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestInspectDir(t *testing.T) {
+	testdata, _ := os.Getwd()
+	testdata = filepath.Join(filepath.Dir(filepath.Dir(testdata)), "testdata")
+
+	input := &commons.UserInput{Exclusions: []string{"tags.xml"}}
+	if code, err := input.Initialize(); code != commons.RootDirectoryIncorrect ||
+		err == nil {
+		// Root path is blank; the rules should be initialized regardless
+		t.Errorf("(inspect/inspectDir) unexpected result while initializing input")
+	}
+
+	res := inspectDir(filepath.Join(testdata, "test 01"), input)
+	for _, expected := range []string{
+		`Media Files: ["sample_640x360.mkv"]`,
+		`Subtitles: ["subtitles.ass"]`,
+		`Chapters: ["chapters.xml"]`,
+		`"ignore_this.exe": unrecognized extension`,
+		`"tags.xml": matches exclusion rules`,
+		"Result: ready",
+	} {
+		if !strings.Contains(res, expected) {
+			t.Errorf(
+				"(inspect/inspectDir) missing text in report \nexpected: `%s` "+
+					"\nreport: \n%s",
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Directories that can't be read should be reported
+	if res := inspectDir(filepath.Join(testdata, "invalid"), input); !strings.Contains(
+		res,
+		"unable to read directory",
+	) {
+		t.Errorf("(inspect/inspectDir) invalid directory not reported \n%s", res)
+	}
+}
@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+// Name of the folder created inside each source directory in the subfolder layout
+const layoutDirName = "merged"
+
+// Marker added to the names of outputs in the sibling layout, i.e. `name.merged.mkv`
+const siblingInfix = ".merged"
+
+/*
+CentralLayout checks if the outputs are stored in the result directory, as opposed to
+being stored next to their source directories
+*/
+func centralLayout(input *commons.UserInput) bool {
+	return input.OutputLayout == "" || input.OutputLayout == commons.LayoutCentral
+}
+
+/*
+LayoutResDir returns the directory the outputs of a source directory are stored in as
+per the output layout, creating the directory if required;
+  - central: the result directory
+  - sibling: the source directory itself
+  - subfolder: a folder named `merged` inside the source directory
+*/
+func layoutResDir(input *commons.UserInput, sourceDir, resDir string) (string, error) {
+	switch input.OutputLayout {
+	case commons.LayoutSibling:
+		return commons.NormalizePath(sourceDir), nil
+
+	case commons.LayoutSubfolder:
+		dir := joinPath(sourceDir, layoutDirName)
+		return dir, os.MkdirAll(dir, 0755)
+
+	default:
+		return resDir, nil
+	}
+}
+
+/*
+IsSiblingOutput checks if a file is an output stored next to its media file in the
+sibling layout, outputs in other languages (split by language) are recognized as well
+*/
+func isSiblingOutput(name string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	return strings.HasSuffix(base, siblingInfix) ||
+		strings.Contains(base, siblingInfix+".")
+}
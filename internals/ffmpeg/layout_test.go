@@ -0,0 +1,162 @@
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestLayoutResDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(layout/layoutResDir) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	resDir := filepath.Join(dir, "output")
+	for layout, expected := range map[string]string{
+		"":                      resDir,
+		commons.LayoutCentral:   resDir,
+		commons.LayoutSibling:   dir,
+		commons.LayoutSubfolder: filepath.Join(dir, layoutDirName),
+	} {
+		input := &commons.UserInput{OutputLayout: layout}
+		res, err := layoutResDir(input, dir, resDir)
+		if err != nil || res != commons.NormalizePath(expected) {
+			t.Errorf(
+				"(layout/layoutResDir) unexpected directory for `%s` \nexpected: %s"+
+					"\nfound: %s \nerror: %v",
+				layout,
+				expected,
+				res,
+				err,
+			)
+		}
+
+		if centralLayout(input) != (expected == resDir) {
+			t.Errorf("(layout/centralLayout) unexpected result for `%s`", layout)
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, layoutDirName)); err != nil ||
+		!info.IsDir() {
+		t.Errorf("(layout/layoutResDir) subfolder not created \nerror: %v", err)
+	}
+}
+
+func TestSiblingOutputs(t *testing.T) {
+	input := &commons.UserInput{OutputLayout: commons.LayoutSibling}
+	if res := outputName("Episode 01.mkv", input); res != "Episode 01.merged.mkv" {
+		t.Errorf("(handler/outputName) unexpected name in sibling layout: %s", res)
+	}
+
+	for name, expected := range map[string]bool{
+		"Episode 01.merged.mkv":        true,
+		"Episode 01.MERGED.mkv":        true,
+		"Episode 01.merged.eng.mkv":    true,
+		"Episode 01.merged.sample.mkv": true,
+		"Episode 01.mkv":               false,
+		"merged.mkv":                   false,
+		"Episode 01.merged-cut.mkv":    false,
+	} {
+		if res := isSiblingOutput(name); res != expected {
+			t.Errorf(
+				"(layout/isSiblingOutput) unexpected result for %q \nexpected: %v"+
+					"\nfound: %v",
+				name,
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Outputs of earlier runs are never picked up as media files
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/classifyFile) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Episode 01.merged.mkv")
+	if err = ioutil.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatalf("(handler/classifyFile) failed to create file: %v", err)
+	}
+
+	output, _ := os.Stat(path)
+	if category, _ := classifyFile(dir, output, input, true); category !=
+		categoryIgnored {
+		t.Errorf("(handler/classifyFile) output of an earlier run not ignored")
+	}
+
+	input.OutputLayout = commons.LayoutCentral
+	if category, _ := classifyFile(dir, output, input, true); category !=
+		categoryMedia {
+		t.Errorf("(handler/classifyFile) media file ignored in central layout")
+	}
+}
+
+func TestTraverseRootLayout(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	if err = os.Mkdir(filepath.Join(root, "Show"), 0755); err != nil {
+		t.Fatalf("(handler/TraverseRoot) failed to create directory: %v", err)
+	}
+
+	var used string
+	monkey.Patch(sourceDir, func(
+		_ context.Context,
+		_, resDir string,
+		_ *commons.UserInput,
+	) int {
+		used = resDir
+		return commons.StatusOK
+	})
+
+	input := &commons.UserInput{
+		RootPath:     root,
+		Order:        commons.OrderName,
+		OutputLayout: commons.LayoutSubfolder,
+	}
+
+	resDir := filepath.Join(root, "output")
+	if exitCode, err := TraverseRoot(context.Background(), input, resDir); err != nil ||
+		exitCode != commons.StatusOK {
+		t.Fatalf(
+			"(handler/TraverseRoot) unexpected result \nexit code: %d \nerror: %v",
+			exitCode,
+			err,
+		)
+	}
+
+	expected := commons.NormalizePath(filepath.Join(root, "Show", layoutDirName))
+	if used != expected {
+		t.Errorf(
+			"(handler/TraverseRoot) unexpected result directory \nexpected: %s"+
+				"\nfound: %s",
+			expected,
+			used,
+		)
+	}
+
+	// Neither the result directory is created, nor the empty subfolder left behind
+	for _, path := range []string{resDir, expected} {
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("(handler/TraverseRoot) unexpected directory: %s", path)
+		}
+	}
+}
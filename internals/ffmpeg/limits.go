@@ -0,0 +1,103 @@
+package ffmpeg
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Number of attachments beyond which a media file is flagged in the summary, a typical
+// media file needs a handful of fonts at most
+const attachmentWarnCount = 100
+
+/*
+CheckLimits compares the number of subtitle files and attachments to be merged into a
+media file against the limits set by the user, returning the exit code for the failure
+(if any). Media files with an unusually large number of attachments are flagged in the
+summary, irrespective of the limits.
+*/
+func checkLimits(
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	subtitles,
+	attachments []os.FileInfo,
+) int {
+	path := joinPath(sourceDir, mediaFile.Name())
+
+	for _, limit := range []struct {
+		kind  string
+		flag  string
+		count int
+		max   int
+	}{
+		{"subtitle files", "--max-subs", len(subtitles), input.MaxSubs},
+		{"attachments", "--max-attachments", len(attachments), input.MaxAttachments},
+	} {
+		if limit.max <= 0 || limit.count <= limit.max {
+			continue
+		}
+
+		log.Debugf(
+			`(limits/checkLimits) %d %s for file "%s", limit is %d`,
+			limit.count,
+			limit.kind,
+			path,
+			limit.max,
+		)
+
+		commons.Error(
+			"Too many %s (%d) for the media file, the limit is %d - "+
+				"use `%s` to change the limit\n\tPath: \"%s\"\n\n",
+			limit.kind,
+			limit.count,
+			limit.max,
+			limit.flag,
+			path,
+		)
+
+		return commons.SourceDirectoryError
+	}
+
+	if len(attachments) > attachmentWarnCount {
+		runSummary.warn(
+			`%d attachments merged into "%s", check the source directory`,
+			len(attachments),
+			path,
+		)
+	}
+
+	return commons.StatusOK
+}
+
+/*
+CheckCommandLength ensures the command can be run on the platform, returning the exit
+code for the failure (if any) - commands exceeding the limit are run with relative
+paths already, and attachments are added through mkvpropedit once FFmpeg completes
+where possible, this is the last resort.
+*/
+func checkCommandLength(args []string, mediaFile string) int {
+	length := commandLength(args)
+	if length <= argLimit {
+		return commons.StatusOK
+	}
+
+	log.Debugf(
+		`(limits/checkCommandLength) command for "%s" is %d bytes, limit is %d`,
+		mediaFile,
+		length,
+		argLimit,
+	)
+
+	commons.Error(
+		"FFmpeg command exceeds the limit for the platform (%d of %d bytes), "+
+			"use `--max-subs` or `--max-attachments` to cap the files merged"+
+			"\n\tPath: \"%s\"\n\n",
+		length,
+		argLimit,
+		mediaFile,
+	)
+
+	return commons.SourceDirectoryError
+}
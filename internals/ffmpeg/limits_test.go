@@ -0,0 +1,146 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestCheckLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(limits/checkLimits) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	runSummary = &Summary{}
+	defer func() { runSummary = &Summary{} }()
+
+	stat := func(name string) os.FileInfo {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(limits/checkLimits) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		return info
+	}
+
+	media := stat("video.mkv")
+	subs := []os.FileInfo{stat("English.srt"), stat("Spanish.srt")}
+
+	var fonts []os.FileInfo
+	for i := 0; i <= attachmentWarnCount; i++ {
+		fonts = append(fonts, stat(strings.Repeat("f", i+1)+".ttf"))
+	}
+
+	for _, in := range []struct {
+		maxSubs, maxAttachments int
+		expected                int
+	}{
+		{0, 0, commons.StatusOK},
+		{2, 0, commons.StatusOK},
+		{1, 0, commons.SourceDirectoryError},
+		{0, len(fonts) - 1, commons.SourceDirectoryError},
+		{-1, -1, commons.StatusOK}, // negative limits are the same as no limit
+	} {
+		input := &commons.UserInput{
+			MaxSubs:        in.maxSubs,
+			MaxAttachments: in.maxAttachments,
+		}
+
+		if code := checkLimits(dir, input, media, subs, fonts); code != in.expected {
+			t.Errorf(
+				"(limits/checkLimits) unexpected exit code \nlimits: %d, %d"+
+					"\nexpected: %d \nfound: %d",
+				in.maxSubs,
+				in.maxAttachments,
+				in.expected,
+				code,
+			)
+		}
+	}
+
+	// The attachment count is flagged for every media file checked successfully
+	if len(runSummary.Warnings) != 3 {
+		t.Errorf(
+			"(limits/checkLimits) unexpected warnings \nwarnings: %v",
+			runSummary.Warnings,
+		)
+	}
+}
+
+func TestLongCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{}
+	})
+
+	// Deeply nested source directory, with enough fonts to exceed the limit when
+	// full paths are used
+	sourceDir := filepath.Join(dir, strings.Repeat("nested", 40))
+	if err = os.Mkdir(sourceDir, 0755); err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	stat := func(name string) os.FileInfo {
+		path := filepath.Join(sourceDir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(handler/generateCmd) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		return info
+	}
+
+	var fonts []os.FileInfo
+	for len(fonts)*len(sourceDir) < argLimit {
+		fonts = append(fonts, stat(fmt.Sprintf("font%03d.ttf", len(fonts))))
+	}
+
+	input := &commons.UserInput{FFmpegPath: "ffmpeg"}
+	cmd := generateCmd(
+		context.Background(),
+		sourceDir,
+		input,
+		filepath.Join(dir, "video.mkv.part"),
+		stat("video.mkv"),
+		nil,
+		nil,
+		fonts,
+		nil,
+	)
+
+	// Paths in the source directory are relative, the command runs from there
+	args := strings.Join(cmd.Args, " ")
+	if cmd.Dir != sourceDir || strings.Contains(args, "nested/") {
+		t.Errorf("(handler/generateCmd) relative paths not used \ndir: %s", cmd.Dir)
+	}
+
+	if code := checkCommandLength(cmd.Args, "video.mkv"); code != commons.StatusOK {
+		t.Errorf("(limits/checkCommandLength) unexpected exit code: %d", code)
+	}
+
+	// Commands still too long are rejected
+	long := []string{strings.Repeat("a", argLimit)}
+	if code := checkCommandLength(long, "video.mkv"); code == commons.StatusOK {
+		t.Errorf("(limits/checkCommandLength) unexpected exit code: %d", code)
+	}
+}
@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"context"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Interval at which the system load is checked again while the run is paused
+var loadPollInterval = 30 * time.Second
+
+// Source of the system load - a variable to allow tests to imitate any load
+var systemLoad = commons.SystemLoad
+
+/*
+WaitForLoad pauses the run while the load on the system is above the limit set by the
+user (if any), checking the load again at regular intervals - prints a message when the
+run is paused and resumed. The run is never paused if the load can't be read.
+
+Returns false if the context is cancelled while waiting.
+*/
+func waitForLoad(ctx context.Context, input *commons.UserInput) bool {
+	if input.MaxLoad <= 0 {
+		return true
+	}
+
+	paused := false
+	for {
+		load, err := systemLoad()
+		if err != nil {
+			log.Debugf("(load/waitForLoad) unable to read system load \nerror: %v", err)
+			return true
+		}
+
+		if load <= input.MaxLoad {
+			if paused {
+				commons.Info("System load at %.2f, resuming\n\n", load)
+			}
+
+			return true
+		}
+
+		if !paused {
+			log.Debugf(
+				"(load/waitForLoad) system load %.2f above %.2f, pausing",
+				load,
+				input.MaxLoad,
+			)
+
+			commons.Info(
+				"System load at %.2f (limit %.2f), pausing until it drops...\n\n",
+				load,
+				input.MaxLoad,
+			)
+
+			paused = true
+		}
+
+		timer := time.NewTimer(loadPollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+	}
+}
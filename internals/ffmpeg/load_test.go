@@ -0,0 +1,62 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestWaitForLoad(t *testing.T) {
+	defer func(interval time.Duration) {
+		loadPollInterval = interval
+		systemLoad = commons.SystemLoad
+	}(loadPollInterval)
+
+	loadPollInterval = time.Millisecond
+
+	// The load is never read without a limit
+	systemLoad = func() (float64, error) {
+		t.Errorf("(load/waitForLoad) load read without a limit")
+		return 0, nil
+	}
+
+	input := &commons.UserInput{}
+	if !waitForLoad(context.Background(), input) {
+		t.Errorf("(load/waitForLoad) run paused without a limit")
+	}
+
+	// The run resumes once the load drops below the limit
+	loads := []float64{6, 5, 3.5}
+	systemLoad = func() (float64, error) {
+		load := loads[0]
+		if len(loads) > 1 {
+			loads = loads[1:]
+		}
+
+		return load, nil
+	}
+
+	input.MaxLoad = 4
+	if !waitForLoad(context.Background(), input) || len(loads) != 1 {
+		t.Errorf("(load/waitForLoad) run not paused until the load dropped")
+	}
+
+	// The run is never paused if the load can't be read
+	systemLoad = func() (float64, error) { return 0, errors.New("unavailable") }
+	if !waitForLoad(context.Background(), input) {
+		t.Errorf("(load/waitForLoad) run paused with the load unavailable")
+	}
+
+	// The wait ends once the context is cancelled
+	systemLoad = func() (float64, error) { return 8, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if waitForLoad(ctx, input) {
+		t.Errorf("(load/waitForLoad) cancelled wait reported as complete")
+	}
+}
@@ -0,0 +1,39 @@
+package ffmpeg
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Rule placed around the header and the footer of a section in the logs
+var sectionRule = strings.Repeat("=", 24)
+
+/*
+LogSection marks the start of the logs for a source directory, the function returned
+marks the end along with the result - every log written in between belongs to the
+source directory. Makes the logs for large batches navigable, i.e. by searching for
+the name of a source directory.
+*/
+func logSection(sourceDir string) (end func(exitCode int)) {
+	started := time.Now()
+	log.Debugf(
+		"(logsection/logSection) %s BEGIN \"%s\" %s",
+		sectionRule,
+		sourceDir,
+		sectionRule,
+	)
+
+	return func(exitCode int) {
+		log.Debugf(
+			"(logsection/logSection) %s END \"%s\" (%s, exit code %d, took %v) %s",
+			sectionRule,
+			sourceDir,
+			resultStatus(exitCode),
+			exitCode,
+			time.Since(started).Truncate(time.Millisecond),
+			sectionRule,
+		)
+	}
+}
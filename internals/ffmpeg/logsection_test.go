@@ -0,0 +1,35 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLogSection(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	level := log.GetLevel()
+	log.SetLevel(log.TraceLevel)
+	defer log.SetLevel(level)
+
+	end := logSection("Show A")
+	log.Debugf("(logsection) inside the section")
+	end(commons.SourceDirectoryError)
+
+	// Logs written in between are placed between the header and the footer
+	res := buf.String()
+	begin := strings.Index(res, `BEGIN \"Show A\"`)
+	inside := strings.Index(res, "inside the section")
+	footer := strings.Index(res, `END \"Show A\" (failed, exit code 15`)
+
+	if begin < 0 || inside < begin || footer < inside {
+		t.Errorf("(logsection/logSection) unexpected logs \n%s", res)
+	}
+}
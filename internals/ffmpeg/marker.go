@@ -0,0 +1,97 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reasons recorded in the marker for the exit codes a source directory can fail with
+var failureReasons = map[int]string{
+	commons.SourceDirectoryError: "source directory not in order, or over the limits",
+	commons.UnexpectedError:      "FFmpeg failed, or an unexpected error occurred",
+	commons.SafetyViolation:      "existing files would be modified in safe mode",
+	commons.SameFileError:        "output would overwrite an input",
+}
+
+/*
+FailedMarker is the content of the marker dropped in a source directory that failed,
+allows a later run to find (and reprocess) only the source directories that failed -
+without relying on the queue, or the history.
+*/
+type failedMarker struct {
+	Reason   string    `json:"reason"`
+	ExitCode int       `json:"exit_code"`
+	Time     time.Time `json:"time"`
+}
+
+// FailureReason returns the reason recorded in the marker for the exit code
+func failureReason(exitCode int) string {
+	if reason, ok := failureReasons[exitCode]; ok {
+		return reason
+	}
+
+	return fmt.Sprintf("failed with exit code %d", exitCode)
+}
+
+// HasFailedMarker checks if the source directory contains the marker for a failure
+func hasFailedMarker(sourceDir string) bool {
+	item, err := os.Stat(joinPath(sourceDir, commons.FailedMarkerName))
+	return err == nil && !item.IsDir()
+}
+
+/*
+UpdateMarker drops the marker in a source directory that failed, replacing the marker
+from an earlier run (if any) - the marker is removed once the source directory is
+processed successfully. Does nothing unless markers are in use, source directories
+that were skipped (or interrupted) are left untouched.
+*/
+func updateMarker(input *commons.UserInput, sourceDir string, exitCode int) {
+	if (!input.TouchFailed && !input.RetryFailed) || input.Safe {
+		return
+	}
+
+	path := joinPath(sourceDir, commons.FailedMarkerName)
+	switch exitCode {
+	case statusSkipped, commons.Interrupted:
+		return
+
+	case commons.StatusOK:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf(
+				"(marker/updateMarker) failed to remove marker: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+		}
+
+		return
+	}
+
+	if !input.TouchFailed {
+		return
+	}
+
+	content, _ := json.MarshalIndent(failedMarker{
+		Reason:   failureReason(exitCode),
+		ExitCode: exitCode,
+		Time:     time.Now(),
+	}, "", "  ")
+
+	if err := ioutil.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		log.Warnf(
+			"(marker/updateMarker) failed to write marker: \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+
+		return
+	}
+
+	log.Debugf(`(marker/updateMarker) marked source directory: "%s"`, sourceDir)
+}
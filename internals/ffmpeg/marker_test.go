@@ -0,0 +1,88 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestUpdateMarker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(marker/updateMarker) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Nothing is written unless markers are in use
+	updateMarker(&commons.UserInput{}, dir, commons.SourceDirectoryError)
+	if hasFailedMarker(dir) {
+		t.Errorf("(marker/updateMarker) marker written without `--touch-failed`")
+	}
+
+	input := &commons.UserInput{TouchFailed: true}
+	updateMarker(input, dir, commons.SourceDirectoryError)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, commons.FailedMarkerName))
+	marker := failedMarker{}
+	if err != nil || json.Unmarshal(content, &marker) != nil ||
+		marker.ExitCode != commons.SourceDirectoryError || marker.Reason == "" ||
+		marker.Time.IsZero() {
+		t.Errorf(
+			"(marker/updateMarker) unexpected marker \ncontent: %s \nerror: %v",
+			content,
+			err,
+		)
+	}
+
+	// Skipped and interrupted source directories keep their marker
+	for _, code := range []int{statusSkipped, commons.Interrupted} {
+		updateMarker(input, dir, code)
+		if !hasFailedMarker(dir) {
+			t.Errorf("(marker/updateMarker) marker removed for exit code %d", code)
+		}
+	}
+
+	// The marker is removed once the source directory succeeds, while retrying
+	updateMarker(&commons.UserInput{RetryFailed: true}, dir, commons.StatusOK)
+	if hasFailedMarker(dir) {
+		t.Errorf("(marker/updateMarker) marker kept for successful source directory")
+	}
+
+	if reason := failureReason(999); reason != "failed with exit code 999" {
+		t.Errorf("(marker/failureReason) unexpected reason: %s", reason)
+	}
+}
+
+func TestRetryFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(marker/retryFailed) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"01/video.mkv", "01/English.srt", "02/video.mkv"} {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("(marker/retryFailed) failed to create file: %v", err)
+		}
+	}
+
+	marker := filepath.Join(dir, "02", commons.FailedMarkerName)
+	if err = ioutil.WriteFile(marker, []byte("{}"), 0644); err != nil {
+		t.Fatalf("(marker/retryFailed) failed to create marker: %v", err)
+	}
+
+	// Only the source directory with a marker counts towards the estimate
+	sourceDirs := []string{filepath.Join(dir, "01"), filepath.Join(dir, "02")}
+	_, count := estimateOutputs(&commons.UserInput{RetryFailed: true}, sourceDirs)
+	if count != 1 {
+		t.Errorf("(marker/retryFailed) expected one media file, found %d", count)
+	}
+}
@@ -0,0 +1,187 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Weights for each part of the score used to rank subtitle files, the weights add up to
+one - keeping the score between zero and one.
+*/
+const (
+	weightSimilarity = 0.5 // similarity between the names of subtitle and media file
+	weightLanguage   = 0.3 // position of the language in the preferred languages
+	weightRecency    = 0.2 // modification time, relative to the other subtitles
+)
+
+/*
+SubtitleMatch is a subtitle file along with the confidence score for the file being the
+best match for a media file - the score ranges between zero and one.
+*/
+type subtitleMatch struct {
+	file  os.FileInfo
+	score float64
+}
+
+/*
+RankSubtitles scores each subtitle file as a match for the media file, returning the
+subtitles sorted by the score (highest first). The score is a weighted sum of;
+  - similarity between the names of the subtitle and media files (shared words)
+  - position of the language of the subtitle in the preferred languages, if any
+  - modification time of the subtitle, newer files being preferred
+
+Subtitles with the same score retain their order.
+*/
+func rankSubtitles(
+	mediaName string,
+	subtitles []os.FileInfo,
+	languages []string,
+) []subtitleMatch {
+	if len(subtitles) == 0 {
+		return nil
+	}
+
+	// Range of modification times, used to scale the recency of each file
+	oldest, newest := subtitles[0].ModTime(), subtitles[0].ModTime()
+	for _, sub := range subtitles {
+		if sub.ModTime().Before(oldest) {
+			oldest = sub.ModTime()
+		}
+
+		if sub.ModTime().After(newest) {
+			newest = sub.ModTime()
+		}
+	}
+
+	matches := make([]subtitleMatch, 0, len(subtitles))
+	for _, sub := range subtitles {
+		// Every language is acceptable without any preference
+		language := 1.0
+		if len(languages) > 0 {
+			rank := languageRank(sub.Name(), languages)
+			language = 1 - float64(rank)/float64(len(languages))
+		}
+
+		recency := 1.0
+		if span := newest.Sub(oldest); span > 0 {
+			recency = float64(sub.ModTime().Sub(oldest)) / float64(span)
+		}
+
+		matches = append(matches, subtitleMatch{
+			file: sub,
+			score: weightSimilarity*nameSimilarity(mediaName, sub.Name()) +
+				weightLanguage*language +
+				weightRecency*recency,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
+
+/*
+SelectSubtitles picks the subtitle file best matching the media file for each language
+when multiple subtitle files are present - subtitle files are grouped by the language
+in their names, files without a language form a group of their own. All subtitle files
+are kept if required by the user. Each file selected is reported along with its score.
+
+Files selected are returned best match first.
+*/
+func selectSubtitles(
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	subtitles []os.FileInfo,
+) []os.FileInfo {
+	if input.KeepAllSubs || len(subtitles) < 2 {
+		return subtitles
+	}
+
+	matches := rankSubtitles(mediaFile.Name(), subtitles, input.SubOrder)
+
+	// Number of candidates in each language, matches are sorted by their score - the
+	// first match in a language is the best match for it
+	candidates := map[string]int{}
+	for _, match := range matches {
+		candidates[subtitleLanguage(match.file.Name())]++
+	}
+
+	selected := make([]os.FileInfo, 0, len(candidates))
+	for _, match := range matches {
+		language := subtitleLanguage(match.file.Name())
+		if _, ok := candidates[language]; !ok {
+			continue
+		}
+
+		log.Debugf(
+			"(match/selectSubtitles) selected \"%s\" for \"%s\" \nscore: %.2f"+
+				"\ncandidates: %d",
+			match.file.Name(),
+			joinPath(sourceDir, mediaFile.Name()),
+			match.score,
+			candidates[language],
+		)
+
+		commons.Info(
+			"Selected subtitle \"%s\" (score %.2f) out of %d candidates \n\tPath: "+
+				"\"%s\"\n\n",
+			match.file.Name(),
+			match.score,
+			candidates[language],
+			joinPath(sourceDir, mediaFile.Name()),
+		)
+
+		selected = append(selected, match.file)
+		delete(candidates, language)
+	}
+
+	return selected
+}
+
+/*
+NameSimilarity returns the similarity between two file names, ranging between zero and
+one - the ratio of words shared by the names (Dice coefficient). Names are split into
+words on anything other than letters and digits, the extension is ignored.
+*/
+func nameSimilarity(first, second string) float64 {
+	firstWords, secondWords := nameWords(first), nameWords(second)
+	if len(firstWords)+len(secondWords) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range firstWords {
+		if secondWords[word] {
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(firstWords)+len(secondWords))
+}
+
+/*
+NameWords returns the set of (lowercase) words present in a file name, ignoring the
+extension
+*/
+func nameWords(name string) map[string]bool {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	words := map[string]bool{}
+
+	for _, word := range strings.FieldsFunc(stem, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[strings.ToLower(word)] = true
+	}
+
+	return words
+}
@@ -0,0 +1,125 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	for _, in := range []struct {
+		first, second string
+		expected      float64
+	}{
+		{"Show - 01.mkv", "Show - 01.ass", 1},
+		{"Show - 01.mkv", "[Group] Show - 01.ass", 0.8},
+		{"Show - 01.mkv", "Another - 02.ass", 0},
+		{".mkv", ".ass", 0},
+	} {
+		if res := nameSimilarity(in.first, in.second); res != in.expected {
+			t.Errorf(
+				"(match/nameSimilarity) unexpected similarity for %q and %q "+
+					"\nexpected: %v \nfound: %v",
+				in.first,
+				in.second,
+				in.expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestSelectSubtitles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(match/selectSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Files are created oldest first
+	now := time.Now()
+	names := []string{
+		"Show - 01 [GroupA].eng.ass",
+		"Show - 01 [GroupB].eng.ass",
+		"Unrelated.jpn.ass",
+		"Show - 01.mkv",
+	}
+
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(match/selectSubtitles) failed to create file: %v", err)
+		}
+
+		modTime := now.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("(match/selectSubtitles) failed to set time: %v", err)
+		}
+	}
+
+	var files []os.FileInfo
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("(match/selectSubtitles) failed to read file: %v", err)
+		}
+
+		files = append(files, info)
+	}
+
+	mediaFile, subtitles := files[3], files[:3]
+	input := &commons.UserInput{}
+
+	// Similar names tie, the newer file is preferred - the best match is picked for
+	// each language
+	res := selectSubtitles(dir, input, mediaFile, subtitles)
+	if len(res) != 2 || res[0].Name() != names[1] || res[1].Name() != names[2] {
+		t.Errorf(
+			"(match/selectSubtitles) expected %q and %q, found %v",
+			names[1],
+			names[2],
+			res,
+		)
+	}
+
+	// Subtitles without a language form a group of their own
+	untagged := []os.FileInfo{
+		nestedFile{files[0], "Show - 01.ass"},
+		nestedFile{files[0], "Show - 01 (v2).ass"},
+		files[0],
+	}
+
+	res = selectSubtitles(dir, input, mediaFile, untagged)
+	if len(res) != 2 || res[0].Name() != "Show - 01.ass" || res[1] != files[0] {
+		t.Errorf("(match/selectSubtitles) unexpected selection: %v", res)
+	}
+
+	// Subtitles in the preferred language are picked over similar names
+	input.SubOrder = []string{"jpn"}
+	matches := rankSubtitles(mediaFile.Name(), subtitles, input.SubOrder)
+	if matches[0].file.Name() != names[2] || matches[1].file.Name() != names[1] {
+		t.Errorf(
+			"(match/rankSubtitles) unexpected order: %q, %q, %q",
+			matches[0].file.Name(),
+			matches[1].file.Name(),
+			matches[2].file.Name(),
+		)
+	}
+
+	for _, match := range matches {
+		if match.score < 0 || match.score > 1 {
+			t.Errorf("(match/rankSubtitles) score out of range: %v", match.score)
+		}
+	}
+
+	// Every subtitle file is kept if required
+	input.KeepAllSubs = true
+	if res := selectSubtitles(dir, input, mediaFile, subtitles); len(res) != 3 {
+		t.Errorf("(match/selectSubtitles) expected every subtitle to be kept")
+	}
+}
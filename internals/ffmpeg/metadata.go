@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extension of the metadata exported next to an output, with `--export-metadata`
+const metadataExt = ".ffprobe.json"
+
+/*
+ExportMetadata saves the streams and the format of an output (as reported by FFprobe,
+in JSON) next to the output - allows other tools to index the outputs without probing
+them again.
+*/
+func exportMetadata(
+	ctx context.Context,
+	input *commons.UserInput,
+	output string,
+) error {
+	// Command being fired:
+	// `ffprobe -v error -show_streams -show_format -of json <output>`
+	cmd := commons.ToolCommand(
+		ctx,
+		input.FFprobePath,
+		"-v", "error",
+		"-show_streams",
+		"-show_format",
+		"-of", "json",
+		output,
+	)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	contents, err := cmd.Output()
+	if err != nil {
+		log.Debugf(
+			"(metadata/exportMetadata) ffprobe failed for: \"%s\" \nerror: %v"+
+				"\noutput: %s",
+			output,
+			err,
+			stderr.String(),
+		)
+
+		return fmt.Errorf("ffprobe failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return ioutil.WriteFile(output+metadataExt, contents, 0644)
+}
+
+/*
+RemoveMetadata removes the metadata exported for an output, if any - used before an
+output is recreated, the metadata would describe the earlier output otherwise.
+*/
+func removeMetadata(output string) {
+	if err := os.Remove(output + metadataExt); err != nil && !os.IsNotExist(err) {
+		log.Debugf(
+			"(metadata/removeMetadata) failed to remove metadata for: \"%s\" "+
+				"\nerror: %v",
+			output,
+			err,
+		)
+	}
+}
@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestExportMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(metadata/exportMetadata) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+
+	var args []string
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"Output",
+		func(c *exec.Cmd) ([]byte, error) {
+			args = c.Args
+			return []byte(`{"streams": [], "format": {}}`), nil
+		},
+	)
+
+	output := filepath.Join(dir, "movie.mkv")
+	input := &commons.UserInput{FFprobePath: "ffprobe"}
+	if err = exportMetadata(context.Background(), input, output); err != nil {
+		t.Errorf("(metadata/exportMetadata) unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"ffprobe", "-v", "error", "-show_streams", "-show_format",
+		"-of", "json", output,
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("(metadata/exportMetadata) unexpected command: %v", args)
+	}
+
+	contents, err := ioutil.ReadFile(output + metadataExt)
+	if err != nil || !strings.Contains(string(contents), `"streams"`) {
+		t.Errorf("(metadata/exportMetadata) metadata not saved \nerror: %v", err)
+	}
+
+	// Metadata is removed before the output is recreated
+	removeMetadata(output)
+	if _, err = os.Stat(output + metadataExt); !os.IsNotExist(err) {
+		t.Errorf("(metadata/removeMetadata) metadata not removed")
+	}
+
+	// Errors from FFprobe are included in the error, nothing is saved
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"Output",
+		func(c *exec.Cmd) ([]byte, error) {
+			_, _ = c.Stderr.Write([]byte("Invalid data found when processing input\n"))
+			return nil, errors.New("exit status 1")
+		},
+	)
+
+	err = exportMetadata(context.Background(), input, output)
+	if err == nil || !strings.Contains(err.Error(), "Invalid data found") {
+		t.Errorf("(metadata/exportMetadata) unexpected error: %v", err)
+	}
+
+	if _, err = os.Stat(output + metadataExt); !os.IsNotExist(err) {
+		t.Errorf("(metadata/exportMetadata) metadata saved for a failed probe")
+	}
+}
@@ -0,0 +1,139 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+/*
+Metrics tracks the throughput of the run, exposed in the Prometheus text format through
+`--metrics-addr` while the run lasts - allows long-running deployments (such as a large
+library on a seedbox) to graph the throughput and alert on failures.
+
+Values are updated from the goroutines tracking the encodes as well, every field is
+accessed atomically.
+*/
+type metrics struct {
+	processed  int64
+	failed     int64
+	skipped    int64
+	active     int64
+	bytes      int64
+	frames     int64
+	encodeTime int64 // in nanoseconds
+
+	// Frame rate of the ongoing encode (float64 bits), zero between encodes
+	fps uint64
+}
+
+// Metrics for the process, never reset - counters only grow while the process runs
+var runMetrics = &metrics{}
+
+/*
+MetricsHandler serves the metrics in the Prometheus text format, the handler can be
+registered on any path.
+*/
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		runMetrics.write(w)
+	})
+}
+
+// Record counts a source directory as per its exit code, same as the summary
+func (m *metrics) record(exitCode int) {
+	switch exitCode {
+	case commons.StatusOK:
+		atomic.AddInt64(&m.processed, 1)
+	case statusSkipped:
+		atomic.AddInt64(&m.skipped, 1)
+	default:
+		atomic.AddInt64(&m.failed, 1)
+	}
+}
+
+// Working marks a source directory as being processed (or done, with a negative delta)
+func (m *metrics) working(delta int64) {
+	atomic.AddInt64(&m.active, delta)
+}
+
+// SetFPS records the frame rate of the ongoing encode
+func (m *metrics) setFPS(fps float64) {
+	atomic.StoreUint64(&m.fps, math.Float64bits(fps))
+}
+
+// Write prints the metrics in the Prometheus text format
+func (m *metrics) write(w io.Writer) {
+	encodeTime := float64(atomic.LoadInt64(&m.encodeTime)) / 1e9
+	for _, metric := range []struct {
+		name  string
+		kind  string
+		help  string
+		value interface{}
+	}{
+		{
+			"autosub_jobs_processed_total",
+			"counter",
+			"Source directories processed successfully.",
+			atomic.LoadInt64(&m.processed),
+		},
+		{
+			"autosub_jobs_failed_total",
+			"counter",
+			"Source directories that could not be processed.",
+			atomic.LoadInt64(&m.failed),
+		},
+		{
+			"autosub_jobs_skipped_total",
+			"counter",
+			"Source directories skipped.",
+			atomic.LoadInt64(&m.skipped),
+		},
+		{
+			"autosub_jobs_active",
+			"gauge",
+			"Source directories being processed.",
+			atomic.LoadInt64(&m.active),
+		},
+		{
+			"autosub_bytes_written_total",
+			"counter",
+			"Total size of the outputs written, in bytes.",
+			atomic.LoadInt64(&m.bytes),
+		},
+		{
+			"autosub_frames_encoded_total",
+			"counter",
+			"Frames encoded by FFmpeg.",
+			atomic.LoadInt64(&m.frames),
+		},
+		{
+			"autosub_encode_seconds_total",
+			"counter",
+			"Time spent encoding, in seconds.",
+			encodeTime,
+		},
+		{
+			"autosub_encode_fps",
+			"gauge",
+			"Frame rate of the ongoing encode.",
+			math.Float64frombits(atomic.LoadUint64(&m.fps)),
+		},
+	} {
+		fmt.Fprintf(
+			w,
+			"# HELP %s %s\n# TYPE %s %s\n%s %v\n",
+			metric.name,
+			metric.help,
+			metric.name,
+			metric.kind,
+			metric.name,
+			metric.value,
+		)
+	}
+}
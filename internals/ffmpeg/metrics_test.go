@@ -0,0 +1,46 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestMetrics(t *testing.T) {
+	defer func(original *metrics) {
+		runMetrics = original
+	}(runMetrics)
+
+	runMetrics = &metrics{}
+	summary := &Summary{}
+
+	summary.record("/root/dir 01", commons.StatusOK)
+	summary.record("/root/dir 02", commons.SourceDirectoryError)
+	summary.skip("/root/dir 03")
+	summary.encoded(1200, 2*time.Second)
+	runMetrics.working(1)
+	runMetrics.setFPS(24.5)
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := ioutil.ReadAll(recorder.Body)
+	for _, line := range []string{
+		"# TYPE autosub_jobs_processed_total counter",
+		"autosub_jobs_processed_total 1\n",
+		"autosub_jobs_failed_total 1\n",
+		"autosub_jobs_skipped_total 1\n",
+		"autosub_jobs_active 1\n",
+		"autosub_frames_encoded_total 1200\n",
+		"autosub_encode_seconds_total 2\n",
+		"autosub_encode_fps 24.5\n",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("(metrics/write) missing `%s` \nmetrics: %s", line, body)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Text subtitle codecs (as named by FFprobe) that can be converted to `mov_text`, the
+// only subtitle codec MP4 players support - image subtitles can't be converted
+var textSubCodecs = map[string]bool{
+	"subrip":   true,
+	"ass":      true,
+	"ssa":      true,
+	"webvtt":   true,
+	"mov_text": true,
+	"text":     true,
+}
+
+/*
+MP4Path returns the path to the MP4 variant of an output, next to the output
+*/
+func mp4Path(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".mp4"
+}
+
+/*
+MP4Args returns the FFmpeg arguments to remux an output into an MP4 variant, from the
+streams in the output - video and audio streams are copied, text subtitles are
+converted to `mov_text`. Image subtitles, attachments and data streams can't be held
+by MP4, these are left out; the number of subtitle streams left out is returned.
+*/
+func mp4Args(
+	input *commons.UserInput,
+	output, dest string,
+	streams []probedStream,
+) (args []string, dropped int) {
+	args = []string{"-hide_banner", "-loglevel", "error", "-i", output}
+
+	for i, stream := range streams {
+		switch stream.CodecType {
+		case "video", "audio":
+		case "subtitle":
+			if !textSubCodecs[stream.CodecName] {
+				dropped++
+				continue
+			}
+		default:
+			continue
+		}
+
+		args = append(args, "-map", fmt.Sprintf("0:%d", i))
+	}
+
+	args = append(args, "-map_chapters", "0", "-c", "copy", "-c:s", "mov_text")
+
+	if input.Threads > 0 {
+		args = append(args, "-threads", fmt.Sprint(input.Threads))
+	}
+
+	return append(args, "-f", "mp4", dest), dropped
+}
+
+/*
+WriteMP4 writes an MP4 variant next to a complete output, through a second pass over
+the output - the variant is written to a partial file first, replacing an existing
+variant (if any) once complete. Subtitle streams that can't be held by MP4 are reported
+in the summary.
+*/
+func writeMP4(ctx context.Context, input *commons.UserInput, output string) error {
+	dest := mp4Path(output)
+	if _, err := os.Stat(dest); err == nil && input.Safe {
+		return fmt.Errorf("%q already exists, can't be overwritten in safe mode", dest)
+	}
+
+	streams, err := probeStreamInfo(ctx, input.FFprobePath, output)
+	if err != nil {
+		return fmt.Errorf("failed to probe the output: %v", err)
+	}
+
+	partial := partialPath(dest)
+	if err = os.Remove(partial); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	args, dropped := mp4Args(input, output, partial, streams)
+	res, err := commons.ToolCommand(ctx, input.FFmpegPath, args...).CombinedOutput()
+	if err != nil {
+		log.Debugf(
+			"(mp4/writeMP4) ffmpeg failed for: \"%s\" \nerror: %v \noutput: %s",
+			output,
+			err,
+			res,
+		)
+
+		_ = os.Remove(partial)
+		return fmt.Errorf("ffmpeg failed: %s", strings.TrimSpace(string(res)))
+	}
+
+	// Variant from an earlier run is replaced
+	runSummary.replacing(dest)
+	if err = commons.Discard(dest); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+
+	if err = os.Rename(partial, dest); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+
+	if dropped > 0 {
+		runSummary.warn(
+			`%d image subtitle stream(s) left out of the MP4 variant: "%s"`,
+			dropped,
+			dest,
+		)
+	}
+
+	runSummary.created(dest)
+	return nil
+}
@@ -0,0 +1,149 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestMP4Args(t *testing.T) {
+	if res := mp4Path("/res/Episode 01.mkv"); res != "/res/Episode 01.mp4" {
+		t.Errorf("(mp4/mp4Path) unexpected path: %s", res)
+	}
+
+	streams := []probedStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "audio", CodecName: "aac"},
+		{CodecType: "subtitle", CodecName: "subrip"},
+		{CodecType: "subtitle", CodecName: "hdmv_pgs_subtitle"},
+		{CodecType: "subtitle", CodecName: "ass"},
+		{CodecType: "attachment", CodecName: "ttf"},
+	}
+
+	input := &commons.UserInput{Threads: 2}
+	args, dropped := mp4Args(input, "out.mkv", "out.mp4.part", streams)
+	expected := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-i", "out.mkv",
+		"-map", "0:0",
+		"-map", "0:1",
+		"-map", "0:2",
+		"-map", "0:4",
+		"-map_chapters", "0",
+		"-c", "copy",
+		"-c:s", "mov_text",
+		"-threads", "2",
+		"-f", "mp4",
+		"out.mp4.part",
+	}
+
+	if dropped != 1 || !reflect.DeepEqual(args, expected) {
+		t.Errorf(
+			"(mp4/mp4Args) unexpected arguments \nexpected: %v \nfound: %v"+
+				"\ndropped: %d",
+			expected,
+			args,
+			dropped,
+		)
+	}
+}
+
+func TestWriteMP4(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer func(original *Summary) { runSummary = original }(runSummary)
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(mp4/writeMP4) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "Episode 01.mkv")
+	variant := filepath.Join(dir, "Episode 01.mp4")
+	for _, path := range []string{output, variant} {
+		if err = ioutil.WriteFile(path, []byte("earlier"), 0644); err != nil {
+			t.Fatalf("(mp4/writeMP4) failed to create file: %v", err)
+		}
+	}
+
+	monkey.Patch(probeStreamInfo, func(
+		context.Context,
+		string,
+		string,
+	) ([]probedStream, error) {
+		return []probedStream{
+			{CodecType: "video", CodecName: "h264"},
+			{CodecType: "subtitle", CodecName: "dvd_subtitle"},
+		}, nil
+	})
+
+	// FFmpeg writes the variant to the last argument
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			return nil, ioutil.WriteFile(c.Args[len(c.Args)-1], []byte("mp4"), 0644)
+		},
+	)
+
+	// Existing variants are never overwritten in safe mode
+	runSummary = &Summary{}
+	input := &commons.UserInput{Safe: true}
+	if err = writeMP4(context.Background(), input, output); err == nil {
+		t.Errorf("(mp4/writeMP4) variant overwritten in safe mode")
+	}
+
+	input.Safe = false
+	if err = writeMP4(context.Background(), input, output); err != nil {
+		t.Fatalf("(mp4/writeMP4) unexpected error: %v", err)
+	}
+
+	if res, _ := ioutil.ReadFile(variant); string(res) != "mp4" {
+		t.Errorf("(mp4/writeMP4) variant not replaced: %s", res)
+	}
+
+	if _, err = os.Stat(partialPath(variant)); !os.IsNotExist(err) {
+		t.Errorf("(mp4/writeMP4) partial variant left behind")
+	}
+
+	if len(runSummary.Created) != 1 || len(runSummary.Warnings) != 1 ||
+		!strings.Contains(runSummary.Warnings[0], "1 image subtitle stream(s)") {
+		t.Errorf(
+			"(mp4/writeMP4) unexpected summary \ncreated: %v \nwarnings: %v",
+			runSummary.Created,
+			runSummary.Warnings,
+		)
+	}
+
+	// Output from FFmpeg is included in the error, the partial variant is removed
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			_ = ioutil.WriteFile(c.Args[len(c.Args)-1], nil, 0644)
+			return []byte("Could not find tag for codec pcm_s16le\n"),
+				errors.New("exit status 1")
+		},
+	)
+
+	err = writeMP4(context.Background(), input, output)
+	if err == nil || !strings.Contains(err.Error(), "pcm_s16le") {
+		t.Errorf("(mp4/writeMP4) unexpected error: %v", err)
+	}
+
+	if _, err = os.Stat(partialPath(variant)); !os.IsNotExist(err) {
+		t.Errorf("(mp4/writeMP4) partial variant left behind after a failure")
+	}
+}
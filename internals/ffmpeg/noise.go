@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+Warnings printed by FFmpeg that are known to be harmless while merging files - timestamp
+adjustments, guesses made for missing stream parameters, and repeated messages. These
+are left out of the output logged for failed commands, leaving the actual cause of the
+failure in view.
+*/
+var benignPattern = regexp.MustCompile(
+	`(?i)past duration \S+ too large|non-monotonous dts|` +
+		`non monotonically increasing dts|starting new cluster due to timestamp|` +
+		`not enough frames to estimate rate|guessed channel layout|` +
+		`last message repeated \d+ times|deprecated pixel format used|` +
+		`could not update timestamps for (skipped|discarded) samples|` +
+		`invalid timestamps? .* (corrected|adjusted)|` +
+		`estimating duration from bitrate`,
+)
+
+/*
+FilterNoise removes the known-benign warnings from the output of an FFmpeg command,
+blank lines are dropped and the remaining lines are kept in order. The number of
+warnings removed is returned as well.
+*/
+func filterNoise(output string) (filtered string, removed int) {
+	lines := strings.Split(output, "\n")
+	kept := lines[:0]
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if benignPattern.MatchString(strings.TrimRight(line, "\r")) {
+			removed++
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n"), removed
+}
@@ -0,0 +1,25 @@
+package ffmpeg
+
+import "testing"
+
+func TestFilterNoise(t *testing.T) {
+	output := "[matroska @ 0x55] Starting new cluster due to timestamp\n" +
+		"\n" +
+		"[ass @ 0x56] Invalid data found when processing input\r\n" +
+		"    Last message repeated 3 times\n" +
+		"Past duration 0.999992 too large\n" +
+		"Error while opening encoder for output stream #0:2"
+
+	expected := "[ass @ 0x56] Invalid data found when processing input\r\n" +
+		"Error while opening encoder for output stream #0:2"
+
+	if res, removed := filterNoise(output); res != expected || removed != 3 {
+		t.Errorf(
+			"(noise/filterNoise) unexpected result \nexpected: %q \nresult: %q"+
+				"\nremoved: %d",
+			expected,
+			res,
+			removed,
+		)
+	}
+}
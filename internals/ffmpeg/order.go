@@ -0,0 +1,66 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+OrderSubtitles sorts the subtitle files in the order of languages preferred by the user.
+The language of a subtitle file is detected through its name, i.e. a file named
+`episode.eng.ass` or `episode [jpn].ass` is matched against the language `eng` or `jpn`
+respectively.
+
+Subtitles are sorted by the position of their language in the list, files not matching
+any language are placed afterwards - files with the same position are sorted
+alphabetically. The list is returned as is if no languages are present.
+*/
+func orderSubtitles(subtitles []os.FileInfo, languages []string) []os.FileInfo {
+	if len(languages) == 0 || len(subtitles) < 2 {
+		return subtitles
+	}
+
+	ranks := make(map[string]int, len(subtitles))
+	for _, sub := range subtitles {
+		ranks[sub.Name()] = languageRank(sub.Name(), languages)
+	}
+
+	ordered := append([]os.FileInfo{}, subtitles...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		first, second := ordered[i].Name(), ordered[j].Name()
+		if ranks[first] != ranks[second] {
+			return ranks[first] < ranks[second]
+		}
+
+		return strings.ToLower(first) < strings.ToLower(second)
+	})
+
+	return ordered
+}
+
+/*
+LanguageRank returns the position of the first language (from the list) present in the
+name of a subtitle file, the length of the list is returned if no language matches.
+The name is split into words on anything other than letters, and each word is
+compared against the languages irrespective of case.
+*/
+func languageRank(name string, languages []string) int {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	words := strings.FieldsFunc(stem, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	rank := len(languages)
+	for _, word := range words {
+		for i, lang := range languages {
+			if i < rank && strings.EqualFold(word, lang) {
+				rank = i
+			}
+		}
+	}
+
+	return rank
+}
@@ -0,0 +1,79 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOrderSubtitles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(order/orderSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"episode.eng.ass",
+		"episode.jpn.ass",
+		"episode [GER].srt",
+		"signs.ass",
+		"episode.fre.ass",
+		"another.eng.srt",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(order/orderSubtitles) failed to create file: %v", err)
+		}
+	}
+
+	subtitles, _ := ioutil.ReadDir(dir)
+	names := func(files []os.FileInfo) (res []string) {
+		for _, file := range files {
+			res = append(res, file.Name())
+		}
+
+		return res
+	}
+
+	for _, in := range []struct {
+		languages []string
+		result    []string
+	}{
+		// No preference, order remains unchanged
+		{nil, names(subtitles)},
+
+		{[]string{"jpn", "ger", "eng"}, []string{
+			"episode.jpn.ass",
+			"episode [GER].srt",
+			"another.eng.srt",
+			"episode.eng.ass",
+			"episode.fre.ass",
+			"signs.ass",
+		}},
+
+		{[]string{"fre"}, []string{
+			"episode.fre.ass",
+			"another.eng.srt",
+			"episode [GER].srt",
+			"episode.eng.ass",
+			"episode.jpn.ass",
+			"signs.ass",
+		}},
+	} {
+		if res := names(orderSubtitles(subtitles, in.languages)); !reflect.DeepEqual(
+			res,
+			in.result,
+		) {
+			t.Errorf(
+				"(order/orderSubtitles) unexpected order \nlanguages: %v"+
+					"\nexpected: %v \nresult: %v",
+				in.languages,
+				in.result,
+				res,
+			)
+		}
+	}
+}
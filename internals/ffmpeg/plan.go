@@ -0,0 +1,176 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+/*
+StreamOption is an FFmpeg option applied to a single stream (or a group of streams) in
+the output, such as `-c:s:0 srt` or `-metadata:s:s:0 language=eng`.
+*/
+type streamOption struct {
+	flag  string
+	value string
+}
+
+/*
+CmdPlan is the FFmpeg command for a media file, in a structured form - allows the
+command to be inspected (and tested) without picking apart the arguments, and to be
+printed or run as required.
+
+The arguments are formed in a fixed order; the log level, the inputs, the codecs, the
+maps, the metadata and the dispositions for the streams, the attachments, the remaining
+options and the output.
+*/
+type cmdPlan struct {
+	// Log level for FFmpeg, the banner is hidden as well - FFmpeg defaults are used
+	// if blank
+	logLevel string
+
+	// Paths to the input files, the index of an input is its position in the list
+	inputs []string
+
+	// Codecs for the streams in the output, the first option applies to all streams
+	codecs []streamOption
+
+	// Stream specifiers for the streams mapped into the output, in order
+	maps []string
+
+	// Input the chapters are copied from, blank to let FFmpeg decide
+	chapters string
+
+	// Metadata for the streams in the output, the value is of the form `key=value`
+	metadata []streamOption
+
+	// Dispositions for the streams in the output, i.e. `+hearing_impaired`
+	dispositions []streamOption
+
+	// Files attached to the output, and the index of the first attachment stream
+	// added - attachment streams copied from the inputs come before these
+	attachments     []attachmentPlan
+	attachmentIndex int
+
+	// Remaining options, such as the number of threads
+	options []string
+
+	// Format (i.e. the muxer) and the path to the output file
+	format string
+	output string
+
+	// Working directory for the command, paths in the plan can be relative to it.
+	// The working directory of the application is used if blank
+	dir string
+}
+
+/*
+Args returns the arguments for FFmpeg (without the executable), the progress is
+reported on `stdout` in a parsable format, instead of `stderr` - leaving only the
+messages at the log level chosen on `stderr`.
+*/
+func (plan *cmdPlan) Args() []string {
+	var args []string
+	if plan.logLevel != "" {
+		args = append(args, "-hide_banner", "-loglevel", plan.logLevel)
+	}
+
+	args = append(args, "-progress", "pipe:1", "-nostats")
+
+	for _, input := range plan.inputs {
+		args = append(args, "-i", input)
+	}
+
+	for _, codec := range plan.codecs {
+		args = append(args, codec.flag, codec.value)
+	}
+
+	for _, spec := range plan.maps {
+		args = append(args, "-map", spec)
+	}
+
+	if plan.chapters != "" {
+		args = append(args, "-map_chapters", plan.chapters)
+	}
+
+	for _, metadata := range plan.metadata {
+		args = append(args, metadata.flag, metadata.value)
+	}
+
+	for _, disposition := range plan.dispositions {
+		args = append(args, disposition.flag, disposition.value)
+	}
+
+	for i, attachment := range plan.attachments {
+		args = append(args, attachment.args(plan.attachmentIndex+i)...)
+	}
+
+	args = append(args, plan.options...)
+
+	if plan.format != "" {
+		args = append(args, "-f", plan.format)
+	}
+
+	return append(args, plan.output)
+}
+
+/*
+String returns the arguments joined into a single line, quoted as required - allows the
+command to be logged (or copied) as is.
+*/
+func (plan *cmdPlan) String() string {
+	return quoteArgs(plan.Args())
+}
+
+// Command returns the command to run the plan through the FFmpeg executable supplied
+func (plan *cmdPlan) command(ctx context.Context, ffmpegPath string) *exec.Cmd {
+	cmd := commons.ToolCommand(ctx, ffmpegPath, plan.Args()...)
+	cmd.Dir = plan.dir
+
+	return cmd
+}
+
+/*
+RelativeTo runs the command from the directory supplied, replacing the paths of the
+inputs and attachments present in the directory with relative paths - shortens the
+command considerably for source directories with a large number of files.
+*/
+func (plan *cmdPlan) relativeTo(dir string) {
+	prefix := filepath.Clean(dir) + string(os.PathSeparator)
+	relative := func(path string) string {
+		if !strings.HasPrefix(path, prefix) {
+			return path
+		}
+
+		// Prefixed with the current directory, a path starting with a hyphen would
+		// be treated as an option otherwise
+		return "." + string(os.PathSeparator) + strings.TrimPrefix(path, prefix)
+	}
+
+	for i, input := range plan.inputs {
+		plan.inputs[i] = relative(input)
+	}
+
+	for i := range plan.attachments {
+		plan.attachments[i].path = relative(plan.attachments[i].path)
+	}
+
+	plan.dir = dir
+}
+
+/*
+CommandLength returns the space taken up by the arguments of a command, each argument
+is followed by a separator (or a null byte) - compared against the limit for the
+platform.
+*/
+func commandLength(args []string) (length int) {
+	for _, arg := range args {
+		length += len(arg) + 1
+	}
+
+	return length
+}
@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPlanArgs(t *testing.T) {
+	plan := &cmdPlan{
+		logLevel: "warning",
+		inputs:   []string{"/media/video 01.mkv", "/media/English.vtt"},
+		codecs:   []streamOption{{"-c", codecCopy}, {"-c:s:1", "srt"}},
+		maps:     []string{"0", "1"},
+		chapters: "1",
+		metadata: []streamOption{{"-metadata:s:s:1", "title=English"}},
+		dispositions: []streamOption{
+			{"-disposition:s:1", "+hearing_impaired"},
+		},
+		attachments: []attachmentPlan{
+			{path: "/media/font.ttf", mimetype: "font/ttf"},
+		},
+		attachmentIndex: 2,
+		options:         []string{"-threads", "2"},
+		format:          "matroska",
+		output:          "/result/video 01.mkv.part",
+	}
+
+	expected := []string{
+		"-hide_banner", "-loglevel", "warning",
+		"-progress", "pipe:1", "-nostats",
+		"-i", "/media/video 01.mkv",
+		"-i", "/media/English.vtt",
+		"-c", "copy",
+		"-c:s:1", "srt",
+		"-map", "0",
+		"-map", "1",
+		"-map_chapters", "1",
+		"-metadata:s:s:1", "title=English",
+		"-disposition:s:1", "+hearing_impaired",
+		"-attach", "/media/font.ttf",
+		"-metadata:s:t:2", "mimetype=font/ttf",
+		"-threads", "2",
+		"-f", "matroska",
+		"/result/video 01.mkv.part",
+	}
+
+	if args := plan.Args(); !reflect.DeepEqual(args, expected) {
+		t.Errorf(
+			"(plan/Args) unexpected arguments \nexpected: %v \nfound: %v",
+			expected,
+			args,
+		)
+	}
+
+	if str := plan.String(); str != quoteArgs(expected) {
+		t.Errorf(
+			"(plan/String) unexpected result \nexpected: %s \nfound: %s",
+			quoteArgs(expected),
+			str,
+		)
+	}
+
+	cmd := plan.command(context.Background(), "ffmpeg")
+	if !reflect.DeepEqual(cmd.Args[1:], expected) {
+		t.Errorf(
+			"(plan/command) unexpected arguments \nexpected: %v \nfound: %v",
+			expected,
+			cmd.Args[1:],
+		)
+	}
+}
+
+func TestPlanArgsMinimal(t *testing.T) {
+	// Optional parts of the plan are left out of the arguments when not set
+	plan := &cmdPlan{inputs: []string{"in.mkv"}, output: "out.mkv"}
+
+	expected := []string{"-progress", "pipe:1", "-nostats", "-i", "in.mkv", "out.mkv"}
+	if args := plan.Args(); !reflect.DeepEqual(args, expected) {
+		t.Errorf(
+			"(plan/Args) unexpected arguments \nexpected: %v \nfound: %v",
+			expected,
+			args,
+		)
+	}
+}
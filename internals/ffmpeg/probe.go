@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"context"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+StreamCount is a simple structure containing the number of subtitle and attachment
+streams present in a file.
+*/
+type streamCount struct {
+	subtitles   int
+	attachments int
+}
+
+/*
+ProbeStreams uses FFprobe to count the subtitle and attachment streams present in a
+file - used to keep a track of the stream indices in the output, since these streams
+are copied over as is.
+
+The counts will be zero if the file can't be probed. Counts from the probe cache are
+used if the file is unchanged since it was last probed.
+*/
+func probeStreams(ctx context.Context, ffprobePath, path string) (count streamCount) {
+	if cached, ok := runProbeCache.streams(path); ok {
+		log.Debugf(`(probe/probeStreams) using cached streams for: "%s"`, path)
+		return cached
+	}
+
+	// Command being fired:
+	// `ffprobe -v error -show_entries stream=codec_type -of csv=p=0 <file>`
+	output, err := commons.ToolCommand(
+		ctx,
+		ffprobePath,
+		"-v", "error",
+		"-show_entries", "stream=codec_type",
+		"-of", "csv=p=0",
+		path,
+	).Output()
+
+	if err != nil {
+		log.Debugf(
+			"(probe/probeStreams) failed to probe file: \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+
+		return count
+	}
+
+	// Each line contains the type of a stream present in the file, some builds add a
+	// trailing separator
+	for _, line := range strings.Split(string(output), "\n") {
+		switch strings.ToLower(strings.Trim(line, ", \t\r")) {
+		case "subtitle":
+			count.subtitles++
+		case "attachment":
+			count.attachments++
+		}
+	}
+
+	runProbeCache.update(path, func(entry *probeEntry) {
+		entry.Subtitles = &count.subtitles
+		entry.Attachments = &count.attachments
+	})
+
+	log.Debugf(
+		"(probe/probeStreams) streams in file: \"%s\""+
+			"\nsubtitles: %d \nattachments: %d",
+		path,
+		count.subtitles,
+		count.attachments,
+	)
+
+	return count
+}
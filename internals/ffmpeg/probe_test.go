@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"bou.ke/monkey"
+)
+
+func TestProbeStreams(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	cmd := exec.Cmd{}
+	for output, result := range map[string]streamCount{
+		"video\naudio\nsubtitle\nsubtitle\nattachment\n": {2, 1},
+		"subtitle\r\nattachment\r\nattachment":           {1, 2},
+		"video\naudio\n":                                 {0, 0},
+		"":                                               {0, 0},
+	} {
+		output := output // pin
+
+		monkey.PatchInstanceMethod(
+			reflect.TypeOf(&cmd),
+			"Output",
+			func(*exec.Cmd) ([]byte, error) { return []byte(output), nil },
+		)
+
+		res := probeStreams(context.Background(), "ffprobe", "file.mks")
+		if res != result {
+			t.Errorf(
+				"(probe/probeStreams) unexpected result \noutput: %q "+
+					"\nexpected: %+v \nresult: %+v",
+				output,
+				result,
+				res,
+			)
+		}
+	}
+
+	// Failure to run ffprobe should result in zero streams
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"Output",
+		func(*exec.Cmd) ([]byte, error) { return nil, errors.New("test error") },
+	)
+
+	if res := probeStreams(context.Background(), "ffprobe", "file.mks"); res !=
+		(streamCount{}) {
+		t.Errorf("(probe/probeStreams) unexpected result on failure \n%+v", res)
+	}
+}
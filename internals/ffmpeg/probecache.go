@@ -0,0 +1,212 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ProbeEntry contains the results of probing a file, along with the size and the
+modification time of the file when it was probed - the entry is discarded if either of
+these change. Results that weren't probed are nil.
+*/
+type probeEntry struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mtime"`
+
+	Frames      *int64   `json:"frames,omitempty"`
+	Duration    *float64 `json:"duration,omitempty"`
+	Subtitles   *int     `json:"subtitles,omitempty"`
+	Attachments *int     `json:"attachments,omitempty"`
+}
+
+/*
+ProbeCache stores the results of probing files on the disk, allowing repeated runs on
+the same files (for example, a dry-run followed by the actual run, or retries) to skip
+running FFprobe again. Entries are keyed by the path to the file.
+*/
+type probeCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*probeEntry
+	dirty   bool
+}
+
+// Probe cache for the ongoing run, nil if the cache is disabled
+var runProbeCache *probeCache
+
+/*
+OpenProbeCache loads the probe cache from the path set by the user, returns nil if the
+cache is disabled. A missing (or unreadable) cache file starts an empty cache.
+*/
+func openProbeCache(input *commons.UserInput) *probeCache {
+	if input.NoProbeCache || input.ProbeCache == "" {
+		return nil
+	}
+
+	cache := &probeCache{
+		path:    input.ProbeCache,
+		entries: make(map[string]*probeEntry),
+	}
+
+	content, err := ioutil.ReadFile(cache.path)
+	if err == nil {
+		err = json.Unmarshal(content, &cache.entries)
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf(
+			"(probecache/openProbeCache) discarding probe cache \"%s\" \nerror: %v",
+			cache.path,
+			err,
+		)
+
+		cache.entries = make(map[string]*probeEntry)
+	}
+
+	return cache
+}
+
+/*
+Lookup returns the entry for a file, if the file is unchanged since it was probed. The
+entry returned should not be modified.
+*/
+func (cache *probeCache) lookup(path string) *probeEntry {
+	if cache == nil {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[path]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return nil
+	}
+
+	return entry
+}
+
+/*
+Update modifies the entry for a file through the function supplied, the entry is reset
+if the file changed since it was last probed.
+*/
+func (cache *probeCache) update(path string, modify func(entry *probeEntry)) {
+	if cache == nil {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[path]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		entry = &probeEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		cache.entries[path] = entry
+	}
+
+	modify(entry)
+	cache.dirty = true
+}
+
+/*
+Frames returns the frame count cached for a file
+*/
+func (cache *probeCache) frames(path string) (int64, bool) {
+	if entry := cache.lookup(path); entry != nil && entry.Frames != nil {
+		return *entry.Frames, true
+	}
+
+	return 0, false
+}
+
+/*
+Duration returns the duration cached for a file
+*/
+func (cache *probeCache) duration(path string) (float64, bool) {
+	if entry := cache.lookup(path); entry != nil && entry.Duration != nil {
+		return *entry.Duration, true
+	}
+
+	return 0, false
+}
+
+/*
+Streams returns the stream counts cached for a file
+*/
+func (cache *probeCache) streams(path string) (streamCount, bool) {
+	entry := cache.lookup(path)
+	if entry == nil || entry.Subtitles == nil || entry.Attachments == nil {
+		return streamCount{}, false
+	}
+
+	return streamCount{
+		subtitles:   *entry.Subtitles,
+		attachments: *entry.Attachments,
+	}, true
+}
+
+/*
+Save writes the cache to the disk if it was modified, the cache is written to a
+temporary file first - ensures a partially written cache never replaces a valid one.
+Entries for files that no longer exist are dropped. Failures are logged without
+affecting the run.
+*/
+func (cache *probeCache) save() {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.dirty {
+		return
+	}
+
+	for path := range cache.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(cache.entries, path)
+		}
+	}
+
+	content, err := json.Marshal(cache.entries)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(cache.path), 0755)
+	}
+
+	if err == nil {
+		temp := cache.path + ".tmp"
+		if err = ioutil.WriteFile(temp, content, 0644); err == nil {
+			err = os.Rename(temp, cache.path)
+		}
+	}
+
+	if err != nil {
+		log.Warnf(
+			"(probecache/save) failed to save probe cache to \"%s\" \nerror: %v",
+			cache.path,
+			err,
+		)
+
+		return
+	}
+
+	cache.dirty = false
+}
@@ -0,0 +1,96 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestProbeCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(probecache) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	media := filepath.Join(dir, "media.mkv")
+	if err = ioutil.WriteFile(media, []byte("media"), 0644); err != nil {
+		t.Fatalf("(probecache) failed to create file: %v", err)
+	}
+
+	// Cache is disabled without a path, or if it is to be bypassed
+	input := &commons.UserInput{ProbeCache: filepath.Join(dir, "cache", "probe.json")}
+	for _, disabled := range []*commons.UserInput{
+		{},
+		{ProbeCache: input.ProbeCache, NoProbeCache: true},
+	} {
+		if cache := openProbeCache(disabled); cache != nil {
+			t.Errorf("(probecache/openProbeCache) cache not disabled \n%+v", disabled)
+		}
+	}
+
+	cache := openProbeCache(input)
+	if _, ok := cache.frames(media); ok {
+		t.Errorf("(probecache/frames) empty cache returned frame count")
+	}
+
+	frames, duration := int64(2400), 100.5
+	cache.update(media, func(entry *probeEntry) {
+		entry.Frames = &frames
+	})
+
+	cache.update(media, func(entry *probeEntry) {
+		entry.Duration = &duration
+	})
+
+	cache.save()
+
+	// Results are read back from the disk, stream counts weren't probed
+	cache = openProbeCache(input)
+	if res, ok := cache.frames(media); !ok || res != frames {
+		t.Errorf("(probecache/frames) expected %d frames, found %d", frames, res)
+	}
+
+	if res, ok := cache.duration(media); !ok || res != duration {
+		t.Errorf("(probecache/duration) expected %v seconds, found %v", duration, res)
+	}
+
+	if _, ok := cache.streams(media); ok {
+		t.Errorf("(probecache/streams) streams returned without being probed")
+	}
+
+	// Entries are discarded once the file changes
+	later := time.Now().Add(time.Hour)
+	if err = os.Chtimes(media, later, later); err != nil {
+		t.Fatalf("(probecache) failed to modify file: %v", err)
+	}
+
+	if _, ok := cache.frames(media); ok {
+		t.Errorf("(probecache/frames) stale frame count returned for modified file")
+	}
+
+	// Malformed caches are discarded
+	if err = ioutil.WriteFile(input.ProbeCache, []byte("{"), 0644); err != nil {
+		t.Fatalf("(probecache) failed to write cache: %v", err)
+	}
+
+	if cache = openProbeCache(input); cache == nil || len(cache.entries) != 0 {
+		t.Errorf("(probecache/openProbeCache) malformed cache not discarded")
+	}
+
+	// Nil caches never return results
+	var nilCache *probeCache
+	nilCache.update(media, func(entry *probeEntry) {
+		entry.Frames = &frames
+	})
+
+	nilCache.save()
+	if _, ok := nilCache.frames(media); ok {
+		t.Errorf("(probecache/frames) nil cache returned frame count")
+	}
+}
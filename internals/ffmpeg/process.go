@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+)
+
+// Reasons an FFmpeg process is classified under when it fails
+const (
+	failureCancelled = "cancelled"
+	failureTimeout   = "timed_out"
+	failureStalled   = "stalled"
+	failureOOM       = "out_of_memory"
+	failureSignal    = "signal"
+	failureInput     = "invalid_input"
+	failureStart     = "not_started"
+	failureError     = "error"
+)
+
+/*
+Patterns in the output of FFmpeg for failures caused by the input files, i.e. a corrupt
+media file or subtitles in a format FFmpeg can't read.
+*/
+var invalidInputPattern = regexp.MustCompile(
+	`(?i)invalid data found when processing input|no such file or directory|` +
+		`could not find codec parameters|error while decoding|moov atom not found|` +
+		`invalid argument|unknown encoder|could not write header`,
+)
+
+/*
+ProcessFailure records the way an FFmpeg process failed - the exit code (-1 if the
+process was stopped by a signal, or never started), the signal that stopped the
+process (if any), and the reason the failure is classified under.
+*/
+type ProcessFailure struct {
+	Path     string `json:"path"`
+	ExitCode int    `json:"exit_code"`
+	Signal   string `json:"signal,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+/*
+ClassifyFailure decides the reason an FFmpeg process failed from the error returned by
+the command, the output of the process, and the state of the run. Processes killed
+without being asked to (SIGKILL) are assumed to be killed by the out-of-memory killer.
+*/
+func classifyFailure(
+	ctx context.Context,
+	err error,
+	stalled bool,
+	output string,
+) *ProcessFailure {
+	failure := &ProcessFailure{ExitCode: -1, Reason: failureError}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		if timedOut(ctx) {
+			failure.Reason = failureTimeout
+		} else if ctx.Err() != nil {
+			failure.Reason = failureCancelled
+		} else {
+			failure.Reason = failureStart
+		}
+
+		return failure
+	}
+
+	failure.ExitCode = exitErr.ExitCode()
+	failure.Signal = exitSignal(exitErr.ProcessState)
+
+	switch {
+	case timedOut(ctx):
+		failure.Reason = failureTimeout
+	case ctx.Err() != nil:
+		failure.Reason = failureCancelled
+	case stalled:
+		failure.Reason = failureStalled
+	case failure.Signal == signalKill:
+		failure.Reason = failureOOM
+	case failure.Signal != "":
+		failure.Reason = failureSignal
+	case invalidInputPattern.MatchString(output):
+		failure.Reason = failureInput
+	}
+
+	return failure
+}
+
+// String describes the failure, as displayed in the summary
+func (failure *ProcessFailure) String() string {
+	status := fmt.Sprintf("exit code %d", failure.ExitCode)
+	if failure.Signal != "" {
+		status = "killed by " + failure.Signal
+	}
+
+	var reason string
+	switch failure.Reason {
+	case failureCancelled:
+		reason = "cancelled"
+	case failureTimeout:
+		reason = "timed out"
+	case failureStalled:
+		reason = "stalled"
+	case failureOOM:
+		reason = "likely out of memory"
+	case failureSignal:
+		reason = "stopped by a signal"
+	case failureInput:
+		reason = "invalid input"
+	case failureStart:
+		return fmt.Sprintf("\"%s\": FFmpeg failed to start", failure.Path)
+	default:
+		reason = "FFmpeg error"
+	}
+
+	return fmt.Sprintf("\"%s\": %s (%s)", failure.Path, reason, status)
+}
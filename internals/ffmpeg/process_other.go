@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package ffmpeg
+
+import "os"
+
+// Processes aren't stopped by signals on Windows, the name is never matched
+const signalKill = "SIGKILL"
+
+// ExitSignal returns the name of the signal that stopped a process - always empty
+func exitSignal(*os.ProcessState) string {
+	return ""
+}
+
+// ProcessAlive checks if a process is running - the process is found only if running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,139 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	run := func(script string) error {
+		return exec.Command("sh", "-c", script).Run()
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+
+	for _, test := range []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		stalled  bool
+		output   string
+		exitCode int
+		signal   string
+		reason   string
+	}{
+		{"error", context.Background(), run("exit 1"), false, "", 1, "", failureError},
+		{
+			"invalid input",
+			context.Background(),
+			run("exit 1"),
+			false,
+			"input.mkv: Invalid data found when processing input",
+			1,
+			"",
+			failureInput,
+		},
+		{
+			"out of memory",
+			context.Background(),
+			run("kill -KILL $$"),
+			false,
+			"",
+			-1,
+			signalKill,
+			failureOOM,
+		},
+		{
+			"signal",
+			context.Background(),
+			run("kill -SEGV $$"),
+			false,
+			"",
+			-1,
+			"SIGSEGV",
+			failureSignal,
+		},
+		{
+			"stalled",
+			context.Background(),
+			run("kill -KILL $$"),
+			true,
+			"",
+			-1,
+			signalKill,
+			failureStalled,
+		},
+		{
+			"cancelled",
+			cancelled,
+			run("kill -KILL $$"),
+			false,
+			"",
+			-1,
+			signalKill,
+			failureCancelled,
+		},
+		{
+			"timed out",
+			expired,
+			run("kill -KILL $$"),
+			false,
+			"",
+			-1,
+			signalKill,
+			failureTimeout,
+		},
+		{
+			"not started",
+			context.Background(),
+			errors.New("test"),
+			false,
+			"",
+			-1,
+			"",
+			failureStart,
+		},
+	} {
+		failure := classifyFailure(test.ctx, test.err, test.stalled, test.output)
+		if failure.ExitCode != test.exitCode || failure.Signal != test.signal ||
+			failure.Reason != test.reason {
+			t.Errorf(
+				"(process/classifyFailure) unexpected result for %s \nresult: %+v",
+				test.name,
+				failure,
+			)
+		}
+	}
+}
+
+func TestProcessFailureString(t *testing.T) {
+	failure := &ProcessFailure{
+		Path:     "movie.mkv",
+		ExitCode: -1,
+		Signal:   signalKill,
+		Reason:   failureOOM,
+	}
+
+	expected := `"movie.mkv": likely out of memory (killed by SIGKILL)`
+	if res := failure.String(); res != expected {
+		t.Errorf("(process/String) unexpected result: %q", res)
+	}
+
+	summary := &Summary{}
+	summary.processFailed(nil)
+	summary.processFailed(failure)
+	if res := summary.String(); !strings.Contains(res, expected) ||
+		len(summary.Processes) != 1 {
+		t.Errorf("(summary/processFailed) failure not listed \nsummary: %s", res)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package ffmpeg
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Name of the signal sent to kill a process
+const signalKill = "SIGKILL"
+
+// Names of the signals that commonly stop FFmpeg, others are named by number
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGKILL: signalKill,
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+}
+
+// ExitSignal returns the name of the signal that stopped a process, empty if none
+func exitSignal(state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	if name, ok := signalNames[status.Signal()]; ok {
+		return name
+	}
+
+	return "signal " + strconv.Itoa(int(status.Signal()))
+}
+
+// ProcessAlive checks if a process is running, processes of other users are counted
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,126 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+ProgressState is a simple structure containing the progress of an encode at a point
+*/
+type progressState struct {
+	frames int64 // frames processed
+	fps    int64 // average frames processed per second
+	size   int64 // size of the output (in bytes)
+	done   bool  // indicates the encode has completed
+
+	// Duration of the media encoded so far (in microseconds), used for the progress
+	// if the frame count is unknown
+	outTime int64
+}
+
+/*
+ProgressSource is implemented by each backend capable of reporting the progress of an
+ongoing encode - allows `Updates` to display the progress irrespective of the source.
+
+Latest returns the latest progress reported, the boolean is false if no progress has
+been reported yet. Should be safe to call while the encode is running.
+*/
+type progressSource interface {
+	latest() (progressState, bool)
+}
+
+/*
+ProgressParser parses the output of FFmpeg's `-progress` option - blocks of `key=value`
+lines, each block ending with a `progress=continue` (or `progress=end`) line. The
+output of the command should be redirected to the parser, i.e. `-progress pipe:1`
+with the parser as `stdout` of the command.
+
+Unlike the statistics printed to `stderr`, this output is meant to be parsed, and
+remains the same across FFmpeg versions and locales.
+*/
+type progressParser struct {
+	mu sync.Mutex
+
+	pending  []byte        // incomplete line from the last write
+	current  progressState // values from the block being read
+	last     progressState // values from the last complete block
+	reported bool          // indicates a complete block has been read
+}
+
+/*
+Write implements `io.Writer`, parsing complete lines as they're written; incomplete
+lines are kept until the rest of the line is written.
+*/
+func (parser *progressParser) Write(p []byte) (int, error) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	parser.pending = append(parser.pending, p...)
+	for {
+		end := bytes.IndexByte(parser.pending, '\n')
+		if end < 0 {
+			break
+		}
+
+		parser.parseLine(string(parser.pending[:end]))
+		parser.pending = parser.pending[end+1:]
+	}
+
+	return len(p), nil
+}
+
+/*
+ParseLine parses a single `key=value` line, unknown keys and values that aren't
+available (i.e. `N/A`) are skipped.
+*/
+func (parser *progressParser) parseLine(line string) {
+	parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	// Keys are matched irrespective of case, values are never translated
+	key := strings.ToLower(strings.TrimSpace(parts[0]))
+	value := strings.ToLower(strings.TrimSpace(parts[1]))
+	switch key {
+	case "frame":
+		if frames, err := strconv.ParseInt(value, 10, 64); err == nil {
+			parser.current.frames = frames
+		}
+
+	case "fps":
+		// FPS is a decimal value, the whole number is enough - localized builds may
+		// use a comma as the decimal separator
+		value = strings.ReplaceAll(value, ",", ".")
+		if fps, err := strconv.ParseFloat(value, 64); err == nil {
+			parser.current.fps = int64(fps)
+		}
+
+	case "out_time_us", "out_time_ms":
+		// Both are in microseconds, `out_time_ms` is misnamed by FFmpeg
+		if outTime, err := strconv.ParseInt(value, 10, 64); err == nil && outTime >= 0 {
+			parser.current.outTime = outTime
+		}
+
+	case "total_size":
+		if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+			parser.current.size = size
+		}
+
+	case "progress":
+		// Marks the end of a block
+		parser.current.done = value == "end"
+		parser.last = parser.current
+		parser.reported = true
+	}
+}
+
+func (parser *progressParser) latest() (progressState, bool) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	return parser.last, parser.reported
+}
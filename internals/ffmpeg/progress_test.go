@@ -0,0 +1,70 @@
+package ffmpeg
+
+import "testing"
+
+func TestProgressParser(t *testing.T) {
+	parser := &progressParser{}
+	if _, reported := parser.latest(); reported {
+		t.Errorf("(progress/latest) progress reported before any output was parsed")
+	}
+
+	// Blocks can be split across writes, the incomplete block should be ignored
+	chunks := []string{
+		"frame=120\nfps=23.97\nstream_0_0_q=28.0\ntotal_size=N/A\n",
+		"out_time_us=5005000\nprogress=continue\nframe=2",
+		"40\nfps=24.50\ntotal_size=1048576\n",
+	}
+
+	for _, chunk := range chunks {
+		if n, err := parser.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Errorf("(progress/Write) failed to write \nwritten: %d \nerr: %v", n, err)
+		}
+	}
+
+	state, reported := parser.latest()
+	if !reported {
+		t.Errorf("(progress/latest) expected progress to be reported")
+	}
+
+	expected := progressState{frames: 120, fps: 23, outTime: 5005000}
+	if state != expected {
+		t.Errorf(
+			"(progress/latest) unexpected progress \nexpected: %+v \nfound: %+v",
+			expected,
+			state,
+		)
+	}
+
+	// Completing the block updates the progress
+	_, _ = parser.Write([]byte("progress=end\n"))
+	state, _ = parser.latest()
+
+	expected = progressState{
+		frames:  240,
+		fps:     24,
+		size:    1048576,
+		done:    true,
+		outTime: 5005000,
+	}
+	if state != expected {
+		t.Errorf(
+			"(progress/latest) unexpected progress \nexpected: %+v \nfound: %+v",
+			expected,
+			state,
+		)
+	}
+
+	// Keys are matched irrespective of case, decimals may use a comma
+	parser = &progressParser{}
+	_, _ = parser.Write([]byte("Frame=48\nFPS=12,50\nTotal_Size=2048\nProgress=End\n"))
+	state, _ = parser.latest()
+
+	expected = progressState{frames: 48, fps: 12, size: 2048, done: true}
+	if state != expected {
+		t.Errorf(
+			"(progress/latest) unexpected progress \nexpected: %+v \nfound: %+v",
+			expected,
+			state,
+		)
+	}
+}
@@ -0,0 +1,244 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// States for a job in the queue
+const (
+	jobPending = "pending"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+	jobSkipped = "skipped"
+)
+
+// Name of the job for the root directory, when used as the source directory
+const directJob = "."
+
+// ErrNoQueue is returned while loading a queue if there is no paused run to resume
+var ErrNoQueue = errors.New("no paused run to resume")
+
+/*
+Job is a single source directory in the queue, identified by its name relative to the
+root directory - ensures the queue remains valid for remote roots, which are staged in
+a different directory for each run.
+*/
+type Job struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+/*
+JobQueue tracks the state of each source directory in a run, the queue is saved to the
+disk as it changes, allowing a run that was paused (or interrupted) to be resumed later
+from the same point. Jobs that are done, failed or skipped are not run again.
+
+Arguments are the command-line arguments for the run, used to resume the run with the
+same input.
+*/
+type JobQueue struct {
+	Root    string    `json:"root"`
+	Args    []string  `json:"args"`
+	Jobs    []Job     `json:"jobs"`
+	Updated time.Time `json:"updated"`
+
+	// Path to the file containing the queue, the queue is not saved if blank
+	path string
+}
+
+// Queue for the ongoing run, nil if the run is not being tracked
+var runQueue *JobQueue
+
+/*
+NewQueue creates an empty queue for a run, saved to the path supplied - jobs are added
+once the run starts.
+*/
+func NewQueue(path, root string, args []string) *JobQueue {
+	return &JobQueue{
+		Root: root,
+		Args: args,
+		path: path,
+	}
+}
+
+/*
+LoadQueue reads the queue saved for a paused run, returns `ErrNoQueue` if the queue
+does not exist.
+*/
+func LoadQueue(path string) (*JobQueue, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoQueue
+	} else if err != nil {
+		return nil, err
+	}
+
+	queue := &JobQueue{}
+	if err = json.Unmarshal(content, queue); err != nil {
+		return nil, err
+	}
+
+	queue.path = path
+	return queue, nil
+}
+
+/*
+UseQueue sets the queue used to track the next run, a nil queue disables tracking
+*/
+func UseQueue(queue *JobQueue) {
+	runQueue = queue
+}
+
+/*
+Remaining returns the number of jobs that are yet to be completed
+*/
+func (queue *JobQueue) Remaining() (count int) {
+	for _, job := range queue.Jobs {
+		if job.State == jobPending || job.State == jobRunning {
+			count++
+		}
+	}
+
+	return count
+}
+
+/*
+Remove deletes the saved queue, used once the run completes - a missing queue is not
+treated as an error.
+*/
+func (queue *JobQueue) Remove() error {
+	if queue == nil || queue.path == "" {
+		return nil
+	}
+
+	if err := os.Remove(queue.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+/*
+Plan adds a pending job for each source directory not already present in the queue,
+jobs from a resumed run keep their state. Jobs left running by the previous run are
+run again.
+*/
+func (queue *JobQueue) plan(names []string) {
+	if queue == nil {
+		return
+	}
+
+	known := make(map[string]bool, len(queue.Jobs))
+	for i, job := range queue.Jobs {
+		known[job.Name] = true
+		if job.State == jobRunning {
+			queue.Jobs[i].State = jobPending
+		}
+	}
+
+	for _, name := range names {
+		if !known[name] {
+			queue.Jobs = append(queue.Jobs, Job{Name: name, State: jobPending})
+		}
+	}
+
+	queue.save()
+}
+
+/*
+Finished checks if the job for a source directory was completed by a previous run,
+i.e. the source directory was processed, failed or was skipped.
+*/
+func (queue *JobQueue) finished(name string) bool {
+	if queue == nil {
+		return false
+	}
+
+	for _, job := range queue.Jobs {
+		if job.Name == name {
+			return job.State != jobPending && job.State != jobRunning
+		}
+	}
+
+	return false
+}
+
+/*
+Set updates the state of the job for a source directory, saving the queue
+*/
+func (queue *JobQueue) set(name, state string) {
+	if queue == nil {
+		return
+	}
+
+	for i := range queue.Jobs {
+		if queue.Jobs[i].Name == name {
+			queue.Jobs[i].State = state
+			queue.save()
+
+			return
+		}
+	}
+
+	queue.Jobs = append(queue.Jobs, Job{Name: name, State: state})
+	queue.save()
+}
+
+/*
+Complete records the result of a source directory - the job is pending again if the
+run was stopped midway through the source directory, ensuring it is run again once the
+run is resumed.
+*/
+func (queue *JobQueue) complete(name string, exitCode int, stopped bool) {
+	switch {
+	case stopped:
+		queue.set(name, jobPending)
+	case exitCode == statusSkipped:
+		queue.set(name, jobSkipped)
+	case exitCode == commons.StatusOK:
+		queue.set(name, jobDone)
+	default:
+		queue.set(name, jobFailed)
+	}
+}
+
+/*
+Save writes the queue to the disk, replacing the queue saved earlier - the queue is
+written to a temporary file first, ensuring a partially written queue never replaces
+a valid one. Failures are logged without affecting the run.
+*/
+func (queue *JobQueue) save() {
+	if queue.path == "" {
+		return
+	}
+
+	queue.Updated = time.Now()
+	content, err := json.MarshalIndent(queue, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(queue.path), 0755)
+	}
+
+	if err == nil {
+		temp := queue.path + ".tmp"
+		if err = ioutil.WriteFile(temp, content, 0644); err == nil {
+			err = os.Rename(temp, queue.path)
+		}
+	}
+
+	if err != nil {
+		log.Warnf(
+			"(queue/save) failed to save queue to \"%s\" \nerror: %v",
+			queue.path,
+			err,
+		)
+	}
+}
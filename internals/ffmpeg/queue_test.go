@@ -0,0 +1,95 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestJobQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(queue/JobQueue) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Missing queue indicates there is no run to resume
+	path := filepath.Join(dir, "nested", "queue.json")
+	if _, err = LoadQueue(path); err != ErrNoQueue {
+		t.Errorf("(queue/LoadQueue) expected `ErrNoQueue` \nfound: %v", err)
+	}
+
+	queue := NewQueue(path, "/root", []string{"/root", "--all-media"})
+	queue.plan([]string{"dir 01", "dir 02", "dir 03", "dir 04"})
+	queue.set("dir 01", jobRunning)
+	queue.complete("dir 01", commons.StatusOK, false)
+	queue.complete("dir 02", commons.SourceDirectoryError, false)
+	queue.set("dir 03", jobRunning)
+	queue.complete("dir 03", commons.Interrupted, true)
+
+	loaded, err := LoadQueue(path)
+	if err != nil {
+		t.Fatalf("(queue/LoadQueue) failed to load saved queue: %v", err)
+	}
+
+	if loaded.Root != "/root" || len(loaded.Args) != 2 || loaded.Remaining() != 2 {
+		t.Errorf(
+			"(queue/LoadQueue) unexpected queue loaded \nroot: %q \nargs: %v "+
+				"\nremaining: %d",
+			loaded.Root,
+			loaded.Args,
+			loaded.Remaining(),
+		)
+	}
+
+	// Completed jobs are skipped, the job stopped midway is run again
+	for name, expected := range map[string]bool{
+		"dir 01": true,
+		"dir 02": true,
+		"dir 03": false,
+		"dir 04": false,
+		"dir 05": false,
+	} {
+		if res := loaded.finished(name); res != expected {
+			t.Errorf(
+				"(queue/finished) unexpected result for %q \nexpected: %v "+
+					"\nfound: %v",
+				name,
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Jobs left running are pending once the queue is planned again, new source
+	// directories are added to the queue
+	loaded.set("dir 04", jobRunning)
+	loaded.plan([]string{"dir 01", "dir 04", "dir 05"})
+	if len(loaded.Jobs) != 5 || loaded.Remaining() != 3 || loaded.finished("dir 04") {
+		t.Errorf("(queue/plan) unexpected jobs after planning \n%+v", loaded.Jobs)
+	}
+
+	if err = loaded.Remove(); err != nil {
+		t.Errorf("(queue/Remove) failed to remove queue: %v", err)
+	}
+
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("(queue/Remove) queue present after removal \nerror: %v", err)
+	}
+
+	// Removing a missing queue (or a nil queue) is not an error
+	if err = loaded.Remove(); err != nil {
+		t.Errorf("(queue/Remove) failed to remove missing queue: %v", err)
+	}
+
+	var nilQueue *JobQueue
+	nilQueue.plan([]string{"dir 01"})
+	nilQueue.set("dir 01", jobDone)
+	if nilQueue.finished("dir 01") || nilQueue.Remove() != nil {
+		t.Errorf("(queue/JobQueue) nil queue should not track jobs")
+	}
+}
@@ -0,0 +1,272 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+	escapes "github.com/snugfox/ansi-escapes"
+)
+
+// Number of events buffered for a reporter, progress events are dropped once the buffer
+// is full - i.e. if the reporter can't keep up with the encode
+const eventBuffer = 16
+
+// EventKind identifies the stage of an encode an event belongs to
+type EventKind int
+
+// Stages of an encode, each encode starts with an `EventStart` and ends with either an
+// `EventComplete` or an `EventError`
+const (
+	EventStart EventKind = iota
+	EventProgress
+	EventComplete
+	EventError
+)
+
+/*
+ProgressEvent is the progress of an encode at a point, delivered to the reporter in use.
+The total frame count is zero if it could not be determined.
+*/
+type ProgressEvent struct {
+	Kind EventKind
+
+	File   string // full path to the media file
+	Output string // full path to the file being written
+
+	Frames      int64 // frames processed
+	TotalFrames int64 // frames present in the media file
+	FPS         int64 // average frames processed per second, as reported by FFmpeg
+	Size        int64 // size of the output (in bytes)
+
+	// Frames processed per second since the last event, the moving average of the
+	// same, and the time remaining estimated from the average (zero if unknown)
+	CurrentFPS float64
+	AverageFPS float64
+	ETA        time.Duration
+
+	// Duration of the media encoded so far, and the duration of the media file - the
+	// duration is set only if the frame count is unknown
+	Encoded  time.Duration
+	Duration time.Duration
+
+	// Warning for the encode, i.e. if the encode has stalled. Blank otherwise
+	Warning string
+
+	// Reason for the failure, set only for `EventError`
+	Err error
+}
+
+/*
+ProgressReporter receives the progress of each encode - the events for an encode are
+delivered in order, from a goroutine separate from the encode. A slow reporter does
+not slow down the encode, progress events are dropped instead.
+
+The terminal renderer is used by default, use `UseReporter` to replace it.
+*/
+type ProgressReporter interface {
+	OnStart(event ProgressEvent)
+	OnProgress(event ProgressEvent)
+	OnComplete(event ProgressEvent)
+	OnError(event ProgressEvent)
+}
+
+// Reporter set for the run, nil to use the terminal renderer
+var runReporter ProgressReporter
+
+/*
+UseReporter sets the reporter receiving the progress of encodes in the next run, a nil
+reporter restores the terminal renderer
+*/
+func UseReporter(reporter ProgressReporter) {
+	runReporter = reporter
+}
+
+/*
+ChannelReporter is a reporter sending every event over the channel, for embedders
+preferring a channel to a callback - the kind of the event identifies the stage. Events
+should be received promptly, the delivery of events is blocked until then.
+*/
+type ChannelReporter chan<- ProgressEvent
+
+// OnStart sends the event over the channel
+func (reporter ChannelReporter) OnStart(event ProgressEvent) {
+	reporter <- event
+}
+
+// OnProgress sends the event over the channel
+func (reporter ChannelReporter) OnProgress(event ProgressEvent) {
+	reporter <- event
+}
+
+// OnComplete sends the event over the channel
+func (reporter ChannelReporter) OnComplete(event ProgressEvent) {
+	reporter <- event
+}
+
+// OnError sends the event over the channel
+func (reporter ChannelReporter) OnError(event ProgressEvent) {
+	reporter <- event
+}
+
+/*
+DeliverEvents calls the reporter for each event received over the channel, until the
+channel is closed - the done channel is closed once every event is delivered.
+*/
+func deliverEvents(
+	reporter ProgressReporter,
+	events <-chan ProgressEvent,
+	done chan<- bool,
+) {
+	defer close(done)
+
+	for event := range events {
+		switch event.Kind {
+		case EventStart:
+			reporter.OnStart(event)
+		case EventProgress:
+			reporter.OnProgress(event)
+		case EventComplete:
+			reporter.OnComplete(event)
+		case EventError:
+			reporter.OnError(event)
+		}
+	}
+}
+
+/*
+TerminalReporter renders the progress of an encode as a dialog on the screen, redrawn
+in place on every update - with porcelain output, the dialog is printed only once the
+encode ends. Nothing is printed in quiet mode.
+*/
+type terminalReporter struct {
+	porcelain bool
+	quiet     bool
+
+	// The progress dialog printed last, and the width of the terminal at the time.
+	// The number of rows to move up is calculated from these, the terminal reflows
+	// the dialog if resized in the meantime
+	lastPrinted string
+	lastWidth   int
+}
+
+// NewTerminalReporter returns the terminal renderer, used unless a reporter is set
+func newTerminalReporter(input *commons.UserInput) *terminalReporter {
+	return &terminalReporter{
+		porcelain: input != nil && input.Porcelain,
+		quiet:     input != nil && input.Quiet,
+	}
+}
+
+// OnStart starts a new dialog, FFmpeg takes a moment to report the progress
+func (reporter *terminalReporter) OnStart(event ProgressEvent) {
+	reporter.lastPrinted = ""
+	reporter.lastWidth = terminalWidth()
+
+	// Reset this counter, ensures the template animation also starts from scratch.
+	tempAnimationProgress = 0
+
+	reporter.redraw(reporter.dialog(event))
+}
+
+// OnProgress redraws the dialog with the progress reported
+func (reporter *terminalReporter) OnProgress(event ProgressEvent) {
+	reporter.redraw(reporter.dialog(event))
+}
+
+// OnComplete prints the final dialog, the dialog is printed even with porcelain output
+func (reporter *terminalReporter) OnComplete(event ProgressEvent) {
+	reporter.finish(event)
+}
+
+// OnError prints the final dialog, the error is reported by the caller
+func (reporter *terminalReporter) OnError(event ProgressEvent) {
+	reporter.finish(event)
+}
+
+/*
+Dialog forms the progress dialog for the event, along with the warning (if any)
+*/
+func (*terminalReporter) dialog(event ProgressEvent) string {
+	update := Updates{
+		fileName:    filepath.Base(event.File),
+		totalFrames: event.TotalFrames,
+		duration:    event.Duration,
+	}
+
+	var progress string
+	if event.Frames > 0 || event.Size > 0 {
+		progress = update.getProgress(event)
+	} else {
+		// Nothing reported yet
+		progress = commons.Sprintf(
+			`  File: "%s"`+"\n\n  Waiting for FFmpeg to report progress...",
+			update.trimString(&update.fileName),
+		)
+	}
+
+	// Warn the user if the encode hasn't progressed for a while
+	if event.Warning != "" {
+		progress += "\n\n  " + event.Warning
+	}
+
+	return progress
+}
+
+/*
+Redraw makes the cursor jump to the start of the previous dialog, and prints the
+dialog. Clears the lines below as well, removes a stall warning once the encode
+resumes, and stale lines left behind if the terminal was resized.
+*/
+func (reporter *terminalReporter) redraw(dialog string) {
+	// Porcelain output can't redraw the dialog in place
+	if reporter.porcelain || reporter.quiet {
+		return
+	}
+
+	width := terminalWidth()
+	if width != reporter.lastWidth {
+		log.Debugf(
+			"(reporter/redraw) terminal resized from %d to %d columns",
+			reporter.lastWidth,
+			width,
+		)
+
+		reporter.lastWidth = width
+	}
+
+	// The dialog replaces the previous one in a single write, output from other
+	// goroutines never lands in between
+	var frame commons.Frame
+	jumpCursor(&frame, renderedRows(reporter.lastPrinted, width)-1)
+	frame.Printf(escapes.EraseDown)
+
+	// Print progress dialog
+	frame.Printf(dialog)
+	frame.Flush()
+	reporter.lastPrinted = dialog
+}
+
+// Finish replaces the dialog with the final values, stall warnings are left out
+func (reporter *terminalReporter) finish(event ProgressEvent) {
+	if reporter.quiet {
+		return
+	}
+
+	var frame commons.Frame
+	if !reporter.porcelain {
+		jumpCursor(&frame, renderedRows(reporter.lastPrinted, terminalWidth())-1)
+		frame.Printf(escapes.EraseDown)
+	}
+
+	update := Updates{
+		fileName:    filepath.Base(event.File),
+		totalFrames: event.TotalFrames,
+		duration:    event.Duration,
+	}
+
+	frame.Printf(update.getProgress(event) + "\n\n\n")
+	frame.Flush()
+	reporter.lastPrinted = ""
+}
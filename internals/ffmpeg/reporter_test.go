@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+// Progress source reporting a fixed state
+type fixedSource progressState
+
+func (source fixedSource) latest() (progressState, bool) {
+	return progressState(source), true
+}
+
+func TestDeliverEvents(t *testing.T) {
+	events := make(chan ProgressEvent, 4)
+	received := make(chan ProgressEvent, 4)
+	done := make(chan bool)
+
+	for _, kind := range []EventKind{
+		EventStart,
+		EventProgress,
+		EventComplete,
+		EventError,
+	} {
+		events <- ProgressEvent{Kind: kind}
+	}
+
+	close(events)
+	deliverEvents(ChannelReporter(received), events, done)
+
+	// The done channel is closed once every event is delivered
+	if _, open := <-done; open {
+		t.Errorf("(reporter/deliverEvents) done channel not closed")
+	}
+
+	for kind := EventStart; kind <= EventError; kind++ {
+		if event := <-received; event.Kind != kind {
+			t.Errorf(
+				"(reporter/deliverEvents) unexpected event \nexpected: %d \nfound: %d",
+				kind,
+				event.Kind,
+			)
+		}
+	}
+}
+
+func TestDisplayUpdatesReporter(t *testing.T) {
+	received := make(chan ProgressEvent, eventBuffer)
+	UseReporter(ChannelReporter(received))
+	defer UseReporter(nil)
+
+	update := Updates{
+		userInput:   &commons.UserInput{},
+		filePath:    "/media/video.mkv",
+		totalFrames: 100,
+		output:      "/result/video.mkv.part",
+		failure:     errors.New("test error"),
+	}
+
+	interrupt := make(chan bool)
+	go update.DisplayUpdates(fixedSource{frames: 40, size: 1024}, interrupt)
+
+	interrupt <- true
+	<-interrupt
+	close(received)
+
+	var kinds []EventKind
+	var last ProgressEvent
+	for event := range received {
+		kinds = append(kinds, event.Kind)
+		last = event
+	}
+
+	// Progress is reported before the interrupt is received, the failure is reported
+	// with the latest values
+	if len(kinds) != 3 || kinds[0] != EventStart || kinds[1] != EventProgress {
+		t.Errorf("(Updates/DisplayUpdates) unexpected events: %v", kinds)
+	}
+
+	if last.Kind != EventError || last.Err == nil || last.Frames != 40 ||
+		last.File != update.filePath || last.Output != update.output {
+		t.Errorf("(Updates/DisplayUpdates) unexpected final event: %+v", last)
+	}
+}
+
+func TestTerminalReporter(t *testing.T) {
+	reporter := newTerminalReporter(&commons.UserInput{Porcelain: true})
+
+	event := ProgressEvent{File: "/media/video.mkv", TotalFrames: 100}
+	if dialog := reporter.dialog(event); dialog == "" {
+		t.Errorf("(reporter/dialog) blank dialog before progress is reported")
+	}
+
+	event.Frames, event.Warning = 40, "test warning"
+	if dialog := reporter.dialog(event); len(dialog) < len(event.Warning) ||
+		dialog[len(dialog)-len(event.Warning):] != event.Warning {
+		t.Errorf("(reporter/dialog) warning missing from dialog: %s", dialog)
+	}
+
+	// Nothing is redrawn with porcelain output
+	reporter.OnStart(event)
+	reporter.OnProgress(event)
+	if reporter.lastPrinted != "" {
+		t.Errorf("(reporter/OnProgress) dialog redrawn with porcelain output")
+	}
+
+	reporter.OnComplete(event)
+
+	// Nothing is printed at all in quiet mode
+	reporter = newTerminalReporter(&commons.UserInput{Quiet: true})
+	reporter.OnStart(event)
+	reporter.OnComplete(event)
+	if reporter.lastPrinted != "" {
+		t.Errorf("(reporter/OnStart) dialog printed in quiet mode")
+	}
+}
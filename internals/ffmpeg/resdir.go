@@ -0,0 +1,9 @@
+package ffmpeg
+
+/*
+IsResultDir checks if a path points to the result directory, irrespective of the form
+of either path - symbolic links to the result directory are recognized as well.
+*/
+func isResultDir(path, resDir string) bool {
+	return samePath(path, resDir)
+}
@@ -0,0 +1,49 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsResultDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(resdir/isResultDir) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	resDir := filepath.Join(dir, "output")
+	source := filepath.Join(dir, "Show")
+	_ = os.Mkdir(resDir, 0755)
+	_ = os.Mkdir(source, 0755)
+
+	wd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(wd) }()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatalf("(resdir/isResultDir) failed to change directory: %v", err)
+	}
+
+	for path, expected := range map[string]bool{
+		resDir:                              true,
+		resDir + string(filepath.Separator): true,
+		"output":                            true,
+		filepath.Join(".", "Show", "..", "output"): true,
+		source:   false,
+		"Show":   false,
+		"":       false,
+		"absent": false,
+	} {
+		if res := isResultDir(path, resDir); res != expected {
+			t.Errorf("(resdir/isResultDir) unexpected result for %q: %v", path, res)
+		}
+	}
+
+	// Links to the result directory point to the same directory
+	link := filepath.Join(dir, "link")
+	if err = os.Symlink(resDir, link); err == nil && !isResultDir(link, resDir) {
+		t.Errorf("(resdir/isResultDir) link to the result directory not recognized")
+	}
+}
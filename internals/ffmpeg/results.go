@@ -0,0 +1,60 @@
+package ffmpeg
+
+import "github.com/demon-rem/auto-sub/internals/commons"
+
+// Status reported in machine-readable results, decided by the exit code
+const (
+	resultProcessed = "processed"
+	resultSkipped   = "skipped"
+	resultFailed    = "failed"
+)
+
+// FileResult is the machine-readable result for a media file
+type fileResult struct {
+	Event     string `json:"event"`
+	SourceDir string `json:"source_dir"`
+	MediaFile string `json:"media_file"`
+	Output    string `json:"output"`
+	Status    string `json:"status"`
+	ExitCode  int    `json:"exit_code"`
+
+	// The way the FFmpeg process failed, if it did
+	Process *ProcessFailure `json:"process,omitempty"`
+}
+
+// DirResult is the machine-readable result for a source directory
+type dirResult struct {
+	Event    string `json:"event"`
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// SummaryResult is the machine-readable summary for a run
+type summaryResult struct {
+	Event     string   `json:"event"`
+	Processed int      `json:"processed"`
+	Skipped   int      `json:"skipped"`
+	Failed    int      `json:"failed"`
+	Files     int      `json:"files_merged"`
+	Bytes     int64    `json:"bytes_produced"`
+	Warnings  []string `json:"warnings"`
+
+	// FFmpeg processes that failed, along with the way each failed
+	Processes []ProcessFailure `json:"process_failures"`
+
+	// Subtitle files left out as duplicates of another subtitle file
+	Duplicates []string `json:"duplicate_subtitles"`
+}
+
+// ResultStatus returns the status reported for an exit code
+func resultStatus(exitCode int) string {
+	switch exitCode {
+	case commons.StatusOK:
+		return resultProcessed
+	case statusSkipped:
+		return resultSkipped
+	default:
+		return resultFailed
+	}
+}
@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestResultStatus(t *testing.T) {
+	for exitCode, expected := range map[int]string{
+		commons.StatusOK:             resultProcessed,
+		statusSkipped:                resultSkipped,
+		commons.SourceDirectoryError: resultFailed,
+		commons.UnexpectedError:      resultFailed,
+	} {
+		if res := resultStatus(exitCode); res != expected {
+			t.Errorf(
+				"(results/resultStatus) unexpected status for exit code %d "+
+					"\nexpected: %s \nfound: %s",
+				exitCode,
+				expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestSummaryResults(t *testing.T) {
+	var buffer bytes.Buffer
+	commons.SetResultOutput(&buffer)
+	defer commons.SetResultOutput(nil)
+
+	summary := &Summary{}
+	summary.record("dir 01", commons.StatusOK)
+	summary.record("dir 02", commons.SourceDirectoryError)
+	summary.skip("dir 03")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf(
+			"(results/record) unexpected number of results \nexpected: 3 "+
+				"\nfound: %d \nresults: \n%s",
+			len(lines),
+			buffer.String(),
+		)
+	}
+
+	for i, expected := range []dirResult{
+		{"directory", "dir 01", resultProcessed, commons.StatusOK},
+		{"directory", "dir 02", resultFailed, commons.SourceDirectoryError},
+		{"directory", "dir 03", resultSkipped, statusSkipped},
+	} {
+		var res dirResult
+		if err := json.Unmarshal([]byte(lines[i]), &res); err != nil ||
+			res != expected {
+			t.Errorf(
+				"(results/record) unexpected result \nexpected: %+v \nfound: %s "+
+					"\nerror: %v",
+				expected,
+				lines[i],
+				err,
+			)
+		}
+	}
+}
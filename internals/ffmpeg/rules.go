@@ -0,0 +1,38 @@
+package ffmpeg
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ExcludeByRules leaves out the files excluded through the metadata rules, the remaining
+files are returned in the same order.
+*/
+func excludeByRules(
+	userInput *commons.UserInput,
+	kind string,
+	files []os.FileInfo,
+) []os.FileInfo {
+	if len(userInput.Rules) == 0 {
+		return files
+	}
+
+	res := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		if rule, _ := userInput.ApplyRules(kind, file.Name()); rule.Exclude {
+			log.Debugf(
+				`(rules/excludeByRules) %s excluded by rules: "%s"`,
+				kind,
+				file.Name(),
+			)
+			continue
+		}
+
+		res = append(res, file)
+	}
+
+	return res
+}
@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestExcludeByRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(rules/excludeByRules) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	input := &commons.UserInput{
+		Rules: []commons.MetadataRule{
+			{Match: "(?i)commentary", Kind: commons.RuleSubtitle, Exclude: true},
+			{Match: "(?i)signs", Kind: commons.RuleAttachment, Title: "Signs"},
+		},
+		IsTest: true,
+	}
+
+	if code, err := input.Initialize(); code != commons.StatusOK {
+		t.Fatalf("(rules/excludeByRules) failed to initialize \nerror: %v", err)
+	}
+
+	var files []os.FileInfo
+	for _, name := range []string{"English.srt", "Commentary.srt", "Signs.ass"} {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(rules/excludeByRules) failed to create file: %v", err)
+		}
+
+		item, _ := os.Stat(path)
+		files = append(files, item)
+	}
+
+	// Only the subtitles excluded are left out, rules for attachments don't apply
+	res := excludeByRules(input, commons.RuleSubtitle, files)
+	if len(res) != 2 || res[0].Name() != "English.srt" || res[1].Name() != "Signs.ass" {
+		t.Errorf("(rules/excludeByRules) unexpected files: %s", commons.Stringify(&res))
+	}
+
+	// Titles from the rules are used as the description for attachments
+	plans := planAttachments(dir, input, nil, files[2:])
+	if len(plans) != 1 || plans[0].description != "Signs" {
+		t.Errorf("(rules/planAttachments) unexpected attachments: %+v", plans)
+	}
+}
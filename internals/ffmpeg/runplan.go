@@ -0,0 +1,348 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+PlanEntry is a single row of the plan for a run - an output to be created from a media
+file in a source directory. Source directories that would fail to be processed carry
+the reason instead of an output.
+*/
+type PlanEntry struct {
+	SourceDir   string `json:"sourceDir"`
+	MediaFile   string `json:"mediaFile,omitempty"`
+	Subtitles   int    `json:"subtitles"`
+	Attachments int    `json:"attachments"`
+	Output      string `json:"output,omitempty"`
+	Size        int64  `json:"estimatedSize"`
+	Error       string `json:"error,omitempty"`
+}
+
+/*
+RunPlan is the result of scanning the root directory before merging anything, printed
+for the user to confirm - or saved to a file, to be applied through `--apply` later.
+Only the source directories present in an applied plan are processed.
+*/
+type RunPlan struct {
+	RootPath string      `json:"rootPath"`
+	Entries  []PlanEntry `json:"entries"`
+}
+
+// Plan applied to the ongoing run, nil if every source directory is to be processed
+var runPlan *RunPlan
+
+/*
+ScanPlan walks the root directory the same way as `TraverseRoot()`, forming the plan
+for the run without merging anything - source directories that would be skipped are
+left out. Output names are adjusted for collisions as per the policy supplied.
+*/
+func ScanPlan(input *commons.UserInput, resDir string) (*RunPlan, int, error) {
+	log.Debugf(`(runplan/ScanPlan) scanning root directory: "%s"`, input.RootPath)
+	defer stopClassifier()
+
+	resDir = commons.NormalizePath(resDir)
+	plan := &RunPlan{RootPath: input.RootPath}
+
+	// Outside the central layout, outputs are listed by their path relative to the
+	// root directory
+	prefix := func(name string) string {
+		switch input.OutputLayout {
+		case commons.LayoutSibling:
+			return name
+		case commons.LayoutSubfolder:
+			return filepath.Join(name, layoutDirName)
+		}
+
+		return ""
+	}
+
+	if input.IsDirect {
+		plan.add(input.RootPath, prefix(""), input)
+		return plan, commons.StatusOK, nil
+	}
+
+	files, err := ioutil.ReadDir(input.RootPath)
+	if err != nil {
+		log.Debugf(
+			"(runplan/ScanPlan) failed to fetch items present in root directory"+
+				"\nerror: `%v`",
+			err,
+		)
+
+		return nil, commons.UnexpectedError, errors.New("unable to read root directory")
+	}
+
+	mirror := false
+	if centralLayout(input) {
+		if mirror, err = planOutputs(input, resDir, files); err != nil {
+			return nil, commons.OutputCollision, err
+		}
+	}
+
+	dirsFound := 0
+	for _, f := range orderSourceDirs(input, resDir, files) {
+		sourcePath := filepath.Join(input.RootPath, f.Name())
+		if !f.IsDir() || isResultDir(sourcePath, resDir) {
+			continue
+		}
+
+		dirsFound++
+		if runQueue.finished(f.Name()) ||
+			input.RetryFailed && !hasFailedMarker(sourcePath) ||
+			input.IgnoreDir(sourcePath) {
+			continue
+		}
+
+		if !input.IncludeItem(f.Name()) {
+			mediaFiles, _, _, _ := groupFiles(sourcePath, input)
+			releaseArchives()
+			if len(mediaFiles) == 0 {
+				continue
+			}
+		}
+
+		dirPrefix := prefix(f.Name())
+		if mirror {
+			dirPrefix = f.Name()
+		}
+
+		plan.add(sourcePath, dirPrefix, input)
+	}
+
+	if dirsFound == 0 {
+		return nil, commons.RootDirectoryIncorrect,
+			errors.New("root directory does not contain any source directories")
+	}
+
+	return plan, commons.StatusOK, nil
+}
+
+/*
+LoadPlan reads a plan saved through `--plan-file`, the plan is to be applied to the
+same root directory it was formed for.
+*/
+func LoadPlan(path string) (*RunPlan, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RunPlan{}
+	if err = json.Unmarshal(content, plan); err != nil {
+		return nil, fmt.Errorf("malformed plan: %v", err)
+	}
+
+	return plan, nil
+}
+
+/*
+UsePlan sets the plan applied to the next run, a nil plan processes every source
+directory as usual
+*/
+func UsePlan(plan *RunPlan) {
+	runPlan = plan
+}
+
+/*
+Save writes the plan to the path supplied as indented JSON, replacing the file if it
+exists already.
+*/
+func (plan *RunPlan) Save(path string) error {
+	content, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+/*
+Outputs returns the number of outputs in the plan, along with their estimated size
+*/
+func (plan *RunPlan) Outputs() (count int, size int64) {
+	for _, entry := range plan.Entries {
+		if entry.Error == "" {
+			count++
+			size += entry.Size
+		}
+	}
+
+	return count, size
+}
+
+/*
+String renders the plan as a table - one row for each output, columns are padded as
+per the width of the text displayed.
+*/
+func (plan *RunPlan) String() string {
+	update := Updates{}
+	rows := [][]string{{
+		"Directory", "Media File", "Subs", "Attachments", "Output", "Est. Size",
+	}}
+
+	for _, entry := range plan.Entries {
+		if entry.Error != "" {
+			rows = append(rows, []string{
+				filepath.Base(entry.SourceDir),
+				"-", "-", "-",
+				"error, " + entry.Error,
+				"-",
+			})
+
+			continue
+		}
+
+		rows = append(rows, []string{
+			filepath.Base(entry.SourceDir),
+			entry.MediaFile,
+			fmt.Sprint(entry.Subtitles),
+			fmt.Sprint(entry.Attachments),
+			entry.Output,
+			update.readableFileSize(float64(entry.Size)),
+		})
+	}
+
+	var res strings.Builder
+	res.WriteString(formatTable(rows))
+
+	count, size := plan.Outputs()
+	res.WriteString(fmt.Sprintf(
+		"\n%d output(s), estimated size: %s\n",
+		count,
+		update.readableFileSize(float64(size)),
+	))
+
+	return res.String()
+}
+
+/*
+Add forms the entries for a source directory, the prefix is the directory the outputs
+are stored in - relative to the result directory (blank unless mirrored), or to the
+root directory outside the central layout.
+*/
+func (plan *RunPlan) add(sourceDir, prefix string, input *commons.UserInput) {
+	defer releaseArchives()
+
+	mediaFiles, subtitles, attachments, chapters := groupFiles(sourceDir, input)
+	subtitles, _ = dedupeSubtitles(sourceDir, subtitles)
+	extras := filesSize(attachments) + filesSize(chapters)
+
+	fail := func(reason string) {
+		plan.Entries = append(plan.Entries, PlanEntry{
+			SourceDir: sourceDir,
+			Error:     reason,
+		})
+	}
+
+	if input.DonorRule != nil {
+		raw, _, err := donorFiles(mediaFiles, input)
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+
+		mediaFiles = []os.FileInfo{raw}
+	}
+
+	switch {
+	case len(mediaFiles) == 0:
+		fail("no media file found")
+		return
+
+	case len(mediaFiles) > 1 && !input.AllMedia:
+		fail("multiple media files found")
+		return
+
+	case input.DonorRule == nil && len(subtitles) == 0 && len(attachments) == 0 &&
+		len(chapters) == 0:
+		fail("no additional files found")
+		return
+	}
+
+	for i, name := range outputNames(mediaFiles, input) {
+		subs := subtitlesFor(mediaFiles[i], subtitles)
+		size := mediaFiles[i].Size() + filesSize(subs) + extras
+
+		plan.Entries = append(plan.Entries, PlanEntry{
+			SourceDir:   sourceDir,
+			MediaFile:   mediaFiles[i].Name(),
+			Subtitles:   len(subs),
+			Attachments: len(attachments),
+			Output:      filepath.Join(prefix, plannedName(sourceDir, name)),
+			Size:        size + int64(float64(size)*containerOverhead),
+		})
+	}
+}
+
+/*
+Includes checks if a source directory is to be processed as per the plan applied to
+the run, every source directory is included if no plan is applied.
+*/
+func (plan *RunPlan) includes(sourceDir string) bool {
+	if plan == nil {
+		return true
+	}
+
+	sourceDir = commons.NormalizePath(sourceDir)
+	for _, entry := range plan.Entries {
+		if commons.NormalizePath(entry.SourceDir) == sourceDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FilesSize returns the total size of the files supplied
+func filesSize(files []os.FileInfo) (size int64) {
+	for _, file := range files {
+		size += file.Size()
+	}
+
+	return size
+}
+
+/*
+FormatTable returns the rows (the first being the header) as a table, with the columns
+padded to the widest cell in them - the width on the terminal is used, keeping wide
+characters aligned. The cells are sanitized in place.
+*/
+func formatTable(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			// Names that aren't valid UTF-8 would throw the columns off
+			cell = commons.SanitizeText(cell)
+			row[i] = cell
+
+			if width := commons.StringWidth(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	var res strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			res.WriteString(cell)
+			if i < len(row)-1 {
+				padding := widths[i] - commons.StringWidth(cell) + 2
+				res.WriteString(strings.Repeat(" ", padding))
+			}
+		}
+
+		res.WriteString("\n")
+	}
+
+	return res.String()
+}
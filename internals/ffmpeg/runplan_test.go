@@ -0,0 +1,113 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestScanPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(runplan/ScanPlan) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string]int{
+		"01/video.mkv":      1000,
+		"01/English.srt":    100,
+		"02/video.mp4":      2000,
+		"02/English.ass":    200,
+		"02/font.ttf":       50,
+		"03/notes.txt":      10, // no media file, reported as an error
+		"output/video.mkv":  10, // result directory, left out
+		"output/video2.mkv": 10,
+	}
+
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("(runplan/ScanPlan) failed to create file: %v", err)
+		}
+	}
+
+	// Outputs of both source directories share a name, adjusted as per the policy
+	input := &commons.UserInput{
+		RootPath:    dir,
+		Order:       commons.OrderName,
+		OnCollision: commons.CollisionSuffix,
+	}
+
+	plan, exitCode, err := ScanPlan(input, filepath.Join(dir, "output"))
+	if err != nil || exitCode != commons.StatusOK {
+		t.Fatalf(
+			"(runplan/ScanPlan) failed to scan root directory \nexit code: %d"+
+				"\nerror: %v",
+			exitCode,
+			err,
+		)
+	}
+
+	if len(plan.Entries) != 3 || plan.Entries[0].Output != "video.mkv" ||
+		plan.Entries[1].Output != "video (2).mkv" ||
+		plan.Entries[1].Attachments != 1 || plan.Entries[1].Subtitles != 1 ||
+		plan.Entries[2].Error == "" {
+		t.Errorf("(runplan/ScanPlan) unexpected plan \n%+v", plan.Entries)
+	}
+
+	// Estimated size includes the container overhead (rounded down)
+	if count, size := plan.Outputs(); count != 2 || size != 1111+2272 {
+		t.Errorf(
+			"(runplan/Outputs) unexpected outputs \ncount: %d \nsize: %d",
+			count,
+			size,
+		)
+	}
+
+	table := plan.String()
+	for _, expected := range []string{
+		"Directory  Media File",
+		"error, no media file found",
+		"2 output(s)",
+	} {
+		if !strings.Contains(table, expected) {
+			t.Errorf(
+				"(runplan/String) expected `%s` in the table \ntable: \n%s",
+				expected,
+				table,
+			)
+		}
+	}
+
+	// Saved plan is loaded as is, and limits the source directories processed
+	path := filepath.Join(dir, "plan.json")
+	if err = plan.Save(path); err != nil {
+		t.Fatalf("(runplan/Save) failed to save plan: %v", err)
+	}
+
+	loaded, err := LoadPlan(path)
+	if err != nil || loaded.RootPath != dir || len(loaded.Entries) != 3 {
+		t.Errorf("(runplan/LoadPlan) unexpected plan \n%+v \nerror: %v", loaded, err)
+	}
+
+	loaded.Entries = loaded.Entries[:1]
+	if !loaded.includes(filepath.Join(dir, "01")) ||
+		loaded.includes(filepath.Join(dir, "02")) {
+		t.Errorf("(runplan/includes) plan not applied to the source directories")
+	}
+
+	var missing *RunPlan
+	if !missing.includes(filepath.Join(dir, "02")) {
+		t.Errorf("(runplan/includes) source directory skipped without a plan")
+	}
+
+	if _, err = LoadPlan(filepath.Join(dir, "01", "English.srt")); err == nil {
+		t.Errorf("(runplan/LoadPlan) malformed plan loaded without an error")
+	}
+}
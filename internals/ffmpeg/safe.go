@@ -0,0 +1,115 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extension for partial outputs kept in safe mode, instead of removing them
+const failedExt = ".failed"
+
+// Error returned when the run is stopped to avoid modifying existing files
+var errSafeMode = errors.New("run stopped to avoid modifying existing files")
+
+/*
+CheckSafeResultDir ensures the result directory won't write to a source directory - in
+safe mode, the result directory can't be the root directory, or be present inside a
+source directory.
+*/
+func checkSafeResultDir(input *commons.UserInput, resDir string) error {
+	rel, err := filepath.Rel(input.RootPath, resDir)
+	parent := ".." + string(filepath.Separator)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, parent) {
+		// Result directory is outside the root directory
+		return nil
+	}
+
+	// The root directory is the source directory if used directly, otherwise, each
+	// directory inside the root directory is a source directory
+	if rel == "." || input.IsDirect || strings.ContainsRune(rel, filepath.Separator) {
+		return fmt.Errorf(
+			"result directory \"%s\" is inside a source directory, use the "+
+				"`--output` flag to store the results elsewhere",
+			resDir,
+		)
+	}
+
+	return nil
+}
+
+/*
+FailedPath returns the path a partial output is renamed to - the first of
+`<output>.failed`, `<output>.1.failed`, `<output>.2.failed`... not present already.
+*/
+func failedPath(output string) string {
+	path := output + failedExt
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+
+		path = fmt.Sprintf("%s.%d%s", output, i, failedExt)
+	}
+}
+
+/*
+KeepFailedOutput renames the partial output of a failed encode, used in safe mode
+instead of removing the output - the partial output is named after the output, i.e.
+`<output>.part` is renamed to `<output>.failed`. Returns the path the output was
+renamed to, blank if no output exists.
+*/
+func keepFailedOutput(output string) (string, error) {
+	if _, err := os.Lstat(output); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	path := failedPath(strings.TrimSuffix(output, partialExt))
+	if err := os.Rename(output, path); err != nil {
+		log.Debugf(
+			`(safe/keepFailedOutput) failed to rename partial output: "%s"`+
+				"\nerror: %v",
+			output,
+			err,
+		)
+
+		return "", err
+	}
+
+	log.Debugf(`(safe/keepFailedOutput) partial output renamed to: "%s"`, path)
+	return path, nil
+}
+
+/*
+FailedOutput keeps the partial output of a failed encode aside in safe mode, returning
+the exit code supplied - or the exit code for a safety violation if the partial output
+can't be renamed. Partial outputs are left as is outside safe mode, these are reported
+(and removed) as leftovers by the next run.
+*/
+func failedOutput(input *commons.UserInput, output string, exitCode int) int {
+	if !input.Safe {
+		return exitCode
+	}
+
+	path, err := keepFailedOutput(output)
+	if err != nil {
+		commons.Error(
+			"Failed to rename the partial output in safe mode \n\tPath: "+
+				"\"%s\"\n\n",
+			output,
+		)
+
+		return commons.SafetyViolation
+	}
+
+	if path != "" {
+		commons.Warn("Partial output kept as \"%s\"\n\n", path)
+	}
+
+	return exitCode
+}
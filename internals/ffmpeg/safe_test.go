@@ -0,0 +1,89 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestCheckSafeResultDir(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "root")
+	input := &commons.UserInput{RootPath: root, Safe: true}
+
+	for resDir, allowed := range map[string]bool{
+		filepath.Join(root, "auto-sub [output]"):     true,
+		filepath.Join(os.TempDir(), "elsewhere"):     true,
+		root:                                         false,
+		filepath.Join(root, "show", "results"):       false,
+		filepath.Join(root, "..", "sibling", "out"):  true,
+		filepath.Join(root, "show", "..", "results"): true,
+	} {
+		if err := checkSafeResultDir(input, resDir); (err == nil) != allowed {
+			t.Errorf(
+				"(safe/checkSafeResultDir) unexpected result for %q \nerror: %v",
+				resDir,
+				err,
+			)
+		}
+	}
+
+	// The root directory itself is the source directory when used directly
+	input.IsDirect = true
+	if err := checkSafeResultDir(
+		input,
+		filepath.Join(root, "auto-sub [output]"),
+	); err == nil {
+		t.Errorf("(safe/checkSafeResultDir) expected an error with a direct root")
+	}
+}
+
+func TestFailedOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(safe/failedOutput) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "episode.mkv")
+	partial := partialPath(output)
+	input := &commons.UserInput{Safe: true}
+
+	// Partial outputs are renamed after the output, without replacing the ones kept
+	// earlier
+	for _, expected := range []string{
+		output + failedExt,
+		output + ".1" + failedExt,
+	} {
+		if err := ioutil.WriteFile(partial, []byte("partial"), 0644); err != nil {
+			t.Fatalf("(safe/failedOutput) failed to create file: %v", err)
+		}
+
+		if code := failedOutput(input, partial, commons.UnexpectedError); code !=
+			commons.UnexpectedError {
+			t.Errorf("(safe/failedOutput) unexpected exit code: %d", code)
+		}
+
+		if _, err := os.Stat(expected); err != nil {
+			t.Errorf("(safe/failedOutput) partial output not renamed to %q", expected)
+		}
+	}
+
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("(safe/failedOutput) partial output still present")
+	}
+
+	// Partial outputs are left as is outside safe mode
+	if err := ioutil.WriteFile(partial, []byte("partial"), 0644); err != nil {
+		t.Fatalf("(safe/failedOutput) failed to create file: %v", err)
+	}
+
+	input.Safe = false
+	failedOutput(input, partial, commons.UnexpectedError)
+	if _, err := os.Stat(partial); err != nil {
+		t.Errorf("(safe/failedOutput) partial output modified outside safe mode")
+	}
+}
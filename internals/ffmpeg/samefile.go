@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+SamePath checks if two paths point to the same file (or directory), irrespective of the
+form of either path (relative paths, trailing separators and the like). Paths that
+still differ are compared by the file they point to (the inode on unix) - recognizes
+symbolic links, and paths differing in case on file systems ignoring case.
+*/
+func samePath(path, other string) bool {
+	if path == "" || other == "" {
+		return false
+	}
+
+	if commons.NormalizePath(path) == commons.NormalizePath(other) {
+		return true
+	}
+
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	otherInfo, err := os.Stat(other)
+	return err == nil && os.SameFile(pathInfo, otherInfo)
+}
+
+/*
+CheckSameFile ensures the output for a media file is not one of the files read to
+create it - FFmpeg would overwrite (and corrupt) the input otherwise, for example, with
+the root directory used as the result directory in direct mode. Returns the exit code
+for the media file, `commons.StatusOK` if the output is safe to write.
+*/
+func checkSameFile(
+	sourceDir, output string,
+	inputs ...[]os.FileInfo,
+) int {
+	for _, group := range inputs {
+		for _, file := range group {
+			path := joinPath(sourceDir, file.Name())
+			if !samePath(path, output) {
+				continue
+			}
+
+			log.Debugf(
+				`(samefile/checkSameFile) output is an input: "%s"`,
+				path,
+			)
+
+			commons.Error(
+				"Output would overwrite the file it is created from, use the "+
+					"`--output` flag to store the results elsewhere"+
+					"\n\tPath: \"%s\"\n\n",
+				path,
+			)
+
+			return commons.SameFileError
+		}
+	}
+
+	return commons.StatusOK
+}
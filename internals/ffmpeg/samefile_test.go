@@ -0,0 +1,62 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestCheckSameFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(samefile/checkSameFile) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	media := filepath.Join(dir, "Movie.mkv")
+	_ = ioutil.WriteFile(media, []byte("media"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(dir, "Movie.srt"), []byte("subs"), 0644)
+	inputs := [][]os.FileInfo{
+		{fileInfo(t, media)},
+		{fileInfo(t, filepath.Join(dir, "Movie.srt"))},
+	}
+
+	// Outputs elsewhere are safe to write, even if named the same as the input
+	output := filepath.Join(dir, "output", "Movie.mkv")
+	if code := checkSameFile(dir, output, inputs...); code != commons.StatusOK {
+		t.Errorf("(samefile/checkSameFile) unexpected exit code: %d", code)
+	}
+
+	// The result directory pointed at the source directory in direct mode
+	for _, output := range []string{
+		media,
+		filepath.Join(dir, ".", "Movie.mkv"),
+		filepath.Join(dir, "output", "..", "Movie.srt"),
+	} {
+		if code := checkSameFile(dir, output, inputs...); code !=
+			commons.SameFileError {
+			t.Errorf("(samefile/checkSameFile) input overwritten: %q", output)
+		}
+	}
+
+	// Links to the source directory lead to the same files
+	link := filepath.Join(os.TempDir(), filepath.Base(dir)+"-link")
+	if err = os.Symlink(dir, link); err == nil {
+		defer os.Remove(link)
+
+		output = filepath.Join(link, "Movie.mkv")
+		if code := checkSameFile(dir, output, inputs...); code !=
+			commons.SameFileError {
+			t.Errorf("(samefile/checkSameFile) input overwritten through a link")
+		}
+	}
+
+	// Files absent from the disk are compared through their paths alone
+	if samePath(filepath.Join(dir, "absent"), output) || samePath("", "") {
+		t.Errorf("(samefile/samePath) unrelated paths matched")
+	}
+}
@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+/*
+Pattern for the names of samples and trailers, the keyword has to be a separate word in
+the name (without the extension) - `Sample.mkv` and `Movie.2020-trailer.mp4` match,
+while `Samples of Life.mkv` does not.
+*/
+var samplePattern = regexp.MustCompile(
+	`(?i)(^|[^a-z0-9])(sample|trailer|teaser)($|[^a-z0-9])`,
+)
+
+/*
+Largest size of a sample (or trailer) as a fraction of the largest media file in the
+source directory - a media file with a matching name that isn't much smaller than the
+rest is never left out.
+*/
+const sampleSizeRatio = 0.3
+
+/*
+IsSample checks if a media file looks like a sample (or trailer), i.e. the name has a
+matching keyword and the file is far smaller than the largest media file
+*/
+func isSample(mediaFile os.FileInfo, largest int64) bool {
+	name := strings.TrimSuffix(mediaFile.Name(), filepath.Ext(mediaFile.Name()))
+
+	return samplePattern.MatchString(name) &&
+		float64(mediaFile.Size()) <= float64(largest)*sampleSizeRatio
+}
+
+/*
+FilterSamples leaves out samples and trailers from the media files in a source
+directory, unless the filter is disabled. Nothing is left out if the source directory
+contains a single media file, the samples are returned separately.
+*/
+func filterSamples(
+	mediaFiles []os.FileInfo,
+	input *commons.UserInput,
+) (kept, samples []os.FileInfo) {
+	if input.NoSampleFilter || len(mediaFiles) < 2 {
+		return mediaFiles, nil
+	}
+
+	var largest int64
+	for _, mediaFile := range mediaFiles {
+		if mediaFile.Size() > largest {
+			largest = mediaFile.Size()
+		}
+	}
+
+	for _, mediaFile := range mediaFiles {
+		if isSample(mediaFile, largest) {
+			samples = append(samples, mediaFile)
+		} else {
+			kept = append(kept, mediaFile)
+		}
+	}
+
+	return kept, samples
+}
@@ -0,0 +1,66 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestFilterSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(sample/filterSamples) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for name, size := range map[string]int{
+		"Movie.mkv":           1000,
+		"sample.mkv":          100,
+		"Movie-Trailer.mp4":   200,
+		"Samples of Life.mkv": 100,
+		"Teaser.mkv":          900,
+		"subtitles.ass":       10,
+	} {
+		content := []byte(strings.Repeat("a", size))
+		if err = ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("(sample/filterSamples) failed to create file: %v", err)
+		}
+	}
+
+	// Samples far smaller than the movie are left out, files with the keyword only
+	// as part of a word (or nearly as large as the movie) are kept
+	input := &commons.UserInput{}
+	mediaFiles, _, _, _ := groupFiles(dir, input)
+	if res := commons.Stringify(&mediaFiles); res != `["Movie.mkv", "Samples of `+
+		`Life.mkv", "Teaser.mkv"]` {
+		t.Errorf("(sample/filterSamples) unexpected media files: %s", res)
+	}
+
+	if res := inspectDir(dir, input); !strings.Contains(
+		res,
+		`"sample.mkv": sample or trailer`,
+	) {
+		t.Errorf("(sample/filterSamples) sample not reported \n%s", res)
+	}
+
+	// Nothing is left out if the filter is disabled, or for a single media file
+	input.NoSampleFilter = true
+	if mediaFiles, _, _, _ = groupFiles(dir, input); len(mediaFiles) != 5 {
+		t.Errorf("(sample/filterSamples) media files left out with the filter off")
+	}
+
+	sample, err := os.Stat(filepath.Join(dir, "sample.mkv"))
+	if err != nil {
+		t.Fatalf("(sample/filterSamples) failed to read file: %v", err)
+	}
+
+	kept, samples := filterSamples([]os.FileInfo{sample}, &commons.UserInput{})
+	if len(kept) != 1 || len(samples) != 0 {
+		t.Errorf("(sample/filterSamples) single media file left out")
+	}
+}
@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"context"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Source of the current time - a variable to allow tests to control the clock
+var timeNow = time.Now
+
+/*
+WaitForSchedule pauses the run until the current time lies inside the schedule window
+set by the user (if any), printing a message when the run is paused and resumed.
+
+Returns false if the context is cancelled while waiting.
+*/
+func waitForSchedule(ctx context.Context, input *commons.UserInput) bool {
+	if input.Schedule == nil {
+		return true
+	}
+
+	wait := input.Schedule.Until(timeNow())
+	if wait <= 0 {
+		return true
+	}
+
+	resumeAt := timeNow().Add(wait)
+	log.Debugf(
+		"(schedule/waitForSchedule) outside schedule window `%s`, pausing for %v",
+		input.Schedule,
+		wait,
+	)
+
+	commons.Info(
+		"Outside the schedule window (%s), pausing until %s...\n\n",
+		input.Schedule,
+		resumeAt.Format("Jan 2 15:04"),
+	)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		commons.Info("Inside the schedule window (%s), resuming\n\n", input.Schedule)
+		return true
+
+	case <-ctx.Done():
+		return false
+	}
+}
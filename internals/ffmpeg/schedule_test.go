@@ -0,0 +1,40 @@
+package ffmpeg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestWaitForSchedule(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	schedule, err := commons.ParseSchedule("01:00-07:00")
+	if err != nil {
+		t.Fatalf("(schedule/waitForSchedule) failed to parse schedule \nerror: %v", err)
+	}
+
+	input := &commons.UserInput{}
+	if !waitForSchedule(context.Background(), input) {
+		t.Errorf("(schedule/waitForSchedule) run paused without a schedule")
+	}
+
+	// Inside the window, no wait
+	input.Schedule = schedule
+	timeNow = func() time.Time { return time.Date(2021, 1, 1, 3, 0, 0, 0, time.Local) }
+	if !waitForSchedule(context.Background(), input) {
+		t.Errorf("(schedule/waitForSchedule) run paused inside the window")
+	}
+
+	// Outside the window, the wait should end once the context is cancelled
+	timeNow = func() time.Time { return time.Date(2021, 1, 1, 8, 0, 0, 0, time.Local) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if waitForSchedule(ctx, input) {
+		t.Errorf("(schedule/waitForSchedule) cancelled wait reported as complete")
+	}
+}
@@ -0,0 +1,44 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+/*
+Markers in the names of subtitle files meant for the hearing impaired, matched against
+the words in the name - i.e. `Episode 01.eng.sdh.srt` or `Episode 01 [CC].ass`.
+*/
+var sdhMarkers = []string{"sdh", "cc"}
+
+/*
+IsSDH checks if a subtitle file is meant for the hearing impaired (SDH) through its
+name, the extension is left out. The pattern supplied through `--sdh-regex` (if any) is
+used instead of the built-in markers.
+*/
+func isSDH(sub os.FileInfo, userInput *commons.UserInput) bool {
+	name := filepath.Base(sub.Name())
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	if userInput.SDHRule != nil {
+		return userInput.SDHRule.MatchString(name)
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		for _, marker := range sdhMarkers {
+			if strings.EqualFold(word, marker) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestIsSDH(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(sdh/isSDH) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	hearing := regexp.MustCompile("(?i)hearing")
+	for _, in := range []struct {
+		name   string
+		rule   *regexp.Regexp
+		result bool
+	}{
+		{"Episode 01.eng.sdh.srt", nil, true},
+		{"Episode 01 [CC].ass", nil, true},
+		{"Episode 01.eng.srt", nil, false},
+		{"Accident.srt", nil, false},
+		{"Soccer.srt", nil, false},
+		{"cc.srt", hearing, false},
+		{"English (Hearing Impaired).srt", hearing, true},
+	} {
+		path := filepath.Join(dir, in.name)
+		if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(sdh/isSDH) failed to create file: %v", err)
+		}
+
+		info, _ := os.Stat(path)
+		input := &commons.UserInput{SDHRule: in.rule}
+
+		if res := isSDH(info, input); res != in.result {
+			t.Errorf(
+				"(sdh/isSDH) unexpected result \nfile: `%s` \nexpected: %v"+
+					"\nresult: %v",
+				in.name,
+				in.result,
+				res,
+			)
+		}
+	}
+}
+
+func TestGenerateCmdSDH(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(handler/generateCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(probeStreams, func(context.Context, string, string) streamCount {
+		return streamCount{}
+	})
+
+	for _, name := range []string{"video.mkv", "English.srt", "English SDH.srt"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("(handler/generateCmd) failed to create file: %v", err)
+		}
+	}
+
+	media, _ := os.Stat(filepath.Join(dir, "video.mkv"))
+	english, _ := os.Stat(filepath.Join(dir, "English.srt"))
+	sdh, _ := os.Stat(filepath.Join(dir, "English SDH.srt"))
+
+	args := strings.Join(generateCmd(
+		context.Background(),
+		dir,
+		&commons.UserInput{},
+		filepath.Join(dir, "out.mkv"),
+		media,
+		nil,
+		[]os.FileInfo{english, sdh},
+		nil,
+		nil,
+	).Args, " ")
+
+	// Only the subtitle marked as SDH should be flagged
+	if !strings.Contains(args, "-disposition:s:1 +hearing_impaired") ||
+		strings.Contains(args, "-disposition:s:0") {
+		t.Errorf("(handler/generateCmd) unexpected dispositions \ncommand: %s", args)
+	}
+}
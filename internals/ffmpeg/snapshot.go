@@ -0,0 +1,189 @@
+package ffmpeg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extension of the snapshot stored next to an output file
+const snapshotExt = ".snapshot"
+
+/*
+InputsHash hashes the set of input files used to create an output - the name, size and
+modification time of each file, along with the user input that modifies the output.
+Any change to the inputs changes the hash, indicating the output is outdated.
+*/
+func inputsHash(
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	donor os.FileInfo, // nil unless in extract-and-merge mode
+	subtitles,
+	attachments,
+	chapters []os.FileInfo,
+) string {
+	hash := sha256.New()
+
+	// User input affecting the output
+	fmt.Fprintf(
+		hash,
+		"language:%s\ntitle:%s\norder:%s\n",
+		input.SubLang,
+		input.SubTitleString,
+		strings.Join(input.SubOrder, ","),
+	)
+
+	// Added only if not the default, ensures existing snapshots remain valid
+	if input.SubPosition == commons.SubPositionFront {
+		fmt.Fprintf(hash, "position:%s\n", input.SubPosition)
+	}
+
+	if input.SubtitleCodec != "" && input.SubtitleCodec != commons.SubCodecAuto {
+		fmt.Fprintf(hash, "codec:%s\n", input.SubtitleCodec)
+	}
+
+	if input.SDHRegex != "" {
+		fmt.Fprintf(hash, "sdh:%s\n", input.SDHRegex)
+	}
+
+	if input.SubEncoding != "" && input.SubEncoding != commons.EncodingAuto {
+		fmt.Fprintf(hash, "encoding:%s\n", input.SubEncoding)
+	}
+
+	// Samples are cut short, never matching the snapshot of a complete output
+	if input.Sample > 0 {
+		fmt.Fprintf(hash, "sample:%d\n", input.Sample)
+	}
+
+	// Tags written into the output, and the files written next to it
+	if input.StatsTags {
+		fmt.Fprintf(hash, "stats-tags\n")
+	}
+
+	if input.AlsoMP4 {
+		fmt.Fprintf(hash, "also-mp4\n")
+	}
+
+	if input.ExportMetadata {
+		fmt.Fprintf(hash, "export-metadata\n")
+	}
+
+	// Attachment descriptions, in a stable order
+	kinds := make([]string, 0, len(input.AttachmentDescs))
+	for kind := range input.AttachmentDescs {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(hash, "description:%s=%s\n", kind, input.AttachmentDescs[kind])
+	}
+
+	// Entries from the title map, in a stable order
+	names := make([]string, 0, len(input.TitleMaps))
+	for name := range input.TitleMaps {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		title := input.TitleMaps[name]
+		fmt.Fprintf(hash, "title-map:%s=%s,%s\n", name, title.Title, title.Language)
+	}
+
+	// Metadata rules, in the order they are applied
+	for _, rule := range input.Rules {
+		fmt.Fprintf(
+			hash,
+			"rule:%s|%s|%s|%s|%s|%v\n",
+			rule.Match,
+			rule.Kind,
+			rule.Language,
+			rule.Title,
+			rule.Disposition,
+			rule.Exclude,
+		)
+	}
+
+	media := []os.FileInfo{mediaFile}
+	if donor != nil {
+		media = append(media, donor)
+	}
+
+	for category, files := range [][]os.FileInfo{
+		media,
+		subtitles,
+		attachments,
+		chapters,
+	} {
+		for _, file := range files {
+			fmt.Fprintf(
+				hash,
+				"%d:%s:%d:%d\n",
+				category,
+				file.Name(),
+				file.Size(),
+				file.ModTime().UnixNano(),
+			)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+/*
+ReadSnapshot returns the hash stored next to an output file after it was created, the
+hash will be blank if the snapshot is missing (or unreadable).
+*/
+func readSnapshot(output string) string {
+	contents, err := ioutil.ReadFile(output + snapshotExt)
+	if err != nil {
+		log.Debugf(
+			"(snapshot/readSnapshot) snapshot not found for output: \"%s\" \nerror: %v",
+			output,
+			err,
+		)
+
+		return ""
+	}
+
+	return strings.TrimSpace(string(contents))
+}
+
+/*
+WriteSnapshot stores the hash of the inputs next to the output file, marking the output
+as created from these inputs.
+*/
+func writeSnapshot(output, hash string) {
+	err := ioutil.WriteFile(output+snapshotExt, []byte(hash+"\n"), 0644)
+	if err != nil {
+		log.Warnf(
+			`(snapshot/writeSnapshot) failed to save snapshot for output: "%s"`+
+				"\nerror: %v",
+			output,
+			err,
+		)
+	}
+}
+
+/*
+RemoveSnapshot removes the snapshot for an output, if any - used before an output is
+recreated, ensuring a failed run doesn't leave a stale snapshot behind.
+*/
+func removeSnapshot(output string) {
+	if err := os.Remove(output + snapshotExt); err != nil && !os.IsNotExist(err) {
+		log.Debugf(
+			"(snapshot/removeSnapshot) failed to remove snapshot for: \"%s\" "+
+				"\nerror: %v",
+			output,
+			err,
+		)
+	}
+}
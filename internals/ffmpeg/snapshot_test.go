@@ -0,0 +1,111 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestInputsHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(snapshot/inputsHash) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	media := filepath.Join(dir, "video.mkv")
+	subs := filepath.Join(dir, "subs.ass")
+	for _, path := range []string{media, subs} {
+		if err := ioutil.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("(snapshot/inputsHash) failed to create file: %v", err)
+		}
+	}
+
+	input := &commons.UserInput{SubLang: "eng"}
+	hash := func() string {
+		mediaInfo, _ := os.Stat(media)
+		subsInfo, _ := os.Stat(subs)
+
+		return inputsHash(input, mediaInfo, nil, []os.FileInfo{subsInfo}, nil, nil)
+	}
+
+	original := hash()
+	if hash() != original {
+		t.Errorf("(snapshot/inputsHash) hash changed without modifying the inputs")
+	}
+
+	// Fixing the subtitles upstream should change the hash
+	modified := time.Now().Add(time.Hour)
+	if err := os.Chtimes(subs, modified, modified); err != nil {
+		t.Fatalf("(snapshot/inputsHash) failed to modify file: %v", err)
+	}
+
+	updated := hash()
+	if updated == original {
+		t.Errorf("(snapshot/inputsHash) hash unchanged after modifying a subtitle")
+	}
+
+	input.SubLang = "jpn"
+	if hash() == updated {
+		t.Errorf("(snapshot/inputsHash) hash unchanged after modifying the language")
+	}
+
+	// Options changing the output alone
+	for name, modify := range map[string]func(){
+		"sample":          func() { input.Sample = 30 },
+		"encoding":        func() { input.SubEncoding = "cp1251" },
+		"stats tags":      func() { input.StatsTags = true },
+		"MP4 variant":     func() { input.AlsoMP4 = true },
+		"metadata export": func() { input.ExportMetadata = true },
+		"description": func() {
+			input.AttachmentDescs = map[string]string{commons.AttachmentFont: "Font"}
+		},
+	} {
+		before := hash()
+		if modify(); hash() == before {
+			t.Errorf("(snapshot/inputsHash) hash unchanged after modifying %s", name)
+		}
+	}
+
+	// Default encoding leaves the hash unchanged, keeping existing snapshots valid
+	before := hash()
+	input.SubEncoding = commons.EncodingAuto
+	encoded := hash()
+	input.SubEncoding = ""
+	if encoded != hash() || encoded == before {
+		t.Errorf("(snapshot/inputsHash) hash changed with the default encoding")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(snapshot/writeSnapshot) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "video.mkv")
+	if res := readSnapshot(output); res != "" {
+		t.Errorf("(snapshot/readSnapshot) unexpected snapshot found: %s", res)
+	}
+
+	writeSnapshot(output, "abc123")
+	if res := readSnapshot(output); res != "abc123" {
+		t.Errorf(
+			"(snapshot/readSnapshot) unexpected snapshot \nexpected: abc123 "+
+				"\nresult: %s",
+			res,
+		)
+	}
+
+	removeSnapshot(output)
+	if res := readSnapshot(output); res != "" {
+		t.Errorf("(snapshot/removeSnapshot) snapshot not removed: %s", res)
+	}
+}
@@ -0,0 +1,157 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Pattern for language codes in the names of subtitle files, i.e. three-letter codes
+var subLanguagePattern = regexp.MustCompile(`^[a-zA-Z]{3}$`)
+
+/*
+SubtitleLanguage detects the language of a subtitle file through its name - the last
+word of the name (minus the extension) is treated as the language if it is a
+three-letter code, i.e. `episode.eng.ass` or `episode [jpn].ass`. Returns a blank string
+if the name contains no language.
+*/
+func subtitleLanguage(name string) string {
+	name = filepath.Base(name)
+	words := strings.FieldsFunc(
+		strings.TrimSuffix(name, filepath.Ext(name)),
+		func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) },
+	)
+
+	if len(words) < 2 || !subLanguagePattern.MatchString(words[len(words)-1]) {
+		return ""
+	}
+
+	return strings.ToLower(words[len(words)-1])
+}
+
+/*
+SplitByLanguage groups the subtitle files by their language, subtitle files without a
+language are grouped under the fallback language. Languages are returned in
+alphabetical order, subtitle files retain their order within a group.
+*/
+func splitByLanguage(
+	subtitles []os.FileInfo,
+	fallback string,
+) (languages []string, groups map[string][]os.FileInfo) {
+	groups = map[string][]os.FileInfo{}
+	for _, sub := range subtitles {
+		lang := subtitleLanguage(sub.Name())
+		if lang == "" {
+			lang = fallback
+		}
+
+		if _, ok := groups[lang]; !ok {
+			languages = append(languages, lang)
+		}
+
+		groups[lang] = append(groups[lang], sub)
+	}
+
+	sort.Strings(languages)
+	return languages, groups
+}
+
+/*
+LanguageOutput returns the path to the output for a language, i.e. `movie.eng.mkv` for
+the output `movie.mkv` - the output is returned as is for a blank language.
+*/
+func languageOutput(output, lang string) string {
+	if lang == "" {
+		return output
+	}
+
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "." + lang + ext
+}
+
+/*
+SplitOutputs checks if the outputs for a media file are split by the language of the
+subtitle files - outputs are never split without any subtitle file.
+*/
+func splitOutputs(input *commons.UserInput, subtitles []os.FileInfo) bool {
+	return input.SplitByLang && len(subtitles) > 0
+}
+
+/*
+MergeOutputs merges the extra files into a media file - with `--split-by-lang`, one
+output is created for each subtitle language instead, each containing only the
+subtitles in the language (with the language set in the metadata). Subtitle streams
+already present in the media file are left out of these outputs, their languages
+aren't known. Attachments and chapters are merged into every output.
+
+Returns the exit code for the first output that failed, if any.
+*/
+func mergeOutputs(
+	ctx context.Context,
+	sourceDir, resDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	donor os.FileInfo,
+	output string,
+	subtitles,
+	attachments,
+	chapters []os.FileInfo,
+) (exitCode int) {
+	if !splitOutputs(input, subtitles) {
+		return mediaFileCmd(
+			ctx,
+			sourceDir,
+			resDir,
+			input,
+			mediaFile,
+			donor,
+			output,
+			subtitles,
+			attachments,
+			chapters,
+		)
+	}
+
+	languages, groups := splitByLanguage(subtitles, input.SubLang)
+	log.Debugf(
+		`(split/mergeOutputs) subtitle languages for "%s": %v`,
+		joinPath(sourceDir, mediaFile.Name()),
+		languages,
+	)
+
+	exitCode = commons.StatusOK
+	for _, lang := range languages {
+		// Each output uses the language of its subtitles, the input is copied to
+		// leave the language for the rest of the run untouched
+		langInput := *input
+		langInput.SubLang = lang
+
+		code := mediaFileCmd(
+			ctx,
+			sourceDir,
+			resDir,
+			&langInput,
+			mediaFile,
+			donor,
+			languageOutput(output, lang),
+			groups[lang],
+			attachments,
+			chapters,
+		)
+
+		exitCode = combineStatus(exitCode, code)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return exitCode
+}
@@ -0,0 +1,142 @@
+package ffmpeg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestSubtitleLanguage(t *testing.T) {
+	names := map[string]string{
+		"movie.eng.ass":     "eng",
+		"movie [SPA].srt":   "spa",
+		"movie_jpn.ass":     "jpn",
+		"English.srt":       "",
+		"movie.english.srt": "",
+		"eng.srt":           "", // the language alone is a name, not a language
+		"movie.e1.srt":      "",
+	}
+
+	for name, expected := range names {
+		if lang := subtitleLanguage(name); lang != expected {
+			t.Errorf(
+				"(split/subtitleLanguage) unexpected language for `%s` "+
+					"\nexpected: `%s` \nretrieved: `%s`",
+				name,
+				expected,
+				lang,
+			)
+		}
+	}
+}
+
+func TestLanguageOutput(t *testing.T) {
+	output := languageOutput("/res/movie.mkv", "eng")
+	if output != "/res/movie.eng.mkv" {
+		t.Errorf("(split/languageOutput) unexpected output: %s", output)
+	}
+
+	if output = languageOutput("/res/movie.mkv", ""); output != "/res/movie.mkv" {
+		t.Errorf("(split/languageOutput) unexpected output: %s", output)
+	}
+}
+
+func TestMergeOutputs(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(split/mergeOutputs) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var files []os.FileInfo
+	names := []string{"movie.mkv", "movie.spa.srt", "English.srt", "movie.eng.ass"}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		_ = ioutil.WriteFile(path, []byte{}, 0644)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("(split/mergeOutputs) failed to create file: %v", err)
+		}
+
+		files = append(files, info)
+	}
+
+	type call struct {
+		lang   string
+		output string
+		subs   []string
+	}
+
+	var calls []call
+	monkey.Patch(mediaFileCmd, func(
+		_ context.Context,
+		_, _ string,
+		input *commons.UserInput,
+		_, _ os.FileInfo,
+		output string,
+		subtitles, _, _ []os.FileInfo,
+	) int {
+		names := []string{}
+		for _, sub := range subtitles {
+			names = append(names, sub.Name())
+		}
+
+		calls = append(calls, call{input.SubLang, output, names})
+		if input.SubLang == "spa" {
+			return commons.EncodingError
+		}
+
+		return commons.StatusOK
+	})
+
+	input := &commons.UserInput{SubLang: "eng"}
+	output := filepath.Join(dir, "res", "movie.mkv")
+
+	// Without the flag, every subtitle goes into a single output
+	ctx := context.Background()
+	subs := files[1:]
+	code := mergeOutputs(ctx, dir, dir, input, files[0], nil, output, subs, nil, nil)
+
+	if code != commons.StatusOK || len(calls) != 1 || calls[0].output != output ||
+		len(calls[0].subs) != 3 {
+		t.Errorf("(split/mergeOutputs) unexpected calls without split: %v", calls)
+	}
+
+	calls = nil
+	input.SplitByLang = true
+	code = mergeOutputs(ctx, dir, dir, input, files[0], nil, output, subs, nil, nil)
+
+	// Subtitles without a language fall back to the language from the input
+	expected := []call{
+		{"eng", filepath.Join(dir, "res", "movie.eng.mkv"), []string{
+			"English.srt", "movie.eng.ass",
+		}},
+		{"spa", filepath.Join(dir, "res", "movie.spa.mkv"), []string{"movie.spa.srt"}},
+	}
+
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf(
+			"(split/mergeOutputs) unexpected calls \nexpected: %v \nretrieved: %v",
+			expected,
+			calls,
+		)
+	}
+
+	if code != commons.EncodingError {
+		t.Errorf("(split/mergeOutputs) unexpected exit code: %d", code)
+	}
+
+	if input.SubLang != "eng" {
+		t.Errorf("(split/mergeOutputs) input modified: `%s`", input.SubLang)
+	}
+}
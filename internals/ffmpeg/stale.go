@@ -0,0 +1,224 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extension for the partial output of an ongoing encode, the partial output is renamed
+// to the output once the encode completes
+const partialExt = ".part"
+
+// Prefix for the directories holding the converted copies of subtitles, within the
+// temporary workspace of the run
+const convertedSubsPrefix = "auto-sub-subs"
+
+// PartialPath returns the path to which the output is written while being encoded
+func partialPath(output string) string {
+	return output + partialExt
+}
+
+/*
+StaleFiles returns the leftovers of earlier runs that did not complete (for example,
+due to a crash or a power cut), i.e. partial outputs present in the result directory
+and temporary workspaces of runs no longer running. Only items last modified before the
+time supplied are returned, ensures files from an ongoing run are never included.
+
+Partial outputs are recognized only for the outputs planned for the source directories
+(the root directory itself in direct mode), and only where these outputs are written -
+directly in the result directory, or the directory mirroring the source directory below
+it. Partial files of other programs sharing the result directory are never included.
+Workspaces are recognized by their prefix, and are returned only if the process owning
+the workspace is gone.
+*/
+func staleFiles(
+	input *commons.UserInput,
+	resDir string,
+	before time.Time,
+) (stale []string) {
+	for dir, outputs := range stalePlan(input, resDir) {
+		// Unreadable directories are skipped
+		items, _ := ioutil.ReadDir(dir)
+		for _, item := range items {
+			if !item.IsDir() && isPartialOutput(item.Name(), outputs) &&
+				item.ModTime().Before(before) {
+				stale = append(stale, filepath.Join(dir, item.Name()))
+			}
+		}
+	}
+
+	sort.Strings(stale)
+
+	items, err := ioutil.ReadDir(os.TempDir())
+	if err != nil {
+		return stale
+	}
+
+	for _, item := range items {
+		if !item.IsDir() || !strings.HasPrefix(item.Name(), commons.TempPrefix) ||
+			!item.ModTime().Before(before) {
+			continue
+		}
+
+		path := filepath.Join(os.TempDir(), item.Name())
+		if pid, ok := workspaceOwner(path); ok && !processAlive(pid) {
+			stale = append(stale, path)
+		}
+	}
+
+	return stale
+}
+
+/*
+StalePlan returns the names of the outputs planned for the source directories, for each
+directory in which these outputs could have been written by an earlier run. Names are
+included both as planned and as adjusted for collisions (if they are).
+*/
+func stalePlan(input *commons.UserInput, resDir string) map[string][]string {
+	sourceDirs := []string{input.RootPath}
+	if !input.IsDirect {
+		sourceDirs = nil
+
+		items, _ := ioutil.ReadDir(input.RootPath)
+		for _, item := range items {
+			path := filepath.Join(input.RootPath, item.Name())
+			if item.IsDir() && !isResultDir(path, resDir) && !input.IgnoreDir(path) {
+				sourceDirs = append(sourceDirs, path)
+			}
+		}
+	}
+
+	plan := make(map[string][]string)
+	for _, sourceDir := range sourceDirs {
+		var names []string
+		for _, name := range plannedOutputs(sourceDir, input) {
+			names = append(names, name)
+			if planned := plannedName(sourceDir, name); planned != name {
+				names = append(names, planned)
+			}
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		plan[resDir] = append(plan[resDir], names...)
+		if !input.IsDirect {
+			mirrored := filepath.Join(resDir, filepath.Base(sourceDir))
+			plan[mirrored] = append(plan[mirrored], names...)
+		}
+	}
+
+	return plan
+}
+
+/*
+IsPartialOutput checks if a file name is the name of the partial output for one of the
+outputs supplied - including the MP4 variant of the output, and the outputs split by
+language, i.e. `movie.eng.mkv.part` for the output `movie.mkv`.
+*/
+func isPartialOutput(name string, outputs []string) bool {
+	if !strings.HasSuffix(name, partialExt) {
+		return false
+	}
+
+	name = strings.TrimSuffix(name, partialExt)
+	for _, output := range outputs {
+		for _, variant := range []string{output, mp4Path(output)} {
+			if name == variant {
+				return true
+			}
+
+			ext := filepath.Ext(variant)
+			prefix := strings.TrimSuffix(variant, ext) + "."
+			if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+				continue
+			}
+
+			lang := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+			if lang != "" && !strings.ContainsAny(lang, ". ") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+WorkspaceOwner returns the process ID of the run that created a temporary workspace,
+the boolean returned is false if the owner is unknown - workspaces without a readable
+owner are never treated as leftovers.
+*/
+func workspaceOwner(workspace string) (int, bool) {
+	contents, err := ioutil.ReadFile(filepath.Join(workspace, commons.TempOwnerFile))
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
+
+/*
+CleanStale reports the leftovers of earlier runs that did not complete, removing them
+if the `clean-stale` flag is used - or if the user agrees to, when prompted. Nothing is
+removed in safe mode. Failures are reported without affecting the run.
+*/
+func cleanStale(input *commons.UserInput, resDir string, before time.Time) {
+	stale := staleFiles(input, resDir, before)
+	if len(stale) == 0 {
+		return
+	}
+
+	commons.Warn("Found leftovers from earlier runs that did not complete;\n")
+	for _, path := range stale {
+		commons.Printf("\t\"%s\"\n", path)
+	}
+
+	commons.Printf("\n")
+
+	switch {
+	case input.Safe:
+		commons.Info("Leftovers are not removed in safe mode\n\n")
+		return
+
+	case input.CleanStale:
+
+	case !input.Prompt.Confirm(fmt.Sprintf("Remove %d leftover item(s)?", len(stale))):
+		commons.Printf("\n")
+		return
+	}
+
+	removed := 0
+	for _, path := range stale {
+		if err := commons.Discard(path); err != nil {
+			log.Debugf(
+				"(stale/cleanStale) failed to remove: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+
+			runSummary.warn(`failed to remove leftover "%s"`, path)
+
+			continue
+		}
+
+		removed++
+	}
+
+	commons.Success("Removed %d leftover item(s)\n\n", removed)
+}
@@ -0,0 +1,161 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestCleanStale(t *testing.T) {
+	root, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(stale/cleanStale) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	runSummary = &Summary{}
+	old := time.Now().Add(-time.Hour)
+
+	// Source directories, each with a media file
+	for _, name := range []string{
+		filepath.Join("Show A", "Episode 01.mp4"),
+		filepath.Join("Show B", "Episode 02.mkv"),
+	} {
+		path := filepath.Join(root, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(stale/cleanStale) failed to create directory: %v", err)
+		}
+
+		if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("(stale/cleanStale) failed to create file: %v", err)
+		}
+	}
+
+	dir := filepath.Join(root, "output")
+	input := &commons.UserInput{RootPath: root}
+
+	// Partial outputs from an earlier run, including the MP4 variant, outputs split
+	// by language and outputs in the mirrored layout
+	stale := []string{
+		filepath.Join(dir, "Episode 01.mkv"+partialExt),
+		filepath.Join(dir, "Episode 01.mp4"+partialExt),
+		filepath.Join(dir, "Episode 02.eng.mkv"+partialExt),
+		filepath.Join(dir, "Show B", "Episode 02.mkv"+partialExt),
+	}
+
+	// Outputs, and partial outputs written after the run started, are left untouched -
+	// along with partial files that aren't planned outputs, or aren't where the
+	// outputs are written
+	kept := []string{
+		filepath.Join(dir, "Episode 03.mkv"),
+		filepath.Join(dir, "Episode 02.mkv"+partialExt),
+		filepath.Join(dir, "notes.txt"+partialExt),
+		filepath.Join(dir, "download.mkv"+partialExt),
+		filepath.Join(dir, "Downloads", "Episode 01.mkv"+partialExt),
+		filepath.Join(dir, "Show B", "Extras", "Episode 02.mkv"+partialExt),
+	}
+
+	for i, path := range append(append([]string{}, stale...), kept...) {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(stale/cleanStale) failed to create directory: %v", err)
+		}
+
+		if err = ioutil.WriteFile(path, []byte("partial"), 0644); err != nil {
+			t.Fatalf("(stale/cleanStale) failed to create file: %v", err)
+		}
+
+		// Files are left untouched regardless of the time, except the partial output
+		// written after the run started
+		if i != len(stale)+1 {
+			if err = os.Chtimes(path, old, old); err != nil {
+				t.Fatalf("(stale/cleanStale) failed to modify time: %v", err)
+			}
+		}
+	}
+
+	start := time.Now().Add(-time.Minute)
+	found := 0
+	for _, path := range staleFiles(input, dir, start) {
+		if filepath.Dir(path) != os.TempDir() {
+			found++
+		}
+	}
+
+	if found != len(stale) {
+		t.Errorf(
+			"(stale/staleFiles) expected %d stale files, found %d",
+			len(stale),
+			found,
+		)
+	}
+
+	// Nothing is removed in safe mode, or if the prompt is declined
+	safe := &commons.UserInput{RootPath: root, Safe: true, CleanStale: true}
+	cleanStale(safe, dir, start)
+	cleanStale(input, dir, start)
+	for _, path := range stale {
+		if _, err = os.Stat(path); err != nil {
+			t.Errorf("(stale/cleanStale) stale file removed unexpectedly: %q", path)
+		}
+	}
+
+	input.CleanStale = true
+	cleanStale(input, dir, start)
+	for _, path := range stale {
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("(stale/cleanStale) stale file not removed: %q", path)
+		}
+	}
+
+	for _, path := range kept {
+		if _, err = os.Stat(path); err != nil {
+			t.Errorf("(stale/cleanStale) file removed unexpectedly: %q", path)
+		}
+	}
+}
+
+func TestStaleWorkspaces(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	// Workspaces owned by a running run, a stopped run, and an unknown owner
+	var workspaces []string
+	for _, owner := range []string{"1", "2", "unknown"} {
+		workspace, err := ioutil.TempDir("", commons.TempPrefix)
+		if err != nil {
+			t.Fatalf("(stale/staleFiles) failed to create directory: %v", err)
+		}
+
+		defer os.RemoveAll(workspace)
+
+		path := filepath.Join(workspace, commons.TempOwnerFile)
+		if err = ioutil.WriteFile(path, []byte(owner), 0644); err != nil {
+			t.Fatalf("(stale/staleFiles) failed to create file: %v", err)
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	monkey.Patch(processAlive, func(pid int) bool { return pid == 1 })
+
+	found := map[string]bool{}
+	before := time.Now().Add(time.Minute)
+	for _, path := range staleFiles(&commons.UserInput{}, "", before) {
+		found[path] = true
+	}
+
+	if found[workspaces[0]] || !found[workspaces[1]] || found[workspaces[2]] {
+		t.Errorf(
+			"(stale/staleFiles) unexpected workspaces \nrunning: %v \nstopped: %v"+
+				"\nunknown owner: %v",
+			found[workspaces[0]],
+			found[workspaces[1]],
+			found[workspaces[2]],
+		)
+	}
+}
@@ -0,0 +1,41 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+WriteStatsTags writes the track statistics tags (`BPS`, `DURATION`, `NUMBER_OF_FRAMES`
+and `NUMBER_OF_BYTES`) to a Matroska file through mkvpropedit - FFmpeg doesn't write
+these tags, media managers rely on them to display the bitrate and the duration of each
+track. The file is modified in place.
+*/
+func writeStatsTags(ctx context.Context, input *commons.UserInput, path string) error {
+	// Command being fired:
+	// `mkvpropedit <path> --add-track-statistics-tags`
+	output, err := commons.ToolCommand(
+		ctx,
+		input.MkvpropeditPath,
+		path,
+		"--add-track-statistics-tags",
+	).CombinedOutput()
+
+	if err != nil {
+		log.Debugf(
+			"(stats/writeStatsTags) mkvpropedit failed for: \"%s\" \nerror: %v"+
+				"\noutput: %s",
+			path,
+			err,
+			output,
+		)
+
+		return fmt.Errorf("mkvpropedit failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestWriteStatsTags(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var args []string
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(c *exec.Cmd) ([]byte, error) {
+			args = c.Args
+			return nil, nil
+		},
+	)
+
+	input := &commons.UserInput{MkvpropeditPath: "mkvpropedit"}
+	err := writeStatsTags(context.Background(), input, "movie.mkv.part")
+	if err != nil {
+		t.Errorf("(stats/writeStatsTags) unexpected error: %v", err)
+	}
+
+	expected := []string{"mkvpropedit", "movie.mkv.part", "--add-track-statistics-tags"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("(stats/writeStatsTags) unexpected command: %v", args)
+	}
+
+	// Output from mkvpropedit is included in the error
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"CombinedOutput",
+		func(*exec.Cmd) ([]byte, error) {
+			return []byte("Error: not a Matroska file\n"), errors.New("exit status 2")
+		},
+	)
+
+	err = writeStatsTags(context.Background(), input, "movie.mkv.part")
+	if err == nil || !strings.Contains(err.Error(), "not a Matroska file") {
+		t.Errorf("(stats/writeStatsTags) unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of streams by the letter used for them in stream specifiers, i.e. `0:s?`
+var streamTypes = map[string]string{
+	"v": "video",
+	"a": "audio",
+	"s": "subtitle",
+	"d": "data",
+	"t": "attachment",
+}
+
+/*
+ProbedStream is a stream present in an input file, as reported by FFprobe - only the
+fields shown in the stream map are read.
+*/
+type probedStream struct {
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+
+	Tags struct {
+		Language string `json:"language"`
+		Title    string `json:"title"`
+	} `json:"tags"`
+
+	Disposition map[string]int `json:"disposition"`
+}
+
+/*
+MappedStream is a stream in the output, along with the stream in the inputs it comes
+from - attached files have no input stream, the input is `-1` for them.
+*/
+type mappedStream struct {
+	input  int
+	stream int
+
+	kind         string
+	codec        string
+	language     string
+	title        string
+	dispositions []string
+}
+
+/*
+ProbeStreamInfo uses FFprobe to list the streams present in a file, along with their
+codec, language, title and dispositions.
+*/
+func probeStreamInfo(
+	ctx context.Context,
+	ffprobePath, path string,
+) ([]probedStream, error) {
+	// Command being fired:
+	// `ffprobe -v error -show_entries stream=codec_type,codec_name:stream_tags=
+	// language,title:stream_disposition -of json <file>`
+	output, err := commons.ToolCommand(
+		ctx,
+		ffprobePath,
+		"-v", "error",
+		"-show_entries",
+		"stream=codec_type,codec_name:stream_tags=language,title:stream_disposition",
+		"-of", "json",
+		path,
+	).Output()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var probed struct {
+		Streams []probedStream `json:"streams"`
+	}
+
+	if err = json.Unmarshal(output, &probed); err != nil {
+		return nil, err
+	}
+
+	return probed.Streams, nil
+}
+
+/*
+StreamMap works out the streams present in the output of the plan, in order - the
+maps are expanded against the streams present in the inputs, followed by the codecs,
+the metadata and the dispositions set for the streams in the output. Files attached
+are placed at the end.
+
+Inputs that can't be probed contribute no streams, the map is a best effort meant to
+be read by the user.
+*/
+func (plan *cmdPlan) streamMap(ctx context.Context, ffprobePath string) []mappedStream {
+	inputs := make([][]probedStream, len(plan.inputs))
+	for i, path := range plan.inputs {
+		streams, err := probeStreamInfo(ctx, ffprobePath, path)
+		if err != nil {
+			log.Debugf(
+				"(streammap/streamMap) failed to probe input: \"%s\" \nerror: %v",
+				path,
+				err,
+			)
+		}
+
+		inputs[i] = streams
+	}
+
+	var streams []mappedStream
+	for _, spec := range plan.maps {
+		parts := strings.SplitN(strings.TrimSuffix(spec, "?"), ":", 2)
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index < 0 || index >= len(inputs) {
+			continue
+		}
+
+		for i, stream := range inputs[index] {
+			if len(parts) == 2 && streamTypes[parts[1]] != stream.CodecType {
+				continue
+			}
+
+			mapped := mappedStream{
+				input:    index,
+				stream:   i,
+				kind:     stream.CodecType,
+				codec:    stream.CodecName,
+				language: stream.Tags.Language,
+				title:    stream.Tags.Title,
+			}
+
+			for name, set := range stream.Disposition {
+				if set != 0 {
+					mapped.dispositions = append(mapped.dispositions, name)
+				}
+			}
+
+			sort.Strings(mapped.dispositions)
+			streams = append(streams, mapped)
+		}
+	}
+
+	// Options are set on the streams in the output by type, i.e. `-c:s:1` is set
+	// on the second subtitle stream in the output
+	target := func(flag string) *mappedStream {
+		parts := strings.Split(flag, ":")
+		if len(parts) < 3 {
+			return nil
+		}
+
+		index, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			return nil
+		}
+
+		kind := streamTypes[parts[len(parts)-2]]
+		for i := range streams {
+			if streams[i].kind != kind {
+				continue
+			}
+
+			if index == 0 {
+				return &streams[i]
+			}
+
+			index--
+		}
+
+		return nil
+	}
+
+	for _, option := range plan.codecs {
+		if stream := target(option.flag); stream != nil && option.value != codecCopy {
+			stream.codec = fmt.Sprintf("%s → %s", stream.codec, option.value)
+		}
+	}
+
+	for _, option := range plan.metadata {
+		stream := target(option.flag)
+		if stream == nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(option.value, "title="):
+			stream.title = strings.TrimPrefix(option.value, "title=")
+		case strings.HasPrefix(option.value, "language="):
+			stream.language = strings.TrimPrefix(option.value, "language=")
+		}
+	}
+
+	for _, option := range plan.dispositions {
+		if stream := target(option.flag); stream != nil {
+			stream.dispositions = applyDisposition(stream.dispositions, option.value)
+		}
+	}
+
+	for _, attachment := range plan.attachments {
+		streams = append(streams, mappedStream{
+			input:  -1,
+			kind:   "attachment",
+			codec:  attachment.mimetype,
+			title:  attachment.description,
+			stream: -1,
+		})
+	}
+
+	return streams
+}
+
+/*
+ApplyDisposition applies a value for the `-disposition` option to the dispositions of
+a stream - values prefixed with a sign add (or remove) dispositions, while other
+values replace the dispositions altogether, i.e. `default+forced`.
+*/
+func applyDisposition(current []string, value string) []string {
+	if value != "" && value[0] != '+' && value[0] != '-' {
+		current = nil
+		value = "+" + value
+	}
+
+	// Splitting ahead of each sign, keeping the sign with the name following it
+	value = strings.NewReplacer("+", "\x00+", "-", "\x00-").Replace(value)
+	for _, change := range strings.Split(value, "\x00") {
+		if len(change) < 2 {
+			continue
+		}
+
+		name, kept := change[1:], current[:0:0]
+		for _, existing := range current {
+			if existing != name {
+				kept = append(kept, existing)
+			}
+		}
+
+		if current = kept; change[0] == '+' {
+			current = append(current, name)
+		}
+	}
+
+	sort.Strings(current)
+	return current
+}
+
+/*
+FormatStreamMap returns the stream map in a human-readable form - the inputs, followed
+by a table with a row for each stream in the output.
+*/
+func formatStreamMap(plan *cmdPlan, streams []mappedStream) string {
+	var res strings.Builder
+	res.WriteString(fmt.Sprintf("Streams in \"%s\":\n", filepath.Base(plan.output)))
+	for i, input := range plan.inputs {
+		res.WriteString(fmt.Sprintf("  Input %d: %s\n", i, filepath.Base(input)))
+	}
+
+	res.WriteString("\n")
+
+	// Blank cells are marked, keeping the columns readable
+	cell := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+
+		return value
+	}
+
+	rows := [][]string{{
+		"#", "Input", "Type", "Codec", "Language", "Title", "Disposition",
+	}}
+
+	for i, stream := range streams {
+		source := "attached"
+		if stream.input >= 0 {
+			source = fmt.Sprintf("%d:%d", stream.input, stream.stream)
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(i),
+			source,
+			cell(stream.kind),
+			cell(stream.codec),
+			cell(stream.language),
+			cell(stream.title),
+			cell(strings.Join(stream.dispositions, ", ")),
+		})
+	}
+
+	res.WriteString(formatTable(rows))
+	return res.String()
+}
+
+/*
+TraceStreams prints the stream map for the output of a media file in verbose mode,
+the map is written to the logs as well (if enabled) - the command is planned through
+the same inputs FFmpeg would be run with.
+*/
+func traceStreams(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	output string,
+
+	mediaFile os.FileInfo,
+	donor os.FileInfo,
+	subtitles,
+	attachments,
+	chapters []os.FileInfo,
+) {
+	if !input.Verbose && !log.IsLevelEnabled(log.DebugLevel) {
+		return
+	}
+
+	plan := planCmd(
+		ctx,
+		sourceDir,
+		input,
+		output,
+		mediaFile,
+		donor,
+		subtitles,
+		attachments,
+		chapters,
+	)
+
+	res := formatStreamMap(plan, plan.streamMap(ctx, input.FFprobePath))
+	log.Debugf("(streammap/traceStreams) stream map \n%s", res)
+
+	if input.Verbose {
+		commons.Info("\n%s\n", res)
+	}
+}
@@ -0,0 +1,124 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+)
+
+func TestStreamMap(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	probed := map[string][]probedStream{
+		"/root/video.mkv": {
+			{CodecType: "video", CodecName: "h264"},
+			{CodecType: "audio", CodecName: "aac"},
+			{CodecType: "subtitle", CodecName: "ass"},
+		},
+		"/root/English.srt": {{CodecType: "subtitle", CodecName: "subrip"}},
+	}
+
+	probed["/root/video.mkv"][1].Tags.Language = "jpn"
+	probed["/root/video.mkv"][1].Disposition = map[string]int{"default": 1, "dub": 0}
+	probed["/root/video.mkv"][2].Tags.Title = "Signs"
+
+	monkey.Patch(probeStreamInfo, func(
+		_ context.Context,
+		_, path string,
+	) ([]probedStream, error) {
+		if streams, ok := probed[path]; ok {
+			return streams, nil
+		}
+
+		return nil, errors.New("unknown file")
+	})
+
+	plan := &cmdPlan{
+		inputs: []string{"/root/video.mkv", "/root/English.srt", "/root/missing.mkv"},
+		codecs: []streamOption{{"-c", codecCopy}, {"-c:s:0", "ass"}},
+		maps:   []string{"0:v?", "0:a?", "1", "0:s?", "0:t?", "2:s?"},
+		metadata: []streamOption{
+			{"-metadata:s:s:0", "title=English"},
+			{"-metadata:s:s:0", "language=eng"},
+		},
+		dispositions: []streamOption{{"-disposition:s:0", "+hearing_impaired"}},
+		attachments:  []attachmentPlan{{path: "/root/font.ttf", mimetype: "font/ttf"}},
+		output:       "/res/video.mkv",
+	}
+
+	expected := []mappedStream{
+		{input: 0, stream: 0, kind: "video", codec: "h264"},
+		{
+			input:        0,
+			stream:       1,
+			kind:         "audio",
+			codec:        "aac",
+			language:     "jpn",
+			dispositions: []string{"default"},
+		},
+		{
+			input:        1,
+			stream:       0,
+			kind:         "subtitle",
+			codec:        "subrip → ass",
+			language:     "eng",
+			title:        "English",
+			dispositions: []string{"hearing_impaired"},
+		},
+		{input: 0, stream: 2, kind: "subtitle", codec: "ass", title: "Signs"},
+		{input: -1, stream: -1, kind: "attachment", codec: "font/ttf"},
+	}
+
+	streams := plan.streamMap(context.Background(), "ffprobe")
+	if !reflect.DeepEqual(streams, expected) {
+		t.Errorf(
+			"(streammap/streamMap) unexpected streams \nexpected: %+v \nfound: %+v",
+			expected,
+			streams,
+		)
+	}
+
+	res := formatStreamMap(plan, streams)
+	for _, line := range []string{
+		`Streams in "video.mkv":`,
+		"Input 1: English.srt",
+		"2  1:0       subtitle    subrip → ass  eng       English  hearing_impaired",
+		"4  attached  attachment  font/ttf      -         -        -",
+	} {
+		if !strings.Contains(res, line) {
+			t.Errorf("(streammap/formatStreamMap) missing line: %q \n%s", line, res)
+		}
+	}
+}
+
+func TestApplyDisposition(t *testing.T) {
+	for _, test := range []struct {
+		current  []string
+		value    string
+		expected []string
+	}{
+		{nil, "+hearing_impaired", []string{"hearing_impaired"}},
+		{[]string{"default"}, "+forced", []string{"default", "forced"}},
+		{[]string{"default", "forced"}, "-default", []string{"forced"}},
+		{[]string{"default"}, "+default-forced", []string{"default"}},
+		{[]string{"default"}, "forced+comment", []string{"comment", "forced"}},
+		{[]string{"default"}, "", []string{"default"}},
+	} {
+		current := append([]string(nil), test.current...)
+		res := applyDisposition(current, test.value)
+		if strings.Join(res, ",") != strings.Join(test.expected, ",") {
+			t.Errorf(
+				"(streammap/applyDisposition) unexpected result for %v with `%s`"+
+					"\nexpected: %v \nfound: %v",
+				test.current,
+				test.value,
+				test.expected,
+				res,
+			)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Names of the subtitle folders present in a source directory, compared irrespective of
+case. Subtitle files directly inside a subtitle folder are used for every media file,
+while the ones inside a per-episode folder (`Subs/<media name>/English.srt`) are used
+for the matching media file only.
+*/
+var subsDirNames = []string{
+	"subs",
+	"subtitles",
+}
+
+/*
+NestedFile is a file present inside a subtitle folder (or an archive), the name of the
+file is its path relative to the source directory - ensures the path to the file can be
+formed using the source directory, same as the rest of the files.
+*/
+type nestedFile struct {
+	os.FileInfo
+	name string
+}
+
+// Name returns the path to the file, relative to the source directory
+func (file nestedFile) Name() string {
+	return file.name
+}
+
+// IsSubsDir checks if the name of a directory is one of the subtitle folder names
+func isSubsDir(name string) bool {
+	for _, dirName := range subsDirNames {
+		if strings.EqualFold(name, dirName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+NestedSubtitles returns the subtitle files present inside the subtitle folders of a
+source directory, and the per-episode folders inside them - files are filtered using
+the ignore rules, same as the files in the source directory.
+*/
+func nestedSubtitles(sourceDir string, userInput *commons.UserInput) (
+	subtitles []os.FileInfo,
+) {
+	items, err := ioutil.ReadDir(sourceDir)
+	if err != nil {
+		return nil
+	}
+
+	for _, item := range items {
+		if !item.IsDir() || !isSubsDir(item.Name()) {
+			continue
+		}
+
+		subtitles = append(
+			subtitles,
+			subsDirFiles(sourceDir, item.Name(), userInput, true)...,
+		)
+	}
+
+	return subtitles
+}
+
+/*
+SubsDirFiles returns the subtitle files inside a folder, the path to the folder is
+relative to the source directory. Per-episode folders are looked into if required.
+*/
+func subsDirFiles(
+	sourceDir, relDir string,
+	userInput *commons.UserInput,
+	episodes bool,
+) (subtitles []os.FileInfo) {
+	dir := joinPath(sourceDir, relDir)
+	if userInput.IgnoreDir(dir) {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Debugf(
+			"(subsdir/subsDirFiles) unable to read subtitle folder: \"%s\" \nerror: %v",
+			dir,
+			err,
+		)
+
+		return nil
+	}
+
+	for _, file := range files {
+		name := filepath.Join(relDir, file.Name())
+		if file.IsDir() {
+			if episodes {
+				subtitles = append(
+					subtitles,
+					subsDirFiles(sourceDir, name, userInput, false)...,
+				)
+			}
+
+			continue
+		}
+
+		if category, _ := classifyFile(dir, file, userInput, true); category ==
+			categorySubtitle {
+			subtitles = append(subtitles, nestedFile{FileInfo: file, name: name})
+		}
+	}
+
+	return subtitles
+}
+
+/*
+EpisodeDir returns the name of the per-episode folder containing a subtitle file, the
+name is blank for files that aren't inside a per-episode folder.
+*/
+func episodeDir(sub os.FileInfo) string {
+	parts := strings.Split(filepath.ToSlash(sub.Name()), "/")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+/*
+SubtitlesFor filters the subtitle files to the ones to be used for a media file, i.e.
+subtitle files inside per-episode folders are dropped unless the name of the folder
+matches the name of the media file (without the extension).
+*/
+func subtitlesFor(mediaFile os.FileInfo, subtitles []os.FileInfo) []os.FileInfo {
+	mediaName := strings.TrimSuffix(mediaFile.Name(), filepath.Ext(mediaFile.Name()))
+
+	res := make([]os.FileInfo, 0, len(subtitles))
+	for _, sub := range subtitles {
+		if episode := episodeDir(sub); episode == "" ||
+			strings.EqualFold(episode, mediaName) {
+			res = append(res, sub)
+		}
+	}
+
+	return res
+}
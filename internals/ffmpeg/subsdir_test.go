@@ -0,0 +1,107 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestNestedSubtitles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(subsdir/nestedSubtitles) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"Show.S01E01.mkv",
+		"Show.S01E02.mkv",
+		"Subs/Signs.ass",
+		"Subs/Show.S01E01/English.srt",
+		"Subs/Show.S01E01/notes.txt",
+		"Subs/Show.S01E02/English.srt",
+		"Subs/Show.S01E02/Extra/Deep.srt",
+		"Other/Ignored.srt",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("(subsdir/nestedSubtitles) failed to create directory: %v", err)
+		}
+
+		if err = ioutil.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatalf("(subsdir/nestedSubtitles) failed to create file: %v", err)
+		}
+	}
+
+	mediaFiles, subtitles, _, _ := groupFiles(dir, &commons.UserInput{})
+	if len(mediaFiles) != 2 {
+		t.Fatalf(
+			"(subsdir/groupFiles) expected 2 media files, found %d",
+			len(mediaFiles),
+		)
+	}
+
+	// Subtitle files nested deeper than the per-episode folders are not used
+	expected := []string{
+		filepath.Join("Subs", "Show.S01E01", "English.srt"),
+		filepath.Join("Subs", "Show.S01E02", "English.srt"),
+		filepath.Join("Subs", "Signs.ass"),
+	}
+
+	var names []string
+	for _, sub := range subtitles {
+		names = append(names, sub.Name())
+	}
+
+	sort.Strings(names)
+	if len(names) != len(expected) {
+		t.Fatalf(
+			"(subsdir/groupFiles) unexpected subtitles \nexpected: %v \nfound: %v",
+			expected,
+			names,
+		)
+	}
+
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Errorf(
+				"(subsdir/groupFiles) unexpected subtitle \nexpected: %q \nfound: %q",
+				expected[i],
+				names[i],
+			)
+		}
+	}
+
+	// Per-episode subtitles are used for the matching media file only
+	for _, mediaFile := range mediaFiles {
+		subs := subtitlesFor(mediaFile, subtitles)
+		if len(subs) != 2 {
+			t.Errorf(
+				"(subsdir/subtitlesFor) expected 2 subtitles for %q, found %d",
+				mediaFile.Name(),
+				len(subs),
+			)
+		}
+
+		for _, sub := range subs {
+			if episode := episodeDir(sub); episode != "" &&
+				episode+".mkv" != mediaFile.Name() {
+				t.Errorf(
+					"(subsdir/subtitlesFor) subtitle %q used for %q",
+					sub.Name(),
+					mediaFile.Name(),
+				)
+			}
+
+			// The relative name locates the file inside the source directory
+			if _, err := os.Stat(joinPath(dir, sub.Name())); err != nil {
+				t.Errorf("(subsdir/nestedFile) file not found: %v", err)
+			}
+		}
+	}
+}
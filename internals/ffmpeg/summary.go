@@ -0,0 +1,284 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Summary is a simple structure to keep a track of the results of a run - the source
+directories processed, the ones that failed, and any warnings raised along the way.
+
+A single instance of this structure is used for a run, this instance is reset when
+`TraverseRoot()` starts and printed to the screen once the run completes.
+*/
+type Summary struct {
+	// Source directories processed successfully
+	Processed []string
+
+	// Source directories that could not be processed
+	Failed []string
+
+	// Source directories skipped due to inclusion rules, or on user request
+	Skipped []string
+
+	// FFmpeg processes that failed, along with the way each failed
+	Processes []ProcessFailure
+
+	// Warnings raised while processing the source directories; these do not stop
+	// a source directory from being processed.
+	Warnings []string
+
+	// Subtitle files left out for being exact duplicates of another subtitle file,
+	// along with the name of the file kept
+	Duplicates []string
+
+	// Media files merged successfully, and the total size of the outputs (in bytes)
+	Files int
+	Bytes int64
+
+	// Frames encoded by FFmpeg, and the time spent encoding them
+	Frames     int64
+	EncodeTime time.Duration
+
+	// Files created by the run - outputs, their snapshots and the extra files copied.
+	// Recorded in the history, allowing the run to be undone
+	Created []CreatedFile
+
+	// Directory holding the outputs of the run, undoing the run never removes it (or
+	// the directories above it)
+	ResultDir string
+
+	// Files that existed before the run, replaced by the files created by the run
+	replaced map[string]bool
+}
+
+// Summary for the current run, reset every time the root directory is traversed.
+var runSummary = &Summary{}
+
+// Exit code used internally for source directories skipped on user request, for
+// example, when the user declines to overwrite an existing output
+const statusSkipped = -1
+
+/*
+CombineStatus combines the exit code of a source directory (or media file) with the
+exit code for one more of its outputs - the first failure takes precedence over outputs
+skipped or merged, and skipped outputs take precedence over the ones merged.
+*/
+func combineStatus(exitCode, code int) int {
+	switch {
+	case exitCode != commons.StatusOK && exitCode != statusSkipped:
+		return exitCode
+	case code == commons.StatusOK:
+		return exitCode
+	default:
+		return code
+	}
+}
+
+/*
+Record adds the result for a source directory to the summary depending on the exit
+code returned while processing the directory.
+*/
+func (summary *Summary) record(sourceDir string, exitCode int) {
+	commons.EmitResult(dirResult{
+		Event:    "directory",
+		Path:     sourceDir,
+		Status:   resultStatus(exitCode),
+		ExitCode: exitCode,
+	})
+
+	runMetrics.record(exitCode)
+	switch exitCode {
+	case commons.StatusOK:
+		summary.Processed = append(summary.Processed, sourceDir)
+	case statusSkipped:
+		summary.Skipped = append(summary.Skipped, sourceDir)
+	default:
+		summary.Failed = append(summary.Failed, sourceDir)
+	}
+}
+
+/*
+Merged adds an output created successfully to the summary
+*/
+func (summary *Summary) merged(output string) {
+	summary.Files++
+	if item, err := os.Stat(output); err == nil {
+		summary.Bytes += item.Size()
+		atomic.AddInt64(&runMetrics.bytes, item.Size())
+	}
+
+	summary.created(output)
+	summary.created(output + snapshotExt)
+	summary.created(output + metadataExt)
+}
+
+/*
+Created adds a file created by the run to the summary, along with its size and
+modification time - missing files are ignored.
+*/
+func (summary *Summary) created(path string) {
+	item, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	summary.Created = append(summary.Created, CreatedFile{
+		Path:     path,
+		Size:     item.Size(),
+		ModTime:  item.ModTime().UnixNano(),
+		Replaced: summary.replaced[path],
+	})
+}
+
+/*
+Replacing marks the files about to be replaced by the run, files that don't exist are
+ignored - files created in place of existing files are never removed by undoing the
+run, the earlier files can't be restored.
+*/
+func (summary *Summary) replacing(paths ...string) {
+	for _, path := range paths {
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+
+		if summary.replaced == nil {
+			summary.replaced = map[string]bool{}
+		}
+
+		summary.replaced[path] = true
+	}
+}
+
+/*
+Encoded adds the frames encoded by a successful FFmpeg command to the summary, along
+with the time taken by the command
+*/
+func (summary *Summary) encoded(frames int64, took time.Duration) {
+	summary.Frames += frames
+	summary.EncodeTime += took
+
+	atomic.AddInt64(&runMetrics.frames, frames)
+	atomic.AddInt64(&runMetrics.encodeTime, int64(took))
+}
+
+/*
+ProcessFailed adds an FFmpeg process that failed to the summary, the failure is logged
+as well
+*/
+func (summary *Summary) processFailed(failure *ProcessFailure) {
+	if failure == nil {
+		return
+	}
+
+	log.Warnf("(summary/processFailed) ffmpeg failed: %s", failure)
+	summary.Processes = append(summary.Processes, *failure)
+}
+
+/*
+Duplicate adds a subtitle file left out as a duplicate to the summary, along with the
+name of the subtitle file kept in its place
+*/
+func (summary *Summary) duplicate(path, original string) {
+	log.Debugf(`(summary/duplicate) "%s" duplicates "%s"`, path, original)
+	summary.Duplicates = append(
+		summary.Duplicates,
+		fmt.Sprintf("\"%s\" (same as \"%s\")", path, original),
+	)
+}
+
+/*
+Skip adds a source directory skipped due to user input to the summary
+*/
+func (summary *Summary) skip(sourceDir string) {
+	commons.EmitResult(dirResult{
+		Event:    "directory",
+		Path:     sourceDir,
+		Status:   resultSkipped,
+		ExitCode: statusSkipped,
+	})
+
+	runMetrics.record(statusSkipped)
+	summary.Skipped = append(summary.Skipped, sourceDir)
+}
+
+/*
+Print displays the summary to the user once the run completes, the summary is emitted
+as a machine-readable result as well.
+*/
+func (summary *Summary) print() {
+	commons.EmitResult(summaryResult{
+		Event:     "summary",
+		Processed: len(summary.Processed),
+		Skipped:   len(summary.Skipped),
+		Failed:    len(summary.Failed),
+		Files:     summary.Files,
+		Bytes:     summary.Bytes,
+		Warnings:  append([]string{}, summary.Warnings...),
+		Processes: append([]ProcessFailure{}, summary.Processes...),
+
+		Duplicates: append([]string{}, summary.Duplicates...),
+	})
+
+	commons.Printf("%s", summary.String())
+}
+
+/*
+Warn adds a warning to the summary. Accepts the same interface as `fmt.Sprintf`; the
+warning is logged as well.
+*/
+func (summary *Summary) warn(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	log.Warnf("(summary/warn) %s", msg)
+
+	summary.Warnings = append(summary.Warnings, msg)
+}
+
+/*
+String forms the text to be displayed to the user once the run completes.
+*/
+func (summary *Summary) String() string {
+	contents := []string{
+		commons.Translate("Summary:"),
+		commons.Sprintf("\tProcessed: %d", len(summary.Processed)),
+		commons.Sprintf("\tSkipped: %d", len(summary.Skipped)),
+		commons.Sprintf("\tFailed: %d", len(summary.Failed)),
+	}
+
+	for _, dir := range summary.Failed {
+		contents = append(contents, fmt.Sprintf("\t - \"%s\"", dir))
+	}
+
+	if len(summary.Processes) > 0 {
+		contents = append(contents, commons.Translate("\nFFmpeg Failures:"))
+		for i := range summary.Processes {
+			contents = append(
+				contents,
+				fmt.Sprintf("\t - %s", summary.Processes[i].String()),
+			)
+		}
+	}
+
+	if len(summary.Duplicates) > 0 {
+		contents = append(contents, commons.Translate("\nDuplicate Subtitles:"))
+		for _, duplicate := range summary.Duplicates {
+			contents = append(contents, fmt.Sprintf("\t - %s", duplicate))
+		}
+	}
+
+	if len(summary.Warnings) > 0 {
+		contents = append(contents, commons.Translate("\nWarnings:"))
+		for _, warning := range summary.Warnings {
+			contents = append(contents, fmt.Sprintf("\t - %s", warning))
+		}
+	}
+
+	return strings.Join(contents, "\n") + "\n\n"
+}
@@ -0,0 +1,82 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestSummary(t *testing.T) {
+	summary := &Summary{}
+
+	summary.record("dir 01", commons.StatusOK)
+	summary.record("dir 02", commons.SourceDirectoryError)
+	summary.record("dir 03", commons.StatusOK)
+	summary.record("dir 04", statusSkipped)
+	summary.warn("test warning for %s", "dir 03")
+
+	if len(summary.Processed) != 2 || len(summary.Failed) != 1 ||
+		len(summary.Skipped) != 1 {
+		t.Errorf(
+			"(summary/record) unexpected results recorded \nprocessed: %v "+
+				"\nfailed: %v \nskipped: %v",
+			summary.Processed,
+			summary.Failed,
+			summary.Skipped,
+		)
+	}
+
+	// The text displayed should contain failed directories and warnings
+	for _, expected := range []string{
+		"Processed: 2",
+		"Failed: 1",
+		"Skipped: 1",
+		`"dir 02"`,
+		"test warning for dir 03",
+	} {
+		if res := summary.String(); !strings.Contains(res, expected) {
+			t.Errorf(
+				"(summary/String) missing text in summary \nexpected: `%s` "+
+					"\nsummary: \n%s",
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Warnings should not be displayed if there are none
+	if res := (&Summary{}).String(); strings.Contains(res, "Warnings") {
+		t.Errorf("(summary/String) empty summary displays warnings \n%s", res)
+	}
+}
+
+func TestCombineStatus(t *testing.T) {
+	failed := commons.SourceDirectoryError
+	for _, test := range []struct {
+		codes    []int
+		expected int
+	}{
+		{[]int{commons.StatusOK, commons.StatusOK}, commons.StatusOK},
+		{[]int{commons.StatusOK, statusSkipped}, statusSkipped},
+		{[]int{statusSkipped, commons.StatusOK}, statusSkipped},
+		{[]int{statusSkipped, failed}, failed},
+		{[]int{failed, statusSkipped}, failed},
+		{[]int{failed, commons.TimedOut}, failed},
+	} {
+		exitCode := commons.StatusOK
+		for _, code := range test.codes {
+			exitCode = combineStatus(exitCode, code)
+		}
+
+		if exitCode != test.expected {
+			t.Errorf(
+				"(summary/combineStatus) unexpected exit code for %v \nexpected: %d "+
+					"\nfound: %d",
+				test.codes,
+				test.expected,
+				exitCode,
+			)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ffmpeg
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+ProbeDuration uses FFprobe to fetch the duration (in seconds) of a file.
+
+For subtitle files, the duration reported by FFprobe is the end-time of the last cue
+present in the file - which makes it possible to compare subtitles against the media
+file they're being attached to. Durations from the probe cache are used if the file is
+unchanged since it was last probed.
+*/
+func probeDuration(ctx context.Context, ffprobePath, path string) (float64, error) {
+	if cached, ok := runProbeCache.duration(path); ok {
+		log.Debugf(`(sync/probeDuration) using cached duration for: "%s"`, path)
+		return cached, nil
+	}
+
+	// Command being fired:
+	// `ffprobe -v error -show_entries format=duration -of csv=p=0 <file>`
+	output, err := commons.ToolCommand(
+		ctx,
+		ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "csv=p=0",
+		path,
+	).Output()
+
+	if err != nil {
+		log.Debugf(
+			"(sync/probeDuration) failed to probe file: \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+
+		return 0, err
+	}
+
+	// Localized builds may use a comma as the decimal separator
+	value := strings.ReplaceAll(strings.TrimSpace(string(output)), ",", ".")
+	duration, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Debugf(
+			"(sync/probeDuration) unexpected output for file: \"%s\" \noutput: %s",
+			path,
+			output,
+		)
+
+		return 0, err
+	}
+
+	runProbeCache.update(path, func(entry *probeEntry) {
+		entry.Duration = &duration
+	})
+
+	return duration, nil
+}
+
+/*
+CheckSync compares the duration of each subtitle file against the duration of the
+media file, adding a warning to the summary for every subtitle file that differs by
+more than the threshold set by the user - typically a sign of subtitles being attached
+to the wrong media file.
+
+Returns true if no mismatch was detected. Files that can't be probed are skipped.
+*/
+func checkSync(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFile os.FileInfo,
+	subtitles []os.FileInfo,
+) bool {
+	if input.SyncThreshold <= 0 || len(subtitles) == 0 {
+		// Check disabled, or nothing to check
+		return true
+	}
+
+	mediaDuration, err := probeDuration(
+		ctx,
+		input.FFprobePath,
+		joinPath(sourceDir, mediaFile.Name()),
+	)
+
+	if err != nil {
+		return true
+	}
+
+	inSync := true
+	for _, sub := range subtitles {
+		subDuration, err := probeDuration(
+			ctx,
+			input.FFprobePath,
+			joinPath(sourceDir, sub.Name()),
+		)
+
+		if err != nil {
+			continue
+		}
+
+		if diff := math.Abs(mediaDuration - subDuration); diff > float64(
+			input.SyncThreshold,
+		) {
+			runSummary.warn(
+				`subtitles "%s" end %.0fs away from the end of "%s"`,
+				joinPath(sourceDir, sub.Name()),
+				diff,
+				mediaFile.Name(),
+			)
+
+			inSync = false
+		}
+	}
+
+	return inSync
+}
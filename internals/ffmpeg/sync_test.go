@@ -0,0 +1,129 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestProbeDuration(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	cmd := exec.Cmd{}
+	for output, result := range map[string]struct {
+		duration float64
+		fail     bool
+	}{
+		"1420.032000\n": {1420.032, false},
+		"24":            {24, false},
+		"N/A\n":         {0, true},
+		"":              {0, true},
+	} {
+		output := output // pin
+
+		monkey.PatchInstanceMethod(
+			reflect.TypeOf(&cmd),
+			"Output",
+			func(*exec.Cmd) ([]byte, error) { return []byte(output), nil },
+		)
+
+		duration, err := probeDuration(context.Background(), "ffprobe", "file.mkv")
+		if duration != result.duration || (err != nil) != result.fail {
+			t.Errorf(
+				"(sync/probeDuration) unexpected result \noutput: `%s` "+
+					"\nduration: %f \nerror: %v",
+				output,
+				duration,
+				err,
+			)
+		}
+	}
+
+	// Failure to run the command should be reported
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"Output",
+		func(*exec.Cmd) ([]byte, error) { return nil, errors.New("test error") },
+	)
+
+	ctx := context.Background()
+	if _, err := probeDuration(ctx, "ffprobe", "file.mkv"); err == nil {
+		t.Errorf("(sync/probeDuration) failure to run ffprobe not reported")
+	}
+}
+
+func TestCheckSync(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	testdata, _ := os.Getwd()
+	testdata = filepath.Join(filepath.Dir(filepath.Dir(testdata)), "testdata")
+
+	items, err := ioutil.ReadDir(filepath.Join(testdata, "test 01"))
+	if err != nil {
+		t.Fatalf("(sync/checkSync) unable to read testdata \nerror: %v", err)
+	}
+
+	var media os.FileInfo
+	var subs []os.FileInfo
+	for _, item := range items {
+		switch {
+		case checkExt(item.Name(), videoExt):
+			media = item
+		case checkExt(item.Name(), subsExt):
+			subs = append(subs, item)
+		}
+	}
+
+	// Media files are reported to be 1400 seconds long, subtitles 1000 seconds
+	cmd := exec.Cmd{}
+	monkey.PatchInstanceMethod(
+		reflect.TypeOf(&cmd),
+		"Output",
+		func(c *exec.Cmd) ([]byte, error) {
+			if checkExt(c.Args[len(c.Args)-1], videoExt) {
+				return []byte("1400.0"), nil
+			}
+
+			return []byte("1000.0"), nil
+		},
+	)
+
+	for threshold, inSync := range map[int]bool{
+		0:    true, // check disabled
+		300:  false,
+		500:  true,
+		-100: true,
+	} {
+		runSummary = &Summary{}
+		input := &commons.UserInput{SyncThreshold: threshold}
+
+		res := checkSync(
+			context.Background(),
+			filepath.Join(testdata, "test 01"),
+			input,
+			media,
+			subs,
+		)
+
+		if res != inSync || (len(runSummary.Warnings) == 0) != inSync {
+			t.Errorf(
+				"(sync/checkSync) unexpected result \nthreshold: %d "+
+					"\nexpected: %v \nresult: %v \nwarnings: [%s]",
+				threshold,
+				inSync,
+				res,
+				strings.Join(runSummary.Warnings, ", "),
+			)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ffmpeg
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+IOThrottle limits the read throughput of the FFmpeg processes in a run - the processes
+are moved into a cgroup sharing the limit (on Linux with cgroup v2), keeping the batch
+as a whole under the limit. The I/O priority of the processes is lowered instead if a
+cgroup can't be used, which gives way to other readers without enforcing the rate.
+*/
+type ioThrottle struct {
+	rate int64
+
+	// Path to the cgroup the processes are moved into, blank if not in use
+	cgroup string
+}
+
+// Throttle for the FFmpeg processes in the current run, nil if not limited
+var runThrottle *ioThrottle
+
+/*
+OpenThrottle sets up the throttle for a run, the limit applies to the device holding
+the root directory. Returns nil if the throughput isn't limited.
+*/
+func openThrottle(input *commons.UserInput) *ioThrottle {
+	if input.IOLimitRate <= 0 {
+		return nil
+	}
+
+	throttle := &ioThrottle{rate: input.IOLimitRate}
+
+	cgroup, err := createCgroup(input.RootPath, input.IOLimitRate)
+	if err != nil {
+		runSummary.warn(
+			"read throughput can't be limited (%v), lowering the I/O priority of "+
+				"FFmpeg instead",
+			err,
+		)
+
+		return throttle
+	}
+
+	log.Debugf(
+		`(throttle/openThrottle) read throughput limited to %d B/s through: "%s"`,
+		input.IOLimitRate,
+		cgroup,
+	)
+
+	throttle.cgroup = cgroup
+	return throttle
+}
+
+/*
+Limit applies the throttle to a process that just started, the I/O priority of the
+process is lowered if it can't be moved into the cgroup.
+*/
+func (throttle *ioThrottle) limit(process *os.Process) {
+	if throttle == nil || process == nil {
+		return
+	}
+
+	if throttle.cgroup != "" {
+		err := joinCgroup(throttle.cgroup, process.Pid)
+		if err == nil {
+			return
+		}
+
+		log.Debugf(
+			"(throttle/limit) failed to move process %d into the cgroup \nerror: %v",
+			process.Pid,
+			err,
+		)
+	}
+
+	if err := lowerIOPriority(process.Pid); err != nil {
+		log.Debugf(
+			"(throttle/limit) failed to lower I/O priority of process %d \nerror: %v",
+			process.Pid,
+			err,
+		)
+	}
+}
+
+/*
+Close removes the cgroup created for the run (if any), called once every FFmpeg process
+in the run has exited.
+*/
+func (throttle *ioThrottle) close() {
+	if throttle == nil || throttle.cgroup == "" {
+		return
+	}
+
+	if err := os.Remove(throttle.cgroup); err != nil {
+		log.Debugf(
+			"(throttle/close) failed to remove cgroup: \"%s\" \nerror: %v",
+			throttle.cgroup,
+			err,
+		)
+	}
+}
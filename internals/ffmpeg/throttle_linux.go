@@ -0,0 +1,185 @@
+//go:build linux
+// +build linux
+
+package ffmpeg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Paths read (and written) while setting up a cgroup, replaced in tests
+var (
+	cgroupMount = "/sys/fs/cgroup"
+	selfCgroup  = "/proc/self/cgroup"
+	blockDevs   = "/sys/dev/block"
+)
+
+// Lowest priority in the best-effort I/O scheduling class - the idle class is avoided,
+// a busy disk would starve FFmpeg until the encode is killed as stalled
+const (
+	ioprioWhoProcess  = 1
+	ioprioClassBE     = 2
+	ioprioClassShift  = 13
+	ioprioLowestLevel = 7
+)
+
+/*
+CreateCgroup creates a cgroup (v2) next to the cgroup of the application, limiting the
+read throughput from the block device holding the path - the `io` controller is
+enabled in the parent cgroup if required. Returns the path to the cgroup created.
+
+Network file systems aren't backed by a block device, these can't be limited.
+*/
+func createCgroup(path string, rate int64) (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroup v2 is not mounted")
+	}
+
+	device, err := blockDevice(path)
+	if err != nil {
+		return "", err
+	}
+
+	own, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+
+	// Processes can't be placed in a cgroup with children, the cgroup is created as
+	// a sibling of the cgroup of the application
+	parent := filepath.Join(cgroupMount, filepath.Dir(own))
+	controllers, _ := ioutil.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if !containsField(string(controllers), "io") {
+		err = ioutil.WriteFile(
+			filepath.Join(parent, "cgroup.subtree_control"),
+			[]byte("+io"),
+			0644,
+		)
+
+		if err != nil {
+			return "", fmt.Errorf("io controller can't be enabled: %v", err)
+		}
+	}
+
+	cgroup := filepath.Join(parent, fmt.Sprintf("auto-sub-%d", os.Getpid()))
+	if err = os.Mkdir(cgroup, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+
+	limit := fmt.Sprintf("%s rbps=%d", device, rate)
+	err = ioutil.WriteFile(filepath.Join(cgroup, "io.max"), []byte(limit), 0644)
+	if err != nil {
+		_ = os.Remove(cgroup)
+		return "", fmt.Errorf("limit can't be set: %v", err)
+	}
+
+	return cgroup, nil
+}
+
+/*
+BlockDevice returns the block device (as `major:minor`) holding a path, partitions are
+resolved to the disk they belong to - limits are set on disks alone.
+*/
+func blockDevice(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", errors.New("device of the root directory is unknown")
+	}
+
+	dev := uint64(stat.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+
+	// Anonymous devices (major zero) back network and virtual file systems
+	if major == 0 {
+		return "", errors.New("root directory is not on a block device")
+	}
+
+	device := fmt.Sprintf("%d:%d", major, minor)
+	sysfs, err := filepath.EvalSymlinks(filepath.Join(blockDevs, device))
+	if err != nil {
+		return device, nil
+	}
+
+	// Partitions are placed inside the directory for their disk
+	if _, err = os.Stat(filepath.Join(sysfs, "partition")); err == nil {
+		disk, err := ioutil.ReadFile(filepath.Join(filepath.Dir(sysfs), "dev"))
+		if err == nil {
+			device = strings.TrimSpace(string(disk))
+		}
+	}
+
+	return device, nil
+}
+
+// OwnCgroup returns the path to the cgroup (v2) of the application, within the mount
+func ownCgroup() (string, error) {
+	file, err := os.Open(selfCgroup)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	// Entry for cgroup v2 is of the form `0::<path>`
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(line, "0::"), nil
+		}
+	}
+
+	return "", errors.New("application is not in a cgroup v2")
+}
+
+// JoinCgroup moves a process into a cgroup
+func joinCgroup(cgroup string, pid int) error {
+	return ioutil.WriteFile(
+		filepath.Join(cgroup, "cgroup.procs"),
+		[]byte(strconv.Itoa(pid)),
+		0644,
+	)
+}
+
+/*
+LowerIOPriority moves a process to the lowest best-effort I/O priority (as
+`ionice -c 2 -n 7` would) - honored by the schedulers for local disks alone.
+*/
+func lowerIOPriority(pid int) error {
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOPRIO_SET,
+		ioprioWhoProcess,
+		uintptr(pid),
+		ioprioClassBE<<ioprioClassShift|ioprioLowestLevel,
+	)
+
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// ContainsField checks if a space separated list contains the field supplied
+func containsField(list, field string) bool {
+	for _, item := range strings.Fields(list) {
+		if item == field {
+			return true
+		}
+	}
+
+	return false
+}
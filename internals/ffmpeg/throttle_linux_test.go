@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package ffmpeg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+)
+
+func TestCreateCgroup(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer func(mount, self string) {
+		cgroupMount, selfCgroup = mount, self
+	}(cgroupMount, selfCgroup)
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(throttle/createCgroup) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// Fake cgroup hierarchy, with the application in `/user.slice/app.scope`
+	cgroupMount = filepath.Join(dir, "cgroup")
+	selfCgroup = filepath.Join(dir, "self")
+	parent := filepath.Join(cgroupMount, "user.slice")
+	for path, contents := range map[string]string{
+		selfCgroup: "0::/user.slice/app.scope\n",
+		filepath.Join(cgroupMount, "cgroup.controllers"): "cpu io",
+		filepath.Join(parent, "cgroup.subtree_control"):  "cpu",
+	} {
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("(throttle/createCgroup) failed to create file: %v", err)
+		}
+	}
+
+	monkey.Patch(blockDevice, func(string) (string, error) { return "8:0", nil })
+
+	cgroup, err := createCgroup("/root", 50<<20)
+	expected := filepath.Join(parent, fmt.Sprintf("auto-sub-%d", os.Getpid()))
+
+	if err != nil || cgroup != expected {
+		t.Fatalf(
+			"(throttle/createCgroup) unexpected cgroup \nexpected: %s \nfound: %s"+
+				"\nerror: %v",
+			expected,
+			cgroup,
+			err,
+		)
+	}
+
+	for path, contents := range map[string]string{
+		filepath.Join(parent, "cgroup.subtree_control"): "+io",
+		filepath.Join(cgroup, "io.max"):                 "8:0 rbps=52428800",
+	} {
+		if res, _ := ioutil.ReadFile(path); string(res) != contents {
+			t.Errorf(
+				"(throttle/createCgroup) unexpected contents for %s \nexpected: %s"+
+					"\nfound: %s",
+				path,
+				contents,
+				res,
+			)
+		}
+	}
+
+	if err = joinCgroup(cgroup, 42); err != nil {
+		t.Errorf("(throttle/joinCgroup) unexpected error: %v", err)
+	}
+
+	if res, _ := ioutil.ReadFile(filepath.Join(cgroup, "cgroup.procs")); string(res) !=
+		"42" {
+		t.Errorf("(throttle/joinCgroup) process not moved: %s", res)
+	}
+
+	// Cgroup v2 should be mounted
+	cgroupMount = filepath.Join(dir, "missing")
+	if _, err = createCgroup("/root", 50<<20); err == nil {
+		t.Errorf("(throttle/createCgroup) cgroup created without cgroup v2")
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package ffmpeg
+
+import "errors"
+
+// Error returned on platforms without cgroups (or I/O priorities)
+var errNoThrottle = errors.New("not supported on this platform")
+
+// CreateCgroup fails right away, cgroups are specific to Linux
+func createCgroup(string, int64) (string, error) {
+	return "", errNoThrottle
+}
+
+// JoinCgroup fails right away, cgroups are specific to Linux
+func joinCgroup(string, int) error {
+	return errNoThrottle
+}
+
+// LowerIOPriority fails right away, I/O priorities are specific to Linux
+func lowerIOPriority(int) error {
+	return errNoThrottle
+}
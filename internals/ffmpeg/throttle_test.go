@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestIOThrottle(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer func(original *Summary) { runSummary = original }(runSummary)
+
+	runSummary = &Summary{}
+
+	// Throughput isn't limited by default
+	if throttle := openThrottle(&commons.UserInput{}); throttle != nil {
+		t.Errorf("(throttle/openThrottle) throttle opened without a limit")
+	}
+
+	var joined, lowered []int
+	monkey.Patch(joinCgroup, func(_ string, pid int) error {
+		joined = append(joined, pid)
+		return nil
+	})
+
+	monkey.Patch(lowerIOPriority, func(pid int) error {
+		lowered = append(lowered, pid)
+		return nil
+	})
+
+	// Processes are moved into the cgroup, if one could be created
+	monkey.Patch(createCgroup, func(string, int64) (string, error) {
+		return "/sys/fs/cgroup/auto-sub-1", nil
+	})
+
+	input := &commons.UserInput{RootPath: "/root", IOLimitRate: 50 << 20}
+	throttle := openThrottle(input)
+	throttle.limit(&os.Process{Pid: 42})
+	if len(joined) != 1 || joined[0] != 42 || len(lowered) != 0 {
+		t.Errorf("(throttle/limit) process not moved into the cgroup")
+	}
+
+	// I/O priority is lowered instead if the cgroup can't be created, with a warning
+	monkey.Patch(createCgroup, func(string, int64) (string, error) {
+		return "", errors.New("cgroup v2 is not mounted")
+	})
+
+	throttle = openThrottle(input)
+	throttle.limit(&os.Process{Pid: 43})
+	throttle.close()
+	if len(lowered) != 1 || lowered[0] != 43 || len(runSummary.Warnings) != 1 ||
+		!strings.Contains(runSummary.Warnings[0], "cgroup v2 is not mounted") {
+		t.Errorf(
+			"(throttle/limit) I/O priority not lowered \nwarnings: %v",
+			runSummary.Warnings,
+		)
+	}
+
+	// Nil throttle is a no-op
+	var none *ioThrottle
+	none.limit(&os.Process{Pid: 44})
+	none.close()
+}
@@ -0,0 +1,92 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+JobTimeout returns the time allowed for a source directory to be processed, scaled by
+the duration of its media files - `duration × factor + base`. Short episodes fail fast,
+while multi-hour movies get the time they need. Durations are capped to the sample in
+sample mode.
+
+No timeout is applied (zero is returned) if disabled, or if the duration of any of the
+media files is unknown - a guess could kill a healthy encode.
+*/
+func jobTimeout(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFiles []os.FileInfo,
+) time.Duration {
+	if input.TimeoutFactor <= 0 && input.TimeoutBase <= 0 {
+		return 0
+	}
+
+	total := 0.0
+	if input.TimeoutFactor > 0 {
+		for _, mediaFile := range mediaFiles {
+			path := joinPath(sourceDir, mediaFile.Name())
+			duration, err := probeDuration(ctx, input.FFprobePath, path)
+			if err != nil || duration <= 0 {
+				log.Debugf(
+					`(timeout/jobTimeout) duration unknown for "%s", no timeout`+
+						"\nerror: %v",
+					path,
+					err,
+				)
+
+				return 0
+			}
+
+			if input.Sample > 0 {
+				duration = math.Min(duration, float64(input.Sample))
+			}
+
+			total += duration
+		}
+	}
+
+	seconds := total*input.TimeoutFactor + float64(input.TimeoutBase)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+/*
+WithJobTimeout derives the context for a source directory from the context of the run,
+cancelled once the source directory runs past its timeout (if any). The cancel function
+should be called once the source directory is processed.
+*/
+func withJobTimeout(
+	ctx context.Context,
+	sourceDir string,
+	input *commons.UserInput,
+	mediaFiles []os.FileInfo,
+) (context.Context, context.CancelFunc) {
+	timeout := jobTimeout(ctx, sourceDir, input, mediaFiles)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	log.Debugf(
+		`(timeout/withJobTimeout) timeout for source directory "%s": %v`,
+		sourceDir,
+		timeout,
+	)
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+/*
+TimedOut checks if a context was cancelled for running past its timeout, as opposed to
+the run being cancelled by the user
+*/
+func timedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,110 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestJobTimeout(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	durations := map[string]float64{
+		"/root/dir/episode.mkv": 1440,
+		"/root/dir/movie.mkv":   7200,
+	}
+
+	monkey.Patch(
+		probeDuration,
+		func(_ context.Context, _, path string) (float64, error) {
+			if duration, ok := durations[path]; ok {
+				return duration, nil
+			}
+
+			return 0, errors.New("test error")
+		},
+	)
+
+	files := func(names ...string) (res []os.FileInfo) {
+		for _, name := range names {
+			res = append(res, nestedFile{name: name})
+		}
+
+		return res
+	}
+
+	for _, test := range []struct {
+		name     string
+		input    commons.UserInput
+		files    []os.FileInfo
+		expected time.Duration
+	}{
+		{"disabled", commons.UserInput{}, files("episode.mkv"), 0},
+		{
+			"scaled",
+			commons.UserInput{TimeoutFactor: 0.5, TimeoutBase: 60},
+			files("episode.mkv", "movie.mkv"),
+			(4320 + 60) * time.Second,
+		},
+		{
+			"sample",
+			commons.UserInput{TimeoutFactor: 2, Sample: 30},
+			files("movie.mkv"),
+			60 * time.Second,
+		},
+		{
+			"base only",
+			commons.UserInput{TimeoutBase: 600},
+			files("missing.mkv"),
+			600 * time.Second,
+		},
+		{
+			"duration unknown",
+			commons.UserInput{TimeoutFactor: 1, TimeoutBase: 600},
+			files("episode.mkv", "missing.mkv"),
+			0,
+		},
+	} {
+		res := jobTimeout(context.Background(), "/root/dir", &test.input, test.files)
+		if res != test.expected {
+			t.Errorf(
+				"(timeout/jobTimeout) unexpected timeout for %s \nexpected: %v"+
+					"\nfound: %v",
+				test.name,
+				test.expected,
+				res,
+			)
+		}
+	}
+
+	// Contexts without a timeout are only cancelled with the run
+	input := &commons.UserInput{TimeoutBase: 600}
+	ctx, cancel := withJobTimeout(context.Background(), "/root/dir", input, nil)
+	if _, ok := ctx.Deadline(); !ok {
+		t.Errorf("(timeout/withJobTimeout) deadline not set for the source directory")
+	}
+
+	cancel()
+	if timedOut(ctx) {
+		t.Errorf("(timeout/timedOut) cancelled context treated as timed out")
+	}
+
+	input = &commons.UserInput{}
+	ctx, cancel = withJobTimeout(context.Background(), "/root/dir", input, nil)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("(timeout/withJobTimeout) deadline set without a timeout")
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	if !timedOut(expired) {
+		t.Errorf("(timeout/timedOut) expired context not treated as timed out")
+	}
+}
@@ -0,0 +1,106 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+UndoCandidates returns the files created by a run that can be removed to undo the run,
+i.e. files that are unchanged since the run - along with the files that were modified
+since, and the files that replaced a file existing before the run (these are never
+removed). Files that no longer exist are left out.
+*/
+func UndoCandidates(record HistoryRecord) (
+	unchanged []CreatedFile,
+	modified,
+	replaced []string,
+) {
+	for _, file := range record.Created {
+		item, err := os.Stat(file.Path)
+		switch {
+		case os.IsNotExist(err):
+			continue
+
+		case file.Replaced:
+			replaced = append(replaced, file.Path)
+
+		case err != nil || item.IsDir() || item.Size() != file.Size ||
+			item.ModTime().UnixNano() != file.ModTime:
+			modified = append(modified, file.Path)
+
+		default:
+			unchanged = append(unchanged, file)
+		}
+	}
+
+	return unchanged, modified, replaced
+}
+
+/*
+ResultRoot returns the directory holding the outputs of a run - the root directory for
+runs recorded by earlier versions.
+*/
+func (record HistoryRecord) ResultRoot() string {
+	if record.ResultDir != "" {
+		return record.ResultDir
+	}
+
+	return record.Root
+}
+
+/*
+RemoveCreated removes (or moves to the trash) the files created by a run, along with
+the directories left empty by their removal within the result root - returns the number
+of files removed, and the first error encountered. A failure does not stop the
+remaining files from being removed.
+*/
+func RemoveCreated(files []CreatedFile, root string) (removed int, err error) {
+	for _, file := range files {
+		if removeErr := commons.Discard(file.Path); removeErr != nil {
+			log.Warnf(
+				"(undo/RemoveCreated) failed to remove \"%s\" \nerror: %v",
+				file.Path,
+				removeErr,
+			)
+
+			if err == nil {
+				err = removeErr
+			}
+
+			continue
+		}
+
+		removed++
+		removeEmptyDirs(filepath.Dir(file.Path), root)
+	}
+
+	return removed, err
+}
+
+/*
+RemoveEmptyDirs removes the directory if empty, moving up to the parent directories
+until a directory that isn't empty (or can't be removed) is found - the root and the
+directories outside it are never removed.
+*/
+func removeEmptyDirs(dir, root string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if root == "" || err != nil || rel == "." || rel == ".." ||
+			strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+
+		// Removing a directory that isn't empty fails, nothing else is required
+		if err = os.Remove(dir); err != nil {
+			return
+		}
+
+		log.Debugf(`(undo/removeEmptyDirs) removed empty directory: "%s"`, dir)
+		dir = filepath.Dir(dir)
+	}
+}
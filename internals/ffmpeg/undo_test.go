@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoCandidates(t *testing.T) {
+	defer func(summary *Summary) { runSummary = summary }(runSummary)
+	runSummary = &Summary{}
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(undo/UndoCandidates) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	resDir := filepath.Join(dir, "res", "show")
+	_ = os.MkdirAll(resDir, 0755)
+	_ = ioutil.WriteFile(filepath.Join(dir, "source.mkv"), []byte{}, 0644)
+
+	for _, name := range []string{"01.mkv", "02.mkv", "03.mkv"} {
+		path := filepath.Join(resDir, name)
+		if err = ioutil.WriteFile(path, []byte("output"), 0644); err != nil {
+			t.Fatalf("(undo/UndoCandidates) failed to create file: %v", err)
+		}
+
+		runSummary.created(path)
+	}
+
+	// Outputs replacing a file that existed before the run are never removed
+	replacedPath := filepath.Join(resDir, "04.mkv")
+	_ = ioutil.WriteFile(replacedPath, []byte("existing"), 0644)
+	runSummary.replacing(replacedPath)
+	runSummary.created(replacedPath)
+
+	// Files modified since the run are left out, missing files are ignored
+	modifiedPath := filepath.Join(resDir, "02.mkv")
+	_ = ioutil.WriteFile(modifiedPath, []byte("modified output"), 0644)
+	_ = os.Remove(filepath.Join(resDir, "03.mkv"))
+
+	record := HistoryRecord{Created: runSummary.Created}
+	files, modified, replaced := UndoCandidates(record)
+	if len(files) != 1 || files[0].Path != filepath.Join(resDir, "01.mkv") ||
+		len(modified) != 1 || modified[0] != modifiedPath ||
+		len(replaced) != 1 || replaced[0] != replacedPath {
+		t.Errorf(
+			"(undo/UndoCandidates) unexpected candidates \nfiles: %v \nmodified: %v"+
+				" \nreplaced: %v",
+			files,
+			modified,
+			replaced,
+		)
+	}
+
+	// Directories left empty are removed along with the files, up to the result root
+	_ = os.Remove(modifiedPath)
+	_ = os.Remove(replacedPath)
+	root := filepath.Join(dir, "res")
+	if removed, err := RemoveCreated(files, root); removed != 1 || err != nil {
+		t.Errorf(
+			"(undo/RemoveCreated) unexpected result \nremoved: %d \nerror: %v",
+			removed,
+			err,
+		)
+	}
+
+	if _, err = os.Stat(resDir); !os.IsNotExist(err) {
+		t.Errorf("(undo/RemoveCreated) empty directories not removed \nerror: %v", err)
+	}
+
+	if _, err = os.Stat(root); err != nil {
+		t.Errorf("(undo/RemoveCreated) result root removed: %v", err)
+	}
+
+	// Directories outside the result root are never removed
+	outside := filepath.Join(dir, "outside")
+	_ = os.MkdirAll(outside, 0755)
+	removeEmptyDirs(outside, root)
+	if _, err = os.Stat(outside); err != nil {
+		t.Errorf("(undo/removeEmptyDirs) directory outside the root removed: %v", err)
+	}
+}
@@ -1,11 +1,10 @@
 package ffmpeg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -23,25 +22,52 @@ const (
 	// Error code to indicate failure, used specifically by `convertor()`
 	convFail = -1
 
-	// Maximum number of characters in a string returned by `trimString`
+	// Maximum width (in columns) of a string returned by `trimString`
 	strTrimLen = 48
+
+	// Columns taken up by the rest of the line containing the progress bar, and the
+	// limits for the length of the progress bar
+	pbMargin = 40
+	pbMinLen = 10
+	pbMaxLen = 100
+
+	// Weight of the current frame rate in the moving average, higher values follow
+	// changes in the speed faster at the cost of a jumpier ETA
+	fpsSmoothing = 0.3
+
+	// Gap between two ticks beyond which the system is taken to have been asleep (or
+	// the clock to have been changed) - ticks are a few seconds apart at most otherwise
+	sleepGap = 30 * time.Second
+
+	// Gaps between two progress updates by default; short on a terminal, fast remuxes
+	// complete in a second or two - long with porcelain output (the dialog is printed
+	// once the encode ends) or while logging, the checkpoint and the metrics are all
+	// that is updated in between
+	terminalInterval = 250 * time.Millisecond
+	quietInterval    = 5 * time.Second
+
+	// Shortest gap allowed between two progress updates
+	minInterval = 50 * time.Millisecond
+
+	// Shortest window the current frame rate is measured over, FFmpeg reports the
+	// progress twice a second - ticks in between would see no frames processed
+	fpsWindow = time.Second
 )
 
 // Counter to keep a track of template animation progress across method calls.
 var tempAnimationProgress = 0
 
+// Source of the terminal width - a variable to allow tests to imitate any terminal
+var terminalWidth = commons.TerminalWidth
+
 /*
-Compiled regex patterns to extract progress from FFmpeg command
+Compiled regex pattern to extract the frame count from the output of an FFmpeg command,
+used to fetch the total frames present in a media file
 
 Important: Ensure that the group at `regexPos` is the one containing the value to be
 extracted, and the group should contain ONLY digits (not even floats)
 */
-//nolint:gocritic
-var (
-	regexFrames = regexp.MustCompile(`.*(\s+|^)frame=\s*(\d+)`)
-	regexFps    = regexp.MustCompile(`.*(\s+|^)fps=\s*(\d+)`)
-	regexSize   = regexp.MustCompile(`.*(\s+|^)L?size=\s*(\d+)`)
-)
+var regexFrames = regexp.MustCompile(`(?i).*(\s+|^)frame=\s*(\d+)`)
 
 /*
 Updates is a simple structure that acts as an easy-abstraction for the main thread to
@@ -54,10 +80,14 @@ progress of an ongoing command, providing updates to the screen for the same.
 Use the method Updates.Initialize() to have the structure fetch the number of frames
 present in the media file.
 
-Use the method Updates.DisplayUpdates() as a goroutine to use the structure display
-the progress of an ongoing encode on the screen.
+Use the method Updates.DisplayUpdates() as a goroutine to use the structure to deliver
+the progress of an ongoing encode to the reporter in use.
 */
 type Updates struct {
+	// Context for the run, cancels the FFmpeg command used to fetch the frame count.
+	// Background context is used if not set.
+	ctx context.Context
+
 	// Input passed by the user
 	userInput *commons.UserInput
 
@@ -65,17 +95,53 @@ type Updates struct {
 	fileName  string // Name of the media file
 	sourceDir string // Path to the source directory
 	resDir    string // Path to the result directory
+	output    string // Full path to the file being written
 
 	// Total frames present in media file; use `Initialize()` method to set its value
 	totalFrames int64
+
+	// Duration of the media file, the progress is based on the duration encoded if
+	// the frame count is unknown. Set by `Initialize()` as well
+	duration time.Duration
+
+	// FFmpeg process being tracked - killed if it stalls and stalled jobs are to be
+	// killed. Can be nil, in which case stalled jobs are only reported.
+	process *os.Process
+
+	// Highest frame count seen so far, and the time at which it was seen. Used to
+	// detect stalls, i.e. the frame count remaining unchanged for too long.
+	lastFrames   int64
+	lastProgress time.Time
+
+	// Indicates if the encode stalled at some point
+	stalled bool
+
+	// Time of the last tick, used to detect the system resuming from sleep
+	lastTick time.Time
+
+	// Frames processed at the last tick and the time of the tick, used to measure the
+	// current frame rate - smoothed into a moving average, used for the ETA
+	tickFrames  int64
+	tickTime    time.Time
+	currentFPS  float64
+	smoothedFPS float64
+
+	// Reason the command failed, set by the main thread before the goroutine is
+	// stopped. Nil if the command completed successfully
+	failure error
 }
 
 /*
 Initialize is a simple helper function designed to fetch the total number of frames
-present in the destination media file implicitly.
+present in the destination media file implicitly - the frame count from the probe cache
+is used if the file is unchanged since it was last probed. If the frame count can't be
+determined, the duration of the media file is fetched instead.
 */
 func (update *Updates) Initialize() {
-	if frames, err := update.getTotalFrames(update.filePath); err != nil {
+	update.duration = 0
+	if frames, ok := runProbeCache.frames(update.filePath); ok {
+		update.totalFrames = frames
+	} else if frames, err := update.frameCount(update.filePath); err != nil {
 		log.Debugf(
 			`(updates/Initialize) unable to fetch frame count for file "%s"`+
 				"\nerror: %v",
@@ -84,12 +150,25 @@ func (update *Updates) Initialize() {
 		)
 
 		update.totalFrames = 0 // default value in case of failure
+		update.duration = update.mediaDuration()
 	} else {
 		update.totalFrames = frames
+		runProbeCache.update(update.filePath, func(entry *probeEntry) {
+			entry.Frames = &frames
+		})
 	}
 
-	// Reset this counter, ensures the template animation also starts from scratch.
-	tempAnimationProgress = 0
+	// Stall detection starts from scratch as well
+	update.lastFrames = 0
+	update.lastProgress = time.Now()
+	update.stalled = false
+	update.lastTick = time.Time{}
+
+	// Frame rates as well
+	update.tickFrames = 0
+	update.tickTime = time.Time{}
+	update.currentFPS = 0
+	update.smoothedFPS = 0
 }
 
 /*
@@ -97,8 +176,10 @@ DisplayUpdates is the main interface for the structure to the rest of the applic
 this method should be fired as a goroutine to independently track the progress of
 a command running in the background.
 
-The contents from `stderr` of the running command should be redirected to the `buffer`
-object supplied as a parameter to this function.
+The progress of the running command is read from the source supplied as a parameter
+to this function, for FFmpeg, this is the parser reading the `-progress` output. The
+progress is delivered to the reporter in use as events over a channel, the terminal
+renderer displays the progress on the screen.
 
 The interrupt channel is used as a two-way stream between the main thread and this
 method.
@@ -106,45 +187,44 @@ method.
 The main thread should fire a signal on the channel when the command completes
 its execution. Once the signal is received, this method will then internally
 complete its own operation(s) and fire the signal (again) to indicate that the main
-thread can move on.
+thread can move on - once every event has been delivered.
 */
-func (update *Updates) DisplayUpdates(buffer *strings.Builder, interrupt chan bool) {
-	// Integer to keep a track of the number of lines to move up. The value will be
-	// updated every time an update is made on the screen.
-	lineCount := 0
-
-	ticker := time.NewTicker(time.Second)
-	for range ticker.C {
-		// Extract frames processed, FPS and current output size from the buffer.
-		frames, fps, size := update.extractData(buffer)
-
-		// Depending on the values fetched, set the contents of the progress message
-		var progress string
-		if frames == convFail && fps == convFail && size == convFail {
-			// Let the user know something went wrong; unlikely scenario.
-			progress = "If you're seeing this message, something broke :(" +
-				"\nPlease file a bug report!"
-		} else {
-			progress = update.getProgress(
-				frames,
-				fps,
-				size,
-			)
-		}
+func (update *Updates) DisplayUpdates(source progressSource, interrupt chan bool) {
+	reporter := runReporter
+	if reporter == nil {
+		reporter = newTerminalReporter(update.userInput)
+	}
 
-		// Make the cursor jump `lineCount` lines up - if any error were to occur,
-		// the flow-of-control will not reach here.
-		jumpCursor(lineCount)
+	events := make(chan ProgressEvent, eventBuffer)
+	delivered := make(chan bool)
+	go deliverEvents(reporter, events, delivered)
 
-		// Print progress dialog
-		commons.Printf(progress)
+	events <- update.event(EventStart, progressState{}, "")
+	runCheckpoint.encoding(update.filePath, update.totalFrames)
 
-		// Count the number of newline(s) present in the string - will be used in the
-		// next iteration of the loop
-		lineCount = strings.Count(progress, "\n")
+	ticker := time.NewTicker(statsInterval(update.userInput))
+	defer ticker.Stop()
 
-		// Clear the buffer - ensures only the latest updates are present in the buffer
-		buffer.Reset()
+	for range ticker.C {
+		// Fetch frames processed, FPS and current output size from the source.
+		state, reported := source.latest()
+		now := time.Now()
+		update.resync(now)
+		update.measureFPS(state.frames, now)
+		runCheckpoint.progress(state.frames, now)
+		runMetrics.setFPS(update.smoothedFPS)
+
+		// Warn the user if the encode hasn't progressed for a while
+		warning := update.checkStall(state.frames, now)
+		if reported || warning != "" {
+			// Progress is dropped if the reporter can't keep up, the next update
+			// replaces it anyway
+			select {
+			case events <- update.event(EventProgress, state, warning):
+			default:
+				log.Debugf(`(Updates/DisplayUpdates) reporter busy, dropped update`)
+			}
+		}
 
 		select {
 		case <-interrupt:
@@ -154,20 +234,29 @@ func (update *Updates) DisplayUpdates(buffer *strings.Builder, interrupt chan bo
 			)
 
 			/*
-				Update value of the progress bar to display 100% completion (since the
-				goroutine runs at one-second interval, the last update could be from
-				one-second ago), pushing a final update to cover the edge-case
+				Push a final update with the latest values (the last update could be
+				from an interval ago) - on success, the progress is set to 100%
+				completion.
 			*/
-
-			// Use the total frame count, and fetch the final file size.
-			frames = update.totalFrames
-			size = update.getFileSize(filepath.Join(update.resDir, update.fileName))
-
-			// Have the cursor jump upwards (again).
-			jumpCursor(lineCount)
-
-			// Printing the latest values, FPS counter can remain unchanged
-			commons.Printf(update.getProgress(frames, fps, size) + "\n\n\n")
+			state, _ = source.latest()
+			if update.failure != nil {
+				event := update.event(EventError, state, "")
+				event.Err = update.failure
+				events <- event
+			} else {
+				// Use the total frame count, and fetch the final file size.
+				state.frames = update.totalFrames
+				state.outTime = update.duration.Microseconds()
+				if size := update.getFileSize(update.output); size >= 0 {
+					state.size = size
+				}
+
+				events <- update.event(EventComplete, state, "")
+			}
+
+			runMetrics.setFPS(0)
+			close(events)
+			<-delivered
 
 			log.Debugf(`(Updates/DisplayUpdates) killing the background thread`)
 			interrupt <- true // indicates the goroutine is done
@@ -180,30 +269,207 @@ func (update *Updates) DisplayUpdates(buffer *strings.Builder, interrupt chan bo
 }
 
 /*
-ExtractData is a helper function to extract values from the buffer input, and return
-the same to the calling method.
+StatsInterval returns the gap between two progress updates - the interval set by the
+user, or a default for the output in use. Intervals too short are raised to the
+minimum.
 */
-//nolint:interfacer // stupid suggestion
-func (update *Updates) extractData(buffer *strings.Builder) (
-	curFrames,
-	curFps,
-	curSize int64,
-) {
-	// Fetch updates from the buffer
-	bufString := buffer.String()
-	if res := regexFrames.FindSubmatch([]byte(bufString)); len(res) >= regexPos {
-		curFrames = update.convertor(string(res[regexPos]))
+func statsInterval(input *commons.UserInput) time.Duration {
+	if input == nil {
+		return terminalInterval
 	}
 
-	if res := regexFps.FindSubmatch([]byte(bufString)); len(res) >= regexPos {
-		curFps = update.convertor(string(res[regexPos]))
+	interval := time.Duration(input.StatsInterval * float64(time.Second))
+	switch {
+	case input.StatsInterval <= 0 && (input.Porcelain || input.Logging):
+		return quietInterval
+	case input.StatsInterval <= 0:
+		return terminalInterval
+	case interval < minInterval:
+		return minInterval
 	}
 
-	if res := regexSize.FindSubmatch([]byte(bufString)); len(res) >= regexPos {
-		curSize = update.convertor(string(res[regexPos])) * 1000 // convert kB to bytes
+	return interval
+}
+
+/*
+Event forms the event for the reporter from the progress of the encode
+*/
+func (update *Updates) event(
+	kind EventKind,
+	state progressState,
+	warning string,
+) ProgressEvent {
+	return ProgressEvent{
+		Kind:        kind,
+		File:        update.filePath,
+		Output:      update.output,
+		Frames:      state.frames,
+		TotalFrames: update.totalFrames,
+		FPS:         state.fps,
+		CurrentFPS:  update.currentFPS,
+		AverageFPS:  update.smoothedFPS,
+		ETA:         update.eta(kind, state.frames),
+		Size:        state.size,
+		Encoded:     time.Duration(state.outTime) * time.Microsecond,
+		Duration:    update.duration,
+		Warning:     warning,
 	}
+}
 
-	return curFrames, curFps, curSize
+/*
+Resync detects the system resuming from sleep (or the clock being changed) through the
+gap since the last tick, comparing both the wall clock and the monotonic clock - the
+latter stops during sleep on some platforms. The time spent asleep is not counted
+towards the stall timeout, or the frame rate used for the ETA; the encode continues
+from the progress FFmpeg reports once it resumes.
+
+Returns true if the progress was resynced.
+*/
+func (update *Updates) resync(now time.Time) bool {
+	last := update.lastTick
+	update.lastTick = now
+	if last.IsZero() {
+		return false
+	}
+
+	gap := now.Sub(last)
+	if wall := now.Round(0).Sub(last.Round(0)); wall > gap {
+		gap = wall
+	}
+
+	if gap < sleepGap {
+		return false
+	}
+
+	log.Debugf(
+		`(updates/resync) clock jumped by %v, resyncing progress: "%s"`,
+		gap.Truncate(time.Second),
+		update.filePath,
+	)
+
+	// Stall timeout starts over, and the frame rate is measured from the next tick
+	update.lastProgress = now
+	update.tickTime = time.Time{}
+
+	return true
+}
+
+/*
+MeasureFPS measures the frame rate since the last tick from the frames processed, and
+folds it into the moving average. FFmpeg only reports the average over the entire
+encode, which is slow to reflect changes in the speed.
+*/
+func (update *Updates) measureFPS(frames int64, now time.Time) {
+	elapsed := now.Sub(update.tickTime).Seconds()
+	if update.tickTime.IsZero() || elapsed <= 0 {
+		update.tickFrames, update.tickTime = frames, now
+		return
+	}
+
+	// Frames are counted over a window spanning several short ticks
+	if elapsed < fpsWindow.Seconds() {
+		return
+	}
+
+	processed := frames - update.tickFrames
+	if processed < 0 {
+		processed = 0
+	}
+
+	update.currentFPS = float64(processed) / elapsed
+	if update.smoothedFPS == 0 {
+		update.smoothedFPS = update.currentFPS
+	} else {
+		update.smoothedFPS = fpsSmoothing*update.currentFPS +
+			(1-fpsSmoothing)*update.smoothedFPS
+	}
+
+	update.tickFrames, update.tickTime = frames, now
+}
+
+/*
+ETA estimates the time remaining for the encode from the smoothed frame rate, returns
+zero if the estimate isn't possible (or the encode has ended)
+*/
+func (update *Updates) eta(kind EventKind, frames int64) time.Duration {
+	if kind != EventProgress || update.smoothedFPS <= 0 ||
+		update.totalFrames <= frames {
+		return 0
+	}
+
+	seconds := float64(update.totalFrames-frames) / update.smoothedFPS
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
+
+/*
+MediaDuration fetches the duration of the media file, returns zero if the duration
+can't be determined
+*/
+func (update *Updates) mediaDuration() time.Duration {
+	ctx := update.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	seconds, err := probeDuration(ctx, update.userInput.FFprobePath, update.filePath)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+/*
+CheckStall compares the frame count against the highest frame count seen so far, to
+detect if the encode has stalled - for example, due to a hung input. Returns a warning
+to be displayed on the screen if the frame count has not changed within the stall
+timeout, returns an empty string otherwise.
+
+The stall is added to the summary once; if stalled jobs are to be killed, the FFmpeg
+process is killed at the same time. Stall detection is disabled if the stall timeout
+is zero (or negative).
+*/
+func (update *Updates) checkStall(frames int64, now time.Time) string {
+	timeout := time.Duration(update.userInput.StallTimeout) * time.Second
+	if timeout <= 0 {
+		return ""
+	}
+
+	if frames > update.lastFrames {
+		update.lastFrames = frames
+		update.lastProgress = now
+
+		return ""
+	}
+
+	idle := now.Sub(update.lastProgress)
+	if idle < timeout {
+		return ""
+	}
+
+	if !update.stalled {
+		update.stalled = true
+		runSummary.warn(
+			`encode stalled at frame %d for over %v: "%s"`,
+			update.lastFrames,
+			timeout,
+			update.filePath,
+		)
+
+		if update.userInput.KillStalled && update.process != nil {
+			if err := update.process.Kill(); err != nil {
+				log.Debugf(
+					"(updates/checkStall) failed to kill stalled process \nerror: %v",
+					err,
+				)
+			}
+		}
+	}
+
+	return fmt.Sprintf(
+		"Warning: no progress for %v, FFmpeg might be stuck!",
+		idle.Truncate(time.Second),
+	)
 }
 
 /*
@@ -211,14 +477,14 @@ GetProgress is a simple method to generate the text to be printed on the screen,
 includes the name of the file being processed, progress bar depicting the current
 progress and other statistics required.
 */
-func (update *Updates) getProgress(
-	curFrames,
-	fps,
-	size int64,
-) (progress string) {
+func (update *Updates) getProgress(event ProgressEvent) (progress string) {
 	// Calculating current progress percentage
-	//nolint // again, stupid
-	curProgress := float32(curFrames*100) / float32(update.totalFrames)
+	curProgress := update.percent(event.Frames, event.Encoded)
+
+	eta := "-"
+	if event.ETA > 0 {
+		eta = event.ETA.String()
+	}
 
 	// String to pad the left of each line, increase/decrease number of spaces on left
 	padLeft := "  "
@@ -229,7 +495,7 @@ func (update *Updates) getProgress(
 
 	// String slice, each element being a line of the final progress dialog.
 	contents := []string{
-		fmt.Sprintf(`File: "%s"`, update.trimString(&update.fileName)),
+		commons.Sprintf(`File: "%s"`, update.trimString(&update.fileName)),
 
 		// The progress bar
 		fmt.Sprintf(
@@ -239,9 +505,17 @@ func (update *Updates) getProgress(
 		) + "%%",
 
 		"", // blank line
-		fmt.Sprintf("Frames Processed: %d", curFrames),
-		fmt.Sprintf("Average FPS: %d", fps),
-		fmt.Sprintf("Output Size: %s", update.readableFileSize(float64(size))),
+		commons.Sprintf("Frames Processed: %d", event.Frames),
+		commons.Sprintf(
+			"FPS: %.1f (current), %.1f (average)",
+			event.CurrentFPS,
+			event.AverageFPS,
+		),
+		commons.Sprintf("ETA: %s", eta),
+		commons.Sprintf(
+			"Output Size: %s",
+			update.readableFileSize(float64(event.Size)),
+		),
 	}
 
 	// Join string slice with a newline character, and return the same
@@ -249,14 +523,28 @@ func (update *Updates) getProgress(
 		strings.Join(contents, padRight+"\n"+padLeft)
 }
 
+/*
+Percent returns the progress of the encode as a percentage - from the frames processed,
+or the duration encoded if the frame count is unknown. The result is not finite if
+neither is known, which is displayed as such by the progress bar.
+*/
+func (update *Updates) percent(curFrames int64, encoded time.Duration) float32 {
+	if update.totalFrames <= 0 && update.duration > 0 {
+		return float32(encoded.Seconds()*100) / float32(update.duration.Seconds())
+	}
+
+	//nolint // again, stupid
+	return float32(curFrames*100) / float32(update.totalFrames)
+}
+
 /*
 ProgressBar generates a progress bar using the total frame count and the frames
 processed currently and returns the same to the calling function
 */
 func (update *Updates) progressBar(progress int) (progressBar string) {
-	// The length of the progress bar. Will be padded by a space and opening/closing
-	// character on both sides (i.e. four extra characters)
-	const pbLen = 40
+	// The length of the progress bar, sized to fit the terminal. Will be padded by a
+	// space and opening/closing character on both sides (i.e. four extra characters)
+	pbLen := barLength()
 
 	/*
 		Constant values used to draw the progress bar, should contain exactly one
@@ -374,12 +662,47 @@ func (*Updates) getFileSize(path string) int64 {
 }
 
 /*
-JumpCursor makes the cursor jump `count` lines vertically upwards.
+RenderedRows returns the number of rows taken up by a dialog printed on a terminal with
+the given width - lines wider than the terminal wrap into multiple rows. Tabs are
+expanded to the next multiple of eight columns, trailing whitespace is ignored.
+
+The dialog is expected to be in the form passed to `commons.Printf`, i.e. with `%%`
+in place of a percent sign.
+*/
+func renderedRows(dialog string, width int) (rows int) {
+	const tabWidth = 8
+
+	if width <= 0 {
+		width = 1
+	}
+
+	dialog = strings.ReplaceAll(dialog, "%%", "%")
+	for _, line := range strings.Split(dialog, "\n") {
+		columns := 0
+		for i, segment := range strings.Split(strings.TrimRight(line, " \t"), "\t") {
+			if i > 0 {
+				// Tabs move the cursor to the next tab stop
+				columns += tabWidth - columns%tabWidth
+			}
+
+			columns += commons.StringWidth(segment)
+		}
+
+		// Empty lines take up a row as well - integer division truncates towards zero
+		rows += 1 + (columns-1)/width
+	}
+
+	return rows
+}
+
+/*
+JumpCursor adds the escapes making the cursor jump `count` lines vertically upwards to
+the frame, the cursor moves once the frame is flushed.
 
 Note: The number of lines (`count`) should NOT be negative
 */
-func jumpCursor(count int) {
-	commons.Printf(
+func jumpCursor(frame *commons.Frame, count int) {
+	frame.Printf(
 		"%s%s",
 		escapes.CursorPosX(0),         // resets x-coordinate of cursor
 		escapes.CursorMove(0, -count), // moves `lineCount` lines up
@@ -399,10 +722,18 @@ func (update *Updates) getTotalFrames(mediaFile string) (frames int64, err error
 	// Basically, will use FFmpeg to copy the first video stream from input to `null`;
 	// ensuring that no copy actually takes place. The output produced by this command
 	// will be
-	cmd := exec.Command(
+	ctx := update.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cmd := commons.ToolCommand(
+		ctx,
 		update.userInput.FFmpegPath, // path to FFmpeg executable
 
-		// arguments for the command being fired
+		// arguments for the command being fired - only errors are logged, the
+		// statistics are printed regardless, keeping warnings away from the regex
+		"-hide_banner", "-loglevel", "error", "-stats",
 		"-i", mediaFile, "-map", "0:v:0", "-c", "copy", "-f", "null", "-",
 	)
 
@@ -455,31 +786,49 @@ func (update *Updates) getTotalFrames(mediaFile string) (frames int64, err error
 }
 
 /*
-TrimString trims the input string to fit a pre-determined length
+BarLength returns the length of the progress bar for the width of the terminal, within
+sensible limits - the bar is 40 characters long on a terminal 80 columns wide.
+*/
+func barLength() int {
+	length := terminalWidth() - pbMargin
+	switch {
+	case length < pbMinLen:
+		return pbMinLen
+	case length > pbMaxLen:
+		return pbMaxLen
+	}
+
+	return length
+}
+
+/*
+TrimString trims the input string to fit a pre-determined width
 
 Example:
 	fmt.Println("this string exceeds the max character limit :/")
 
 Will print "this string exceeds ....x character limit :/"
 
-The maximum number of characters allowed is a constant value.
+The maximum width allowed is a constant value, reduced further on narrow terminals.
+The width is measured in terminal columns - i.e. wide (CJK) characters take up two
+columns, and the string is never cut in the middle of a character.
 */
 func (*Updates) trimString(in *string) string {
-	if len(*in) <= strTrimLen {
+	limit := strTrimLen
+	if width := terminalWidth() - pbMargin/2; width >= pbMinLen && width < limit {
+		limit = width
+	}
+
+	if commons.StringWidth(*in) <= limit {
 		// String is too small to be trimmed
 		return *in
 	}
 
 	separator := "...."
-	half := (strTrimLen - len(separator)) / 2
-	res := fmt.Sprintf(
-		"%s%s%s",
-		(*in)[:half],
-		separator,
-		(*in)[len(*in)-half:],
-	)
+	half := (limit - len(separator)) / 2
 
-	return res
+	// Characters from the start, and the end of the string fitting in half the width
+	return commons.Truncate(*in, half) + separator + commons.TruncateLeft(*in, half)
 }
 
 /*
@@ -7,7 +7,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"bou.ke/monkey"
 	"github.com/demon-rem/auto-sub/internals/commons"
@@ -92,6 +95,9 @@ func TestConvertor(t *testing.T) {
 }
 
 func TestTrimString(t *testing.T) {
+	defer func() { terminalWidth = commons.TerminalWidth }()
+	terminalWidth = func() int { return 80 }
+
 	// Change the test set if trim length changes
 	set := map[string]string{
 		"this string remains unmodified": "this string remains unmodified",
@@ -117,6 +123,103 @@ func TestTrimString(t *testing.T) {
 	}
 }
 
+func TestTrimStringWide(t *testing.T) {
+	defer func() { terminalWidth = commons.TerminalWidth }()
+	terminalWidth = func() int { return 80 }
+
+	// Wide characters take up two columns each, the result should never exceed the
+	// width limit, or contain partial characters
+	for _, input := range []string{
+		"進撃の巨人 The Final Season 第1話 「海の向こう側」 1080p.mkv",
+		"신과함께 죄와 벌 - 신과함께 인과 연 [BD 1080p].mkv",
+		"Ame e\u0301 to Yuki no Ookami Kodomo 🐺🐺🐺🐺🐺🐺 [1080p].mkv",
+		"Family Trip to the Mountains " + strings.Repeat("👨\u200d👩\u200d👧", 3) +
+			" Travel Vlog Episode 1080p.mkv",
+	} {
+		val := update.trimString(&input)
+		if width := commons.StringWidth(val); width > strTrimLen ||
+			!utf8.ValidString(val) || !strings.Contains(val, "....") {
+			t.Errorf(
+				"(Updates/trimString) incorrect result for wide characters"+
+					"\ninput: \"%s\" \nresult: \"%s\" \nwidth: %d",
+				input,
+				val,
+				width,
+			)
+		}
+	}
+
+	// Narrow terminals should reduce the width further
+	terminalWidth = func() int { return 50 }
+	input := "this string exceeds the reduced limit on a narrow terminal"
+	if val := update.trimString(&input); commons.StringWidth(val) > 30 {
+		t.Errorf("(Updates/trimString) result exceeds terminal width: \"%s\"", val)
+	}
+}
+
+func TestBarLength(t *testing.T) {
+	defer func() { terminalWidth = commons.TerminalWidth }()
+
+	for width, length := range map[int]int{
+		80:  40,
+		120: 80,
+		20:  pbMinLen,
+		400: pbMaxLen,
+	} {
+		width := width // pin
+		terminalWidth = func() int { return width }
+
+		if res := barLength(); res != length {
+			t.Errorf(
+				"(Updates/barLength) unexpected length \nterminal width: %d"+
+					"\nexpected: %d \nresult: %d",
+				width,
+				length,
+				res,
+			)
+		}
+
+		// Progress bar should take up the length, with four extra characters
+		if bar := update.progressBar(50); len(bar) != length+4 {
+			t.Errorf(
+				"(Updates/progressBar) unexpected progress bar for width %d: %s",
+				width,
+				bar,
+			)
+		}
+	}
+}
+
+func TestRenderedRows(t *testing.T) {
+	for _, in := range []struct {
+		dialog string
+		width  int
+		rows   int
+	}{
+		{"", 80, 1},
+		{"single line", 80, 1},
+		{"first\n\nthird", 80, 3},
+		{strings.Repeat("x", 80), 80, 1},
+		{strings.Repeat("x", 81), 80, 2},
+		{strings.Repeat("x", 100) + "\nshort", 40, 4},
+		{"進撃の巨人", 6, 2},
+		{"👨\u200d👩\u200d👧 ❤\ufe0f", 4, 1},
+		{"\t[ ==> ]\t50.00%%\t\t", 16, 2},
+		{"  trailing padding\t\t", 18, 1},
+	} {
+		if res := renderedRows(in.dialog, in.width); res != in.rows {
+			t.Errorf(
+				"(Updates/renderedRows) unexpected row count \ndialog: %q "+
+					"\nwidth: %d \nexpected: %d \nresult: %d",
+				in.dialog,
+				in.width,
+				in.rows,
+				res,
+			)
+		}
+	}
+}
+
 func TestGetTotalFrames(t *testing.T) {
 	defer monkey.UnpatchAll()
 
@@ -267,3 +370,181 @@ func TestProgress(t *testing.T) {
 	update.progressBar(200)
 	tempAnimationProgress = 0
 }
+
+func TestCheckStall(t *testing.T) {
+	runSummary = &Summary{}
+	defer func() { runSummary = &Summary{} }()
+
+	stallTest := Updates{
+		userInput: &commons.UserInput{StallTimeout: 10},
+		filePath:  "media.mkv",
+	}
+
+	stallTest.Initialize()
+	start := stallTest.lastProgress
+
+	for _, in := range []struct {
+		frames  int64
+		elapsed time.Duration
+		stalled bool
+	}{
+		{10, 5 * time.Second, false},
+		{0, 10 * time.Second, false},  // no output within the timeout
+		{10, 20 * time.Second, true},  // frame count unchanged for 15s
+		{0, 30 * time.Second, true},   // still stalled
+		{20, 40 * time.Second, false}, // encode resumed
+	} {
+		warning := stallTest.checkStall(in.frames, start.Add(in.elapsed))
+		if (warning != "") != in.stalled {
+			t.Errorf(
+				"(Updates/checkStall) unexpected result \nframes: %d \nelapsed: %v"+
+					"\nexpected stall: %v \nwarning: `%s`",
+				in.frames,
+				in.elapsed,
+				in.stalled,
+				warning,
+			)
+		}
+	}
+
+	// The stall should be reported exactly once
+	if !stallTest.stalled || len(runSummary.Warnings) != 1 {
+		t.Errorf(
+			"(Updates/checkStall) stall not reported correctly \nwarnings: %v",
+			runSummary.Warnings,
+		)
+	}
+
+	// Stall detection should be disabled without a timeout
+	stallTest.userInput.StallTimeout = 0
+	if warning := stallTest.checkStall(0, start.Add(time.Hour)); warning != "" {
+		t.Errorf("(Updates/checkStall) stall detected with detection disabled")
+	}
+}
+
+func TestMeasureFPS(t *testing.T) {
+	fpsTest := Updates{totalFrames: 1000}
+	start := time.Now()
+
+	// The first tick has nothing to compare against
+	fpsTest.measureFPS(0, start)
+	if fpsTest.currentFPS != 0 || fpsTest.eta(EventProgress, 0) != 0 {
+		t.Errorf("(Updates/measureFPS) frame rate measured without a previous tick")
+	}
+
+	fpsTest.measureFPS(100, start.Add(time.Second))
+	fpsTest.measureFPS(150, start.Add(2*time.Second))
+
+	// 50 FPS now, averaged with the 100 FPS from the tick before
+	expected := fpsSmoothing*50 + (1-fpsSmoothing)*100
+	if fpsTest.currentFPS != 50 || fpsTest.smoothedFPS != expected {
+		t.Errorf(
+			"(Updates/measureFPS) unexpected frame rates \ncurrent: %v \naverage: %v",
+			fpsTest.currentFPS,
+			fpsTest.smoothedFPS,
+		)
+	}
+
+	// The ETA is based on the average, and only reported while the encode runs
+	eta := time.Duration(850 / expected * float64(time.Second)).Round(time.Second)
+	if res := fpsTest.eta(EventProgress, 150); res != eta {
+		t.Errorf("(Updates/eta) unexpected ETA \nexpected: %v \nfound: %v", eta, res)
+	}
+
+	if res := fpsTest.eta(EventComplete, 150); res != 0 {
+		t.Errorf("(Updates/eta) ETA reported once the encode ended: %v", res)
+	}
+
+	// Ticks shorter than the window are folded into the next measurement
+	fpsTest.measureFPS(175, start.Add(2500*time.Millisecond))
+	if fpsTest.currentFPS != 50 {
+		t.Errorf("(Updates/measureFPS) frame rate measured within the window")
+	}
+
+	fpsTest.measureFPS(210, start.Add(3*time.Second))
+	if fpsTest.currentFPS != 60 {
+		t.Errorf(
+			"(Updates/measureFPS) unexpected frame rate over the window: %v",
+			fpsTest.currentFPS,
+		)
+	}
+}
+
+func TestStatsInterval(t *testing.T) {
+	for _, test := range []struct {
+		input    *commons.UserInput
+		expected time.Duration
+	}{
+		{nil, terminalInterval},
+		{&commons.UserInput{}, terminalInterval},
+		{&commons.UserInput{Porcelain: true}, quietInterval},
+		{&commons.UserInput{Logging: true}, quietInterval},
+		{&commons.UserInput{StatsInterval: 0.5, Logging: true}, 500 * time.Millisecond},
+		{&commons.UserInput{StatsInterval: 2}, 2 * time.Second},
+		{&commons.UserInput{StatsInterval: 0.001}, minInterval},
+	} {
+		if res := statsInterval(test.input); res != test.expected {
+			t.Errorf(
+				"(Updates/statsInterval) unexpected interval for %+v \nexpected: %v"+
+					"\nfound: %v",
+				test.input,
+				test.expected,
+				res,
+			)
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	runSummary = &Summary{}
+	defer func() { runSummary = &Summary{} }()
+
+	sleepTest := Updates{
+		userInput: &commons.UserInput{StallTimeout: 60},
+		filePath:  "media.mkv",
+	}
+
+	sleepTest.Initialize()
+	start := sleepTest.lastProgress
+
+	// Ticks a second apart never resync the progress
+	for i := 0; i < 5; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		if sleepTest.resync(now) {
+			t.Errorf("(Updates/resync) progress resynced without a clock jump")
+		}
+
+		sleepTest.measureFPS(int64(i*25), now)
+		sleepTest.checkStall(int64(i*25), now)
+	}
+
+	// An hour asleep isn't a stall, and doesn't bring the frame rate down
+	fps := sleepTest.smoothedFPS
+	wake := start.Add(time.Hour)
+	if !sleepTest.resync(wake) {
+		t.Errorf("(Updates/resync) clock jump not detected")
+	}
+
+	sleepTest.measureFPS(100, wake)
+	sleepTest.measureFPS(125, wake.Add(time.Second))
+	if warning := sleepTest.checkStall(125, wake.Add(time.Second)); warning != "" ||
+		sleepTest.stalled || len(runSummary.Warnings) != 0 {
+		t.Errorf("(Updates/resync) sleep reported as a stall: %q", warning)
+	}
+
+	if sleepTest.smoothedFPS != fps {
+		t.Errorf(
+			"(Updates/resync) frame rate changed by the sleep \nexpected: %v "+
+				"\nfound: %v",
+			fps,
+			sleepTest.smoothedFPS,
+		)
+	}
+
+	// Jumps in the wall clock alone are detected as well, i.e. the monotonic clock
+	// stopping during sleep
+	sleepTest.lastTick = time.Now().Round(0).Add(-time.Hour)
+	if !sleepTest.resync(time.Now().Round(0)) {
+		t.Errorf("(Updates/resync) wall clock jump not detected")
+	}
+}
@@ -0,0 +1,111 @@
+package internals
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Boolean containing the value of the `json` flag for the history command
+var historyJSON bool
+
+var historyCmd = &cobra.Command{
+	Use: "history",
+
+	Short: "Display cumulative statistics across past runs",
+
+	Long: `
+Reads the results recorded for each past run, printing cumulative
+statistics - total files merged, total size of the outputs, average
+encoding speed and the failure rate.
+
+Results are recorded in a file inside the user config directory, the
+path can be changed through the AUTOSUB_HISTORY environment variable.
+`,
+
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Same as the root command, set up the output stream if required
+		if commons.GetOutput() == nil {
+			commons.SetOutput(cmd.OutOrStdout())
+			commons.SetErrorOutput(cmd.ErrOrStderr())
+		}
+
+		records, err := ffmpeg.ReadHistory(config.HistoryPath(title))
+		if err != nil {
+			log.Debugf("(historyCmd/RunE) failed to read history \nerror: %v", err)
+			return err
+		}
+
+		stats := ffmpeg.AggregateHistory(records)
+		if !historyJSON {
+			commons.Printf("%s", stats.String())
+			return nil
+		}
+
+		res, err := json.MarshalIndent(historyOutput{
+			HistoryStats: stats,
+			AverageFPS:   stats.AverageFPS(),
+			FailureRate:  stats.FailureRate(),
+		}, "", "  ")
+		if err != nil {
+			log.Debugf("(historyCmd/RunE) failed to marshal stats \nerror: %v", err)
+			return err
+		}
+
+		commons.Printf("%s\n", res)
+		return nil
+	},
+}
+
+/*
+HistoryOutput is the structure displayed for the `json` flag, the statistics along with
+the values derived from them
+*/
+type historyOutput struct {
+	ffmpeg.HistoryStats
+	AverageFPS  float64 `json:"average_fps"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+/*
+HistoryFlags is a simple helper function to attach flags to the history command
+*/
+func historyFlags(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&historyJSON,
+		"json",
+		false,
+		"Display the statistics as JSON",
+	)
+}
+
+/*
+RecordHistory appends the results of the run that just completed to the history file,
+failures are logged without affecting the run.
+*/
+func recordHistory(start time.Time, exitCode int) {
+	path := config.HistoryPath(title)
+	if path == "" {
+		return
+	}
+
+	root := userInput.RootPath
+	if userInput.Remote != nil {
+		root = userInput.Remote.String()
+	}
+
+	if err := ffmpeg.RecordHistory(path, start, root, exitCode); err != nil {
+		log.Warnf(
+			"(historyCmd/recordHistory) failed to record run in \"%s\" \nerror: %v",
+			path,
+			err,
+		)
+	}
+}
@@ -0,0 +1,54 @@
+package internals
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+)
+
+func TestHistoryCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(historyCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv(config.EnvHistory)
+	defer os.Setenv(config.EnvHistory, original)
+
+	path := filepath.Join(dir, "history.jsonl")
+	_ = os.Setenv(config.EnvHistory, path)
+
+	userInput = testConfig(t)
+	defer resetConfig()
+
+	recordHistory(time.Now(), commons.StatusOK)
+	if records, err := ioutil.ReadFile(path); err != nil ||
+		!strings.Contains(string(records), `"exit_code":0`) {
+		t.Errorf("(historyCmd/recordHistory) run not recorded \nerror: %v", err)
+	}
+
+	// The output stream may have been set by an earlier test, capture the output
+	// only if it wasn't
+	var out bytes.Buffer
+	historyCmd.SetOut(&out)
+
+	historyJSON = true
+	defer func() { historyJSON = false }()
+
+	if err := historyCmd.RunE(historyCmd, []string{}); err != nil {
+		t.Errorf("(historyCmd/RunE) unexpected error: %v", err)
+	}
+
+	if commons.GetOutput() == &out && !strings.Contains(out.String(), `"runs": 1`) {
+		t.Errorf("(historyCmd/RunE) unexpected output: %s", out.String())
+	}
+}
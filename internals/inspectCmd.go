@@ -0,0 +1,96 @@
+package internals
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmd = &cobra.Command{
+	Use: "inspect \"/path/to/root\" [flags]",
+
+	Short: "Report the way files will be grouped, without merging anything",
+
+	Long: `
+Walks the root directory the same way as a normal run, printing the
+files grouped as media files, subtitles, attachments and chapters for
+each source directory - along with the files being ignored, and the
+reason for the same.
+
+Nothing is merged or written to the disk, making this command useful
+to diagnose why a file was (or wasn't) picked up.
+`,
+
+	Args: cobra.ExactArgs(1),
+
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if userInput.Logging {
+			log.SetLevel(log.TraceLevel)
+		}
+
+		userInput.RootPath = args[0]
+		return initializeInput(cmd)
+	},
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if userInput.Remote != nil {
+			// Inspecting would require downloading the entire root directory
+			commons.Error("Remote root directories can't be inspected\n\n")
+			os.Exit(commons.RemoteError)
+		}
+
+		exitCode, err := ffmpeg.Inspect(&userInput, resultPath())
+		if exitCode != commons.StatusOK || err != nil {
+			log.Debugf(
+				"(inspectCmd/RunE) failed to inspect root directory"+
+					"\nexit code: %d \nerror: %v",
+				exitCode,
+				err,
+			)
+
+			commons.Error("%v\n\n", err)
+			os.Exit(exitCode)
+		}
+
+		return nil
+	},
+}
+
+/*
+InspectFlags is a simple helper function to attach flags to the inspect command - uses
+the same flags as the root command to traverse the root directory.
+*/
+func inspectFlags(
+	command *cobra.Command,
+	input *commons.UserInput,
+	defaults *config.Defaults,
+) {
+	traversalFlags(command, input)
+
+	command.Flags().BoolVar(
+		&input.AllMedia,
+		"all-media",
+		false,
+		"Process each media file in a source directory with multiple media files",
+	)
+
+	command.Flags().BoolVar(
+		&input.Logging,
+		"log",
+		false,
+		"Generate logs for the current run",
+	)
+
+	command.Flags().StringVarP(
+		&input.OutputPath,
+		"output",
+		"o",
+		defaults.OutputPath,
+		fmt.Sprintf("Directory to store the results in, skipped by %s", title),
+	)
+}
@@ -0,0 +1,50 @@
+package internals
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+)
+
+func TestInspectCmd(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	userInput = testConfig(t)
+	defer resetConfig()
+
+	root := userInput.RootPath
+	if err := inspectCmd.PreRunE(inspectCmd, []string{root}); err != nil ||
+		userInput.RootPath != root {
+		t.Errorf(
+			"(inspectCmd/PreRunE) failed to use argument as root path"+
+				"\nroot path: \"%s\" \nerror: %v",
+			userInput.RootPath,
+			err,
+		)
+	}
+
+	// Ensure failure while inspecting the root directory is reported
+	monkey.Patch(
+		ffmpeg.Inspect,
+		func(*commons.UserInput, string) (int, error) {
+			return commons.RootDirectoryIncorrect, errors.New("test error")
+		},
+	)
+
+	exitCode := commons.StatusOK
+	monkey.Patch(os.Exit, func(code int) { exitCode = code })
+
+	_ = inspectCmd.RunE(inspectCmd, []string{root})
+	if exitCode != commons.RootDirectoryIncorrect {
+		t.Errorf(
+			"(inspectCmd/RunE) unexpected exit code \nexpected: %d \nfound: %d",
+			commons.RootDirectoryIncorrect,
+			exitCode,
+		)
+	}
+}
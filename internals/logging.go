@@ -0,0 +1,101 @@
+package internals
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/demon-rem/auto-sub/internals/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log file opened for the run (and the path to it), nil if logs are being written to
+// the standard error
+var (
+	logOutput *os.File
+	logPath   string
+)
+
+/*
+OpenLogFile directs the logs to the log file - the path passed through the `log-file`
+flag, or the file in the state directory by default. The directory containing the log
+file is created if required.
+
+If the log file can't be opened (for example, the directory is read-only), logs are
+written to the standard error instead - ensures logs are never lost silently. Logs are
+mirrored to the standard error if the `log-stderr` flag is used.
+*/
+func openLogFile() {
+	path := userInput.LogFile
+	if path == "" {
+		path = config.LogPath(title)
+	}
+
+	file, err := createLogFile(path)
+	if err != nil {
+		log.SetOutput(os.Stderr)
+		log.Warnf(
+			`(logging/openLogFile) failed to open log file "%s", logging to stderr`+
+				"\nerror: %v",
+			path,
+			err,
+		)
+
+		return
+	}
+
+	logOutput, logPath = file, path
+	if userInput.LogStderr {
+		log.SetOutput(io.MultiWriter(file, os.Stderr))
+	} else {
+		log.SetOutput(file)
+	}
+
+	log.Debugf(`(logging/openLogFile) writing logs to "%s"`, path)
+}
+
+// CreateLogFile opens the log file for appending, creating the file if required
+func createLogFile(path string) (*os.File, error) {
+	if path == "" {
+		return nil, errors.New("unable to locate the state directory")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+}
+
+/*
+CloseLogFile closes the log file (if open), logs are written to the standard error
+from this point onwards.
+*/
+func closeLogFile() {
+	if logOutput == nil {
+		return
+	}
+
+	log.SetOutput(os.Stderr)
+	if err := logOutput.Close(); err != nil {
+		log.Warn("(logging/closeLogFile) failed to close connection to the log file")
+	}
+
+	logOutput, logPath = nil, ""
+}
+
+/*
+LogDestination returns the path to the log file, or `stderr` if the file isn't open -
+logs mirrored to the standard error are mentioned as well
+*/
+func logDestination() string {
+	switch {
+	case logOutput == nil:
+		return "stderr"
+	case userInput.LogStderr:
+		return logPath + " (mirrored to stderr)"
+	default:
+		return logPath
+	}
+}
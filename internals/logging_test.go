@@ -0,0 +1,88 @@
+package internals
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(logging) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer log.SetOutput(os.Stderr)
+
+	userInput = testConfig(t)
+	defer resetConfig()
+
+	// Directories leading to the log file are created
+	userInput.LogFile = filepath.Join(dir, "nested", "logs.txt")
+	openLogFile()
+
+	if logDestination() != userInput.LogFile {
+		t.Errorf(
+			"(logging/openLogFile) log file not opened \nexpected: %q \nfound: %q",
+			userInput.LogFile,
+			logDestination(),
+		)
+	}
+
+	log.Warn("(logging) test message")
+	closeLogFile()
+
+	if content, err := ioutil.ReadFile(userInput.LogFile); err != nil ||
+		len(content) == 0 {
+		t.Errorf("(logging/openLogFile) nothing written to log file \nerror: %v", err)
+	}
+
+	// Logs are mirrored to the standard error if required
+	stderr, err := ioutil.TempFile(dir, "stderr")
+	if err != nil {
+		t.Fatalf("(logging) failed to create file: %v", err)
+	}
+
+	defer func(file *os.File) { os.Stderr = file }(os.Stderr)
+	os.Stderr = stderr
+
+	userInput.LogStderr = true
+	openLogFile()
+
+	log.Warn("(logging) mirrored message")
+	closeLogFile()
+
+	userInput.LogStderr = false
+	if content, err := ioutil.ReadFile(stderr.Name()); err != nil ||
+		!strings.Contains(string(content), "mirrored message") {
+		t.Errorf("(logging/openLogFile) logs not mirrored to stderr \nerror: %v", err)
+	}
+
+	if content, err := ioutil.ReadFile(userInput.LogFile); err != nil ||
+		!strings.Contains(string(content), "mirrored message") {
+		t.Errorf("(logging/openLogFile) mirrored logs not written to the log file")
+	}
+
+	// Logs are written to the standard error if the log file can't be opened
+	blocker := filepath.Join(dir, "file")
+	if err = ioutil.WriteFile(blocker, []byte{}, 0644); err != nil {
+		t.Fatalf("(logging) failed to create file: %v", err)
+	}
+
+	userInput.LogFile = filepath.Join(blocker, "logs.txt")
+	openLogFile()
+
+	if logDestination() != "stderr" {
+		t.Errorf(
+			"(logging/openLogFile) expected logs on stderr, found %q",
+			logDestination(),
+		)
+	}
+
+	closeLogFile()
+}
@@ -0,0 +1,52 @@
+package internals
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+)
+
+// Path the metrics are served on
+const metricsPath = "/metrics"
+
+/*
+StartMetrics serves the metrics for the run on the address supplied by the user (if
+any), until the function returned is called. Exits if the address can't be listened
+on - the run is never started without the metrics asked for.
+*/
+func startMetrics(input *commons.UserInput) (stop func()) {
+	if input.MetricsAddr == "" {
+		return func() {}
+	}
+
+	listener, err := net.Listen("tcp", input.MetricsAddr)
+	if err != nil {
+		log.Debugf("(metrics/startMetrics) failed to listen \nerror: %v", err)
+		commons.Error("Unable to serve metrics on `%s`: %v\n\n", input.MetricsAddr, err)
+		os.Exit(commons.MetricsError)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, ffmpeg.MetricsHandler())
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Warnf("(metrics/startMetrics) metrics server failed \nerror: %v", err)
+		}
+	}()
+
+	log.Debugf(
+		"(metrics/startMetrics) serving metrics on http://%s%s",
+		listener.Addr(),
+		metricsPath,
+	)
+
+	return func() {
+		_ = server.Close()
+	}
+}
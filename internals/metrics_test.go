@@ -0,0 +1,40 @@
+package internals
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+)
+
+func TestStartMetrics(t *testing.T) {
+	// Nothing is served unless asked for
+	startMetrics(&commons.UserInput{})()
+
+	// Free port for the server, released before the server listens on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("(metrics/startMetrics) unable to listen: %v", err)
+	}
+
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	stop := startMetrics(&commons.UserInput{MetricsAddr: addr})
+	defer stop()
+
+	res, err := http.Get("http://" + addr + metricsPath)
+	if err != nil {
+		t.Fatalf("(metrics/startMetrics) failed to fetch metrics: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if !strings.Contains(string(body), "autosub_jobs_active") {
+		t.Errorf("(metrics/startMetrics) unexpected metrics: %s", body)
+	}
+}
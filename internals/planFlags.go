@@ -0,0 +1,104 @@
+package internals
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+PreparePlan handles the two-pass flow before the run starts. With `--plan`, the root
+directory is scanned and the plan for the run printed - the plan is saved if a plan
+file is supplied, the user is asked to confirm otherwise. A plan saved earlier is
+loaded and applied to the run through `--apply`.
+
+Returns false if nothing is to be merged, force-stops the application if the plan
+can't be formed, saved or applied.
+*/
+func preparePlan(input *commons.UserInput) bool {
+	ffmpeg.UsePlan(nil)
+	if !input.Plan && input.ApplyPlan == "" {
+		return true
+	}
+
+	if input.Remote != nil {
+		// Plans are formed for the local copy, which only lasts for a single run
+		commons.Error("Plans can't be used with remote root directories\n\n")
+		os.Exit(commons.RemoteError)
+		return false
+	}
+
+	if input.ApplyPlan != "" {
+		plan, err := ffmpeg.LoadPlan(input.ApplyPlan)
+		if err == nil && commons.NormalizePath(plan.RootPath) !=
+			commons.NormalizePath(input.RootPath) {
+			err = fmt.Errorf(
+				"plan was formed for another root directory \"%s\"",
+				plan.RootPath,
+			)
+		}
+
+		if err != nil {
+			log.Debugf(
+				"(planFlags/preparePlan) failed to apply plan: \"%s\" \nerror: %v",
+				input.ApplyPlan,
+				err,
+			)
+
+			commons.Error("Unable to apply plan \"%s\": %v\n\n", input.ApplyPlan, err)
+			os.Exit(commons.PlanError)
+			return false
+		}
+
+		count, _ := plan.Outputs()
+		commons.Info(
+			"Applying plan \"%s\" with %d output(s)\n\n",
+			input.ApplyPlan,
+			count,
+		)
+		ffmpeg.UsePlan(plan)
+		return true
+	}
+
+	plan, exitCode, err := ffmpeg.ScanPlan(input, resultPath())
+	if err != nil {
+		log.Debugf(
+			"(planFlags/preparePlan) failed to scan root directory"+
+				"\nexit code: %d \nerror: %v",
+			exitCode,
+			err,
+		)
+
+		commons.Error("%v\n\n", err)
+		os.Exit(exitCode)
+		return false
+	}
+
+	commons.Printf("%s\n", plan.String())
+	if input.PlanFile != "" {
+		if err = plan.Save(input.PlanFile); err != nil {
+			commons.Error("Unable to save plan \"%s\": %v\n\n", input.PlanFile, err)
+			os.Exit(commons.PlanError)
+			return false
+		}
+
+		commons.Success(
+			"Plan saved to \"%s\", use `--apply` to merge the files in it\n\n",
+			input.PlanFile,
+		)
+
+		return false
+	}
+
+	if !input.Prompt.Confirm("Start merging?") {
+		commons.Info("Nothing merged\n\n")
+		return false
+	}
+
+	// Source directories appearing after the scan are left for the next run
+	ffmpeg.UsePlan(plan)
+	return true
+}
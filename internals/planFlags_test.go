@@ -0,0 +1,75 @@
+package internals
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+)
+
+func TestPreparePlan(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer ffmpeg.UsePlan(nil)
+
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(planFlags/preparePlan) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"01/video.mkv", "01/English.srt"} {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err = ioutil.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("(planFlags/preparePlan) failed to create file: %v", err)
+		}
+	}
+
+	exitCode := commons.StatusOK
+	monkey.Patch(os.Exit, func(code int) { exitCode = code })
+
+	// Runs without a plan proceed as usual
+	input := &commons.UserInput{RootPath: dir, Order: commons.OrderName}
+	if !preparePlan(input) {
+		t.Errorf("(planFlags/preparePlan) run stopped without a plan")
+	}
+
+	// Plan saved to a file stops the run, without merging anything
+	path := filepath.Join(dir, "plan.json")
+	input.Plan, input.PlanFile = true, path
+	if preparePlan(input) || exitCode != commons.StatusOK {
+		t.Errorf("(planFlags/preparePlan) run not stopped \nexit code: %d", exitCode)
+	}
+
+	plan, err := ffmpeg.LoadPlan(path)
+	if count, _ := plan.Outputs(); err != nil || count != 1 {
+		t.Fatalf("(planFlags/preparePlan) unexpected plan \n%+v \nerror: %v", plan, err)
+	}
+
+	// Plan is declined without a prompt policy
+	input.PlanFile = ""
+	if preparePlan(input) {
+		t.Errorf("(planFlags/preparePlan) run continued without confirmation")
+	}
+
+	// Saved plan is applied to the same root directory alone
+	input.Plan, input.ApplyPlan = false, path
+	if !preparePlan(input) || exitCode != commons.StatusOK {
+		t.Errorf("(planFlags/preparePlan) plan not applied \nexit code: %d", exitCode)
+	}
+
+	input.RootPath = filepath.Join(dir, "01")
+	if preparePlan(input) || exitCode != commons.PlanError {
+		t.Errorf(
+			"(planFlags/preparePlan) unexpected exit code \nexpected: %d \nfound: %d",
+			commons.PlanError,
+			exitCode,
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package internals
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Configuration file loaded for the run, and the error encountered while loading it -
+// the error is reported only if a profile is selected, the file is ignored otherwise
+var (
+	configFile = &config.File{}
+	configErr  error
+)
+
+/*
+LoadConfigFile loads the configuration file, the file is looked for at the path set
+through the environment variable, or in the config directory by default.
+*/
+func loadConfigFile() {
+	configFile, configErr = config.LoadFile(config.FilePath(title))
+	if configErr != nil {
+		log.Warnf("(profile/loadConfigFile) ignoring configuration file: %v", configErr)
+	}
+}
+
+/*
+ApplyProfile replaces the values of the flags with the values from the profile selected
+by the user (if any) - flags used explicitly are left untouched, giving the order of
+precedence;
+
+	flags > profile > environment variables > configuration file > OS locale
+
+Fails if the configuration file is invalid, or does not contain the profile.
+*/
+func applyProfile(command *cobra.Command, input *commons.UserInput) error {
+	// Metadata rules have no flags, the rules from the file apply to every run
+	input.Rules = append([]commons.MetadataRule{}, configFile.Rules...)
+	if input.Profile == "" {
+		return nil
+	}
+
+	if configErr != nil {
+		return configErr
+	}
+
+	profile, err := configFile.Profile(input.Profile)
+	if err != nil {
+		return err
+	}
+
+	// Checks if the value for a flag is to be taken from the profile
+	unset := func(flag string) bool {
+		return !command.Flags().Changed(flag)
+	}
+
+	if profile.Language != "" && unset("language") {
+		input.SubLang = profile.Language
+	}
+
+	if profile.Subtitle != "" && unset("subtitle") {
+		input.SubTitleString = profile.Subtitle
+	}
+
+	if profile.Output != "" && unset("output") {
+		input.OutputPath = profile.Output
+	}
+
+	if len(profile.Exclude) > 0 && unset("exclude") {
+		input.Exclusions = append([]string{}, profile.Exclude...)
+	}
+
+	if len(profile.Rexclude) > 0 && unset("rexclude") {
+		input.RegexExclusions = append([]string{}, profile.Rexclude...)
+	}
+
+	if len(profile.MediaExt) > 0 && unset("media-ext") {
+		input.MediaExt = append([]string{}, profile.MediaExt...)
+	}
+
+	if len(profile.SubOrder) > 0 && unset("sub-order") {
+		input.SubOrder = append([]string{}, profile.SubOrder...)
+	}
+
+	input.Rules = append(input.Rules, profile.Rules...)
+
+	log.Debugf("(profile/applyProfile) applied profile `%s`", input.Profile)
+	return nil
+}
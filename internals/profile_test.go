@@ -0,0 +1,69 @@
+package internals
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyProfile(t *testing.T) {
+	defer func() {
+		configFile, configErr = &config.File{}, nil
+	}()
+
+	configFile = &config.File{
+		Profiles: map[string]config.Profile{
+			"anime": {
+				Language: "jpn",
+				Subtitle: "Full Subs",
+				Exclude:  []string{"NCOP", "NCED"},
+				MediaExt: []string{"avi"},
+				Rules:    []commons.MetadataRule{{Match: "signs", Title: "Signs"}},
+			},
+		},
+		Rules: []commons.MetadataRule{{Match: "sdh", Disposition: "+hearing_impaired"}},
+	}
+
+	command := &cobra.Command{}
+	boolFlags(command, &commons.UserInput{})
+	input := commons.UserInput{SubLang: "eng", Profile: "anime"}
+
+	if err := applyProfile(command, &input); err != nil {
+		t.Fatalf("(profile/applyProfile) failed to apply profile: %v", err)
+	}
+
+	if input.SubLang != "jpn" || input.SubTitleString != "Full Subs" ||
+		!reflect.DeepEqual(input.Exclusions, []string{"NCOP", "NCED"}) ||
+		!reflect.DeepEqual(input.MediaExt, []string{"avi"}) {
+		t.Errorf("(profile/applyProfile) profile not applied \nresult: %+v", input)
+	}
+
+	// Rules from the profile are applied after the rules at the top level
+	if len(input.Rules) != 2 || input.Rules[1].Title != "Signs" {
+		t.Errorf("(profile/applyProfile) unexpected rules: %+v", input.Rules)
+	}
+
+	// Nothing changes without a profile
+	input = commons.UserInput{SubLang: "eng"}
+	if err := applyProfile(command, &input); err != nil || input.SubLang != "eng" ||
+		len(input.Rules) != 1 {
+		t.Errorf("(profile/applyProfile) unexpected change without a profile")
+	}
+
+	input.Profile = "movies"
+	if err := applyProfile(command, &input); err == nil {
+		t.Errorf("(profile/applyProfile) expected failure for unknown profile")
+	}
+
+	// Errors from the configuration file are reported once a profile is selected
+	configErr = errors.New("invalid configuration file")
+	input.Profile = "anime"
+	if err := applyProfile(command, &input); err != configErr {
+		t.Errorf("(profile/applyProfile) expected configuration error, found %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package internals
+
+import (
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Queue for the run being resumed, nil unless the resume command is used
+var resumeQueue *ffmpeg.JobQueue
+
+var resumeCmd = &cobra.Command{
+	Use: "resume",
+
+	Short: "Resume the last run that was paused (or interrupted)",
+
+	Long: `
+Resumes the last run that was paused or interrupted, using the same
+root directory and flags as the original run. Source directories that
+were processed, failed or skipped earlier are not run again - the
+source directory being processed when the run stopped starts over.
+
+On Linux and macOS, a run is paused by sending SIGTSTP to it (Ctrl+Z
+in most terminals). Runs stopped with Ctrl+C can be resumed as well,
+same as runs that died midway - the point at which the run died is
+reported in the summary.
+`,
+
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Same as the root command, set up the output stream if required
+		if commons.GetOutput() == nil {
+			commons.SetOutput(cmd.OutOrStderr())
+			commons.SetErrorOutput(cmd.ErrOrStderr())
+		}
+
+		queue, err := ffmpeg.LoadQueue(config.QueuePath(title))
+		if err != nil {
+			log.Debugf("(resumeCmd/RunE) failed to load queue \nerror: %v", err)
+			commons.Error("%v\n\n", err)
+			os.Exit(commons.UnexpectedError)
+		}
+
+		commons.Info(
+			"Resuming the run for \"%s\", %d of %d source directories remaining\n\n",
+			queue.Root,
+			queue.Remaining(),
+			len(queue.Jobs),
+		)
+
+		// The root command is run with the arguments from the original run, the
+		// queue ensures completed source directories are skipped
+		resumeQueue = queue
+		defer func() {
+			resumeQueue = nil
+		}()
+
+		root := cmd.Root()
+		if err = root.Flags().Parse(queue.Args); err != nil {
+			log.Debugf("(resumeCmd/RunE) failed to parse arguments \nerror: %v", err)
+			return err
+		}
+
+		rootArgs := root.Flags().Args()
+		if err = root.Args(root, rootArgs); err != nil {
+			return err
+		}
+
+		if err = root.PreRunE(root, rootArgs); err != nil {
+			return err
+		}
+
+		return root.RunE(root, rootArgs)
+	},
+}
+
+/*
+StartQueue sets up the queue tracking the source directories for the run - the queue
+from the run being resumed is used if present, a new queue is created otherwise.
+*/
+func startQueue() *ffmpeg.JobQueue {
+	queue := resumeQueue
+	if queue == nil {
+		root := userInput.RootPath
+		if userInput.Remote != nil {
+			root = userInput.Remote.String()
+		}
+
+		queue = ffmpeg.NewQueue(config.QueuePath(title), root, os.Args[1:])
+	}
+
+	ffmpeg.UseQueue(queue)
+	return queue
+}
+
+/*
+FinishQueue removes the queue once the run completes, the queue is kept if the run was
+stopped midway - allowing the run to be resumed later.
+*/
+func finishQueue(queue *ffmpeg.JobQueue, exitCode int) {
+	defer ffmpeg.UseQueue(nil)
+
+	if exitCode == commons.Interrupted {
+		if runPaused {
+			commons.Info("Run paused, use `%s resume` to continue\n\n", title)
+		} else {
+			commons.Warn(
+				"Run stopped, use `%s resume` to continue from this point\n\n",
+				title,
+			)
+		}
+
+		return
+	}
+
+	if err := queue.Remove(); err != nil {
+		log.Warnf("(resumeCmd/finishQueue) failed to remove queue \nerror: %v", err)
+	}
+}
+
+/*
+StartCheckpoint sets up the checkpoint recording the progress of the run, along with
+the checkpoint left by the previous run if it died midway.
+*/
+func startCheckpoint() *ffmpeg.Checkpoint {
+	path := config.ProgressPath(title)
+
+	previous, err := ffmpeg.LoadCheckpoint(path)
+	if err != nil && err != ffmpeg.ErrNoCheckpoint {
+		log.Debugf(
+			"(resumeCmd/startCheckpoint) failed to load checkpoint \nerror: %v",
+			err,
+		)
+	}
+
+	checkpoint := ffmpeg.NewCheckpoint(path, previous)
+	ffmpeg.UseCheckpoint(checkpoint)
+
+	return checkpoint
+}
+
+/*
+FinishCheckpoint removes the checkpoint once the run ends, including runs that were
+paused or stopped - only runs that die midway leave a checkpoint behind.
+*/
+func finishCheckpoint(checkpoint *ffmpeg.Checkpoint) {
+	defer ffmpeg.UseCheckpoint(nil)
+
+	if err := checkpoint.Remove(); err != nil {
+		log.Warnf(
+			"(resumeCmd/finishCheckpoint) failed to remove checkpoint \nerror: %v",
+			err,
+		)
+	}
+}
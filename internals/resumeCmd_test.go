@@ -0,0 +1,104 @@
+package internals
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(resumeCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv(config.EnvQueue)
+	defer os.Setenv(config.EnvQueue, original)
+
+	path := filepath.Join(dir, "queue.json")
+	_ = os.Setenv(config.EnvQueue, path)
+
+	userInput = testConfig(t)
+	defer resetConfig()
+
+	if commons.GetOutput() == nil {
+		commons.SetOutput(ioutil.Discard)
+	}
+
+	// New runs are tracked through a new queue
+	queue := startQueue()
+	if queue == nil || queue.Root != userInput.RootPath {
+		t.Fatalf("(resumeCmd/startQueue) unexpected queue for new run \n%+v", queue)
+	}
+
+	if err = ioutil.WriteFile(path, []byte(`{"jobs": []}`), 0644); err != nil {
+		t.Fatalf("(resumeCmd) failed to write queue: %v", err)
+	}
+
+	// Queues are kept for runs that were stopped midway
+	finishQueue(queue, commons.Interrupted)
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("(resumeCmd/finishQueue) queue removed for stopped run: %v", err)
+	}
+
+	// The queue for the run being resumed is used as is
+	if resumeQueue, err = ffmpeg.LoadQueue(path); err != nil {
+		t.Fatalf("(resumeCmd) failed to load queue: %v", err)
+	}
+
+	defer func() {
+		resumeQueue = nil
+	}()
+
+	if queue = startQueue(); queue != resumeQueue {
+		t.Errorf("(resumeCmd/startQueue) queue for resumed run not used")
+	}
+
+	// Queues are removed once the run completes
+	finishQueue(queue, commons.StatusOK)
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("(resumeCmd/finishQueue) queue present after the run \nerror: %v", err)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(resumeCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv(config.EnvProgress)
+	defer os.Setenv(config.EnvProgress, original)
+
+	path := filepath.Join(dir, "progress.json")
+	_ = os.Setenv(config.EnvProgress, path)
+
+	content := []byte(`{"sourceDir": "/root/dir 01", "stage": "preparing"}`)
+	if err = ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("(resumeCmd) failed to write checkpoint: %v", err)
+	}
+
+	// Checkpoint left behind by the previous run is replaced by the new run
+	checkpoint := startCheckpoint()
+	if checkpoint == nil {
+		t.Fatalf("(resumeCmd/startCheckpoint) checkpoint not created")
+	}
+
+	// Checkpoints are removed once the run ends, even if the run was stopped
+	finishCheckpoint(checkpoint)
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf(
+			"(resumeCmd/finishCheckpoint) checkpoint present after the run \nerror: %v",
+			err,
+		)
+	}
+}
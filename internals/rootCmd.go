@@ -6,10 +6,15 @@ functions/methods/structures and any other internal components that are required
 package internals
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/demon-rem/auto-sub/internals/commons"
 	"github.com/demon-rem/auto-sub/internals/ffmpeg"
@@ -59,55 +64,7 @@ The subtitle stream language/title can be modified using flags
 		invalid.
 	*/
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// Setting up the output stream, the check will be useful when the main method
-		// is being called multiple times (during tests)
-		if commons.GetOutput() == nil {
-			commons.SetOutput(cmd.OutOrStderr())
-		}
-
-		// Validate user input. Force-stop if this step fails. The method call will
-		// internally validate the root path, and log user input.
-		//
-		// Note: The function will allow flow-of-control to pass even if the root
-		// path is empty as long as the test flag is present
-		if errCode, err := userInput.Initialize(); err != nil ||
-			errCode != commons.StatusOK {
-			log.Warnf(
-				"(rootCmd/RunE) unexpected input\nerror: `%v`\nexit code: %d",
-				err,
-				errCode,
-			)
-
-			// Form output message depending on exit code
-			outMsg := ""
-			switch errCode {
-			case commons.RegexError:
-				outMsg = "Error: Failed to compile the regex pattern"
-
-			case commons.UnexpectedError:
-				outMsg = "Error: Path to root directory is incorrect"
-
-			case commons.RootDirectoryIncorrect:
-				// Will be the case if path to root directory is not present and
-				// `test` flag is not used.
-				//
-				// Returning error to have `RunE` function display command help
-				return errors.New("path to root directory not found")
-
-			default:
-				// Will end up here in case of `UnexpectedError` or any other
-				// error code introduced in the future.
-
-				outMsg = "Error: Ran into an unexpected error. Check the logs for" +
-					" details"
-			}
-
-			commons.Printf(outMsg + "\n\n")
-			os.Exit(errCode)
-		}
-
-		log.Debugf("(rootCmd/PreRunE) user input initialized")
-		return nil
+		return initializeInput(cmd)
 	},
 
 	Args: func(cmd *cobra.Command, args []string) error {
@@ -162,9 +119,16 @@ The subtitle stream language/title can be modified using flags
 		if userInput.Logging {
 			// Log level will be internally modified while validating user input,
 			// printing confirmation to the screen in here
-			commons.Printf("\nLogging enabled \nLog level set to `Trace`\n\n")
+			commons.Info(
+				"\nLogging enabled \nLog level set to `Trace`\nLog file: %s\n\n",
+				logDestination(),
+			)
 		}
 
+		// Executables are only looked up for a run (or to report their paths and
+		// versions), never while printing the help or inspecting the root directory
+		locateBinaries(&userInput)
+
 		if userInput.IsTest {
 			// Handle the test flag - once done, direct exit, ensuring that the test
 			// flag can't be combined with any other flag
@@ -175,16 +139,64 @@ The subtitle stream language/title can be modified using flags
 			os.Exit(exitCode)
 		}
 
+		requireBinaries(&userInput)
+
+		// Root directory is scanned first in the two-pass flow, merging only starts
+		// once the plan is confirmed (or a saved plan is applied)
+		if !preparePlan(&userInput) {
+			return nil
+		}
+
+		// Metrics (if asked for) are served for as long as the run lasts
+		stopMetrics := startMetrics(&userInput)
+		defer stopMetrics()
+
+		// Results are written to the standard output in porcelain mode, the rest of
+		// the output goes to the standard error as usual
+		if userInput.Porcelain {
+			commons.SetResultOutput(cmd.OutOrStdout())
+			defer commons.SetResultOutput(nil)
+		}
+
+		// Cancel the run (killing any running FFmpeg process) on an interrupt
+		ctx, cancel := interruptContext()
+		defer cancel()
+
+		// Remote root directories are staged locally, results are uploaded back once
+		// the run completes
+		stageDir := ""
+		if userInput.Remote != nil {
+			var err error
+			if stageDir, err = stageRemote(ctx); err != nil {
+				commons.Error("%v\n\n", err)
+				os.Exit(commons.RemoteError)
+			}
+		}
+
+		// Source directories are tracked in a queue, allowing the run to be resumed
+		// if it is paused (or interrupted) midway
+		queue := startQueue()
+
+		// Progress is recorded as the run goes, reporting where the run died if it
+		// crashes midway
+		checkpoint := startCheckpoint()
+
+		// Temporary files of the run are kept in a single workspace, removed once the
+		// run completes or is stopped
+		temp := commons.NewTempManager(userInput.KeepTemp)
+		commons.SetTemp(temp)
+
 		// Root path has been validated already
-		exitCode, err := ffmpeg.TraverseRoot(
-			&userInput,
-
-			// Defaulting output directory to `<root-dir>/auto-sub [output]`
-			filepath.Join(
-				userInput.RootPath,
-				fmt.Sprintf("%s [output]", title),
-			),
-		)
+		start := time.Now()
+		exitCode, err := ffmpeg.TraverseRoot(ctx, &userInput, resultDir())
+		if userInput.Remote != nil {
+			exitCode, err = finishRemote(ctx, stageDir, exitCode, err)
+		}
+
+		finishTemp(temp)
+		recordHistory(start, exitCode)
+		finishQueue(queue, exitCode)
+		finishCheckpoint(checkpoint)
 
 		if exitCode != commons.StatusOK || err != nil {
 			if exitCode == commons.StatusOK {
@@ -209,7 +221,12 @@ The subtitle stream language/title can be modified using flags
 				err,
 			)
 
-			commons.Printf("Error: %v", err)
+			commons.Error("%v", err)
+			if exitCode == commons.Interrupted {
+				// Help message is irrelevant if the run was stopped by the user
+				os.Exit(exitCode)
+			}
+
 			if err := cmd.Help(); err != nil {
 				log.Debugf(
 					"(rootCmd/RunE) an error occurred while printing the help "+
@@ -225,10 +242,297 @@ The subtitle stream language/title can be modified using flags
 	},
 }
 
+/*
+InitializeInput validates user input - failing if the input is invalid. Shared by the
+commands that work on a root directory.
+
+Output messages for invalid input are printed to the screen, before force-stopping
+with the relevant exit code.
+*/
+func initializeInput(cmd *cobra.Command) error {
+	// Setting up the output stream, the check will be useful when the main method
+	// is being called multiple times (during tests)
+	if commons.GetOutput() == nil {
+		commons.SetOutput(cmd.OutOrStderr())
+		commons.SetErrorOutput(cmd.ErrOrStderr())
+	}
+
+	// Values from the profile are applied before validating, and validated the same
+	// way as the values passed through flags
+	if err := applyProfile(cmd, &userInput); err != nil {
+		log.Warnf("(rootCmd/initializeInput) failed to apply profile \nerror: %v", err)
+		commons.Error("%v\n\n", err)
+		os.Exit(commons.ConfigError)
+	}
+
+	// Printing the resolved configuration, or validating the configuration file,
+	// exits without running - the root directory is not required for either
+	handleConfigFlags(cmd, &userInput)
+
+	// Validate user input. Force-stop if this step fails. The method call will
+	// internally validate the root path, and log user input.
+	//
+	// Note: The function will allow flow-of-control to pass even if the root
+	// path is empty as long as the test flag is present
+	if errCode, err := userInput.Initialize(); err != nil ||
+		errCode != commons.StatusOK {
+		log.Warnf(
+			"(rootCmd/initializeInput) unexpected input\nerror: `%v`\nexit code: %d",
+			err,
+			errCode,
+		)
+
+		// Form output message depending on the validation report - every invalid
+		// field is listed at once
+		outMsg := ""
+		var report *commons.ValidationReport
+
+		switch {
+		case errors.As(err, &report) && len(report.Errors) == 1 &&
+			report.Errors[0].Code == commons.RootDirectoryIncorrect &&
+			userInput.RootPath == "":
+			// Will be the case if path to root directory is not present and
+			// `test` flag is not used, with no other problems.
+			//
+			// Returning error to have `RunE` function display command help
+			return errors.New("path to root directory not found")
+
+		case report != nil:
+			outMsg = commons.Translate("Invalid input")
+			for _, fieldErr := range report.Errors {
+				outMsg += fmt.Sprintf("\n\t%v", fieldErr)
+			}
+
+		default:
+			// Will end up here if the error isn't a validation report, an unlikely
+			// scenario
+
+			outMsg = commons.Translate(
+				"Ran into an unexpected error. Check the logs for details",
+			)
+		}
+
+		commons.Error("%s\n\n", outMsg)
+		os.Exit(errCode)
+	}
+
+	log.Debugf("(rootCmd/initializeInput) user input initialized")
+	return nil
+}
+
+/*
+ResultDir returns the path to the directory in which the results are to be stored.
+
+Uses the output directory supplied by the user if present, defaulting to a directory
+inside the root directory otherwise. If the root directory is read-only (for example,
+a disc mounted as read-only), the default can't be used - in such a case, the
+application will be force-stopped asking the user to supply an output directory.
+*/
+func resultDir() string {
+	if userInput.OutputPath == "" && !commons.IsWritable(userInput.RootPath) {
+		log.Warnf(
+			`(rootCmd/resultDir) root directory is read-only: "%s"`,
+			userInput.RootPath,
+		)
+
+		commons.Error(
+			"Root directory is read-only, results can't be stored inside it"+
+				"\n\tPath: \"%s\"\n\nUse the `--output` flag to store the results "+
+				"elsewhere\n\n",
+			userInput.RootPath,
+		)
+
+		os.Exit(commons.ReadOnlyRoot)
+	}
+
+	return resultPath()
+}
+
+/*
+ResultPath returns the path to the result directory, without checking if the directory
+can be created - uses the output directory supplied by the user if present.
+*/
+func resultPath() string {
+	if userInput.OutputPath != "" {
+		return userInput.OutputPath
+	}
+
+	// Defaulting output directory to `<root-dir>/auto-sub [output]`
+	return filepath.Join(
+		userInput.RootPath,
+		fmt.Sprintf("%s [output]", title),
+	)
+}
+
+/*
+StageRemote downloads the remote root directory into a temporary directory, pointing
+the root path to the local copy - FFmpeg can then be run on local files. The result
+directory present in the remote root directory (if any) is not downloaded.
+
+Returns the path to the temporary directory, to be removed once the run completes.
+*/
+func stageRemote(ctx context.Context) (string, error) {
+	remote := userInput.Remote
+	names, err := remote.List(ctx)
+	if err != nil {
+		log.Warnf("(rootCmd/stageRemote) failed to list remote root \nerror: %v", err)
+		return "", err
+	}
+
+	stageDir, err := ioutil.TempDir("", title+"-remote-")
+	if err != nil {
+		log.Warnf("(rootCmd/stageRemote) failed to create directory \nerror: %v", err)
+		return "", err
+	}
+
+	resName := filepath.Base(resultPath())
+	for _, name := range names {
+		if userInput.OutputPath == "" && name == resName {
+			continue
+		}
+
+		log.Debugf(`(rootCmd/stageRemote) downloading "%s" from "%s"`, name, remote)
+		commons.Info("Downloading \"%s\"\n", name)
+
+		if err = remote.Download(ctx, name, stageDir); err != nil {
+			log.Warnf(
+				"(rootCmd/stageRemote) failed to download \"%s\" \nerror: %v",
+				name,
+				err,
+			)
+
+			_ = os.RemoveAll(stageDir)
+			return "", err
+		}
+	}
+
+	commons.Info("\n")
+	userInput.RootPath = stageDir
+	return stageDir, nil
+}
+
+/*
+FinishTemp removes the temporary workspace of the run once the run completes (or is
+stopped), the path to the workspace is printed instead if the workspace is to be kept.
+*/
+func finishTemp(temp *commons.TempManager) {
+	defer commons.SetTemp(nil)
+
+	if kept := temp.Cleanup(); kept != "" {
+		commons.Info("Temporary files kept in \"%s\"\n\n", kept)
+	}
+}
+
+/*
+FinishRemote uploads the results into the remote root directory once the run completes
+and removes the local copy of the remote root directory. Results stored in a custom
+output directory are left as is.
+
+The local copy is kept (and its path printed) if the results are not uploaded, i.e. if
+the run is stopped or the upload fails - the source directories are marked done in the
+queue, the results would be lost otherwise.
+
+Returns the exit code and error from the run, unless the upload fails.
+*/
+func finishRemote(
+	ctx context.Context,
+	stageDir string,
+	exitCode int,
+	runErr error,
+) (int, error) {
+	resDir := resultPath()
+	if _, err := os.Stat(resDir); userInput.OutputPath != "" || err != nil {
+		// Results are stored locally, or no results to upload
+		_ = os.RemoveAll(stageDir)
+		return exitCode, runErr
+	}
+
+	if ctx.Err() != nil {
+		commons.Warn(
+			"Run stopped, results not uploaded to \"%s\"\n\tKept in: \"%s\"\n\n",
+			userInput.Remote,
+			stageDir,
+		)
+
+		return exitCode, runErr
+	}
+
+	commons.Info("Uploading results to \"%s\"\n\n", userInput.Remote)
+	if err := userInput.Remote.Upload(ctx, resDir); err != nil {
+		log.Warnf("(rootCmd/finishRemote) failed to upload results \nerror: %v", err)
+		commons.Warn(
+			"Results not uploaded to \"%s\"\n\tKept in: \"%s\"\n\n",
+			userInput.Remote,
+			stageDir,
+		)
+
+		if exitCode == commons.StatusOK {
+			return commons.RemoteError, err
+		}
+
+		return exitCode, runErr
+	}
+
+	_ = os.RemoveAll(stageDir)
+	return exitCode, runErr
+}
+
+// Boolean indicating if the run was stopped through one of the pause signals
+var runPaused bool
+
+/*
+InterruptContext returns a context that is cancelled when the application receives an
+interrupt (or termination) signal - allows the ongoing run to be stopped cleanly. Pause
+signals stop the run the same way, the run can be resumed later.
+
+The cancel function should be called once the context is no longer required, this
+stops listening for the signals.
+*/
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, append([]os.Signal{os.Interrupt, syscall.SIGTERM},
+		pauseSignals...)...)
+
+	go func() {
+		defer signal.Stop(sig)
+
+		select {
+		case received := <-sig:
+			log.Debugf("(rootCmd/interruptContext) received signal: %v", received)
+			if isPauseSignal(received) {
+				runPaused = true
+				commons.Warn("\nReceived %v, pausing the run...\n\n", received)
+			} else {
+				commons.Warn("\nReceived %v, stopping the run...\n\n", received)
+			}
+
+			cancel()
+
+		case <-ctx.Done():
+			// Context no longer required
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// IsPauseSignal checks if the signal received is one of the pause signals
+func isPauseSignal(received os.Signal) bool {
+	for _, sig := range pauseSignals {
+		if received == sig {
+			return true
+		}
+	}
+
+	return false
+}
+
 func handleTestFlag() (exitCode int) {
 	ffmpegVersion, ffprobeVersion := handlerTest()
 	if ffmpegVersion == "" || ffprobeVersion == "" {
-		commons.Printf(
+		commons.Error(
 			"Ran into an unexpected error! Attempting fallback\n\t"+
 				"FFmpeg Version: %v\n\tFFprobe Version: %v\n\n",
 			ffmpegVersion,
@@ -238,7 +542,7 @@ func handleTestFlag() (exitCode int) {
 		return commons.ExecNotFound
 	}
 
-	commons.Printf(
+	commons.Success(
 		"FFmpeg version found: %v\n"+
 			"FFprobe version found: %v\n\n",
 		ffmpegVersion,
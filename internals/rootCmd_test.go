@@ -1,14 +1,18 @@
 package internals
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
 	"github.com/demon-rem/auto-sub/internals/ffmpeg"
 
 	"bou.ke/monkey"
@@ -201,9 +205,14 @@ func TestRun(t *testing.T) {
 
 	monkey.Patch(
 		ffmpeg.TraverseRoot,
-		func(*commons.UserInput, string) (int, error) { return commons.StatusOK, nil },
+		func(context.Context, *commons.UserInput, string) (int, error) {
+			return commons.StatusOK, nil
+		},
 	)
 
+	// Executables are not present while testing, runs are never started anyway
+	monkey.Patch(requireBinaries, func(*commons.UserInput) {})
+
 	/*
 		Verify the test flag - patch `handlerTest()` function to ensure isolation.
 
@@ -287,14 +296,37 @@ func TestTraverseRoot(t *testing.T) {
 	userInput = testConfig(t)
 	defer resetConfig()
 
+	// Executables are not present while testing, the run itself is patched anyway
+	monkey.Patch(locateBinaries, func(*commons.UserInput) {})
+	monkey.Patch(requireBinaries, func(*commons.UserInput) {})
+
+	// Runs in tests aren't recorded in the history
+	monkey.Patch(ffmpeg.RecordHistory, func(string, time.Time, string, int) error {
+		return nil
+	})
+
+	// Queues for runs in tests are kept away from the user config directory
+	original := os.Getenv(config.EnvQueue)
+	defer os.Setenv(config.EnvQueue, original)
+
+	_ = os.Setenv(config.EnvQueue, filepath.Join(os.TempDir(), "auto-sub-queue.json"))
+
+	original = os.Getenv(config.EnvProgress)
+	defer os.Setenv(config.EnvProgress, original)
+
+	_ = os.Setenv(
+		config.EnvProgress,
+		filepath.Join(os.TempDir(), "auto-sub-progress.json"),
+	)
+
 	tempError := errors.New("(rootCmd/RunE) error thrown as a test")
 	for err, exitCode := range map[error]int{
 		nil:       commons.StatusOK,
 		tempError: commons.StatusOK,
 		nil:       commons.RootDirectoryIncorrect,
 	} {
-		monkey.Patch(ffmpeg.TraverseRoot, func(*commons.UserInput, string) (int,
-			error) {
+		monkey.Patch(ffmpeg.TraverseRoot, func(context.Context, *commons.UserInput,
+			string) (int, error) {
 			return exitCode, err
 		})
 
@@ -315,3 +347,82 @@ func TestTraverseRoot(t *testing.T) {
 		_ = cmd.RunE(cmd, []string{})
 	}
 }
+
+// Remote root directory recording the uploads, failing them if required
+type uploadRemote struct {
+	uploaded []string
+	err      error
+}
+
+func (remote *uploadRemote) List(context.Context) ([]string, error) { return nil, nil }
+
+func (remote *uploadRemote) Download(context.Context, string, string) error {
+	return nil
+}
+
+func (remote *uploadRemote) Upload(_ context.Context, localPath string) error {
+	remote.uploaded = append(remote.uploaded, localPath)
+	return remote.err
+}
+
+func (remote *uploadRemote) String() string { return "sftp://host/media" }
+
+func TestFinishRemote(t *testing.T) {
+	defer func(original commons.UserInput) { userInput = original }(userInput)
+
+	stage := func() string {
+		dir, err := ioutil.TempDir("", "auto-sub-test")
+		if err != nil {
+			t.Fatalf("(rootCmd/finishRemote) failed to create directory: %v", err)
+		}
+
+		userInput.RootPath = dir
+		if err = os.Mkdir(resultPath(), 0755); err != nil {
+			t.Fatalf("(rootCmd/finishRemote) failed to create directory: %v", err)
+		}
+
+		return dir
+	}
+
+	// Local copy is removed once the results are uploaded
+	remote := &uploadRemote{}
+	userInput = commons.UserInput{Remote: remote}
+	stageDir := stage()
+	exitCode, err := finishRemote(context.Background(), stageDir, commons.StatusOK, nil)
+	if _, statErr := os.Stat(stageDir); exitCode != commons.StatusOK || err != nil ||
+		len(remote.uploaded) != 1 || !os.IsNotExist(statErr) {
+		t.Errorf(
+			"(rootCmd/finishRemote) results not uploaded \nexit code: %d \nerror: %v",
+			exitCode,
+			err,
+		)
+	}
+
+	// Local copy is kept if the upload fails, or is skipped as the run was stopped
+	remote.err = errors.New("connection reset")
+	stageDir = stage()
+	defer os.RemoveAll(stageDir)
+
+	exitCode, err = finishRemote(context.Background(), stageDir, commons.StatusOK, nil)
+	if _, statErr := os.Stat(stageDir); exitCode != commons.RemoteError ||
+		err != remote.err || statErr != nil {
+		t.Errorf(
+			"(rootCmd/finishRemote) local copy removed after a failed upload"+
+				"\nexit code: %d \nerror: %v",
+			exitCode,
+			err,
+		)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stopped := stage()
+	defer os.RemoveAll(stopped)
+
+	exitCode, _ = finishRemote(ctx, stopped, commons.Interrupted, nil)
+	if _, statErr := os.Stat(stopped); exitCode != commons.Interrupted ||
+		statErr != nil || len(remote.uploaded) != 2 {
+		t.Errorf("(rootCmd/finishRemote) local copy removed after the run stopped")
+	}
+}
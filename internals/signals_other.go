@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package internals
+
+import "os"
+
+// Runs can't be paused through a signal on this platform, interrupts stop the run
+var pauseSignals []os.Signal
@@ -0,0 +1,12 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package internals
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals that pause the run, i.e. stop the run while keeping the queue to resume it
+var pauseSignals = []os.Signal{syscall.SIGTSTP}
@@ -0,0 +1,174 @@
+package internals
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use: "sync [library...]",
+
+	Short: "Run every library present in the configuration file",
+
+	Long: `
+Runs each library present in the configuration file, one after the
+other - or only the libraries named. Each library is a root directory
+along with the options for it, outputs already created (and still
+up-to-date) are skipped, only new or modified media files are merged.
+
+Libraries are defined in the "libraries" section of the configuration
+file, each library is run the same way as the root command;
+
+	{
+		"libraries": [
+			{"name": "anime", "root": "/media/anime", "profile": "anime"},
+			{"root": "/media/movies", "args": ["--language", "eng"]}
+		]
+	}
+
+A failing library does not stop the remaining libraries from running.
+`,
+
+	Args: cobra.ArbitraryArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Same as the root command, set up the output stream if required
+		if commons.GetOutput() == nil {
+			commons.SetOutput(cmd.OutOrStderr())
+			commons.SetErrorOutput(cmd.ErrOrStderr())
+		}
+
+		exitCode, err := syncLibraries(args)
+		if err != nil {
+			log.Debugf("(syncCmd/RunE) failed to sync libraries \nerror: %v", err)
+			commons.Error("%v\n\n", err)
+		}
+
+		if exitCode != commons.StatusOK {
+			os.Exit(exitCode)
+		}
+
+		return nil
+	},
+}
+
+/*
+SyncLibraries runs the libraries with the names supplied (or every library) one after
+the other, returning the exit code of the first library that failed - the sync stops
+early only if a run is interrupted.
+*/
+func syncLibraries(names []string) (int, error) {
+	if configErr != nil {
+		return commons.ConfigError, configErr
+	}
+
+	if errs := configFile.Validate(); len(errs) > 0 {
+		return commons.ConfigError, fmt.Errorf(
+			"invalid configuration file, check with `--validate-config` \n\t%v",
+			errs[0],
+		)
+	}
+
+	libraries, err := configFile.SelectLibraries(names)
+	if err != nil {
+		return commons.ConfigError, err
+	}
+
+	if len(libraries) == 0 {
+		return commons.ConfigError, errors.New(
+			"no libraries are present in the configuration file",
+		)
+	}
+
+	exitCode, failed := commons.StatusOK, 0
+	for i, library := range libraries {
+		commons.Info(
+			"Syncing library \"%s\" (%d of %d)\n\n",
+			library.Title(),
+			i+1,
+			len(libraries),
+		)
+
+		code := runLibrary(library)
+		if code == commons.StatusOK {
+			continue
+		}
+
+		log.Warnf(
+			"(syncCmd/syncLibraries) library `%s` failed with exit code %d",
+			library.Title(),
+			code,
+		)
+
+		failed++
+		if exitCode == commons.StatusOK {
+			exitCode = code
+		}
+
+		if code == commons.Interrupted {
+			return code, errors.New("sync interrupted, remaining libraries skipped")
+		}
+	}
+
+	report := commons.Success
+	if failed > 0 {
+		report = commons.Warn
+	}
+
+	report(
+		"Synced %d of %d libraries\n\n",
+		len(libraries)-failed,
+		len(libraries),
+	)
+
+	return exitCode, nil
+}
+
+/*
+LibraryArgs returns the arguments to run a library through the root command - existing
+outputs are skipped, the profile (if any) is applied before the additional arguments.
+*/
+func libraryArgs(library config.Library) []string {
+	args := []string{library.Root, "--skip-existing"}
+	if library.Profile != "" {
+		args = append(args, "--profile", library.Profile)
+	}
+
+	return append(args, library.Args...)
+}
+
+/*
+RunLibrary runs a library through a new process of the executable - each run starts
+with a clean state. Returns the exit code of the process.
+*/
+func runLibrary(library config.Library) int {
+	executable, err := os.Executable()
+	if err != nil {
+		log.Warnf("(syncCmd/runLibrary) failed to locate executable: %v", err)
+		return commons.UnexpectedError
+	}
+
+	process := exec.Command(executable, libraryArgs(library)...)
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
+
+	log.Debugf("(syncCmd/runLibrary) running library: %v", process.Args)
+
+	var exitErr *exec.ExitError
+	if err = process.Run(); errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	} else if err != nil {
+		log.Warnf("(syncCmd/runLibrary) failed to run library: %v", err)
+		return commons.UnexpectedError
+	}
+
+	return commons.StatusOK
+}
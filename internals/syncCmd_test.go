@@ -0,0 +1,113 @@
+package internals
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"bou.ke/monkey"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+)
+
+func TestLibraryArgs(t *testing.T) {
+	args := libraryArgs(config.Library{
+		Root:    "/media/anime",
+		Profile: "anime",
+		Args:    []string{"--language", "jpn"},
+	})
+
+	expected := []string{
+		"/media/anime",
+		"--skip-existing",
+		"--profile",
+		"anime",
+		"--language",
+		"jpn",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf(
+			"(syncCmd/libraryArgs) unexpected arguments \nexpected: %v \nfound: %v",
+			expected,
+			args,
+		)
+	}
+}
+
+func TestSyncLibraries(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	original := configFile
+	defer func() { configFile = original }()
+
+	configFile = &config.File{
+		Libraries: []config.Library{
+			{Name: "anime", Root: "/media/anime"},
+			{Name: "movies", Root: "/media/movies"},
+			{Name: "shows", Root: "/media/shows"},
+		},
+	}
+
+	// The second library fails, remaining libraries are run regardless
+	var ran []string
+	monkey.Patch(runLibrary, func(library config.Library) int {
+		ran = append(ran, library.Name)
+		if library.Name == "movies" {
+			return commons.SourceDirectoryError
+		}
+
+		return commons.StatusOK
+	})
+
+	exitCode, err := syncLibraries(nil)
+	if exitCode != commons.SourceDirectoryError || err != nil || len(ran) != 3 {
+		t.Errorf(
+			"(syncCmd/syncLibraries) unexpected result \nexit code: %d"+
+				"\nlibraries run: %v \nerror: %v",
+			exitCode,
+			ran,
+			err,
+		)
+	}
+
+	// Only the libraries named are run
+	ran = nil
+	if exitCode, _ = syncLibraries([]string{"Shows"}); exitCode != commons.StatusOK ||
+		!reflect.DeepEqual(ran, []string{"shows"}) {
+		t.Errorf("(syncCmd/syncLibraries) unexpected libraries run: %v", ran)
+	}
+
+	// An interrupted run stops the sync
+	ran = nil
+	monkey.Patch(runLibrary, func(library config.Library) int {
+		ran = append(ran, library.Name)
+		return commons.Interrupted
+	})
+
+	if exitCode, err = syncLibraries(nil); exitCode != commons.Interrupted ||
+		err == nil || len(ran) != 1 {
+		t.Errorf("(syncCmd/syncLibraries) expected sync to stop, ran: %v", ran)
+	}
+
+	// Nothing to run without libraries
+	configFile = &config.File{}
+	if exitCode, _ = syncLibraries(nil); exitCode != commons.ConfigError {
+		t.Errorf("(syncCmd/syncLibraries) unexpected exit code: %d", exitCode)
+	}
+}
+
+func TestRunLibrary(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	// Failure to locate the executable is reported as an unexpected error
+	monkey.Patch(os.Executable, func() (string, error) {
+		return "", os.ErrNotExist
+	})
+
+	code := runLibrary(config.Library{Root: "/media"})
+	if code != commons.UnexpectedError {
+		t.Errorf("(syncCmd/runLibrary) unexpected exit code: %d", code)
+	}
+}
@@ -0,0 +1,141 @@
+package internals
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+	"github.com/demon-rem/auto-sub/internals/ffmpeg"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Booleans containing the values of the `yes` and `use-trash` flags for undo
+var undoYes, undoTrash bool
+
+var undoCmd = &cobra.Command{
+	Use: "undo",
+
+	Short: "Remove the outputs created by the last run",
+
+	Long: `
+Removes the files created by the last run recorded in the history -
+merged outputs, their snapshots and the extra files copied - along with
+the directories left empty. Source directories are never touched.
+
+Files modified since the run are left as is, and outputs that replaced
+an existing file can't be restored; the files to be removed are listed
+before asking for confirmation.
+`,
+
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Same as the root command, set up the output stream if required
+		if commons.GetOutput() == nil {
+			commons.SetOutput(cmd.OutOrStderr())
+			commons.SetErrorOutput(cmd.ErrOrStderr())
+		}
+
+		commons.SetTrash(undoTrash)
+		defer commons.SetTrash(false)
+
+		exitCode, err := undoLastRun(commons.NewPromptPolicy(undoYes, false))
+		if err != nil {
+			log.Debugf("(undoCmd/RunE) failed to undo the last run \nerror: %v", err)
+			commons.Error("%v\n\n", err)
+		}
+
+		if exitCode != commons.StatusOK {
+			os.Exit(exitCode)
+		}
+
+		return nil
+	},
+}
+
+/*
+UndoFlags is a simple helper function to attach flags to the undo command
+*/
+func undoFlags(command *cobra.Command) {
+	command.Flags().BoolVarP(
+		&undoYes,
+		"yes",
+		"y",
+		false,
+		"Remove the files without asking",
+	)
+
+	command.Flags().BoolVar(
+		&undoTrash,
+		"use-trash",
+		false,
+		"Move the files to the trash instead of deleting them permanently",
+	)
+}
+
+/*
+UndoLastRun removes the files created by the last run in the history, once the user
+confirms - returns the exit code for the command, along with the error (if any).
+*/
+func undoLastRun(prompt *commons.PromptPolicy) (int, error) {
+	records, err := ffmpeg.ReadHistory(config.HistoryPath(title))
+	if err != nil {
+		return commons.UnexpectedError, err
+	}
+
+	if len(records) == 0 {
+		commons.Info("No runs recorded, nothing to undo\n\n")
+		return commons.StatusOK, nil
+	}
+
+	last := records[len(records)-1]
+	commons.Info(
+		"Last run: \"%s\", started %s\n\n",
+		last.Root,
+		last.Start.Format("Jan 2 15:04"),
+	)
+
+	files, modified, replaced := ffmpeg.UndoCandidates(last)
+	for _, path := range modified {
+		commons.Warn("Modified since the run, left as is \n\tPath: \"%s\"\n", path)
+	}
+
+	for _, path := range replaced {
+		commons.Warn(
+			"Replaced a file that existed before the run, left as is \n\tPath: "+
+				"\"%s\"\n",
+			path,
+		)
+	}
+
+	if len(files) == 0 {
+		commons.Info("No files left to remove, nothing to undo\n\n")
+		return commons.StatusOK, nil
+	}
+
+	commons.Printf("Files created by the run;\n")
+	for _, file := range files {
+		commons.Printf("\t\"%s\"\n", file.Path)
+	}
+
+	commons.Printf("\n")
+	if !prompt.Confirm(fmt.Sprintf("Remove %d file(s)?", len(files))) {
+		commons.Info("\nNothing removed\n\n")
+		return commons.StatusOK, nil
+	}
+
+	removed, err := ffmpeg.RemoveCreated(files, last.ResultRoot())
+	if err != nil {
+		return commons.UnexpectedError, fmt.Errorf(
+			"removed %d of %d file(s), %v",
+			removed,
+			len(files),
+			err,
+		)
+	}
+
+	commons.Success("\nRemoved %d file(s)\n\n", removed)
+	return commons.StatusOK, nil
+}
@@ -0,0 +1,66 @@
+package internals
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	"github.com/demon-rem/auto-sub/internals/config"
+)
+
+func TestUndoLastRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto-sub-test")
+	if err != nil {
+		t.Fatalf("(undoCmd) failed to create directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	original := os.Getenv(config.EnvHistory)
+	defer os.Setenv(config.EnvHistory, original)
+
+	history := filepath.Join(dir, "history.jsonl")
+	_ = os.Setenv(config.EnvHistory, history)
+
+	// Nothing to undo without any run recorded
+	if code, err := undoLastRun(nil); code != commons.StatusOK || err != nil {
+		t.Errorf("(undoCmd/undoLastRun) unexpected failure \nerror: %v", err)
+	}
+
+	output := filepath.Join(dir, "output.mkv")
+	_ = ioutil.WriteFile(output, []byte("output"), 0644)
+	item, _ := os.Stat(output)
+
+	// Only the files created by the last run are considered
+	records := fmt.Sprintf(
+		`{"root": "/media/old", "created": []}`+"\n"+
+			`{"root": "/media", "created": [{"path": %q, "size": 6, "mtime": %d}]}`,
+		output,
+		item.ModTime().UnixNano(),
+	)
+
+	if err = ioutil.WriteFile(history, []byte(records), 0644); err != nil {
+		t.Fatalf("(undoCmd) failed to write history: %v", err)
+	}
+
+	// The prompt is declined, nothing is removed
+	if code, _ := undoLastRun(nil); code != commons.StatusOK {
+		t.Errorf("(undoCmd/undoLastRun) unexpected exit code: %d", code)
+	}
+
+	if _, err = os.Stat(output); err != nil {
+		t.Errorf("(undoCmd/undoLastRun) output removed without confirmation")
+	}
+
+	if code, err := undoLastRun(commons.NewPromptPolicy(true, false)); code !=
+		commons.StatusOK || err != nil {
+		t.Errorf("(undoCmd/undoLastRun) unexpected failure \nerror: %v", err)
+	}
+
+	if _, err = os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("(undoCmd/undoLastRun) output not removed \nerror: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package internals
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/demon-rem/auto-sub/internals/commons"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+/*
+Build metadata - these values are embedded while building the binary using `-ldflags`,
+for example;
+
+	go build -ldflags "-X github.com/demon-rem/auto-sub/internals.commit=<hash>"
+
+Will remain `unknown` for binaries built without these flags.
+*/
+var (
+	// Git commit the binary was built from
+	commit = "unknown"
+
+	// Date (and time) at which the binary was built
+	buildDate = "unknown"
+)
+
+// Boolean containing the value of the `json` flag for the version command
+var versionJSON bool
+
+/*
+BuildInfo is a simple structure containing the build metadata for the application,
+along with the path/version of FFmpeg and FFprobe executables detected at runtime.
+*/
+type buildInfo struct {
+	Version   string     `json:"version"`
+	Commit    string     `json:"commit"`
+	BuildDate string     `json:"build_date"`
+	GoVersion string     `json:"go_version"`
+	Platform  string     `json:"platform"`
+	FFmpeg    binaryInfo `json:"ffmpeg"`
+	FFprobe   binaryInfo `json:"ffprobe"`
+}
+
+// BinaryInfo contains the path to an executable, and the version detected for it.
+type binaryInfo struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+var versionCmd = &cobra.Command{
+	Use: "version",
+
+	Short: fmt.Sprintf("Display build information for %s", title),
+
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Same as the root command, set up the output stream if required
+		if commons.GetOutput() == nil {
+			commons.SetOutput(cmd.OutOrStdout())
+			commons.SetErrorOutput(cmd.ErrOrStderr())
+		}
+
+		info := fetchBuildInfo()
+		if !versionJSON {
+			commons.Printf("%s", info.String())
+			return nil
+		}
+
+		res, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			log.Debugf(
+				"(versionCmd/RunE) failed to marshal build info \nerror: %v",
+				err,
+			)
+
+			return err
+		}
+
+		commons.Printf("%s\n", res)
+		return nil
+	},
+}
+
+/*
+VersionFlags is a simple helper function to attach flags to the version command
+*/
+func versionFlags(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&versionJSON,
+		"json",
+		false,
+		"Display build information as JSON",
+	)
+}
+
+/*
+FetchBuildInfo collects the build metadata, and fetches versions for the FFmpeg and
+FFprobe executables - versions will be blank if the executables can't be run.
+*/
+func fetchBuildInfo() buildInfo {
+	locateBinaries(&userInput)
+	ffmpegVersion, ffprobeVersion := handlerTest()
+
+	return buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		FFmpeg: binaryInfo{
+			Path:    userInput.FFmpegPath,
+			Version: ffmpegVersion,
+		},
+		FFprobe: binaryInfo{
+			Path:    userInput.FFprobePath,
+			Version: ffprobeVersion,
+		},
+	}
+}
+
+/*
+String forms the text displayed to the user for the `--version` flag (and the version
+command)
+*/
+func (info buildInfo) String() string {
+	// Replaces blank values with a placeholder
+	orNotFound := func(in string) string {
+		if in == "" {
+			return "not found"
+		}
+
+		return in
+	}
+
+	return fmt.Sprintf(
+		`
+%s v%s
+ - commit: %s
+ - build date: %s
+ - os/arch: %s
+ - go version: %s
+ - ffmpeg: %s (%s)
+ - ffprobe: %s (%s)
+
+Licensed under MIT
+`,
+		title,
+		info.Version,
+		info.Commit,
+		info.BuildDate,
+		info.Platform,
+		info.GoVersion,
+		orNotFound(info.FFmpeg.Version),
+		orNotFound(info.FFmpeg.Path),
+		orNotFound(info.FFprobe.Version),
+		orNotFound(info.FFprobe.Path),
+	)
+}
@@ -0,0 +1,58 @@
+package internals
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+)
+
+func TestFetchBuildInfo(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	userInput = testConfig(t)
+	defer resetConfig()
+
+	userInput.FFmpegPath = "/path/to/ffmpeg"
+	monkey.Patch(handlerTest, func() (string, string) { return "4.3.1", "" })
+
+	info := fetchBuildInfo()
+	if info.Version != version || info.Commit != commit ||
+		info.FFmpeg.Path != userInput.FFmpegPath || info.FFmpeg.Version != "4.3.1" ||
+		info.FFprobe.Version != "" {
+		t.Errorf("(versionCmd/fetchBuildInfo) unexpected build info: %+v", info)
+	}
+
+	// Blank values should be replaced while displaying build info
+	for _, expected := range []string{
+		"v" + version,
+		"ffmpeg: 4.3.1 (/path/to/ffmpeg)",
+		"ffprobe: not found (not found)",
+	} {
+		if res := info.String(); !strings.Contains(res, expected) {
+			t.Errorf(
+				"(versionCmd/String) missing text in output \nexpected: `%s` "+
+					"\noutput: %s",
+				expected,
+				res,
+			)
+		}
+	}
+
+	// Ensure the keys used in JSON output remain stable - used by external tools
+	res, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("(versionCmd) failed to marshal build info \nerror: %v", err)
+	}
+
+	for _, key := range []string{`"version"`, `"commit"`, `"build_date"`, `"path"`} {
+		if !strings.Contains(string(res), key) {
+			t.Errorf(
+				"(versionCmd) key `%s` missing in JSON output \noutput: %s",
+				key,
+				res,
+			)
+		}
+	}
+}
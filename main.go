@@ -8,9 +8,6 @@ import (
 	easy "github.com/t-tomalak/logrus-easy-formatter"
 )
 
-// Name of the text file containing logs
-const logFile = "[auto-sub] logs.txt"
-
 // Entry point when the script is run - sets up a logger, and hands over the flow
 // of control to the central command.
 func main() {
@@ -24,22 +21,9 @@ func main() {
 		LogFormat:       "[%lvl%]: %time% - %msg%\n",
 	})
 
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-	if err != nil {
-		// If the file can't be opened, set the output channel to be stderr
-		log.SetOutput(os.Stderr)
-		log.Warn("(main/main) failed to open a connection to the log file")
-	} else {
-		// Writing logs to the log file.
-		log.SetOutput(file)
-
-		// Close the log file when the function ends.
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Warn("(main/main) failed to close connection to the log file")
-			}
-		}()
-	}
+	// Logs are written to the standard error until the log file is opened, the
+	// location of the log file depends on the flags
+	log.SetOutput(os.Stderr)
 
 	// Call the main internal method
 	internals.Execute()
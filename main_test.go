@@ -1,9 +1,6 @@
 package main
 
 import (
-	"errors"
-	"os"
-	"reflect"
 	"testing"
 
 	"bou.ke/monkey"
@@ -32,29 +29,4 @@ func TestMainFunction(t *testing.T) {
 	if !flag {
 		t.Errorf("failed initial run - main")
 	}
-
-	// Replacing call to `os.OpenFile` with a template function throwing an error
-	monkey.Patch(os.OpenFile, func(string, int, os.FileMode) (*os.File, error) {
-		return nil, errors.New("(main/main) test to emulate failure in opening file")
-	})
-
-	// Initiating call to main
-	main() // Error should be handled internally
-
-	// Unpatch the previous patch
-	monkey.Unpatch(os.OpenFile)
-
-	/*
-		Emulate scenario if the main method fails to close connection to the log file,
-	*/
-	var file os.File
-	monkey.PatchInstanceMethod(
-		reflect.TypeOf(&file),
-		"Close",
-		func(*os.File) error {
-			return errors.New("(main/main) test failure if a file fails to close")
-		},
-	)
-
-	main()
 }